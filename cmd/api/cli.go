@@ -0,0 +1,441 @@
+package main
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/db"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/seed"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/spreadsheet"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"time"
+)
+
+const usage = `Использование: api [команда]
+
+Без команды запускается HTTP-сервер.
+
+  migrate [up]                      применить все миграции
+  migrate down                      откатить последнюю миграцию
+  migrate status                    показать состояние миграций
+  migrate create NAME [sql|go]      создать файл новой миграции
+  seed                              загрузить демо-каталог (только вне продакшена)
+  user create-admin --username NAME --phone +7... [--email E] [--password P]
+                                    создать администратора; без --password пароль будет сгенерирован
+  user reset-password LOGIN [--password P]
+                                    сменить пароль (LOGIN — телефон, e-mail или имя)
+  cache flush [PREFIX...]           очистить кэш каталога или ключи с указанными префиксами
+  push test [--message TEXT] [--all]
+                                    отправить тестовое уведомление администраторам (--all — всем)
+  orders export [--from DATE] [--to DATE] [--out FILE]
+                                    выгрузить заказы в CSV (по умолчанию в stdout)
+  catalog import FILE [--dry-run]   импортировать товары из CSV/XLSX
+`
+
+// errUsage — неверные аргументы; вместе с ошибкой печатается справка
+var errUsage = errors.New("invalid arguments")
+
+// cli выполняет команды обслуживания из консоли через те же сервисы, что и API.
+// База и Redis подключаются только для команд, которым они нужны.
+type cli struct {
+	ctx    context.Context
+	cfg    *config.Config
+	logger *zap.Logger
+	out    io.Writer
+
+	dbConn *sqlx.DB
+	cache  *cache.RedisCache
+}
+
+// runCommand выполняет команду из аргументов командной строки; отмена ctx прерывает её запросы
+func runCommand(ctx context.Context, cfg *config.Config, logger *zap.Logger, args []string) error {
+	c := &cli{ctx: ctx, cfg: cfg, logger: logger, out: os.Stdout}
+	defer c.close()
+
+	var err error
+	switch args[0] {
+	case "migrate":
+		err = c.migrate(args[1:])
+	case "seed":
+		err = c.seed()
+	case "user":
+		err = c.user(args[1:])
+	case "cache":
+		err = c.cacheCommand(args[1:])
+	case "push":
+		err = c.push(args[1:])
+	case "orders":
+		err = c.orders(args[1:])
+	case "catalog":
+		err = c.catalog(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(c.out, usage)
+		return nil
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	return err
+}
+
+func (c *cli) migrate(args []string) error {
+	sub, rest := subcommand(args)
+	if sub == "create" {
+		if len(rest) == 0 {
+			return fmt.Errorf("%w: migrate create NAME", errUsage)
+		}
+		migrationType := "sql"
+		if len(rest) > 1 {
+			migrationType = rest[1]
+		}
+		return goose.Create(nil, c.cfg.MigrationsDir, rest[0], migrationType)
+	}
+
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+	// Миграция может перестраивать большие таблицы дольше обычного таймаута запроса
+	ctx := db.WithoutQueryTimeout(c.ctx)
+	switch sub {
+	case "", "up":
+		c.logger.Info("Running goose migrations...")
+		if err := goose.UpContext(ctx, dbConn.DB, c.cfg.MigrationsDir); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		c.logger.Info("Migrations completed.")
+		return nil
+	case "down":
+		return goose.DownContext(ctx, dbConn.DB, c.cfg.MigrationsDir)
+	case "status":
+		return goose.StatusContext(ctx, dbConn.DB, c.cfg.MigrationsDir)
+	}
+	return fmt.Errorf("%w: unknown migrate command %q", errUsage, sub)
+}
+
+func (c *cli) seed() error {
+	if c.cfg.IsProduction() {
+		return fmt.Errorf("seed is allowed only outside production (ENV=%q)", c.cfg.Env)
+	}
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+
+	categoryRepo := repositories.NewCategoryRepo(dbConn)
+	productRepo := repositories.NewProductRepo(dbConn)
+	report, err := seed.Run(c.ctx,
+		services.NewCategoryService(categoryRepo, c.logger),
+		services.NewCatalogService(productRepo, categoryRepo, c.logger),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to seed demo data: %w", err)
+	}
+	c.clearCatalogCache()
+
+	fmt.Fprintf(c.out, "Демо-каталог загружен: создано %d, обновлено %d, без изменений %d\n", report.Created, report.Updated, report.Unchanged)
+	return nil
+}
+
+func (c *cli) user(args []string) error {
+	sub, rest := subcommand(args)
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+	userRepo := repositories.NewUserRepo(dbConn)
+	userService := services.NewUserService(userRepo, nil, nil, nil, nil, nil, c.cfg, c.logger)
+
+	switch sub {
+	case "create-admin":
+		fs := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+		username := fs.String("username", "", "имя администратора")
+		phone := fs.String("phone", "", "телефон в формате +7...")
+		email := fs.String("email", "", "e-mail")
+		password := fs.String("password", "", "пароль; если не указан, будет сгенерирован")
+		if _, err := parseFlags(fs, rest); err != nil {
+			return err
+		}
+		if *username == "" || *phone == "" {
+			return fmt.Errorf("%w: --username and --phone are required", errUsage)
+		}
+
+		req := services.RegisterRequest{Username: *username, Phone: *phone, Password: *password}
+		if *email != "" {
+			req.Email = email
+		}
+		user, generated, err := userService.CreateAdmin(c.ctx, req)
+		if err != nil {
+			return err
+		}
+		c.logger.Info("admin created from cli", zap.Int("user_id", user.ID), zap.String("username", user.Username))
+		fmt.Fprintf(c.out, "Администратор создан: id=%d, имя=%s\n", user.ID, user.Username)
+		if *password == "" {
+			fmt.Fprintf(c.out, "Пароль: %s\n", generated)
+		}
+		return nil
+
+	case "reset-password":
+		fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+		password := fs.String("password", "", "новый пароль; если не указан, будет сгенерирован")
+		positional, err := parseFlags(fs, rest)
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("%w: user reset-password LOGIN", errUsage)
+		}
+
+		user, newPassword, err := userService.ResetPassword(c.ctx, positional[0], *password)
+		if err != nil {
+			return err
+		}
+		c.logger.Info("password reset from cli", zap.Int("user_id", user.ID))
+		fmt.Fprintf(c.out, "Пароль пользователя %s (id=%d) изменён\n", user.Username, user.ID)
+		if *password == "" {
+			fmt.Fprintf(c.out, "Новый пароль: %s\n", newPassword)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown user command %q", errUsage, sub)
+}
+
+func (c *cli) cacheCommand(args []string) error {
+	sub, prefixes := subcommand(args)
+	if sub != "flush" {
+		return fmt.Errorf("%w: unknown cache command %q", errUsage, sub)
+	}
+	redisCache, err := c.redisCache()
+	if err != nil {
+		return err
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = []string{"products:", "product:"}
+	}
+	for _, prefix := range prefixes {
+		if err := redisCache.ClearPrefix(c.ctx, prefix); err != nil {
+			return fmt.Errorf("failed to flush %q: %w", prefix, err)
+		}
+		fmt.Fprintf(c.out, "Кэш очищен: %s*\n", prefix)
+	}
+	return nil
+}
+
+func (c *cli) push(args []string) error {
+	sub, rest := subcommand(args)
+	if sub != "test" {
+		return fmt.Errorf("%w: unknown push command %q", errUsage, sub)
+	}
+	fs := flag.NewFlagSet("push test", flag.ContinueOnError)
+	message := fs.String("message", "Тестовое уведомление", "текст уведомления")
+	all := fs.Bool("all", false, "отправить всем подписчикам, а не только администраторам")
+	if _, err := parseFlags(fs, rest); err != nil {
+		return err
+	}
+
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+	pushService := services.NewPushService(repositories.NewPushRepo(dbConn), c.logger, c.cfg)
+
+	if *all {
+		err = pushService.Broadcast(c.ctx, *message)
+	} else {
+		err = pushService.SendPushToAdmins(c.ctx, *message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send push: %w", err)
+	}
+	fmt.Fprintln(c.out, "Уведомление отправлено; ошибки доставки отдельным подписчикам смотрите в логах")
+	return nil
+}
+
+func (c *cli) orders(args []string) error {
+	sub, rest := subcommand(args)
+	if sub != "export" {
+		return fmt.Errorf("%w: unknown orders command %q", errUsage, sub)
+	}
+	fs := flag.NewFlagSet("orders export", flag.ContinueOnError)
+	fromArg := fs.String("from", "", "начало периода (YYYY-MM-DD или RFC3339)")
+	toArg := fs.String("to", "", "конец периода, не включая (YYYY-MM-DD или RFC3339)")
+	outPath := fs.String("out", "", "файл для выгрузки; по умолчанию stdout")
+	if _, err := parseFlags(fs, rest); err != nil {
+		return err
+	}
+	from, err := parseDate(*fromArg)
+	if err != nil {
+		return err
+	}
+	to, err := parseDate(*toArg)
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+	// Для выгрузки уведомления и WebSocket-хаб не нужны
+	orderService := services.NewOrderService(
+		repositories.NewCartRepo(dbConn),
+		repositories.NewOrderRepo(dbConn),
+		repositories.NewProductRepo(dbConn),
+		repositories.NewUserRepo(dbConn),
+		nil, nil, nil, nil, c.logger,
+	)
+
+	out := c.out
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *outPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	count, err := orderService.ExportCSV(c.ctx, out, from, to)
+	if err != nil {
+		return err
+	}
+	if *outPath != "" {
+		fmt.Fprintf(c.out, "Выгружено заказов: %d → %s\n", count, *outPath)
+	}
+	return nil
+}
+
+func (c *cli) catalog(args []string) error {
+	sub, rest := subcommand(args)
+	if sub != "import" {
+		return fmt.Errorf("%w: unknown catalog command %q", errUsage, sub)
+	}
+	fs := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "только показать изменения, ничего не сохраняя")
+	positional, err := parseFlags(fs, rest)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%w: catalog import FILE", errUsage)
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dbConn, err := c.database()
+	if err != nil {
+		return err
+	}
+	catalogService := services.NewCatalogService(repositories.NewProductRepo(dbConn), repositories.NewCategoryRepo(dbConn), c.logger)
+
+	report, importErr := catalogService.Import(c.ctx, spreadsheet.FormatByName(positional[0]), file, *dryRun, 0)
+	if report != nil {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+	if importErr != nil {
+		return importErr
+	}
+	if report.Applied {
+		c.clearCatalogCache()
+	}
+	return nil
+}
+
+// clearCatalogCache сбрасывает кэш каталога, если Redis доступен; иначе кэш истечёт сам
+func (c *cli) clearCatalogCache() {
+	redisCache, err := c.redisCache()
+	if err != nil {
+		c.logger.Warn("catalog cache not cleared", zap.Error(err))
+		fmt.Fprintln(os.Stderr, "Redis недоступен, кэш каталога обновится по истечении TTL")
+		return
+	}
+	redisCache.ClearPrefix(c.ctx, "products:")
+	redisCache.ClearPrefix(c.ctx, "product:")
+}
+
+func (c *cli) database() (*sqlx.DB, error) {
+	if c.dbConn == nil {
+		dbConn, err := db.NewPostgresDB(c.cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		c.dbConn = dbConn
+	}
+	return c.dbConn, nil
+}
+
+func (c *cli) redisCache() (*cache.RedisCache, error) {
+	if c.cache == nil {
+		client := redis.NewClient(c.cfg.GetRedisOptions())
+		if err := client.Ping(c.ctx).Err(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		c.cache = cache.NewRedisCache(client, time.Duration(c.cfg.RedisTTLMinutes)*time.Minute, c.logger)
+	}
+	return c.cache, nil
+}
+
+func (c *cli) close() {
+	if c.dbConn != nil {
+		c.dbConn.Close()
+	}
+}
+
+// subcommand отделяет подкоманду от остальных аргументов
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+// parseFlags разбирает флаги в любом месте строки (flag останавливается на первом позиционном аргументе)
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(os.Stderr)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parseDate принимает дату или RFC3339; пустая строка — без ограничения
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: invalid date %q", errUsage, value)
+}
@@ -7,14 +7,17 @@ import (
 	"chechnya-product/internal/cache"
 	"chechnya-product/internal/db"
 	"chechnya-product/internal/logger"
+	"chechnya-product/internal/payments"
+	"chechnya-product/internal/storage"
+	"chechnya-product/internal/tracing"
 	"context"
-	"errors"
-	"github.com/pressly/goose/v3"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -37,6 +40,34 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// SIGINT/SIGTERM отменяют контекст: сервер плавно останавливается, а команда обслуживания прерывает запросы к базе
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 🛠 Команды обслуживания: migrate, seed, user, cache, push, orders, catalog
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, cfg, logger, os.Args[1:]); err != nil {
+			logger.Error("Command failed", zap.Strings("args", os.Args[1:]), zap.Error(err))
+			fmt.Fprintln(os.Stderr, "Ошибка:", err)
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 🔭 Трассировка: экспортёр задаётся OTEL_TRACES_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	// 📦 Подключение к базе
 	dbConn, err := db.NewPostgresDB(cfg)
 	if err != nil {
@@ -44,22 +75,14 @@ func main() {
 	}
 	defer dbConn.Close()
 
-	// ✅ Проверка аргументов
-	if len(os.Args) > 1 && os.Args[1] == "migrate" {
-		logger.Sugar().Info("Running goose migrations...")
-		if err := goose.Up(dbConn.DB, "migrations"); err != nil {
-			logger.Fatal("Failed to apply migrations", zap.Error(err))
-		}
-		logger.Sugar().Info("Migrations completed.")
-		return
-	}
 	redisClient := redis.NewClient(cfg.GetRedisOptions())
+	defer redisClient.Close()
 
 	logger.Sugar().Infow("🔌 Подключение к Redis", "addr", cfg.RedisAddr)
 
 	logger.Sugar().Info("✅ Успешное подключение к Redis")
 
-	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+	if err := redisClient.Ping(ctx).Err(); err != nil {
 		logger.Fatal("Не удалось подключиться к Redis", zap.Error(err))
 	}
 
@@ -67,11 +90,28 @@ func main() {
 	ttl := time.Duration(cfg.RedisTTLMinutes) * time.Minute
 	redisCache := cache.NewRedisCache(redisClient, ttl, logger)
 
-	// 🚀 Запуск сервера
-	srv := app.NewServer(cfg, logger, dbConn, redisCache)
+	// 🗂 Хранилище файлов
+	fileStorage, err := storage.New(ctx, cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize file storage", zap.Error(err))
+	}
+	logger.Sugar().Infow("File storage initialized", "driver", fileStorage.Driver())
+
+	// 💳 Платёжный провайдер; без него заказы оплачиваются только при получении
+	paymentProvider, err := payments.New(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize payment provider", zap.Error(err))
+	}
+	if paymentProvider != nil {
+		logger.Sugar().Infow("Online payments enabled", "provider", paymentProvider.Name())
+	}
+
+	// 🚀 Запуск сервера; SIGINT/SIGTERM запускают плавную остановку
+	srv := app.NewServer(cfg, logger, dbConn, redisCache, fileStorage, paymentProvider)
 	logger.Sugar().Infow("Server is running", "port", cfg.Port)
 
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := srv.Run(ctx); err != nil {
 		logger.Fatal("Server failed to start", zap.Error(err))
 	}
+	logger.Info("Server stopped")
 }
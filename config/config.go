@@ -4,8 +4,11 @@ import (
 	"errors"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -22,6 +25,78 @@ type Config struct {
 	RedisTTLMinutes int
 	VAPIDPublicKey  string
 	VAPIDPrivateKey string
+	PublicBaseURL   string
+	UploadMaxSizeMB int
+
+	StorageDriver string
+	UploadDir     string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3UseSSL      bool
+	S3PublicURL   string
+
+	OrphanCleanupInterval time.Duration
+	// PriceSchedulerInterval — как часто применяются запланированные цены
+	PriceSchedulerInterval time.Duration
+	// TrashRetention — сколько удалённые записи хранятся в корзине до окончательного удаления
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	OrphanGracePeriod  time.Duration
+
+	ReviewPremoderation bool
+	ReviewStopWords     []string
+
+	// Обмен с 1С по протоколу CommerceML; без логина обмен выключен
+	Exchange1CLogin       string
+	Exchange1CPassword    string
+	Exchange1CFileLimitMB int
+	Exchange1CPriceType   string
+
+	// MigrationsDir — каталог SQL-миграций goose; по нему же /readyz проверяет, что схема базы актуальна
+	MigrationsDir string
+
+	// BackupDir — каталог логических резервных копий; он не должен раздаваться наружу
+	BackupDir string
+
+	// Таймауты HTTP-сервера. WebSocket-соединения под них не попадают.
+	HTTPReadTimeout  time.Duration
+	HTTPWriteTimeout time.Duration
+	HTTPIdleTimeout  time.Duration
+	// DBQueryTimeout ограничивает каждый SQL-запрос; обрыв соединения клиентом прерывает запрос раньше
+	DBQueryTimeout time.Duration
+	// MetricsToken — Bearer-токен для /metrics; если пуст, метрики доступны без авторизации
+	MetricsToken string
+	// Трассировка OpenTelemetry: экспортёр otlp, stdout или none и доля записываемых трасс (0..1)
+	TraceExporter    string
+	TraceServiceName string
+	TraceSampleRatio float64
+
+	// LoginMaxAttempts — сколько неудачных попыток входа подряд приводят к блокировке аккаунта на LoginLockout
+	LoginMaxAttempts int
+	LoginLockout     time.Duration
+
+	// IdempotencyTTL — сколько хранится ответ на запрос с Idempotency-Key и возвращается на его повторы
+	IdempotencyTTL time.Duration
+
+	// Онлайн-оплата: провайдер fake или cloudpayments; пустой — оплата только при получении.
+	// PaymentAPISecret подписывает и вебхуки провайдера.
+	PaymentProvider  string
+	PaymentPublicID  string
+	PaymentAPISecret string
+	PaymentAPIURL    string
+	// PaymentReturnURL — куда провайдер возвращает покупателя после оплаты; %d заменяется номером заказа
+	PaymentReturnURL string
+
+	// RateLimitPolicies — лимиты запросов по маршрутам (RATE_LIMITS); политика с маршрутом "*" действует по умолчанию
+	RateLimitPolicies []RateLimitPolicy
+	// TrustedProxies — адреса и подсети прокси, которым доверяется X-Forwarded-For и X-Real-IP
+	TrustedProxies []netip.Prefix
+
+	// ShutdownTimeout — сколько при остановке ждать текущие запросы и фоновую работу
+	ShutdownTimeout time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -32,6 +107,55 @@ func LoadConfig() (*Config, error) {
 	if err != nil || ttlMinutes <= 0 {
 		ttlMinutes = 10 // значение по умолчанию
 	}
+
+	uploadMaxSizeMB, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_SIZE_MB"))
+	if err != nil || uploadMaxSizeMB <= 0 {
+		uploadMaxSizeMB = 10
+	}
+
+	exchangeFileLimitMB, err := strconv.Atoi(os.Getenv("EXCHANGE_1C_FILE_LIMIT_MB"))
+	if err != nil || exchangeFileLimitMB <= 0 {
+		exchangeFileLimitMB = 50
+	}
+
+	loginMaxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || loginMaxAttempts <= 0 {
+		loginMaxAttempts = 10
+	}
+
+	migrationsDir := os.Getenv("MIGRATIONS_DIR")
+	if migrationsDir == "" {
+		migrationsDir = "migrations"
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "chechnya-product"
+	}
+
+	backupDir := os.Getenv("BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = "backups"
+	}
+
+	publicBaseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if publicBaseURL == "" {
+		publicBaseURL = "https://chechnya-product.ru"
+	}
+	paymentAPIURL := strings.TrimRight(os.Getenv("PAYMENT_API_URL"), "/")
+	if paymentAPIURL == "" {
+		paymentAPIURL = "https://api.cloudpayments.ru"
+	}
+	paymentReturnURL := os.Getenv("PAYMENT_RETURN_URL")
+	if paymentReturnURL == "" {
+		paymentReturnURL = publicBaseURL + "/orders/%d"
+	}
+
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+
 	cfg := &Config{
 		DBHost:          os.Getenv("DB_HOST"),
 		DBPort:          os.Getenv("DB_PORT"),
@@ -46,6 +170,70 @@ func LoadConfig() (*Config, error) {
 		RedisTTLMinutes: ttlMinutes,
 		VAPIDPublicKey:  os.Getenv("VAPID_PUBLIC_KEY"),
 		VAPIDPrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		PublicBaseURL:   publicBaseURL,
+		UploadMaxSizeMB: uploadMaxSizeMB,
+
+		StorageDriver: os.Getenv("STORAGE_DRIVER"),
+		UploadDir:     uploadDir,
+		S3Endpoint:    os.Getenv("S3_ENDPOINT"),
+		S3Region:      os.Getenv("S3_REGION"),
+		S3Bucket:      os.Getenv("S3_BUCKET"),
+		S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:      os.Getenv("S3_USE_SSL") != "false",
+		S3PublicURL:   os.Getenv("S3_PUBLIC_URL"),
+
+		OrphanCleanupInterval: getEnvHours("ORPHAN_CLEANUP_INTERVAL_HOURS", 24),
+		OrphanGracePeriod:     getEnvHours("ORPHAN_GRACE_HOURS", 24),
+
+		PriceSchedulerInterval: getEnvSeconds("PRICE_SCHEDULER_INTERVAL_SECONDS", 60),
+
+		TrashRetention:     getEnvHours("TRASH_RETENTION_DAYS", 30) * 24,
+		TrashPurgeInterval: getEnvHours("TRASH_PURGE_INTERVAL_HOURS", 24),
+
+		ReviewPremoderation: os.Getenv("REVIEW_PREMODERATION") != "false",
+		ReviewStopWords:     getEnvList("REVIEW_STOP_WORDS"),
+
+		Exchange1CLogin:       os.Getenv("EXCHANGE_1C_LOGIN"),
+		Exchange1CPassword:    os.Getenv("EXCHANGE_1C_PASSWORD"),
+		Exchange1CFileLimitMB: exchangeFileLimitMB,
+		Exchange1CPriceType:   os.Getenv("EXCHANGE_1C_PRICE_TYPE"),
+
+		MigrationsDir: migrationsDir,
+		BackupDir:     backupDir,
+
+		HTTPReadTimeout:  getEnvSeconds("HTTP_READ_TIMEOUT_SECONDS", 60),
+		HTTPWriteTimeout: getEnvSeconds("HTTP_WRITE_TIMEOUT_SECONDS", 120),
+		HTTPIdleTimeout:  getEnvSeconds("HTTP_IDLE_TIMEOUT_SECONDS", 120),
+		ShutdownTimeout:  getEnvSeconds("SHUTDOWN_TIMEOUT_SECONDS", 30),
+		DBQueryTimeout:   getEnvSeconds("DB_QUERY_TIMEOUT_SECONDS", 30),
+
+		LoginMaxAttempts: loginMaxAttempts,
+		LoginLockout:     getEnvSeconds("LOGIN_LOCKOUT_SECONDS", 900),
+		IdempotencyTTL:   getEnvHours("IDEMPOTENCY_TTL_HOURS", 24),
+
+		PaymentProvider:  strings.ToLower(os.Getenv("PAYMENT_PROVIDER")),
+		PaymentPublicID:  os.Getenv("PAYMENT_PUBLIC_ID"),
+		PaymentAPISecret: os.Getenv("PAYMENT_API_SECRET"),
+		PaymentAPIURL:    paymentAPIURL,
+		PaymentReturnURL: paymentReturnURL,
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+
+		TraceExporter:    os.Getenv("OTEL_TRACES_EXPORTER"),
+		TraceServiceName: serviceName,
+		TraceSampleRatio: getEnvRatio("OTEL_TRACES_SAMPLER_ARG", 1),
+	}
+
+	rateLimits := os.Getenv("RATE_LIMITS")
+	if rateLimits == "" {
+		rateLimits = defaultRateLimits
+	}
+	if cfg.RateLimitPolicies, err = ParseRateLimits(rateLimits); err != nil {
+		return nil, err
+	}
+	if cfg.TrustedProxies, err = ParseTrustedProxies(getEnvList("TRUSTED_PROXIES")); err != nil {
+		return nil, err
 	}
 
 	if cfg.Port == "" || cfg.JWTSecret == "" || cfg.RedisAddr == "" {
@@ -55,6 +243,36 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// getEnvHours читает длительность в часах; при пустом или некорректном значении возвращает значение по умолчанию
+func getEnvHours(key string, def int) time.Duration {
+	hours, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || hours <= 0 {
+		hours = def
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// getEnvList читает список значений через запятую, пропуская пустые
+func getEnvList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// IsProduction сообщает, запущено ли приложение в боевом окружении. Пустой или неизвестный
+// ENV считается продакшеном, чтобы очистка таблиц и демо-данные не включились по забывчивости.
+func (c *Config) IsProduction() bool {
+	switch strings.ToLower(c.Env) {
+	case "dev", "development", "local", "test", "staging":
+		return false
+	}
+	return true
+}
+
 func (c *Config) GetRedisOptions() *redis.Options {
 	return &redis.Options{
 		Addr:     c.RedisAddr,
@@ -62,3 +280,21 @@ func (c *Config) GetRedisOptions() *redis.Options {
 		DB:       0,
 	}
 }
+
+// getEnvSeconds читает длительность в секундах; при пустом или некорректном значении возвращает значение по умолчанию
+func getEnvSeconds(key string, def int) time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || seconds <= 0 {
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// getEnvRatio читает долю от 0 до 1; при пустом или некорректном значении возвращает значение по умолчанию
+func getEnvRatio(key string, def float64) float64 {
+	ratio, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return def
+	}
+	return ratio
+}
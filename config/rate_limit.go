@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRateLimits — лимиты, если RATE_LIMITS не задан. Вход, регистрация и checkauth обмена с 1С
+// ограничены строже всего, чтобы пароли нельзя было подбирать перебором.
+const defaultRateLimits = "POST /api/login=5/1m; POST /api/register=5/1m; /api/1c/exchange=5/1m; POST /api/cart=60/1m; POST /api/cart/bulk=30/1m; *=120/1m"
+
+// RateLimitPolicy — не больше Limit запросов за скользящее окно Window.
+// Route — шаблон маршрута mux ("/api/products/{id}") или "*" для политики по умолчанию;
+// пустой Method подходит к любому методу.
+type RateLimitPolicy struct {
+	Method string
+	Route  string
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRateLimits разбирает политики вида "POST /api/login=5/1m; /api/cart=60/1m; *=120/1m",
+// разделённые точкой с запятой. Окно записывается как длительность Go (30s, 1m, 1h).
+func ParseRateLimits(value string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		policy, err := parseRateLimit(item)
+		if err != nil {
+			return nil, fmt.Errorf("RATE_LIMITS: %q: %w", item, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parseRateLimit(item string) (RateLimitPolicy, error) {
+	var policy RateLimitPolicy
+
+	target, rule, ok := strings.Cut(item, "=")
+	if !ok {
+		return policy, fmt.Errorf("expected <route>=<limit>/<window>")
+	}
+
+	fields := strings.Fields(target)
+	switch len(fields) {
+	case 1:
+		policy.Route = fields[0]
+	case 2:
+		policy.Method = strings.ToUpper(fields[0])
+		policy.Route = fields[1]
+	default:
+		return policy, fmt.Errorf("expected [METHOD] <route>")
+	}
+	if policy.Method != "" && !validMethod(policy.Method) {
+		return policy, fmt.Errorf("unknown method %s", policy.Method)
+	}
+	if policy.Route != "*" && !strings.HasPrefix(policy.Route, "/") {
+		return policy, fmt.Errorf("route must start with / or be *")
+	}
+
+	limit, window, ok := strings.Cut(strings.TrimSpace(rule), "/")
+	if !ok {
+		return policy, fmt.Errorf("expected <limit>/<window>")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n <= 0 {
+		return policy, fmt.Errorf("limit must be a positive integer")
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d < time.Second {
+		return policy, fmt.Errorf("window must be a duration of at least 1s")
+	}
+	policy.Limit = n
+	policy.Window = d
+	return policy, nil
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// ParseTrustedProxies разбирает адреса и подсети доверенных прокси ("10.0.0.0/8", "127.0.0.1")
+func ParseTrustedProxies(items []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(items))
+	for _, item := range items {
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
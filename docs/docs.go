@@ -15,39 +15,111 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/admin//upload/{filename}": {
-            "delete": {
+        "/api/1c/exchange": {
+            "get": {
+                "description": "Точка обмена по стандартному протоколу 1С-Битрикс. Ответы — text/plain: \"success\" или \"failure\\nпричина\".\ntype=catalog: checkauth (Basic-авторизация, логин и пароль из EXCHANGE_1C_LOGIN / EXCHANGE_1C_PASSWORD) → init → file (тело запроса — содержимое файла, может приходить частями) → import (import.xml — группы и товары, offers.xml — цены и остатки).\ntype=sale: checkauth → init → query (XML с новыми заказами) → success (подтверждение, после него заказы больше не выгружаются).",
+                "consumes": [
+                    "application/octet-stream"
+                ],
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "Загрузка"
+                    "1С"
                 ],
-                "summary": "Удалить изображение",
+                "summary": "Обмен с 1С (CommerceML)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Имя файла",
-                        "name": "filename",
-                        "in": "path",
+                        "description": "catalog или sale",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "checkauth, init, file, import, query или success",
+                        "name": "mode",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя файла для file и import",
+                        "name": "filename",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "success",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "failure",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток checkauth",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    }
+                }
+            },
+            "post": {
+                "description": "Точка обмена по стандартному протоколу 1С-Битрикс. Ответы — text/plain: \"success\" или \"failure\\nпричина\".\ntype=catalog: checkauth (Basic-авторизация, логин и пароль из EXCHANGE_1C_LOGIN / EXCHANGE_1C_PASSWORD) → init → file (тело запроса — содержимое файла, может приходить частями) → import (import.xml — группы и товары, offers.xml — цены и остатки).\ntype=sale: checkauth → init → query (XML с новыми заказами) → success (подтверждение, после него заказы больше не выгружаются).",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "1С"
+                ],
+                "summary": "Обмен с 1С (CommerceML)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "catalog или sale",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
                     },
-                    "404": {
-                        "description": "Not Found",
+                    {
+                        "type": "string",
+                        "description": "checkauth, init, file, import, query или success",
+                        "name": "mode",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя файла для file и import",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "failure",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток checkauth",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -199,40 +271,91 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/categories": {
-            "post": {
+        "/api/admin/audit": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Добавляет новую категорию (только для администратора)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Все изменяющие запросы к /api/admin: кто, когда, над какой сущностью, что изменилось (changes: поле → before/after), IP и request id. Новые записи первыми.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Категории"
+                    "Журнал"
                 ],
-                "summary": "Создать новую категорию",
+                "summary": "Журнал действий администраторов",
                 "parameters": [
                     {
-                        "description": "Название категории",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/utils.CategoryRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID администратора",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие, например \\",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип сущности: product, category, order, user, review, announcement, file, table, catalog...",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сущности",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода, не включая (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.AuditEntry"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -240,168 +363,166 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/admin/categories/bulk": {
-            "post": {
+        "/api/admin/audit/export": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Добавляет несколько категорий сразу (только для администратора)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Те же фильтры, что и у списка, но без постраничной разбивки",
                 "produces": [
-                    "application/json"
+                    "text/csv"
                 ],
                 "tags": [
-                    "Категории"
+                    "Журнал"
                 ],
-                "summary": "Массовое создание категорий",
+                "summary": "Экспорт журнала действий в CSV",
                 "parameters": [
                     {
-                        "description": "Список категорий",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/utils.CategoryRequest"
-                            }
-                        }
+                        "type": "integer",
+                        "description": "ID администратора",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип сущности",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сущности",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода, не включая (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Categories created",
+                    "200": {
+                        "description": "CSV файл",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid body",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/admin/categories/{id}": {
-            "put": {
+        "/api/admin/backups": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Изменяет название категории (только для администратора)",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Категории"
-                ],
-                "summary": "Обновить категорию",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Идентификатор категории",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Новое имя категории",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/utils.CategoryRequest"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Список резервных копий",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.BackupInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет категорию по ID (только для администратора)",
+                "description": "Выгружает пользователей, каталог, цены, заказы, отзывы и объявления в JSON-файл (строки в формате to_jsonb). Корзины, подписки и журнал действий в копию не входят.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Категории"
+                    "Admin"
                 ],
-                "summary": "Удалить категорию",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID категории",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Category deleted",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "400": {
-                        "description": "Удаление не удалось",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/admin/dashboard": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Возвращает метрики: заказы, выручка, топ товары, продажи по дням",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Дэшборд"
-                ],
-                "summary": "Дэшборд администратора",
+                "summary": "Создать резервную копию",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.DashboardData"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BackupInfo"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -413,46 +534,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/logs": {
-            "get": {
+        "/api/admin/backups/upload": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает лог за указанную дату. Поддерживает скачивание. Тип: info или error.",
+                "description": "Сохраняет файл копии (например, снятой на другом сервере) для последующего восстановления. Файл проверяется целиком.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Логи"
+                    "Admin"
                 ],
-                "summary": "Получить лог-файл",
+                "summary": "Загрузить резервную копию",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Тип логов: info (по умолчанию) или error",
-                        "name": "type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Дата в формате YYYY-MM-DD (по умолчанию — сегодня)",
-                        "name": "date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Скачать файл (true) или отобразить в браузере",
-                        "name": "download",
-                        "in": "query"
+                        "type": "file",
+                        "description": "Файл копии",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Содержимое лог-файла",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.BackupInfo"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -461,14 +586,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -476,87 +595,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/orders": {
+        "/api/admin/backups/{name}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список всех заказов (только для админа)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Admin"
                 ],
-                "summary": "Получить все заказы (админ)",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/admin/orders/export": {
-            "get": {
-                "security": [
+                "summary": "Скачать резервную копию",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Имя файла копии",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "Экспортирует все заказы в формате CSV (только для админа)",
-                "produces": [
-                    "text/csv"
-                ],
-                "tags": [
-                    "Заказ"
-                ],
-                "summary": "Экспорт заказов в CSV (админ)",
                 "responses": {
                     "200": {
-                        "description": "CSV файл",
+                        "description": "Файл копии",
                         "schema": {
                             "type": "file"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/admin/orders/{id}": {
+            },
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Заказ"
+                    "Admin"
                 ],
-                "summary": "Удаление заказа по ID (только для админов)",
+                "summary": "Удалить резервную копию",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Имя файла копии",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -568,8 +662,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -577,13 +671,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/orders/{id}/status": {
-            "patch": {
+        "/api/admin/backups/{name}/restore": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Заменяет все данные из копии (включая пользователей) её содержимым; корзины покупателей очищаются. Копия должна быть снята на той же версии схемы (409 иначе). Первый запрос без confirm_token возвращает 202 с токеном, восстановление выполняется повторным запросом с токеном.",
                 "consumes": [
                     "application/json"
                 ],
@@ -591,24 +686,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Admin"
                 ],
-                "summary": "Обновить статус заказа",
+                "summary": "Восстановить данные из резервной копии",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Имя файла копии",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новый статус",
-                        "name": "status",
+                        "description": "Токен подтверждения",
+                        "name": "input",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.OrderStatusRequest"
+                            "$ref": "#/definitions/handlers.ConfirmRequest"
                         }
                     }
                 ],
@@ -619,14 +713,38 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Confirmation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -634,40 +752,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/products": {
-            "post": {
+        "/api/admin/catalog/export": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создаёт новый товар (только для администратора)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Выгружает все товары в формате, который принимает импорт",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "Товар"
+                    "Каталог"
                 ],
-                "summary": "Добавить товар (админ)",
+                "summary": "Экспорт каталога в CSV/XLSX (админ)",
                 "parameters": [
                     {
-                        "description": "Данные товара",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Product"
-                        }
+                        "type": "string",
+                        "description": "csv (по умолчанию) или xlsx",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "Файл каталога",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -676,12 +789,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -691,46 +798,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/products/bulk": {
+        "/api/admin/catalog/import": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Добавляет несколько товаров сразу (только для администратора)",
+                "description": "Первая строка — заголовки: sku (артикул), name (название), description, price (цена), availability (в наличии: да/нет), category (название категории), url. Товар ищется по артикулу, затем по названию; найденный обновляется, иначе создаётся. Пустая ячейка значение не меняет. С dry_run=true ничего не сохраняется — возвращается построчный отчёт с изменениями и ошибками. Без dry_run изменения применяются одной транзакцией и только если ошибок нет.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Каталог"
                 ],
-                "summary": "Массовое добавление товаров (админ)",
+                "summary": "Импорт каталога из CSV/XLSX (админ)",
                 "parameters": [
                     {
-                        "description": "Массив товаров",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Product"
-                            }
-                        }
+                        "type": "file",
+                        "description": "Файл .csv или .xlsx",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv или xlsx (по умолчанию — по расширению файла)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить и показать изменения",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.ProductResponse"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CatalogImportReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -739,62 +862,67 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CatalogImportReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             }
         },
-        "/api/admin/products/{id}": {
-            "put": {
+        "/api/admin/categories": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет существующий товар по его ID (только для администратора)",
+                "description": "Добавляет новую категорию (только для администратора)",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Категории"
                 ],
-                "summary": "Обновить товар (админ)",
+                "summary": "Создать новую категорию",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Новые данные товара",
+                        "description": "Название, родитель, slug, описание и изображение категории",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/utils.CategoryRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.ProductResponse"
+                            "$ref": "#/definitions/models.Category"
                         }
                     },
                     "400": {
@@ -802,78 +930,90 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/admin/categories/bulk": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет товар по его ID (только для администратора)",
+                "description": "Добавляет несколько категорий сразу (только для администратора)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Категории"
                 ],
-                "summary": "Удалить товар (админ)",
+                "summary": "Массовое создание категорий",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Список категорий",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/utils.CategoryRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Product deleted",
+                    "201": {
+                        "description": "Categories created",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос с этим Idempotency-Key ещё выполняется",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "413": {
+                        "description": "Тело запроса больше middleware.MaxJSONBody",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/api/admin/categories/{id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет отдельные поля товара по его ID (только для администратора)",
+                "description": "Изменяет название, порядок, slug, описание или изображение категории (только для администратора). Пустая строка очищает описание и изображение.",
                 "consumes": [
                     "application/json"
                 ],
@@ -881,26 +1021,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Категории"
                 ],
-                "summary": "Частичное обновление товара (админ)",
+                "summary": "Обновить категорию",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID товара",
+                        "description": "Идентификатор категории",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Поля для обновления товара",
+                        "description": "Изменяемые поля",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ProductPatchInput"
+                            "$ref": "#/definitions/models.CategoryUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Category"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет категорию по ID (только для администратора). Подкатегории переходят к родителю удаляемой категории. Если в категории есть товары, нужно указать reassign_to — категорию, куда их перенести, иначе удаление отклоняется. Категория попадает в корзину, откуда её можно восстановить.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Удалить категорию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID категории для переноса товаров",
+                        "name": "reassign_to",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -915,14 +1111,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -930,13 +1126,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/truncate": {
-            "post": {
+        "/api/admin/categories/{id}/move": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Делает категорию подкатегорией parent_id (null — корневой). Перенос в саму себя или в свою подкатегорию запрещён.",
                 "consumes": [
                     "application/json"
                 ],
@@ -944,17 +1141,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin"
+                    "Категории"
                 ],
-                "summary": "Очистка таблицы  с  перезапуском ID",
+                "summary": "Перенести категорию",
                 "parameters": [
                     {
-                        "description": "Название таблицы для очистки",
-                        "name": "table",
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый родитель и порядок",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TruncateRequest"
+                            "$ref": "#/definitions/models.CategoryMoveRequest"
                         }
                     }
                 ],
@@ -962,7 +1166,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Category"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -970,33 +1186,46 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/admin/truncate/all": {
-            "post": {
+        "/api/admin/dashboard": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Возвращает метрики: заказы, выручка, топ товары, продажи по дням",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin"
+                    "Дэшборд"
                 ],
-                "summary": "Очистить все таблицы (только админ)",
+                "summary": "Дэшборд администратора",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "$ref": "#/definitions/models.DashboardData"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1004,23 +1233,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/upload": {
+        "/api/admin/logs": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает лог за указанную дату. Поддерживает скачивание. Тип: info или error.",
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "Загрузка"
+                    "Логи"
+                ],
+                "summary": "Получить лог-файл",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип логов: info (по умолчанию) или error",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата в формате YYYY-MM-DD (по умолчанию — сегодня)",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Скачать файл (true) или отобразить в браузере",
+                        "name": "download",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получить список изображений",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Содержимое лог-файла",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.UploadedFile"
-                            }
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1030,25 +1294,77 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "consumes": [
-                    "multipart/form-data"
+            }
+        },
+        "/api/admin/logs/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Ищет записи во всех хранимых файлах журнала, новые первыми. С follow=true ответ — поток NDJSON:\nсначала последние limit подходящих записей по порядку, затем новые записи по мере появления, пока клиент не закроет соединение.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Загрузка"
+                    "Логи"
                 ],
-                "summary": "Загрузить изображение",
+                "summary": "Поиск по журналу приложения",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Файл изображения",
-                        "name": "image",
-                        "in": "formData",
-                        "required": true
+                        "type": "string",
+                        "description": "Уровни через запятую: debug, info, warn, error",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода, не включая (RFC3339 или YYYY-MM-DD); в режиме follow не применяется",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID запроса (заголовок X-Request-ID)",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Текст, который ищется во всей записи без учёта регистра",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение; в режиме follow не применяется",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Следить за журналом",
+                        "name": "follow",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1063,10 +1379,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": {
-                                                "type": "string"
-                                            }
+                                            "$ref": "#/definitions/models.LogPage"
                                         }
                                     }
                                 }
@@ -1078,42 +1391,9 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/admin/users": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Админ"
-                ],
-                "summary": "Создать пользователя по номеру телефона",
-                "parameters": [
-                    {
-                        "description": "Номер телефона",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateByPhoneRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1121,27 +1401,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/users/all": {
+        "/api/admin/orders": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Возвращает список всех заказов (только для админа)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Заказ"
                 ],
-                "summary": "Получить всех пользователей",
+                "summary": "Получить все заказы (админ)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/models.Order"
                             }
                         }
                     },
@@ -1154,78 +1435,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/users/{id}": {
+        "/api/admin/orders/export": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Экспортирует заказы в формате CSV (только для админа). Без from и to выгружаются все заказы.",
                 "produces": [
-                    "application/json"
+                    "text/csv"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Заказ"
                 ],
-                "summary": "Получить пользователя по ID",
+                "summary": "Экспорт заказов в CSV (админ)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода, не включая (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "CSV файл",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.User"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Некорректный ID",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/announcements": {
-            "get": {
-                "tags": [
-                    "Объявления"
-                ],
-                "summary": "Получить все объявления",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Announcement"
-                            }
-                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -1236,16 +1486,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/announcements/{id}": {
-            "get": {
+        "/api/admin/orders/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заказ перемещается в корзину и пропадает из списков и статистики; его можно восстановить.",
                 "tags": [
-                    "Объявления"
+                    "Заказ"
                 ],
-                "summary": "Получить объявление по ID",
+                "summary": "Удаление заказа по ID (только для админов)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID",
+                        "description": "ID заказа",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1255,7 +1511,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Announcement"
+                            "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
                     "400": {
@@ -1263,31 +1519,69 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/api/cart": {
-            "get": {
-                "description": "Возвращает список товаров в корзине для owner_id",
-                "produces": [
-                    "application/json"
-                ],
+        "/api/admin/orders/{id}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Неоплаченный онлайн-заказ можно только отклонить. При отклонении ожидающий платёж отменяется, оплаченный — возвращается.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Корзина"
+                    "Заказ"
+                ],
+                "summary": "Обновить статус заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый статус",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderStatusRequest"
+                        }
+                    }
                 ],
-                "summary": "Получить содержимое корзины",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CartBulkResponse"
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Онлайн-заказ ещё не оплачен",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1295,37 +1589,50 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Статус изменён, но вернуть оплату не удалось",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/products": {
             "post": {
-                "description": "Добавляет товар в корзину по owner_id (user или ip)",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт новый товар (только для администратора)",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Корзина"
+                    "Товар"
                 ],
-                "summary": "Добавить товар в корзину",
+                "summary": "Добавить товар (админ)",
                 "parameters": [
                     {
-                        "description": "ID товара и количество",
+                        "description": "Данные товара",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.AddToCartRequest"
+                            "$ref": "#/definitions/models.Product"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Added to cart",
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
                     "400": {
@@ -1333,13 +1640,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/cart/bulk": {
+        "/api/admin/products/bulk": {
             "post": {
-                "description": "Добавляет несколько товаров в корзину за один запрос (bulk).",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет несколько товаров сразу (только для администратора)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1347,72 +1671,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Корзина"
+                    "Товар"
                 ],
-                "summary": "Добавить несколько товаров в корзину",
+                "summary": "Массовое добавление товаров (админ)",
                 "parameters": [
                     {
-                        "description": "Список товаров для добавления",
+                        "description": "Массив товаров",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.AddToCartRequest"
+                                "$ref": "#/definitions/models.Product"
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Товары добавлены, возвращены список и сумма",
+                        "description": "Created",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/utils.SuccessResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.CartBulkResponse"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера при получении корзины",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/cart/clear": {
-            "delete": {
-                "description": "Удаляет все товары из корзины owner_id",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "Корзина"
-                ],
-                "summary": "Очистить корзину",
-                "responses": {
-                    "200": {
-                        "description": "Cart cleared",
+                    },
+                    "409": {
+                        "description": "Запрос с этим Idempotency-Key ещё выполняется",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше middleware.MaxJSONBody",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1424,9 +1743,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/cart/{product_id}": {
+        "/api/admin/products/{id}": {
             "put": {
-                "description": "Обновляет количество указанного товара для owner_id",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет существующий товар по его ID (только для администратора)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1434,32 +1758,32 @@ const docTemplate = `{
                     "text/plain"
                 ],
                 "tags": [
-                    "Корзина"
+                    "Товар"
                 ],
-                "summary": "Обновить количество товара в корзине",
+                "summary": "Обновить товар (админ)",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "ID товара",
-                        "name": "product_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новое количество",
+                        "description": "Новые данные товара",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/utils.UpdateItemRequest"
+                            "$ref": "#/definitions/models.Product"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Quantity updated",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ProductResponse"
                         }
                     },
                     "400": {
@@ -1467,61 +1791,61 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             },
             "delete": {
-                "description": "Удаляет товар по ID из корзины owner_id",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Перемещает товар в корзину (только для администратора). Его можно восстановить до окончательной очистки.",
                 "produces": [
                     "text/plain"
                 ],
                 "tags": [
-                    "Корзина"
+                    "Товар"
                 ],
-                "summary": "Удалить товар из корзины",
+                "summary": "Удалить товар (админ)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Идентификатор товара, который нужно удалить или обновить",
-                        "name": "product_id",
+                        "description": "ID товара",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Item deleted",
+                        "description": "Product deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/categories": {
-            "get": {
-                "description": "Возвращает все доступные категории товаров",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Категории"
-                ],
-                "summary": "Получить список категорий",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1531,29 +1855,39 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/login": {
-            "post": {
-                "description": "Вход по телефону/почте и паролю. Возвращает JWT токен при успехе.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет отдельные поля товара по его ID (только для администратора)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Товар"
                 ],
-                "summary": "Вход пользователя",
+                "summary": "Частичное обновление товара (админ)",
                 "parameters": [
                     {
-                        "description": "Телефон/почта и пароль",
-                        "name": "login",
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления товара",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
+                            "$ref": "#/definitions/models.ProductPatchInput"
                         }
                     }
                 ],
@@ -1561,7 +1895,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginResponse"
+                            "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
                     "400": {
@@ -1570,8 +1904,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1579,163 +1919,239 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/me": {
-            "get": {
+        "/api/admin/products/{id}/images": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает данные профиля для авторизованного пользователя",
+                "description": "Загружает изображение, генерирует уменьшенные копии и добавляет его в конец галереи. Первое изображение становится основным.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Изображения товара"
+                ],
+                "summary": "Добавить изображение в галерею (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл изображения",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "Получить профиль пользователя",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserProfileResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductImage"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/me/address": {
-            "get": {
+        "/api/admin/products/{id}/images/order": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает адрес текущего авторизованного пользователя",
+                "description": "Принимает полный список ID изображений товара в новом порядке",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Изображения товара"
                 ],
-                "summary": "Получить адрес пользователя",
-                "responses": {
-                    "200": {
-                        "description": "Адрес пользователя",
+                "summary": "Изменить порядок изображений (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ID изображений в новом порядке",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.ProductImagesOrderRequest"
                         }
-                    },
-                    "401": {
-                        "description": "Не авторизован",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ProductImage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "500": {
-                        "description": "Ошибка получения адреса",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/admin/products/{id}/images/{image_id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет или добавляет адрес текущего пользователя",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Удаляет изображение и его файлы. Если оно было основным, основным становится следующее.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Изображения товара"
                 ],
-                "summary": "Обновить адрес пользователя",
+                "summary": "Удалить изображение из галереи (админ)",
                 "parameters": [
                     {
-                        "description": "Новый адрес",
-                        "name": "address",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID изображения",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Адрес обновлён",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный JSON",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Не авторизован",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка обновления адреса",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/admin/products/{id}/images/{image_id}/primary": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Очищает поле адреса у текущего пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Изображения товара"
+                ],
+                "summary": "Сделать изображение основным (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID изображения",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Удалить адрес пользователя",
                 "responses": {
                     "200": {
-                        "description": "Адрес удалён",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка удаления адреса",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1743,28 +2159,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/order": {
-            "post": {
-                "description": "Оформляет заказ из текущей корзины по owner_id. Можно указать координаты (latitude и longitude), чтобы рассчитать доставку.",
-                "consumes": [
-                    "application/json"
+        "/api/admin/products/{id}/price-history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Все изменения цены товара — новые первыми: кто, когда и откуда (admin, import, 1c, schedule) поменял цену",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Цены"
                 ],
-                "summary": "Оформить заказ",
+                "summary": "История цен товара (админ)",
                 "parameters": [
                     {
-                        "description": "Данные заказа с координатами",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PlaceOrderRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1779,7 +2201,10 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Order"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.PriceHistoryEntry"
+                                            }
                                         }
                                     }
                                 }
@@ -1791,11 +2216,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/order-reviews": {
+        "/api/admin/products/{id}/scheduled-prices": {
             "get": {
                 "security": [
                     {
@@ -1806,50 +2237,122 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы заказов"
+                    "Цены"
                 ],
-                "summary": "Получить все отзывы на заказы",
-                "responses": {
+                "summary": "Запланированные цены товара (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Показать и уже применённые",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.OrderReview"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ScheduledPrice"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/orders": {
-            "get": {
-                "description": "Возвращает список заказов для текущего owner_id",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Цена будет установлена фоновой задачей в момент starts_at. old_price — зачёркнутая цена на время акции; без неё зачёркнутая цена снимается. Для акции планируются две записи: цена со скидкой на начало и обычная цена на окончание.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Цены"
+                ],
+                "summary": "Запланировать смену цены (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена и время начала",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledPriceRequest"
+                        }
+                    }
                 ],
-                "summary": "Получить заказы пользователя",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ScheduledPrice"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1857,27 +2360,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/history": {
-            "get": {
+        "/api/admin/products/{id}/scheduled-prices/{schedule_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Цены"
+                ],
+                "summary": "Отменить запланированную цену (админ)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID запланированной цены",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "История заказов пользователя",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
+                            "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1885,23 +2412,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/{id}": {
+        "/api/admin/reviews": {
             "get": {
-                "description": "Возвращает заказ с товарами по ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "По умолчанию — отзывы, ожидающие проверки, от самых старых к новым",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказ"
+                    "Отзывы"
                 ],
-                "summary": "Получить заказ по ID",
+                "summary": "Очередь модерации отзывов (админ)",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Статус: pending (по умолчанию), approved, rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Количество (по умолчанию 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1916,7 +2459,8 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Order"
+                                            "type": "object",
+                                            "additionalProperties": true
                                         }
                                     }
                                 }
@@ -1924,13 +2468,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Некорректный ID",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Заказ не найден",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -1938,31 +2482,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/orders/{id}/repeat": {
-            "post": {
+        "/api/admin/reviews/analytics": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Средние оценки товаров, заказов, доставки и курьеров по дням, неделям или месяцам (по умолчанию — по дням за последние 30 дней) и товары с самым низким рейтингом",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Заказ"
+                    "Отзывы"
                 ],
-                "summary": "Повторить заказ",
+                "summary": "Аналитика оценок (админ)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID заказа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "day (по умолчанию), week или month",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.FeedbackAnalytics"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1970,26 +2541,50 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/orders/{id}/review": {
-            "get": {
+        "/api/admin/reviews/{id}/moderate": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Для отклонения обязательна причина. Рейтинг товара пересчитывается сразу.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы заказов"
+                    "Отзывы"
                 ],
-                "summary": "Получить отзыв к заказу",
+                "summary": "Модерация отзыва (админ)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID заказа",
+                        "description": "ID отзыва",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Решение модератора",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewModerationRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -2004,13 +2599,19 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.OrderReview"
+                                            "$ref": "#/definitions/models.Review"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2018,13 +2619,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/api/admin/reviews/{id}/reply": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Ответ показывается под отзывом. Пустой ответ удаляет его.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2032,24 +2636,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы заказов"
+                    "Отзывы"
                 ],
-                "summary": "Оставить отзыв к заказу",
+                "summary": "Ответить на отзыв (админ)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID заказа",
+                        "description": "ID отзыва",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Комментарий и оценка (1–5)",
-                        "name": "review",
+                        "description": "Текст ответа",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.OrderReviewRequest"
+                            "$ref": "#/definitions/models.ReviewReplyRequest"
                         }
                     }
                 ],
@@ -2057,7 +2661,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Review"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -2065,76 +2681,102 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/products": {
+        "/api/admin/search/zero-results": {
             "get": {
-                "description": "Получает список товаров с возможностью фильтрации и пагинации",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Самые частые поисковые запросы, по которым ничего не нашлось",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Поиск"
                 ],
-                "summary": "Получить список товаров",
+                "summary": "Запросы без результатов (админ)",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Поиск по названию или описанию",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID категории",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Минимальная цена",
-                        "name": "min_price",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Максимальная цена",
-                        "name": "max_price",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Сортировка (price_asc, price_desc, name_asc, name_desc, available_first)",
-                        "name": "sort",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "description": "Ограничение количества результатов на странице",
+                        "description": "Количество запросов (по умолчанию 20, максимум 100)",
                         "name": "limit",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SearchZeroResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/system": {
+            "get": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Смещение для пагинации",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Фильтр по наличию",
-                        "name": "availability",
-                        "in": "query"
+                        "BearerAuth": []
                     }
                 ],
+                "description": "Версия и сборка, время работы, версия схемы, число WebSocket-клиентов, пулы соединений Postgres и Redis",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Система"
+                ],
+                "summary": "Состояние экземпляра (админ)",
                 "responses": {
                     "200": {
-                        "description": "Результат с пагинацией",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.SystemInfo"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -2146,30 +2788,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/products/{id}": {
+        "/api/admin/trash": {
             "get": {
-                "description": "Возвращает детали товара по его идентификатору",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удалённые товары, категории и заказы, новые первыми. purge_at — когда запись будет удалена окончательно (TRASH_RETENTION_DAYS).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Товар"
+                    "Корзина"
                 ],
-                "summary": "Получить товар по ID",
+                "summary": "Корзина (админ)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "product, category или order (по умолчанию — все)",
+                        "name": "type",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ProductResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TrashItem"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -2177,42 +2838,42 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/api/products/{id}/reviews": {
-            "get": {
+        "/api/admin/trash/purge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сразу удаляет окончательно записи, которые лежат в корзине дольше срока хранения. Обычно это делает фоновая задача.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы"
-                ],
-                "summary": "Получить отзывы товара",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Корзина"
                 ],
+                "summary": "Очистить корзину (админ)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Review"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.TrashPurgeResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -2222,35 +2883,37 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Может обновить только тот, кто оставил (по owner_id)",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/api/admin/trash/{type}/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Товар восстанавливается без категории, если она тоже удалена; категория — корневой, если удалён её родитель. Если за это время появилась активная запись с тем же названием, артикулом или slug, возвращается 409.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы"
+                    "Корзина"
                 ],
-                "summary": "Обновить отзыв",
+                "summary": "Восстановить из корзины (админ)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
+                        "type": "string",
+                        "description": "product, category или order",
+                        "name": "type",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновлённая оценка и комментарий",
-                        "name": "review",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ReviewRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID записи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2266,16 +2929,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/truncate": {
             "post": {
-                "description": "Отзыв может оставить как авторизованный, так и гость. Повторный отзыв от одного владельца невозможен.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только вне продакшена (ENV=dev, development, local, test или staging). Первый запрос без confirm_token возвращает 202 с токеном; очистка выполняется повторным запросом с этим токеном в течение 5 минут.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2283,69 +2959,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Отзывы"
+                    "Admin"
                 ],
-                "summary": "Добавить отзыв",
+                "summary": "Очистка таблицы  с  перезапуском ID",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Оценка и комментарий",
-                        "name": "review",
+                        "description": "Название таблицы для очистки и токен подтверждения",
+                        "name": "table",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ReviewRequest"
+                            "$ref": "#/definitions/handlers.TruncateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Confirmation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Может удалить только тот, кто оставил (по owner_id)",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Отзывы"
-                ],
-                "summary": "Удалить отзыв",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID товара",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -2353,9 +3013,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/push/broadcast": {
+        "/api/admin/truncate/all": {
             "post": {
-                "description": "Рассылает сообщение всем подписанным пользователям",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только вне продакшена. Первый запрос без confirm_token возвращает 202 с токеном; очистка выполняется повторным запросом с этим токеном.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2363,20 +3028,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Push"
+                    "Admin"
                 ],
-                "summary": "Массовая рассылка push-уведомлений",
+                "summary": "Очистить все таблицы (только админ)",
                 "parameters": [
                     {
-                        "description": "Сообщение для рассылки",
+                        "description": "Токен подтверждения",
                         "name": "input",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.ConfirmRequest"
                         }
                     }
                 ],
@@ -2387,14 +3048,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
                     },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Confirmation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -2402,22 +3081,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/push/delete": {
-            "delete": {
-                "description": "Удаляет подписку по endpoint",
+        "/api/admin/upload": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Поле referenced показывает, используется ли файл каким-либо товаром",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Push"
+                    "Загрузка"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Получить список изображений",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UploadedFile"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Тип файла определяется по содержимому (JPEG, PNG, GIF, WebP). Изображение перекодируется без метаданных, дополнительно создаются уменьшенные копии.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Загрузка"
+                ],
+                "summary": "Загрузить изображение",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "URL подписки",
-                        "name": "endpoint",
-                        "in": "query",
+                        "type": "file",
+                        "description": "Файл изображения",
+                        "name": "image",
+                        "in": "formData",
                         "required": true
                     }
                 ],
@@ -2425,7 +3144,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadedImage"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -2434,8 +3165,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -2443,41 +3174,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/push/send": {
+        "/api/admin/upload/cleanup": {
             "post": {
-                "description": "Отправляет уведомление одному пользователю по подписке",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Удаляет загруженные файлы, на которые не ссылается ни один товар и которые старше периода ожидания. С dry_run=true только возвращает список.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Push"
+                    "Загрузка"
                 ],
-                "summary": "Отправить push-уведомление",
+                "summary": "Очистить неиспользуемые файлы (админ)",
                 "parameters": [
                     {
-                        "description": "Подписка и сообщение",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.pushRequest"
-                        }
+                        "type": "boolean",
+                        "description": "Только показать, что будет удалено",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/utils.SuccessResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/utils.ErrorResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -2489,33 +3228,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/push/subscribe": {
-            "post": {
-                "description": "Регистрирует push-подписку пользователя, сохраняет её в базе",
-                "consumes": [
-                    "application/json"
+        "/api/admin/upload/{filename}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Файл, который используется товаром, удалить нельзя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Push"
+                    "Загрузка"
                 ],
-                "summary": "Подписка на push-уведомления",
+                "summary": "Удалить изображение",
                 "parameters": [
                     {
-                        "description": "Объект подписки",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PushSubscriptionRequest"
-                        }
+                        "type": "string",
+                        "description": "Имя файла",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/utils.SuccessResponse"
                         }
@@ -2526,8 +3265,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/utils.ErrorResponse"
                         }
@@ -2535,9 +3280,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/register": {
+        "/api/admin/users": {
             "post": {
-                "description": "Регистрирует нового пользователя по телефону, паролю, имени и e-mail",
                 "consumes": [
                     "application/json"
                 ],
@@ -2545,17 +3289,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Профиль"
+                    "Админ"
                 ],
-                "summary": "Зарегистрировать нового пользователя",
+                "summary": "Создать пользователя по номеру телефона",
                 "parameters": [
                     {
-                        "description": "Данные для регистрации",
-                        "name": "register",
+                        "description": "Номер телефона",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.RegisterRequest"
+                            "$ref": "#/definitions/handlers.CreateByPhoneRequest"
                         }
                     }
                 ],
@@ -2575,254 +3319,3517 @@ const docTemplate = `{
                 }
             }
         },
-        "/ws/announcements": {
+        "/api/admin/users/all": {
             "get": {
-                "tags": [
-                    "WebSocket"
-                ],
-                "summary": "WebSocket подключение для объявлений",
-                "responses": {
-                    "101": {
-                        "description": "Switching Protocols",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Получить всех пользователей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/ws/orders": {
+        "/api/admin/users/{id}": {
             "get": {
-                "description": "Устанавливает WebSocket-соединение. Админы получают уведомления о новых заказах.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "WebSocket"
+                    "Пользователи"
+                ],
+                "summary": "Получить пользователя по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Подключение к WebSocket для уведомлений о заказах",
                 "responses": {
-                    "101": {
-                        "description": "Switching Protocols",
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает блокировку или задержку входа после неудачных попыток и обнуляет их счётчик",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Снять блокировку входа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Блокировка снята",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/announcements": {
+            "get": {
+                "tags": [
+                    "Объявления"
+                ],
+                "summary": "Получить все объявления",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Announcement"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/announcements/{id}": {
+            "get": {
+                "tags": [
+                    "Объявления"
+                ],
+                "summary": "Получить объявление по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Announcement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cart": {
+            "get": {
+                "description": "Возвращает список товаров в корзине для owner_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Получить содержимое корзины",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CartBulkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет товар в корзину по owner_id (user или ip)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Добавить товар в корзину",
+                "parameters": [
+                    {
+                        "description": "ID товара и количество",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddToCartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Added to cart",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.AddToCartRequest": {
-            "type": "object",
+        },
+        "/api/cart/bulk": {
+            "post": {
+                "description": "Добавляет несколько товаров в корзину за один запрос (bulk).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Добавить несколько товаров в корзину",
+                "parameters": [
+                    {
+                        "description": "Список товаров для добавления",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.AddToCartRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Товары добавлены, возвращены список и сумма",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CartBulkResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос с этим Idempotency-Key ещё выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше middleware.MaxJSONBody",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера при получении корзины",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cart/clear": {
+            "delete": {
+                "description": "Удаляет все товары из корзины owner_id",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Очистить корзину",
+                "responses": {
+                    "200": {
+                        "description": "Cart cleared",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cart/{product_id}": {
+            "put": {
+                "description": "Обновляет количество указанного товара для owner_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Обновить количество товара в корзине",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое количество",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/utils.UpdateItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quantity updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет товар по ID из корзины owner_id",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Удалить товар из корзины",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор товара, который нужно удалить или обновить",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/categories": {
+            "get": {
+                "description": "Возвращает все категории плоским списком (родитель — в parent_id)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Получить список категорий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Category"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/categories/tree": {
+            "get": {
+                "description": "Корневые категории с вложенными подкатегориями, на каждом уровне — по порядку сортировки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Дерево категорий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.CategoryNode"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/categories/{slug}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Категория по slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug категории",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Category"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/login": {
+            "post": {
+                "description": "Вход по телефону/почте и паролю. Возвращает JWT токен при успехе.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Вход пользователя",
+                "parameters": [
+                    {
+                        "description": "Телефон/почта и пароль",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные данные для входа — одинаково для неизвестного аккаунта и неверного пароля",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Телефон не подтверждён",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Вход временно закрыт после неудачных попыток; см. заголовок Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает данные профиля для авторизованного пользователя и последние попытки входа в аккаунт",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Получить профиль пользователя",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserProfileResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me/address": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает адрес текущего авторизованного пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Получить адрес пользователя",
+                "responses": {
+                    "200": {
+                        "description": "Адрес пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения адреса",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет или добавляет адрес текущего пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Обновить адрес пользователя",
+                "parameters": [
+                    {
+                        "description": "Новый адрес",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Адрес обновлён",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный JSON",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка обновления адреса",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Очищает поле адреса у текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Удалить адрес пользователя",
+                "responses": {
+                    "200": {
+                        "description": "Адрес удалён",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка удаления адреса",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order": {
+            "post": {
+                "description": "Оформляет заказ из текущей корзины по owner_id. Можно указать координаты (latitude и longitude), чтобы рассчитать доставку.\nЦены и названия товаров берутся из каталога, price и name позиций в запросе не учитываются; quantity должно быть больше нуля.\npayment_type: cash (по умолчанию), card или online. Для online в ответе есть payment_url — на него нужно перенаправить покупателя; заказ принимается в работу после оплаты.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказ"
+                ],
+                "summary": "Оформить заказ",
+                "parameters": [
+                    {
+                        "description": "Данные заказа с координатами",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaceOrderRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Order"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос с этим Idempotency-Key ещё выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше middleware.MaxJSONBody",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/order-reviews": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы заказов"
+                ],
+                "summary": "Все отзывы о заказах",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.OrderReview"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders": {
+            "get": {
+                "description": "Возвращает список заказов для текущего owner_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказ"
+                ],
+                "summary": "Получить заказы пользователя",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Order"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказ"
+                ],
+                "summary": "История заказов пользователя",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Order"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}": {
+            "get": {
+                "description": "Возвращает заказ с товарами по ID. Доступен только владельцу заказа (по owner_id), для чужого заказа — 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказ"
+                ],
+                "summary": "Получить заказ по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Order"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}/feedback": {
+            "post": {
+                "description": "Общая оценка заказа, оценки доставки и курьера и оценки товаров из заказа одним запросом. Доступно владельцу заказа после доставки, один раз. Оценки товаров публикуются как отзывы с пометкой \"проверенная покупка\" и проходят модерацию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы заказов"
+                ],
+                "summary": "Оценить заказ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Оценки заказа и товаров",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderFeedbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.OrderReview"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}/payment": {
+            "post": {
+                "description": "Возвращает заказ со ссылкой на оплату (payment_url): действующей, если платёж ещё ожидает оплаты, или новой после неудачной попытки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Оплата"
+                ],
+                "summary": "Оплатить заказ онлайн",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Order"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден или онлайн-оплата не настроена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ уже оплачен или не оплачивается онлайн",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Платёжный провайдер недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}/repeat": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "Заказ"
+                ],
+                "summary": "Повторить заказ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{id}/review": {
+            "get": {
+                "description": "Оценки заказа, доставки и курьера вместе с оценками товаров",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы заказов"
+                ],
+                "summary": "Отзыв о заказе",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заказа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.OrderReview"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/payments/webhook": {
+            "post": {
+                "description": "Провайдер сообщает об оплате, отказе или возврате. Запрос подписан: для CloudPayments — заголовок Content-HMAC, для fake — X-Signature (hex HMAC-SHA256 тела).\nОтвет {\"code\":0} означает, что уведомление принято; на другой ответ провайдер повторяет отправку.",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Оплата"
+                ],
+                "summary": "Уведомление платёжного провайдера",
+                "responses": {
+                    "200": {
+                        "description": "{\"code\":0}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неразборчивое уведомление",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверная подпись",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Онлайн-оплата не настроена",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products": {
+            "get": {
+                "description": "Получает список товаров с фильтрацией, фасетами и пагинацией. Для бесконечной прокрутки используйте next_cursor из ответа в параметре cursor — новые товары не сдвигают уже показанные.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Товар"
+                ],
+                "summary": "Получить список товаров",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поиск по названию, описанию и категории (с учётом морфологии и опечаток)",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID категорий через запятую (или параметр повторяется); товары подкатегорий тоже попадают в выборку",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная цена",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная цена",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка (price_asc, price_desc, name_asc, name_desc, available_first, rating_desc); при поиске по умолчанию — по релевантности",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ограничение количества результатов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение для пагинации (игнорируется вместе с cursor)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Фильтр по наличию",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная средняя оценка (1–5)",
+                        "name": "min_rating",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат с пагинацией и фасетами",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}": {
+            "get": {
+                "description": "Возвращает детали товара по его идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Товар"
+                ],
+                "summary": "Получить товар по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/images": {
+            "get": {
+                "description": "Возвращает изображения товара: основное первым, затем по порядку сортировки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Изображения товара"
+                ],
+                "summary": "Галерея товара",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ProductImage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/rating": {
+            "get": {
+                "description": "Средняя оценка, количество отзывов и число отзывов с каждой оценкой от 5 до 1",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Распределение оценок товара",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductRatingSummary"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/reviews": {
+            "get": {
+                "description": "Только опубликованные отзывы, с фото и ответом магазина",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Получить отзывы товара",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Review"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Может обновить только тот, кто оставил (по owner_id). Изменённый отзыв проходит модерацию заново.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Обновить отзыв",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновлённая оценка и комментарий",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Review"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Отзыв может оставить как авторизованный, так и гость. Повторный отзыв от одного владельца невозможен. Отзыв покупателя с доставленным заказом помечается как \"проверенная покупка\". При включённой премодерации, а также если в тексте найдены ссылки или стоп-слова, отзыв публикуется после проверки администратором.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Добавить отзыв",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Оценка и комментарий",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Review"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Может удалить только тот, кто оставил (по owner_id)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Удалить отзыв",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/reviews/photos": {
+            "post": {
+                "description": "Фото обрабатывается так же, как изображения товаров (проверка формата, удаление метаданных, уменьшенные копии). Не больше 5 фото на отзыв.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Добавить фото к отзыву",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Фото",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ReviewPhoto"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products/{id}/reviews/photos/{photo_id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Отзывы"
+                ],
+                "summary": "Удалить фото из отзыва",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID товара",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photo_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/push/broadcast": {
+            "post": {
+                "description": "Рассылает сообщение всем подписанным пользователям",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Push"
+                ],
+                "summary": "Массовая рассылка push-уведомлений",
+                "parameters": [
+                    {
+                        "description": "Сообщение для рассылки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/push/delete": {
+            "delete": {
+                "description": "Удаляет подписку по endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Push"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "URL подписки",
+                        "name": "endpoint",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/push/send": {
+            "post": {
+                "description": "Отправляет уведомление одному пользователю по подписке",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Push"
+                ],
+                "summary": "Отправить push-уведомление",
+                "parameters": [
+                    {
+                        "description": "Подписка и сообщение",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.pushRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/push/subscribe": {
+            "post": {
+                "description": "Регистрирует push-подписку пользователя, сохраняет её в базе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Push"
+                ],
+                "summary": "Подписка на push-уведомления",
+                "parameters": [
+                    {
+                        "description": "Объект подписки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PushSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/register": {
+            "post": {
+                "description": "Регистрирует нового пользователя по телефону, паролю, имени и e-mail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Зарегистрировать нового пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для регистрации",
+                        "name": "register",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/utils.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search": {
+            "get": {
+                "description": "Полнотекстовый поиск с учётом русской морфологии и опечаток по названию, описанию и категории. Результаты отсортированы по релевантности, найденные слова выделены тегом \u003cmark\u003e, остальной текст в name_highlight и snippet экранирован как HTML.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Поиск"
+                ],
+                "summary": "Поиск товаров",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество результатов (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение для пагинации",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductSearchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/suggestions": {
+            "get": {
+                "description": "Автодополнение по названиям товаров и категорий. Запросы короче 2 символов возвращают пустой список.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Поиск"
+                ],
+                "summary": "Подсказки поиска",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало запроса",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество подсказок (по умолчанию 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.SuccessResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.SearchSuggestion"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс обслуживает запросы. Зависимости не проверяются — для этого есть /readyz.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Система"
+                ],
+                "summary": "Проверка жизнеспособности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет Postgres, Redis, что все миграции из каталога применены, и запись в файловое хранилище. 503 — экземпляр не должен получать трафик.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Система"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadinessReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadinessReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/announcements": {
+            "get": {
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "WebSocket подключение для объявлений",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/orders": {
+            "get": {
+                "description": "Устанавливает WebSocket-соединение. Админы получают уведомления о новых заказах.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "Подключение к WebSocket для уведомлений о заказах",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AddToCartRequest": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ConfirmRequest": {
+            "type": "object",
+            "properties": {
+                "confirm_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateByPhoneRequest": {
+            "type": "object",
+            "properties": {
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RegisterRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TruncateRequest": {
+            "type": "object",
+            "properties": {
+                "confirm_token": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UserProfileResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isVerified": {
+                    "type": "boolean"
+                },
+                "login_history": {
+                    "description": "Последние попытки входа в аккаунт, новые первыми",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LoginEvent"
+                    }
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.pushRequest": {
+            "type": "object"
+        },
+        "models.Announcement": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "actor_name": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "request": {
+                    "type": "object"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BackupInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BuildInfo": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "modified": {
+                    "type": "boolean"
+                },
+                "revision": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CartBulkResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CartItemResponse"
+                    }
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CartItemResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CatalogImportReport": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CatalogImportRow"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CatalogImportRow": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "description": "артикул или название, по которому искали товар",
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "row": {
+                    "description": "номер строки в файле, начиная с 1 (включая заголовок)",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Category": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CategoryMoveRequest": {
+            "type": "object",
+            "properties": {
+                "parent_id": {
+                    "type": "integer"
+                },
+                "sortOrder": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CategoryNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CategoryNode"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CategoryUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "sortOrder": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Confirmation": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "confirm_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DBPoolStats": {
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "max_open": {
+                    "type": "integer"
+                },
+                "open": {
+                    "type": "integer"
+                },
+                "wait_count": {
+                    "type": "integer"
+                },
+                "wait_duration_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DailySales": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.DashboardData": {
+            "type": "object",
+            "properties": {
+                "sales_by_day": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DailySales"
+                    }
+                },
+                "top_products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TopProduct"
+                    }
+                },
+                "total_orders": {
+                    "type": "integer"
+                },
+                "total_revenue": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.FeedbackAnalytics": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "lowest_rated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LowestRatedProduct"
+                    }
+                },
+                "period": {
+                    "type": "string"
+                },
+                "timeline": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RatingTimelinePoint"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LogEntry": {
+            "type": "object",
+            "properties": {
+                "caller": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "level": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LogPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LogEntry"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.LoginEvent": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LowestRatedProduct": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "rating_avg": {
+                    "type": "number"
+                },
+                "review_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "change_for": {
+                    "type": "number"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_orders": {
+                    "type": "integer"
+                },
+                "delivery_fee": {
+                    "type": "number"
+                },
+                "delivery_text": {
+                    "type": "string"
+                },
+                "delivery_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItem"
+                    }
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order_comment": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "paid_at": {
+                    "type": "string"
+                },
+                "payment_status": {
+                    "description": "Онлайн-оплата; у заказов с оплатой при получении поля пустые",
+                    "type": "string",
+                    "example": "paid"
+                },
+                "payment_type": {
+                    "type": "string"
+                },
+                "payment_url": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "refunded_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.OrderFeedbackRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Привезли быстро"
+                },
+                "courier_rating": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "delivery_rating": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItemFeedback"
+                    }
+                },
+                "rating": {
+                    "description": "общая оценка заказа",
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "models.OrderItem": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderItemFeedback": {
+            "type": "object",
             "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Свежие, вкусные"
+                },
                 "product_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "rating": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "models.OrderReview": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "courier_rating": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivery_rating": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Review"
+                    }
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatusRequest": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "в пути"
+                }
+            }
+        },
+        "models.PlaceOrderRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "change_for": {
+                    "type": "number"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
                     "type": "integer"
                 },
-                "quantity": {
+                "delivery_fee": {
+                    "type": "number"
+                },
+                "delivery_text": {
+                    "type": "string"
+                },
+                "delivery_type": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItem"
+                    }
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "order_comment": {
+                    "type": "string"
+                },
+                "payment_type": {
+                    "description": "cash, card или online; пустой — cash",
+                    "type": "string",
+                    "example": "online"
+                },
+                "rating": {
                     "type": "integer"
+                },
+                "status": {
+                    "description": "не учитывается: новый заказ всегда создаётся со статусом «новый»",
+                    "type": "string"
                 }
             }
         },
-        "handlers.CreateByPhoneRequest": {
+        "models.PriceHistoryEntry": {
             "type": "object",
             "properties": {
-                "phone": {
+                "changed_by": {
+                    "type": "integer"
+                },
+                "changed_by_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_price": {
+                    "type": "number"
+                },
+                "old_price": {
+                    "type": "number"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "source": {
                     "type": "string"
                 }
             }
         },
-        "handlers.LoginRequest": {
+        "models.Product": {
+            "type": "object"
+        },
+        "models.ProductImage": {
             "type": "object",
             "properties": {
-                "identifier": {
+                "created_at": {
                     "type": "string"
                 },
-                "password": {
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "medium_url": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "thumb_url": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
+                },
+                "width": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.LoginResponse": {
+        "models.ProductImagesOrderRequest": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string"
+                "image_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
                 }
             }
         },
-        "handlers.OrderReviewRequest": {
+        "models.ProductPatchInput": {
             "type": "object",
             "properties": {
-                "comment": {
-                    "type": "string"
+                "availability": {
+                    "type": "boolean"
                 },
-                "rating": {
+                "category_id": {
                     "type": "integer"
-                }
-            }
-        },
-        "handlers.RegisterRequest": {
-            "type": "object",
-            "properties": {
-                "email": {
+                },
+                "description": {
                     "type": "string"
                 },
-                "password": {
+                "name": {
                     "type": "string"
                 },
-                "phone": {
+                "old_price": {
+                    "description": "0 — убрать зачёркнутую цену",
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sku": {
                     "type": "string"
                 },
-                "username": {
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "handlers.TruncateRequest": {
+        "models.ProductRatingSummary": {
             "type": "object",
             "properties": {
-                "table": {
-                    "type": "string"
+                "average": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "distribution": {
+                    "description": "от 5 до 1",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RatingBucket"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.UserProfileResponse": {
+        "models.ProductResponse": {
             "type": "object",
             "properties": {
-                "email": {
+                "availability": {
+                    "type": "boolean"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "category_name": {
+                    "type": "string"
+                },
+                "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "isVerified": {
-                    "type": "boolean"
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
                 },
-                "owner_id": {
+                "name": {
                     "type": "string"
                 },
-                "phone": {
-                    "type": "string"
+                "old_price": {
+                    "type": "number"
                 },
-                "role": {
-                    "type": "string"
+                "price": {
+                    "type": "number"
                 },
-                "username": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.pushRequest": {
-            "type": "object"
-        },
-        "models.Announcement": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
+                "rating": {
+                    "type": "number"
                 },
-                "id": {
+                "review_count": {
                     "type": "integer"
                 },
-                "title": {
+                "sale_price": {
+                    "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "models.CartBulkResponse": {
+        "models.ProductSearchResponse": {
             "type": "object",
             "properties": {
                 "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.CartItemResponse"
+                        "$ref": "#/definitions/models.ProductSearchResult"
                     }
                 },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
                 "total": {
-                    "type": "number"
+                    "type": "integer"
                 }
             }
         },
-        "models.CartItemResponse": {
+        "models.ProductSearchResult": {
             "type": "object",
             "properties": {
+                "availability": {
+                    "type": "boolean"
+                },
+                "category_id": {
+                    "type": "integer"
+                },
+                "category_name": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
+                "name_highlight": {
+                    "description": "экранированное HTML-название с найденными словами в \u003cmark\u003e\u003c/mark\u003e",
+                    "type": "string"
+                },
+                "old_price": {
+                    "type": "number"
+                },
                 "price": {
                     "type": "number"
                 },
-                "quantity": {
+                "rank": {
+                    "type": "number"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "review_count": {
                     "type": "integer"
                 },
-                "total": {
+                "sale_price": {
                     "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "description": "фрагмент описания с подсветкой",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "models.Category": {
+        "models.PushSubscriptionRequest": {
+            "type": "object"
+        },
+        "models.RatingBucket": {
             "type": "object",
             "properties": {
-                "id": {
+                "count": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "percent": {
+                    "type": "number"
                 },
-                "sort_order": {
+                "rating": {
                     "type": "integer"
                 }
             }
         },
-        "models.DailySales": {
+        "models.RatingTimelinePoint": {
             "type": "object",
             "properties": {
-                "date": {
-                    "type": "string"
+                "courier_avg": {
+                    "type": "number"
                 },
-                "orders": {
+                "delivery_avg": {
+                    "type": "number"
+                },
+                "order_avg": {
+                    "type": "number"
+                },
+                "order_count": {
                     "type": "integer"
                 },
-                "revenue": {
+                "period": {
+                    "type": "string"
+                },
+                "product_avg": {
                     "type": "number"
+                },
+                "product_count": {
+                    "type": "integer"
                 }
             }
         },
-        "models.DashboardData": {
+        "models.ReadinessReport": {
             "type": "object",
             "properties": {
-                "sales_by_day": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.DailySales"
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.HealthCheck"
                     }
                 },
-                "top_products": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.TopProduct"
-                    }
+                "ready": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.RedisPoolStats": {
+            "type": "object",
+            "properties": {
+                "hits": {
+                    "type": "integer"
+                },
+                "idle_conns": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "stale_conns": {
+                    "type": "integer"
                 },
-                "total_orders": {
+                "timeouts": {
                     "type": "integer"
                 },
-                "total_revenue": {
-                    "type": "number"
+                "total_conns": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Order": {
+        "models.Review": {
             "type": "object",
             "properties": {
-                "address": {
+                "admin_reply": {
                     "type": "string"
                 },
-                "change_for": {
-                    "type": "number"
+                "admin_reply_at": {
+                    "type": "string"
                 },
                 "comment": {
                     "type": "string"
@@ -2830,271 +6837,268 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
-                "date_orders": {
+                "id": {
                     "type": "integer"
                 },
-                "delivery_fee": {
-                    "type": "number"
-                },
-                "delivery_text": {
-                    "type": "string"
-                },
-                "delivery_type": {
+                "moderated_at": {
                     "type": "string"
                 },
-                "id": {
+                "moderated_by": {
                     "type": "integer"
                 },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.OrderItem"
-                    }
-                },
-                "latitude": {
-                    "type": "number"
-                },
-                "longitude": {
-                    "type": "number"
-                },
-                "name": {
+                "moderation_note": {
                     "type": "string"
                 },
-                "order_comment": {
-                    "type": "string"
+                "order_id": {
+                    "type": "integer"
                 },
                 "owner_id": {
                     "type": "string"
                 },
-                "payment_type": {
-                    "type": "string"
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReviewPhoto"
+                    }
+                },
+                "product_id": {
+                    "type": "integer"
                 },
                 "rating": {
                     "type": "integer"
                 },
+                "rejection_reason": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
-                "total": {
-                    "type": "number"
+                "verified_purchase": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.OrderItem": {
+        "models.ReviewModerationRequest": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "order_id": {
-                    "type": "integer"
-                },
-                "price": {
-                    "type": "number"
-                },
-                "product_id": {
-                    "type": "integer"
+                "reason": {
+                    "type": "string",
+                    "example": "Отзыв не о товаре"
                 },
-                "quantity": {
-                    "type": "integer"
+                "status": {
+                    "description": "approved | rejected",
+                    "type": "string",
+                    "example": "rejected"
                 }
             }
         },
-        "models.OrderReview": {
+        "models.ReviewPhoto": {
             "type": "object",
             "properties": {
-                "comment": {
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "order_id": {
-                    "type": "integer"
+                "medium_url": {
+                    "type": "string"
                 },
-                "rating": {
+                "review_id": {
                     "type": "integer"
                 },
-                "user_id": {
-                    "type": "integer"
+                "thumb_url": {
+                    "type": "string"
                 },
-                "username": {
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "models.OrderStatusRequest": {
+        "models.ReviewReplyRequest": {
             "type": "object",
             "properties": {
-                "status": {
+                "reply": {
+                    "description": "пустая строка удаляет ответ",
                     "type": "string",
-                    "example": "в пути"
+                    "example": "Спасибо за отзыв!"
                 }
             }
         },
-        "models.PlaceOrderRequest": {
+        "models.ReviewRequest": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "change_for": {
-                    "type": "number"
-                },
                 "comment": {
+                    "type": "string",
+                    "example": "Отличный товар!"
+                },
+                "rating": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "models.ScheduledPrice": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
                     "type": "string"
                 },
                 "created_at": {
-                    "type": "integer"
-                },
-                "delivery_fee": {
-                    "type": "number"
-                },
-                "delivery_text": {
                     "type": "string"
                 },
-                "delivery_type": {
-                    "type": "string"
+                "created_by": {
+                    "type": "integer"
                 },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.OrderItem"
-                    }
+                "id": {
+                    "type": "integer"
                 },
-                "latitude": {
+                "old_price": {
                     "type": "number"
                 },
-                "longitude": {
+                "price": {
                     "type": "number"
                 },
-                "name": {
-                    "type": "string"
+                "product_id": {
+                    "type": "integer"
                 },
-                "order_comment": {
+                "starts_at": {
                     "type": "string"
+                }
+            }
+        },
+        "models.ScheduledPriceRequest": {
+            "type": "object",
+            "properties": {
+                "old_price": {
+                    "type": "number",
+                    "example": 119.9
                 },
-                "payment_type": {
-                    "type": "string"
+                "price": {
+                    "type": "number",
+                    "example": 89.9
                 },
-                "rating": {
+                "starts_at": {
+                    "type": "string",
+                    "example": "2026-11-01T00:00:00Z"
+                }
+            }
+        },
+        "models.SearchSuggestion": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer"
                 },
-                "status": {
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "product | category",
                     "type": "string"
                 }
             }
         },
-        "models.Product": {
-            "type": "object"
-        },
-        "models.ProductPatchInput": {
+        "models.SearchZeroResult": {
             "type": "object",
             "properties": {
-                "availability": {
-                    "type": "boolean"
+                "first_searched_at": {
+                    "type": "string"
                 },
-                "category_id": {
+                "hits": {
                     "type": "integer"
                 },
-                "description": {
-                    "type": "string"
-                },
-                "name": {
+                "last_searched_at": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
-                },
-                "url": {
+                "query": {
                     "type": "string"
                 }
             }
         },
-        "models.ProductResponse": {
+        "models.SystemInfo": {
             "type": "object",
             "properties": {
-                "availability": {
-                    "type": "boolean"
+                "build": {
+                    "$ref": "#/definitions/models.BuildInfo"
                 },
-                "category_id": {
-                    "type": "integer"
+                "database": {
+                    "$ref": "#/definitions/models.DBPoolStats"
                 },
-                "category_name": {
+                "env": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
+                "goroutines": {
+                    "type": "integer"
                 },
-                "id": {
+                "latest_migration": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "redis": {
+                    "$ref": "#/definitions/models.RedisPoolStats"
                 },
-                "price": {
-                    "type": "number"
+                "schema_version": {
+                    "type": "integer"
                 },
-                "rating": {
-                    "type": "number"
+                "started_at": {
+                    "type": "string"
                 },
-                "url": {
+                "storage_driver": {
                     "type": "string"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                },
+                "websocket_clients": {
+                    "type": "integer"
                 }
             }
         },
-        "models.PushSubscriptionRequest": {
-            "type": "object"
-        },
-        "models.Review": {
+        "models.TopProduct": {
             "type": "object",
             "properties": {
-                "comment": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "owner_id": {
+                "name": {
                     "type": "string"
                 },
                 "product_id": {
                     "type": "integer"
                 },
-                "rating": {
+                "sold": {
                     "type": "integer"
                 }
             }
         },
-        "models.ReviewRequest": {
+        "models.TrashItem": {
             "type": "object",
             "properties": {
-                "comment": {
-                    "type": "string",
-                    "example": "Отличный товар!"
+                "deleted_at": {
+                    "type": "string"
                 },
-                "rating": {
-                    "type": "integer",
-                    "example": 5
+                "id": {
+                    "type": "integer"
+                },
+                "purge_at": {
+                    "description": "PurgeAt — когда запись будет удалена окончательно",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "models.TopProduct": {
+        "models.TrashPurgeResult": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "categories": {
+                    "type": "integer"
                 },
-                "product_id": {
+                "orders": {
                     "type": "integer"
                 },
-                "sold": {
+                "products": {
                     "type": "integer"
                 }
             }
@@ -3105,6 +7109,9 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "referenced": {
+                    "type": "boolean"
+                },
                 "size": {
                     "type": "integer"
                 },
@@ -3116,6 +7123,26 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UploadedImage": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer"
+                },
+                "medium_url": {
+                    "type": "string"
+                },
+                "thumb_url": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -3128,12 +7155,19 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "failed_logins": {
+                    "description": "Защита от подбора пароля: неудачные попытки подряд и время, до которого вход закрыт",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "is_verified": {
                     "type": "boolean"
                 },
+                "locked_until": {
+                    "type": "string"
+                },
                 "owner_id": {
                     "type": "string"
                 },
@@ -3162,9 +7196,22 @@ const docTemplate = `{
         "utils.CategoryRequest": {
             "type": "object",
             "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "description": "если не задан, строится из названия",
+                    "type": "string"
+                },
                 "sortOrder": {
                     "type": "integer"
                 }
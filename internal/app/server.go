@@ -6,23 +6,42 @@ import (
 	"chechnya-product/internal/cache"
 	"chechnya-product/internal/handlers"
 	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/payments"
 	"chechnya-product/internal/repositories"
 	"chechnya-product/internal/routes"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/storage"
 	"chechnya-product/internal/utils"
 	"chechnya-product/internal/ws"
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
+	"net"
 	"net/http"
 	"time"
 )
 
-func NewServer(cfg *config.Config, logger *zap.Logger, dbConn *sqlx.DB, redisCache *cache.RedisCache) *http.Server {
+// Server — HTTP-сервер вместе с фоновыми задачами, которые живут столько же, сколько он
+type Server struct {
+	cfg        *config.Config
+	logger     *zap.Logger
+	httpServer *http.Server
+	// jobs — фоновые циклы (WebSocket-хаб, планировщики, очистка); работают до отмены переданного ctx
+	jobs []func(ctx context.Context)
+	// tasks — фоновая работа, запущенная из запросов (push-уведомления о заказах)
+	tasks *utils.TaskGroup
+	// stopStreams отменяется в начале остановки и закрывает долгие ответы (utils.UntilShutdown)
+	stopStreams context.CancelFunc
+}
+
+func NewServer(cfg *config.Config, logger *zap.Logger, dbConn *sqlx.DB, redisCache *cache.RedisCache, fileStorage storage.Storage, paymentProvider payments.PaymentProvider) *Server {
 	hub := ws.NewHub(logger)
-	go hub.Run()
+	tasks := utils.NewTaskGroup()
 
 	// --- Repositories ---
 	userRepo := repositories.NewUserRepo(dbConn)
@@ -35,58 +54,188 @@ func NewServer(cfg *config.Config, logger *zap.Logger, dbConn *sqlx.DB, redisCac
 	reviewRepo := repositories.NewReviewRepo(dbConn)
 	adminRepo := repositories.NewAdminRepo(dbConn)
 	pushRepo := repositories.NewPushRepo(dbConn)
+	productImageRepo := repositories.NewProductImageRepo(dbConn)
+	fileRepo := repositories.NewFileRepo(dbConn)
+	searchRepo := repositories.NewSearchRepo(dbConn)
+	priceRepo := repositories.NewPriceRepo(dbConn)
+	auditRepo := repositories.NewAuditRepo(dbConn)
+	systemRepo := repositories.NewSystemRepo(dbConn)
+	exchangeFileRepo := repositories.NewExchangeFileRepo(dbConn)
 
 	// --- JWT ---
 	jwtManager := utils.NewJWTManager(cfg.JWTSecret, 7200*time.Hour)
 
 	// --- Services ---
 	cartService := services.NewCartService(cartRepo, productRepo)
-	userService := services.NewUserService(userRepo, jwtManager, cartService)
-	productService := services.NewProductService(productRepo, logger)
+	fileService := services.NewFileService(fileStorage, fileRepo, cfg.OrphanGracePeriod, logger)
+	productImageService := services.NewProductImageService(productImageRepo, fileService, cfg, logger)
+	productService := services.NewProductService(productRepo, productImageService, logger)
+	priceService := services.NewPriceService(priceRepo, productRepo, redisCache, logger)
+	searchService := services.NewSearchService(searchRepo, logger)
 	categoryService := services.NewCategoryService(categoryRepo, logger)
 	dashboardService := services.NewDashboardService(dashboardRepo)
 	announcementService := services.NewAnnouncementService(announcementRepo, hub)
-	reviewService := services.NewReviewService(reviewRepo)
-	adminService := services.NewAdminService(adminRepo)
+	reviewService := services.NewReviewService(reviewRepo, orderRepo, productImageService, fileService, cfg, logger)
+	adminService := services.NewAdminService(adminRepo, redisCache, cfg, logger)
 	pushService := services.NewPushService(pushRepo, logger, cfg)
-	orderService := services.NewOrderService(cartRepo, orderRepo, productRepo, userRepo, pushService, hub, logger)
+	userService := services.NewUserService(userRepo, redisCache, jwtManager, cartService, pushService, tasks, cfg, logger)
+	catalogService := services.NewCatalogService(productRepo, categoryRepo, logger)
+	paymentService := services.NewPaymentService(paymentProvider, orderRepo, pushService, hub, tasks, cfg, logger)
+	orderService := services.NewOrderService(cartRepo, orderRepo, productRepo, userRepo, pushService, paymentService, hub, tasks, logger)
+	trashService := services.NewTrashService(productRepo, categoryRepo, orderRepo, cfg.TrashRetention, logger)
+	auditService := services.NewAuditService(auditRepo, logger)
+	systemService := services.NewSystemService(systemRepo, adminRepo, redisCache, fileStorage, hub, cfg, logger)
+	logService := services.NewLogService()
+	exchangeService := services.NewExchangeService(productService, categoryService, orderService, exchangeFileRepo, redisCache, cfg, logger)
 
 	// --- Handlers ---
 	userHandler := handlers.NewUserHandler(userService, logger)
 	cartHandler := handlers.NewCartHandler(cartService, logger)
-	productHandler := handlers.NewProductHandler(productService, logger, redisCache)
+	productHandler := handlers.NewProductHandler(productService, productImageService, fileService, logger, redisCache)
+	fileHandler := handlers.NewFileHandler(fileService, logger)
+	productImageHandler := handlers.NewProductImageHandler(productImageService, logger, redisCache)
+	priceHandler := handlers.NewPriceHandler(priceService, logger)
+	searchHandler := handlers.NewSearchHandler(searchService, logger)
 	orderHandler := handlers.NewOrderHandler(orderService, logger)
-	categoryHandler := handlers.NewCategoryHandler(categoryService, logger)
-	logHandler := handlers.NewLogHandler(logger, "logs/app.log")
+	categoryHandler := handlers.NewCategoryHandler(categoryService, logger, redisCache)
+	catalogHandler := handlers.NewCatalogHandler(catalogService, logger, redisCache)
+	logHandler := handlers.NewLogHandler(logService, logger, "logs/app.log")
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService, logger)
 	announcementHandler := handlers.NewAnnouncementHandler(announcementService, logger)
-	reviewHandler := handlers.NewReviewHandler(reviewService, logger)
-	adminHandler := handlers.NewAdminHandler(adminService, logger)
+	reviewHandler := handlers.NewReviewHandler(reviewService, logger, redisCache)
+	adminHandler := handlers.NewAdminHandler(adminService, logger, redisCache)
 	pushHandler := handlers.NewPushHandler(pushService, logger)
+	trashHandler := handlers.NewTrashHandler(trashService, logger, redisCache)
+	auditHandler := handlers.NewAuditHandler(auditService, logger)
+	systemHandler := handlers.NewSystemHandler(systemService, logger)
+	exchangeHandler := handlers.NewExchangeHandler(exchangeService, logger, redisCache)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, logger)
+	rateLimit := middleware.RateLimitMiddleware(redisCache, cfg.RateLimitPolicies, logger)
+	idempotent := middleware.IdempotencyMiddleware(redisCache, cfg.IdempotencyTTL, cfg.HTTPWriteTimeout, logger)
+
 	// --- Router ---
 	router := mux.NewRouter()
-	router.Use(middleware.RecoveryMiddleware(logger))
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(middleware.ClientIPMiddleware(cfg.TrustedProxies))
+	router.Use(middleware.MetricsMiddleware)
+	router.Use(middleware.TracingMiddleware)
 	router.Use(middleware.LoggerMiddleware(logger))
+	router.Use(middleware.RecoveryMiddleware(logger))
 	router.HandleFunc("/ws/orders", hub.HandleConnections)
 	router.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
-	// Раздача файлов из папки "uploads" по пути "/uploads/*"
-	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	// Раздача загруженных файлов из хранилища по пути "/uploads/*"
+	router.HandleFunc("/uploads/{key}", fileHandler.Serve).Methods(http.MethodGet, http.MethodHead)
 
-	routes.RegisterPublicRoutes(router, userHandler, productHandler, categoryHandler, cartHandler, orderHandler, announcementHandler, reviewHandler, pushHandler, jwtManager)
+	routes.RegisterPublicRoutes(router, userHandler, productHandler, productImageHandler, searchHandler, categoryHandler, cartHandler, orderHandler, announcementHandler, reviewHandler, pushHandler, paymentHandler, jwtManager, rateLimit, idempotent)
 	routes.RegisterPrivateRoutes(router, userHandler, jwtManager)
-	routes.RegisterAdminRoutes(router, userHandler, productHandler, orderHandler, categoryHandler, logHandler, dashboardHandler, jwtManager, announcementHandler, adminHandler)
+	routes.RegisterExchangeRoutes(router, exchangeHandler, rateLimit)
+	routes.RegisterPaymentRoutes(router, paymentHandler)
+	routes.RegisterAdminRoutes(router, userHandler, productHandler, productImageHandler, priceHandler, orderHandler, categoryHandler, catalogHandler, fileHandler, searchHandler, reviewHandler, logHandler, dashboardHandler, trashHandler, auditHandler, systemHandler, auditService, jwtManager, announcementHandler, adminHandler, idempotent)
 
 	// --- CORS ---
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowCredentials: true,
 		AllowedMethods:   []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "traceparent", "tracestate", middleware.RequestIDHeader, middleware.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{middleware.TraceIDHeader, middleware.RequestIDHeader, middleware.IdempotentReplayedHeader, "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 	})
 
+	// Пробы оркестратора и сбор метрик обслуживаются в обход роутера: они приходят каждые несколько секунд
+	// и не должны попадать в журнал запросов и лимиты
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", systemHandler.Healthz)
+	root.HandleFunc("GET /readyz", systemHandler.Readyz)
+	root.Handle("GET /metrics", middleware.MetricsAuthMiddleware(cfg.MetricsToken)(promhttp.Handler()))
+	root.Handle("/", corsMiddleware.Handler(router))
+
 	// --- HTTP Server ---
-	return &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: corsMiddleware.Handler(router),
+	streams, stopStreams := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      root,
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.HTTPWriteTimeout,
+		IdleTimeout:  cfg.HTTPIdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return utils.WithShutdown(context.Background(), streams)
+		},
+	}
+	// Shutdown не отменяет контексты запросов, поэтому потоки закрываются отдельным сигналом
+	httpServer.RegisterOnShutdown(stopStreams)
+
+	return &Server{
+		cfg:         cfg,
+		logger:      logger,
+		httpServer:  httpServer,
+		stopStreams: stopStreams,
+		jobs: []func(ctx context.Context){
+			hub.Run,
+			func(ctx context.Context) { fileService.RunOrphanCleanup(ctx, cfg.OrphanCleanupInterval) },
+			func(ctx context.Context) { priceService.RunScheduler(ctx, cfg.PriceSchedulerInterval) },
+			func(ctx context.Context) { trashService.RunPurge(ctx, cfg.TrashPurgeInterval) },
+		},
+		tasks: tasks,
+	}
+}
+
+// backgroundShutdownShare — доля SHUTDOWN_TIMEOUT_SECONDS, которую HTTP-запросы не могут занять:
+// даже если запросы не уложились, у фоновых циклов и задач остаётся время завершиться
+const backgroundShutdownShare = 3
+
+// Run запускает фоновые задачи и HTTP-сервер и блокируется до отмены ctx (SIGTERM) или ошибки запуска.
+// Остановка идёт по шагам, общее время ограничено SHUTDOWN_TIMEOUT_SECONDS:
+//  1. сервер перестаёт принимать соединения, закрывает потоки (follow журнала) и дожидается
+//     текущих запросов — не дольше, чем до последней 1/backgroundShutdownShare таймаута;
+//  2. останавливаются фоновые циклы, WebSocket-клиенты получают close-кадр;
+//  3. дожидается фоновая работа запросов — push-уведомления не обрываются на середине.
+func (s *Server) Run(ctx context.Context) error {
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs := utils.NewTaskGroup()
+	for _, job := range s.jobs {
+		jobs.Go(func() { job(jobsCtx) })
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		runErr = err
+	case <-ctx.Done():
+		s.logger.Info("Shutdown signal received, stopping server")
+	}
+
+	deadline := time.Now().Add(s.cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	httpCtx, cancelHTTP := context.WithDeadline(shutdownCtx, deadline.Add(-s.cfg.ShutdownTimeout/backgroundShutdownShare))
+	defer cancelHTTP()
+
+	if runErr == nil {
+		if err := s.httpServer.Shutdown(httpCtx); err != nil {
+			s.logger.Warn("HTTP server shutdown timed out, closing connections", zap.Error(err))
+			s.httpServer.Close()
+		}
+	}
+	// Если сервер не запустился, RegisterOnShutdown не сработал
+	s.stopStreams()
+
+	stopJobs()
+	if err := jobs.Wait(shutdownCtx); err != nil {
+		s.logger.Warn("Background jobs did not stop in time", zap.Error(err))
+	}
+	if err := s.tasks.Wait(shutdownCtx); err != nil {
+		s.logger.Warn("Background tasks did not finish in time", zap.Error(err))
+	}
+
+	if runErr != nil && !errors.Is(runErr, http.ErrServerClosed) {
+		return runErr
 	}
+	return nil
 }
@@ -0,0 +1,35 @@
+package buildinfo
+
+import (
+	"chechnya-product/internal/models"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version задаётся при сборке:
+// go build -ldflags "-X chechnya-product/internal/buildinfo.Version=1.2.3" ./cmd/api
+var Version = "dev"
+
+// Read возвращает версию и сведения VCS, которые go build встраивает в бинарник
+func Read() models.BuildInfo {
+	info := models.BuildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.BuildTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
@@ -1,10 +1,12 @@
 package cache
 
 import (
+	"chechnya-product/internal/metrics"
 	"context"
 	"encoding/json"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,7 @@ type RedisCache struct {
 }
 
 func NewRedisCache(client *redis.Client, ttl time.Duration, logger *zap.Logger) *RedisCache {
+	client.AddHook(tracingHook{})
 	return &RedisCache{
 		client: client,
 		ttl:    ttl,
@@ -27,12 +30,16 @@ func (c *RedisCache) GetOrSet(ctx context.Context, key string, target any, fetch
 	// Попробовать получить из кэша
 	val, err := c.client.Get(ctx, key).Result()
 	if err == nil {
+		metrics.CacheRequests.WithLabelValues(cacheName(key), "hit").Inc()
 		return json.Unmarshal([]byte(val), target)
 	}
 
 	if err != redis.Nil {
+		metrics.CacheRequests.WithLabelValues(cacheName(key), "error").Inc()
 		c.logger.Warn("Redis Get error", zap.Error(err))
 		// Возвращать nil, чтобы не падал весь API
+	} else {
+		metrics.CacheRequests.WithLabelValues(cacheName(key), "miss").Inc()
 	}
 
 	// Получаем данные заново
@@ -57,6 +64,12 @@ func (c *RedisCache) GetOrSet(ctx context.Context, key string, target any, fetch
 	return json.Unmarshal(encoded, target)
 }
 
+// cacheName — префикс ключа до двоеточия ("products", "product"), чтобы метки метрик не зависели от параметров
+func cacheName(key string) string {
+	name, _, _ := strings.Cut(key, ":")
+	return name
+}
+
 func (c *RedisCache) ClearPrefix(ctx context.Context, prefix string) error {
 	iter := c.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
 	deleted := 0
@@ -89,3 +102,13 @@ func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	}
 	return err
 }
+
+// Ping проверяет доступность Redis
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// PoolStats — статистика пула соединений с Redis
+func (c *RedisCache) PoolStats() *redis.PoolStats {
+	return c.client.PoolStats()
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// confirmationPrefix — префикс ключей токенов подтверждения опасных операций в админке.
+// Токен хранится хэшем: по содержимому Redis подтвердить операцию нельзя.
+const confirmationPrefix = "confirmation:"
+
+func confirmationKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return confirmationPrefix + hex.EncodeToString(sum[:])
+}
+
+// SaveConfirmation сохраняет токен со значением value на ttl
+func (c *RedisCache) SaveConfirmation(ctx context.Context, token string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, confirmationKey(token), value, ttl).Err()
+}
+
+// TakeConfirmation возвращает значение токена и сразу удаляет его, поэтому токен срабатывает один раз.
+// Для неизвестного или истёкшего токена возвращает nil без ошибки.
+func (c *RedisCache) TakeConfirmation(ctx context.Context, token string) ([]byte, error) {
+	value, err := c.client.GetDel(ctx, confirmationKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
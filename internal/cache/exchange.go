@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Сессии обмена с 1С живут в Redis, чтобы запросы одного сеанса могли попадать на разные реплики.
+// Токен хранится хэшем, как и токены подтверждения.
+const (
+	exchangeSessionPrefix = "exchange:session:"
+	exchangePendingPrefix = "exchange:pending:"
+)
+
+func exchangeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateExchangeSession открывает сессию обмена на ttl
+func (c *RedisCache) CreateExchangeSession(ctx context.Context, token string, ttl time.Duration) error {
+	return c.client.Set(ctx, exchangeSessionPrefix+exchangeKey(token), 1, ttl).Err()
+}
+
+// TouchExchangeSession продлевает сессию на ttl; false — сессии нет или она истекла
+func (c *RedisCache) TouchExchangeSession(ctx context.Context, token string, ttl time.Duration) (bool, error) {
+	return c.client.PExpire(ctx, exchangeSessionPrefix+exchangeKey(token), ttl).Result()
+}
+
+// SetExchangePending запоминает заказы, отданные сессии и ещё не подтверждённые 1С
+func (c *RedisCache) SetExchangePending(ctx context.Context, token string, orderIDs []int, ttl time.Duration) error {
+	data, err := json.Marshal(orderIDs)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, exchangePendingPrefix+exchangeKey(token), data, ttl).Err()
+}
+
+// TakeExchangePending возвращает неподтверждённые заказы сессии и сразу удаляет их, поэтому
+// подтверждение срабатывает один раз. nil без ошибки — выгрузки в этой сессии не было.
+func (c *RedisCache) TakeExchangePending(ctx context.Context, token string) ([]int, error) {
+	data, err := c.client.GetDel(ctx, exchangePendingPrefix+exchangeKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := []int{}
+	if err := json.Unmarshal(data, &orderIDs); err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
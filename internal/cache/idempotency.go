@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// idempotencyPrefix — префикс ключей сохранённых ответов на запросы с Idempotency-Key
+const idempotencyPrefix = "idempotency:"
+
+// ReserveIdempotencyKey занимает ключ значением value на ttl. Если ключ уже занят, возвращает
+// reserved=false и сохранённое значение; existing пустой, если ключ успел освободиться между проверками.
+func (c *RedisCache) ReserveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) (existing []byte, reserved bool, err error) {
+	reserved, err = c.client.SetNX(ctx, idempotencyPrefix+key, value, ttl).Result()
+	if err != nil || reserved {
+		return nil, reserved, err
+	}
+	existing, err = c.client.Get(ctx, idempotencyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	return existing, false, err
+}
+
+// SaveIdempotencyKey заменяет значение занятого ключа, например готовым ответом
+func (c *RedisCache) SaveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, idempotencyPrefix+key, value, ttl).Err()
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы запрос можно было повторить
+func (c *RedisCache) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return c.client.Del(ctx, idempotencyPrefix+key).Err()
+}
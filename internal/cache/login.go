@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+// Ключи счётчика неудачных входов по логину, для которого нет аккаунта. Логин хранится хэшем,
+// чтобы в Redis не оседали телефоны и адреса почты.
+const (
+	loginFailuresPrefix = "login:failures:"
+	loginLockPrefix     = "login:lock:"
+)
+
+func loginKey(identifier string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(identifier))))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetIdentifierLoginLock возвращает, сколько ещё закрыт вход по логину; 0 — вход открыт
+func (c *RedisCache) GetIdentifierLoginLock(ctx context.Context, identifier string) (time.Duration, error) {
+	ttl, err := c.client.PTTL(ctx, loginLockPrefix+loginKey(identifier)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Для отсутствующего ключа PTTL возвращает отрицательное значение
+	return max(ttl, 0), nil
+}
+
+// RecordIdentifierLoginFailure учитывает неудачу и возвращает число неудач подряд.
+// Счётчик живёт window с последней неудачи — как failed_logins у существующего аккаунта.
+func (c *RedisCache) RecordIdentifierLoginFailure(ctx context.Context, identifier string, window time.Duration) (int, error) {
+	key := loginFailuresPrefix + loginKey(identifier)
+	var incr *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+// extendLoginLockScript ставит блокировку на ARGV[1] мс, только если она дольше уже стоящей:
+// параллельная неудача с короткой задержкой не должна сокращать блокировку
+var extendLoginLockScript = redis.NewScript(`
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], 1, 'PX', ARGV[1])
+end
+return 1
+`)
+
+// DelayIdentifierLogin закрывает вход по логину на delay, не сбрасывая счётчик неудач.
+// Уже стоящая более длинная блокировка не сокращается.
+func (c *RedisCache) DelayIdentifierLogin(ctx context.Context, identifier string, delay time.Duration) error {
+	return extendLoginLockScript.Run(ctx, c.client, []string{loginLockPrefix + loginKey(identifier)}, delay.Milliseconds()).Err()
+}
+
+// LockIdentifierLogin блокирует вход по логину на duration и сбрасывает счётчик неудач;
+// как и DelayIdentifierLogin, не сокращает уже стоящую блокировку
+func (c *RedisCache) LockIdentifierLogin(ctx context.Context, identifier string, duration time.Duration) error {
+	key := loginKey(identifier)
+	if err := extendLoginLockScript.Run(ctx, c.client, []string{loginLockPrefix + key}, duration.Milliseconds()).Err(); err != nil {
+		return err
+	}
+	return c.client.Del(ctx, loginFailuresPrefix+key).Err()
+}
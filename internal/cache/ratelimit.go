@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// rateLimitPrefix — префикс ключей скользящих окон ограничения частоты
+const rateLimitPrefix = "ratelimit:"
+
+// RateLimitResult — решение по запросу: пропущен ли он, сколько запросов ещё осталось в окне
+// и через сколько освободится следующий слот
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Duration
+}
+
+// slidingWindowScript ведёт окно в ZSET: элемент — запрос, score — время в миллисекундах.
+// Время берётся у Redis, чтобы реплики приложения с расходящимися часами считали одинаково.
+// Отклонённые запросы в окно не попадают: клиент, упёршийся в лимит, не продлевает себе блокировку.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, now .. ':' .. ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, math.max(limit - count, 0), reset}
+`)
+
+// AllowRate учитывает запрос в скользящем окне key длиной window и решает, укладывается ли он в limit.
+// Окно общее для всех реплик приложения, подключённых к этому Redis.
+func (c *RedisCache) AllowRate(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	values, err := slidingWindowScript.Run(ctx, c.client, []string{rateLimitPrefix + key},
+		limit, window.Milliseconds(), uuid.NewString()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{
+		Allowed:   values[0] == 1,
+		Remaining: int(values[1]),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
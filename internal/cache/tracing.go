@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"chechnya-product/internal/tracing"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"net"
+)
+
+// tracingHook создаёт span на каждую команду Redis, выполненную в контексте запроса
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span, traced := tracing.StartChild(ctx, "redis "+cmd.Name(),
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", cmd.Name()),
+		)
+		err := next(ctx, cmd)
+		if traced {
+			tracing.End(span, redisError(err))
+		}
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span, traced := tracing.StartChild(ctx, "redis pipeline",
+			attribute.String("db.system", "redis"),
+			attribute.Int("db.redis.commands", len(cmds)),
+		)
+		err := next(ctx, cmds)
+		if traced {
+			tracing.End(span, redisError(err))
+		}
+		return err
+	}
+}
+
+// redisError не считает промах кэша (redis.Nil) ошибкой span'а
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,176 @@
+// Package commerceml разбирает и формирует документы обмена с 1С в формате CommerceML 2
+package commerceml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// SchemaVersion — версия схемы, которую мы отдаём в выгрузке заказов
+const SchemaVersion = "2.05"
+
+var ErrUnknownDocument = errors.New("document contains neither catalog nor offers")
+
+// Document — корневой элемент <КоммерческаяИнформация>
+type Document struct {
+	XMLName    xml.Name    `xml:"КоммерческаяИнформация"`
+	Classifier *Classifier `xml:"Классификатор"`
+	Catalog    *Catalog    `xml:"Каталог"`
+	Offers     *OfferPack  `xml:"ПакетПредложений"`
+}
+
+// Classifier — дерево групп товаров
+type Classifier struct {
+	ID     string  `xml:"Ид"`
+	Name   string  `xml:"Наименование"`
+	Groups []Group `xml:"Группы>Группа"`
+}
+
+type Group struct {
+	ID     string  `xml:"Ид"`
+	Name   string  `xml:"Наименование"`
+	Groups []Group `xml:"Группы>Группа"`
+}
+
+// Catalog — товары (без цен и остатков)
+type Catalog struct {
+	ID          string    `xml:"Ид"`
+	Name        string    `xml:"Наименование"`
+	OnlyChanges bool      `xml:"СодержитТолькоИзменения,attr"`
+	Products    []Product `xml:"Товары>Товар"`
+}
+
+type Product struct {
+	ID          string   `xml:"Ид"`
+	SKU         string   `xml:"Артикул"`
+	Name        string   `xml:"Наименование"`
+	Description string   `xml:"Описание"`
+	GroupIDs    []string `xml:"Группы>Ид"`
+	Images      []string `xml:"Картинка"`
+	Status      string   `xml:"Статус,attr"`
+}
+
+// Deleted — товар помечен в 1С на удаление
+func (p Product) Deleted() bool {
+	return strings.EqualFold(p.Status, "Удален")
+}
+
+// OfferPack — цены и остатки
+type OfferPack struct {
+	ID          string  `xml:"Ид"`
+	OnlyChanges bool    `xml:"СодержитТолькоИзменения,attr"`
+	Offers      []Offer `xml:"Предложения>Предложение"`
+}
+
+type Offer struct {
+	ID       string   `xml:"Ид"`
+	SKU      string   `xml:"Артикул"`
+	Name     string   `xml:"Наименование"`
+	Prices   []Price  `xml:"Цены>Цена"`
+	Quantity *string  `xml:"Количество"`
+	Stocks   []string `xml:"Остатки>Остаток>Количество"`
+}
+
+type Price struct {
+	TypeID  string `xml:"ИдТипаЦены"`
+	Display string `xml:"Представление"`
+	Value   string `xml:"ЦенаЗаЕдиницу"`
+}
+
+// ProductID — идентификатор товара. У предложений с характеристиками он имеет вид "товар#характеристика".
+func (o Offer) ProductID() string {
+	id, _, _ := strings.Cut(o.ID, "#")
+	return id
+}
+
+// PriceValue возвращает цену нужного типа; пустой priceType — первая цена в списке
+func (o Offer) PriceValue(priceType string) (*float64, error) {
+	for _, p := range o.Prices {
+		if priceType != "" && p.TypeID != priceType {
+			continue
+		}
+		v, err := parseNumber(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q for offer %s", p.Value, o.ID)
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+// QuantityValue — остаток: элемент <Количество> или сумма по складам <Остатки>
+func (o Offer) QuantityValue() (*float64, error) {
+	if o.Quantity != nil {
+		v, err := parseNumber(*o.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for offer %s", *o.Quantity, o.ID)
+		}
+		return &v, nil
+	}
+	if len(o.Stocks) == 0 {
+		return nil, nil
+	}
+	var total float64
+	for _, s := range o.Stocks {
+		v, err := parseNumber(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stock %q for offer %s", s, o.ID)
+		}
+		total += v
+	}
+	return &total, nil
+}
+
+// Parse читает документ из 1С. Поддерживаются кодировки UTF-8 и windows-1251.
+func Parse(r io.Reader) (*Document, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "windows-1251", "cp1251":
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		}
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse commerceml: %w", err)
+	}
+	if doc.Catalog == nil && doc.Offers == nil && doc.Classifier == nil {
+		return nil, ErrUnknownDocument
+	}
+	return &doc, nil
+}
+
+func parseNumber(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(strings.TrimSpace(v))
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	// ParseFloat принимает "NaN" и "Inf", которые не бывают ни ценой, ни остатком
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("not a finite number: %q", v)
+	}
+	return n, nil
+}
+
+// Marshal сериализует документ с XML-заголовком
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to build commerceml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,210 @@
+package commerceml
+
+import (
+	"bytes"
+	"chechnya-product/internal/models"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func parseFixture(t *testing.T, name string) *Document {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return doc
+}
+
+func TestParseImport(t *testing.T) {
+	doc := parseFixture(t, "import.xml")
+
+	if doc.Classifier == nil || len(doc.Classifier.Groups) != 1 {
+		t.Fatalf("classifier = %+v, want one root group", doc.Classifier)
+	}
+	root := doc.Classifier.Groups[0]
+	if root.Name != "Продукты" || len(root.Groups) != 1 || root.Groups[0].ID != "group-honey" {
+		t.Fatalf("groups = %+v, want Продукты > Мёд", root)
+	}
+
+	if doc.Catalog == nil || len(doc.Catalog.Products) != 2 {
+		t.Fatalf("catalog = %+v, want two products", doc.Catalog)
+	}
+	honey, old := doc.Catalog.Products[0], doc.Catalog.Products[1]
+	if honey.ID != "product-honey" || honey.SKU != "HN-001" || honey.Name != "Мёд горный" || honey.Description != "Мёд с горных пасек" {
+		t.Fatalf("product = %+v", honey)
+	}
+	if len(honey.GroupIDs) != 1 || honey.GroupIDs[0] != "group-honey" {
+		t.Fatalf("product groups = %v, want [group-honey]", honey.GroupIDs)
+	}
+	if len(honey.Images) != 1 || honey.Images[0] != "import_files/ab/honey.jpg" {
+		t.Fatalf("product images = %v", honey.Images)
+	}
+	if honey.Deleted() || !old.Deleted() {
+		t.Fatalf("deleted = %v/%v, want false/true", honey.Deleted(), old.Deleted())
+	}
+	if doc.Offers != nil {
+		t.Fatal("import.xml must not contain offers")
+	}
+}
+
+func TestParseOffers(t *testing.T) {
+	doc := parseFixture(t, "offers.xml")
+
+	if doc.Offers == nil || !doc.Offers.OnlyChanges || len(doc.Offers.Offers) != 2 {
+		t.Fatalf("offers = %+v, want two changed offers", doc.Offers)
+	}
+	honey, nuts := doc.Offers.Offers[0], doc.Offers.Offers[1]
+
+	if honey.ProductID() != "product-honey" {
+		t.Fatalf("ProductID() = %q, want product-honey", honey.ProductID())
+	}
+	for priceType, want := range map[string]float64{"": 1100, "retail": 1250.5, "wholesale": 1100} {
+		price, err := honey.PriceValue(priceType)
+		if err != nil || price == nil || *price != want {
+			t.Fatalf("PriceValue(%q) = %v, %v; want %v", priceType, price, err, want)
+		}
+	}
+	if price, err := honey.PriceValue("unknown"); price != nil || err != nil {
+		t.Fatalf("PriceValue(unknown) = %v, %v; want nil", price, err)
+	}
+	if quantity, err := honey.QuantityValue(); err != nil || quantity == nil || *quantity != 12 {
+		t.Fatalf("QuantityValue() = %v, %v; want 12", quantity, err)
+	}
+
+	// Без <Количество> остаток складывается по складам
+	if quantity, err := nuts.QuantityValue(); err != nil || quantity == nil || *quantity != 7.5 {
+		t.Fatalf("stocks QuantityValue() = %v, %v; want 7.5", quantity, err)
+	}
+	if price, err := nuts.PriceValue(""); price != nil || err != nil {
+		t.Fatalf("offer without prices: %v, %v; want nil", price, err)
+	}
+}
+
+func TestParseWindows1251(t *testing.T) {
+	data, err := os.ReadFile("testdata/import.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`encoding="UTF-8"`), []byte(`encoding="windows-1251"`), 1)
+	encoded, err := charmap.Windows1251.NewEncoder().Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Parse(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := doc.Catalog.Products[0].Name; got != "Мёд горный" {
+		t.Fatalf("name = %q, want Мёд горный", got)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`<КоммерческаяИнформация></КоммерческаяИнформация>`)); !errors.Is(err, ErrUnknownDocument) {
+		t.Fatalf("empty document: err = %v, want ErrUnknownDocument", err)
+	}
+	if _, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="koi8-r"?><КоммерческаяИнформация/>`)); err == nil {
+		t.Fatal("unsupported charset must fail")
+	}
+
+	for _, value := range []string{"NaN", "Inf", "-Inf", "abc"} {
+		offer := Offer{ID: "x", Prices: []Price{{Value: value}}, Quantity: &value}
+		if _, err := offer.PriceValue(""); err == nil {
+			t.Fatalf("PriceValue(%q) must fail", value)
+		}
+		if _, err := offer.QuantityValue(); err == nil {
+			t.Fatalf("QuantityValue(%q) must fail", value)
+		}
+	}
+}
+
+func TestBuildOrdersRoundTrip(t *testing.T) {
+	name, address, comment, paid := "Иван", "Грозный, ул. Мира, 1", "Позвонить заранее", "paid"
+	honeyName, nutsName := "Мёд горный", "Орехи"
+	honeyPrice, nutsPrice, fee := 1250.5, 300.0, 150.0
+	createdAt := time.Date(2026, 10, 19, 9, 30, 15, 0, time.UTC)
+
+	orders := []models.Order{
+		{
+			ID: 42, OwnerID: "owner-1", Total: 2951, CreatedAt: createdAt, Status: models.OrderStatusNew,
+			Name: &name, Address: &address, OrderComment: &comment, DeliveryFee: &fee,
+			DeliveryType: "delivery", PaymentType: models.PaymentTypeOnline, PaymentStatus: &paid,
+			Items: []models.OrderItem{
+				{ProductID: 1, Name: &honeyName, Quantity: 2, Price: &honeyPrice},
+				{ProductID: 2, Name: &nutsName, Quantity: 1, Price: &nutsPrice},
+			},
+		},
+		{ID: 43, OwnerID: "owner-2", Total: 300, CreatedAt: createdAt, Status: models.OrderStatusNew,
+			Items: []models.OrderItem{{ProductID: 2, Name: &nutsName, Quantity: 1, Price: &nutsPrice}}},
+	}
+
+	data, err := BuildOrders(orders, map[int]string{1: "product-honey"}, createdAt)
+	if err != nil {
+		t.Fatalf("BuildOrders: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte(xml.Header)) {
+		t.Fatal("document must start with xml header")
+	}
+
+	var doc OrdersDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if doc.Version != SchemaVersion || doc.GeneratedAt != "2026-10-19T09:30:15" || len(doc.Documents) != 2 {
+		t.Fatalf("document = %+v", doc)
+	}
+
+	d := doc.Documents[0]
+	if d.Number != "42" || d.Date != "2026-10-19" || d.Time != "09:30:15" || d.Sum != "2951.00" || d.Comment != comment {
+		t.Fatalf("order = %+v", d)
+	}
+	if len(d.Counterparts) != 1 || d.Counterparts[0].Name != name || d.Counterparts[0].Address != address {
+		t.Fatalf("counterparts = %+v", d.Counterparts)
+	}
+	wantItems := []struct{ id, price, quantity, sum string }{
+		{"product-honey", "1250.50", "2", "2501.00"},
+		{"2", "300.00", "1", "300.00"},
+		{DeliveryItemID, "150.00", "1", "150.00"},
+	}
+	if len(d.Items) != len(wantItems) {
+		t.Fatalf("items = %+v, want %d", d.Items, len(wantItems))
+	}
+	for i, want := range wantItems {
+		got := d.Items[i]
+		if got.ID != want.id || got.Price != want.price || got.Quantity != want.quantity || got.Sum != want.sum {
+			t.Fatalf("item %d = %+v, want %+v", i, got, want)
+		}
+	}
+	properties := map[string]string{}
+	for _, p := range d.Properties {
+		properties[p.Name] = p.Value
+	}
+	if properties["Заказ оплачен"] != "true" || properties["Метод оплаты"] != models.PaymentTypeOnline {
+		t.Fatalf("properties = %v", properties)
+	}
+
+	// Заказ без имени и доставки: покупатель по owner_id, без строки доставки, не оплачен
+	d = doc.Documents[1]
+	if d.Counterparts[0].Name != "Покупатель owner-2" || len(d.Items) != 1 {
+		t.Fatalf("order without name = %+v", d)
+	}
+	for _, p := range d.Properties {
+		if p.Name == "Заказ оплачен" && p.Value != "false" {
+			t.Fatalf("unpaid order exported as paid")
+		}
+	}
+}
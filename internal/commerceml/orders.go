@@ -0,0 +1,157 @@
+package commerceml
+
+import (
+	"chechnya-product/internal/models"
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// DeliveryItemID — условный идентификатор услуги доставки, которым её распознаёт 1С
+const DeliveryItemID = "ORDER_DELIVERY"
+
+type OrdersDocument struct {
+	XMLName     xml.Name        `xml:"КоммерческаяИнформация"`
+	Version     string          `xml:"ВерсияСхемы,attr"`
+	GeneratedAt string          `xml:"ДатаФормирования,attr"`
+	Documents   []OrderDocument `xml:"Документ"`
+}
+
+type OrderDocument struct {
+	ID           string         `xml:"Ид"`
+	Number       string         `xml:"Номер"`
+	Date         string         `xml:"Дата"`
+	Time         string         `xml:"Время"`
+	Operation    string         `xml:"ХозОперация"`
+	Role         string         `xml:"Роль"`
+	Currency     string         `xml:"Валюта"`
+	Rate         string         `xml:"Курс"`
+	Sum          string         `xml:"Сумма"`
+	Counterparts []Counterparty `xml:"Контрагенты>Контрагент"`
+	Comment      string         `xml:"Комментарий,omitempty"`
+	Items        []OrderItem    `xml:"Товары>Товар"`
+	Properties   []Property     `xml:"ЗначенияРеквизитов>ЗначениеРеквизита"`
+}
+
+type Counterparty struct {
+	ID       string `xml:"Ид"`
+	Name     string `xml:"Наименование"`
+	Role     string `xml:"Роль"`
+	FullName string `xml:"ПолноеНаименование"`
+	Address  string `xml:"АдресРегистрации>Представление,omitempty"`
+}
+
+type OrderItem struct {
+	ID         string     `xml:"Ид"`
+	Name       string     `xml:"Наименование"`
+	Unit       Unit       `xml:"БазоваяЕдиница"`
+	Price      string     `xml:"ЦенаЗаЕдиницу"`
+	Quantity   string     `xml:"Количество"`
+	Sum        string     `xml:"Сумма"`
+	Properties []Property `xml:"ЗначенияРеквизитов>ЗначениеРеквизита,omitempty"`
+}
+
+type Unit struct {
+	Code     string `xml:"Код,attr"`
+	FullName string `xml:"НаименованиеПолное,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type Property struct {
+	Name  string `xml:"Наименование"`
+	Value string `xml:"Значение"`
+}
+
+var pieceUnit = Unit{Code: "796", FullName: "Штука", Name: "шт"}
+
+// BuildOrders формирует выгрузку заказов. externalIDs — идентификаторы товаров в 1С;
+// товары без них выгружаются под нашим id.
+func BuildOrders(orders []models.Order, externalIDs map[int]string, generatedAt time.Time) ([]byte, error) {
+	doc := OrdersDocument{
+		Version:     SchemaVersion,
+		GeneratedAt: generatedAt.Format("2006-01-02T15:04:05"),
+		Documents:   make([]OrderDocument, 0, len(orders)),
+	}
+
+	for _, o := range orders {
+		name := stringOr(o.Name, "Покупатель "+o.OwnerID)
+		d := OrderDocument{
+			ID:        strconv.Itoa(o.ID),
+			Number:    strconv.Itoa(o.ID),
+			Date:      o.CreatedAt.Format("2006-01-02"),
+			Time:      o.CreatedAt.Format("15:04:05"),
+			Operation: "Заказ товара",
+			Role:      "Продавец",
+			Currency:  "руб",
+			Rate:      "1",
+			Sum:       formatNumber(o.Total),
+			Counterparts: []Counterparty{{
+				ID:       o.OwnerID,
+				Name:     name,
+				Role:     "Покупатель",
+				FullName: name,
+				Address:  stringOr(o.Address, ""),
+			}},
+			Comment: stringOr(o.OrderComment, ""),
+			Properties: []Property{
+				{Name: "Статус заказа", Value: o.Status},
+				{Name: "Метод оплаты", Value: o.PaymentType},
+				{Name: "Заказ оплачен", Value: strconv.FormatBool(o.PaymentStatus != nil && *o.PaymentStatus == "paid")},
+				{Name: "Способ доставки", Value: o.DeliveryType},
+			},
+		}
+
+		for _, item := range o.Items {
+			id, ok := externalIDs[item.ProductID]
+			if !ok {
+				id = strconv.Itoa(item.ProductID)
+			}
+			price := floatOr(item.Price)
+			d.Items = append(d.Items, OrderItem{
+				ID:       id,
+				Name:     stringOr(item.Name, ""),
+				Unit:     pieceUnit,
+				Price:    formatNumber(price),
+				Quantity: strconv.Itoa(item.Quantity),
+				Sum:      formatNumber(price * float64(item.Quantity)),
+			})
+		}
+
+		if fee := floatOr(o.DeliveryFee); fee > 0 {
+			d.Items = append(d.Items, OrderItem{
+				ID:       DeliveryItemID,
+				Name:     "Доставка заказа",
+				Unit:     pieceUnit,
+				Price:    formatNumber(fee),
+				Quantity: "1",
+				Sum:      formatNumber(fee),
+				Properties: []Property{
+					{Name: "ВидНоменклатуры", Value: "Услуга"},
+					{Name: "ТипНоменклатуры", Value: "Услуга"},
+				},
+			})
+		}
+
+		doc.Documents = append(doc.Documents, d)
+	}
+
+	return Marshal(doc)
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func stringOr(v *string, def string) string {
+	if v == nil || *v == "" {
+		return def
+	}
+	return *v
+}
+
+func floatOr(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"chechnya-product/internal/metrics"
+	"chechnya-product/internal/tracing"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// repositoriesPkg — по этому префиксу в стеке вызовов запрос относится к методу репозитория
+const repositoriesPkg = "chechnya-product/internal/repositories."
+
+// instrumentedConnector оборачивает соединения драйвера, чтобы замерять время запросов
+// для метрики chechnya_db_query_duration_seconds, создавать span'ы SQL-запросов и ограничивать их время
+type instrumentedConnector struct {
+	driver.Connector
+	queryTimeout time.Duration
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn: conn, queryTimeout: c.queryTimeout}, nil
+}
+
+type noQueryTimeoutKey struct{}
+
+// WithoutQueryTimeout снимает с запросов ограничение DB_QUERY_TIMEOUT_SECONDS — для миграций,
+// резервного копирования и выгрузок, которые законно идут дольше. Отмена ctx по-прежнему их прерывает.
+func WithoutQueryTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryTimeoutKey{}, true)
+}
+
+// instrumentedConn замеряет QueryContext и ExecContext; остальное передаёт соединению lib/pq как есть.
+// Span создаётся, только если репозиторий передал контекст запроса.
+// Запросы внутри транзакций идут через те же методы. Явно подготовленные выражения не замеряются —
+// в репозиториях они не используются.
+type instrumentedConn struct {
+	conn         driver.Conn
+	queryTimeout time.Duration
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *instrumentedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *instrumentedConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	finish := startQuery(ctx, query)
+	rows, err := q.QueryContext(ctx, query, args)
+	err = timeoutError(ctx, err)
+	finish(err)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Таймаут действует, пока читаются строки, и снимается при их закрытии
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	finish := startQuery(ctx, query)
+	result, err := e.ExecContext(ctx, query, args)
+	err = timeoutError(ctx, err)
+	finish(err)
+	return result, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// withTimeout ограничивает запрос временем queryTimeout, если его не сняли через WithoutQueryTimeout.
+// Более ранний дедлайн родительского контекста остаётся в силе.
+func (c *instrumentedConn) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.queryTimeout <= 0 || ctx.Value(noQueryTimeoutKey{}) != nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.queryTimeout)
+}
+
+// timeoutError заменяет ошибку отменённого запроса ("canceling statement due to user request")
+// на ошибку контекста, чтобы вызывающий код мог отличить таймаут и отмену через errors.Is
+func timeoutError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, driver.ErrSkip) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ctx.Err(), err)
+}
+
+// timeoutRows снимает таймаут запроса при закрытии результата.
+// Необязательные интерфейсы lib/pq (типы колонок) не пробрасываются — репозитории их не используют.
+type timeoutRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// startQuery начинает замер запроса; возвращённая функция записывает метрику и завершает span
+func startQuery(ctx context.Context, query string) func(err error) {
+	repository, method := queryCaller()
+	_, span, traced := tracing.StartChild(ctx, repository+"."+method,
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", compactQuery(query)),
+	)
+	start := time.Now()
+
+	return func(err error) {
+		if errors.Is(err, driver.ErrSkip) {
+			if traced {
+				span.End()
+			}
+			return
+		}
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		metrics.DBQueryDuration.WithLabelValues(repository, method, status).Observe(time.Since(start).Seconds())
+		if traced {
+			tracing.End(span, err)
+		}
+	}
+}
+
+// compactQuery схлопывает переносы и отступы запроса для атрибута span'а
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// queryCaller ищет в стеке ближайший метод репозитория, например (*ProductRepo).GetByID → ProductRepo, GetByID.
+// Вложенные функции сводятся к методу, в котором объявлены; если метода нет — берётся функция пакета.
+// Запросы не из репозиториев (миграции goose) получают метку "other".
+func queryCaller() (string, string) {
+	var pcs [48]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	fallback := ""
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, repositoriesPkg); ok {
+			if strings.HasPrefix(name, "(") {
+				typeName, method, _ := strings.Cut(name, ").")
+				method, _, _ = strings.Cut(method, ".")
+				return strings.TrimPrefix(typeName, "(*"), method
+			}
+			if fallback == "" {
+				fallback, _, _ = strings.Cut(name, ".")
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	if fallback != "" {
+		return "repositories", fallback
+	}
+	return "other", "other"
+}
@@ -2,19 +2,27 @@ package db
 
 import (
 	"chechnya-product/config"
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func NewPostgresDB(cfg *config.Config) (*sqlx.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
 
-	db, err := sqlx.Connect("postgres", dsn)
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, err
 	}
 
+	// Соединения оборачиваются для метрик и таймаута запросов; имя драйвера "postgres" нужно sqlx для плейсхолдеров $1
+	db := sqlx.NewDb(sql.OpenDB(instrumentedConnector{Connector: connector, queryTimeout: cfg.DBQueryTimeout}), "postgres")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return db, nil
 }
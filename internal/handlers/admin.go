@@ -1,41 +1,66 @@
 package handlers
 
 import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/models"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
 	"net/http"
 
 	"go.uber.org/zap"
 )
 
+// backupUploadMaxSize — максимальный размер загружаемой копии
+const backupUploadMaxSize = 1 << 30
+
 type AdminInterface interface {
 	TruncateTableHandler(w http.ResponseWriter, r *http.Request)
 	TruncateAllTablesHandler(w http.ResponseWriter, r *http.Request)
+	CreateBackup(w http.ResponseWriter, r *http.Request)
+	ListBackups(w http.ResponseWriter, r *http.Request)
+	DownloadBackup(w http.ResponseWriter, r *http.Request)
+	UploadBackup(w http.ResponseWriter, r *http.Request)
+	DeleteBackup(w http.ResponseWriter, r *http.Request)
+	RestoreBackup(w http.ResponseWriter, r *http.Request)
 }
 
 type AdminHandler struct {
 	service services.AdminServiceInterface
 	logger  *zap.Logger
+	cache   *cache.RedisCache
 }
 
-func NewAdminHandler(service services.AdminServiceInterface, logger *zap.Logger) *AdminHandler {
-	return &AdminHandler{service: service, logger: logger}
+func NewAdminHandler(service services.AdminServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *AdminHandler {
+	return &AdminHandler{service: service, logger: logger, cache: cache}
 }
 
 type TruncateRequest struct {
-	Table string `json:"table"`
+	Table        string `json:"table"`
+	ConfirmToken string `json:"confirm_token"`
+}
+
+// ConfirmRequest — повторный запрос опасной операции с токеном подтверждения
+type ConfirmRequest struct {
+	ConfirmToken string `json:"confirm_token"`
 }
 
 // TruncateTableHandler
 // @Summary Очистка таблицы  с  перезапуском ID
+// @Description Доступно только вне продакшена (ENV=dev, development, local, test или staging). Первый запрос без confirm_token возвращает 202 с токеном; очистка выполняется повторным запросом с этим токеном в течение 5 минут.
 // @Security BearerAuth
 // @Tags Admin
 // @Accept json
 // @Produce json
-// @Param table body TruncateRequest true "Название таблицы для очистки"
+// @Param table body TruncateRequest true "Название таблицы для очистки и токен подтверждения"
 // @Success 200 {object} utils.SuccessResponse
+// @Success 202 {object} utils.SuccessResponse{data=models.Confirmation}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Router /api/admin/truncate [post]
 func (h *AdminHandler) TruncateTableHandler(w http.ResponseWriter, r *http.Request) {
 	var req TruncateRequest
@@ -44,31 +69,245 @@ func (h *AdminHandler) TruncateTableHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.service.TruncateTable(req.Table); err != nil {
-		h.logger.Error("ошибка очистки таблицы", zap.String("table", req.Table), zap.Error(err))
-		utils.ErrorJSON(w, http.StatusBadRequest, "Ошибка: "+err.Error())
+	confirmation, err := h.service.TruncateTable(r.Context(), req.Table, middleware.GetUserID(r), req.ConfirmToken)
+	if err != nil {
+		h.writeError(w, r, "ошибка очистки таблицы", err, zap.String("table", req.Table))
+		return
+	}
+	if confirmation != nil {
+		writeConfirmation(w, confirmation)
 		return
 	}
 
-	h.logger.Info("Таблица очищена", zap.String("table", req.Table))
+	h.clearCache(r)
+	tracing.Logger(r.Context(), h.logger).Warn("Таблица очищена", zap.String("table", req.Table), zap.Int("user_id", middleware.GetUserID(r)))
 	utils.JSONResponse(w, http.StatusOK, "Таблица очищена", nil)
 }
 
 // TruncateAllTablesHandler
 // @Summary Очистить все таблицы (только админ)
+// @Description Доступно только вне продакшена. Первый запрос без confirm_token возвращает 202 с токеном; очистка выполняется повторным запросом с этим токеном.
 // @Security BearerAuth
 // @Tags Admin
+// @Accept json
 // @Produce json
+// @Param input body ConfirmRequest false "Токен подтверждения"
 // @Success 200 {object} utils.SuccessResponse
+// @Success 202 {object} utils.SuccessResponse{data=models.Confirmation}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Router /api/admin/truncate/all [post]
 func (h *AdminHandler) TruncateAllTablesHandler(w http.ResponseWriter, r *http.Request) {
-	if err := h.service.TruncateAllTables(); err != nil {
-		h.logger.Error("ошибка очистки всех таблиц", zap.Error(err))
-		utils.ErrorJSON(w, http.StatusBadRequest, "Ошибка очистки всех таблиц: "+err.Error())
+	var req ConfirmRequest
+	if !decodeConfirmRequest(w, r, &req) {
+		return
+	}
+
+	confirmation, err := h.service.TruncateAllTables(r.Context(), middleware.GetUserID(r), req.ConfirmToken)
+	if err != nil {
+		h.writeError(w, r, "ошибка очистки всех таблиц", err)
+		return
+	}
+	if confirmation != nil {
+		writeConfirmation(w, confirmation)
 		return
 	}
 
-	h.logger.Info("Все таблицы очищены")
+	h.clearCache(r)
+	tracing.Logger(r.Context(), h.logger).Warn("Все таблицы очищены", zap.Int("user_id", middleware.GetUserID(r)))
 	utils.JSONResponse(w, http.StatusOK, "Все таблицы очищены", nil)
 }
+
+// CreateBackup
+// @Summary Создать резервную копию
+// @Description Выгружает пользователей, каталог, цены, заказы, отзывы и объявления в JSON-файл (строки в формате to_jsonb). Корзины, подписки и журнал действий в копию не входят.
+// @Security BearerAuth
+// @Tags Admin
+// @Produce json
+// @Success 201 {object} utils.SuccessResponse{data=models.BackupInfo}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/backups [post]
+func (h *AdminHandler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := h.service.CreateBackup(r.Context())
+	if err != nil {
+		h.writeError(w, r, "failed to create backup", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusCreated, "Backup created", backup)
+}
+
+// ListBackups
+// @Summary Список резервных копий
+// @Security BearerAuth
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=[]models.BackupInfo}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/backups [get]
+func (h *AdminHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.service.ListBackups(r.Context())
+	if err != nil {
+		h.writeError(w, r, "failed to list backups", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Backups fetched", backups)
+}
+
+// DownloadBackup
+// @Summary Скачать резервную копию
+// @Security BearerAuth
+// @Tags Admin
+// @Produce application/json
+// @Param name path string true "Имя файла копии"
+// @Success 200 {file} file "Файл копии"
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/backups/{name} [get]
+func (h *AdminHandler) DownloadBackup(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	file, err := h.service.OpenBackup(r.Context(), name)
+	if err != nil {
+		h.writeError(w, r, "failed to open backup", err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		h.writeError(w, r, "failed to open backup", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment;filename="+name)
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
+// UploadBackup
+// @Summary Загрузить резервную копию
+// @Description Сохраняет файл копии (например, снятой на другом сервере) для последующего восстановления. Файл проверяется целиком.
+// @Security BearerAuth
+// @Tags Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл копии"
+// @Success 201 {object} utils.SuccessResponse{data=models.BackupInfo}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /api/admin/backups/upload [post]
+func (h *AdminHandler) UploadBackup(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, backupUploadMaxSize+1<<20)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Файл слишком большой")
+			return
+		}
+		utils.ErrorJSON(w, http.StatusBadRequest, "Файл не получен")
+		return
+	}
+	defer file.Close()
+
+	backup, err := h.service.UploadBackup(r.Context(), file)
+	if err != nil {
+		h.writeError(w, r, "failed to upload backup", err)
+		return
+	}
+	tracing.Logger(r.Context(), h.logger).Info("backup uploaded", zap.String("name", backup.Name))
+	utils.JSONResponse(w, http.StatusCreated, "Backup uploaded", backup)
+}
+
+// DeleteBackup
+// @Summary Удалить резервную копию
+// @Security BearerAuth
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Имя файла копии"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/backups/{name} [delete]
+func (h *AdminHandler) DeleteBackup(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteBackup(r.Context(), mux.Vars(r)["name"]); err != nil {
+		h.writeError(w, r, "failed to delete backup", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Backup deleted", nil)
+}
+
+// RestoreBackup
+// @Summary Восстановить данные из резервной копии
+// @Description Заменяет все данные из копии (включая пользователей) её содержимым; корзины покупателей очищаются. Копия должна быть снята на той же версии схемы (409 иначе). Первый запрос без confirm_token возвращает 202 с токеном, восстановление выполняется повторным запросом с токеном.
+// @Security BearerAuth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Имя файла копии"
+// @Param input body ConfirmRequest false "Токен подтверждения"
+// @Success 200 {object} utils.SuccessResponse
+// @Success 202 {object} utils.SuccessResponse{data=models.Confirmation}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/admin/backups/{name}/restore [post]
+func (h *AdminHandler) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmRequest
+	if !decodeConfirmRequest(w, r, &req) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	confirmation, err := h.service.RestoreBackup(r.Context(), name, middleware.GetUserID(r), req.ConfirmToken)
+	if err != nil {
+		h.writeError(w, r, "failed to restore backup", err, zap.String("name", name))
+		return
+	}
+	if confirmation != nil {
+		writeConfirmation(w, confirmation)
+		return
+	}
+
+	h.clearCache(r)
+	utils.JSONResponse(w, http.StatusOK, "Backup restored", nil)
+}
+
+// decodeConfirmRequest читает необязательное тело с токеном подтверждения
+func decodeConfirmRequest(w http.ResponseWriter, r *http.Request, req *ConfirmRequest) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Ошибка декодирования")
+		return false
+	}
+	return true
+}
+
+func writeConfirmation(w http.ResponseWriter, confirmation *models.Confirmation) {
+	utils.JSONResponse(w, http.StatusAccepted, "Подтвердите действие: повторите запрос с confirm_token", confirmation)
+}
+
+// clearCache сбрасывает кэш каталога после массового изменения данных
+func (h *AdminHandler) clearCache(r *http.Request) {
+	h.cache.ClearPrefix(r.Context(), "products:")
+	h.cache.ClearPrefix(r.Context(), "product:")
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error, fields ...zap.Field) {
+	switch {
+	case errors.Is(err, services.ErrTruncateDisabled):
+		utils.ErrorJSON(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrUnknownTable),
+		errors.Is(err, services.ErrInvalidConfirmation),
+		errors.Is(err, services.ErrInvalidBackup),
+		errors.Is(err, services.ErrBackupUnexpectedTable):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrBackupNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrBackupSchemaMismatch):
+		utils.ErrorJSON(w, http.StatusConflict, err.Error())
+	default:
+		tracing.Logger(r.Context(), h.logger).Error(msg, append(fields, zap.Error(err))...)
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
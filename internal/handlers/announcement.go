@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
 	"go.uber.org/zap"
@@ -54,7 +55,7 @@ func (h *AnnouncementHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Update(id, body.Title, body.Content); err != nil {
+	if err := h.service.Update(r.Context(), id, body.Title, body.Content); err != nil {
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to update announcement")
 		return
 	}
@@ -76,7 +77,7 @@ func (h *AnnouncementHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid ID")
 		return
 	}
-	ann, err := h.service.GetByID(id)
+	ann, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
 		utils.ErrorJSON(w, http.StatusNotFound, "Announcement not found")
 		return
@@ -91,9 +92,9 @@ func (h *AnnouncementHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/announcements [get]
 func (h *AnnouncementHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	anns, err := h.service.GetAll()
+	anns, err := h.service.GetAll(r.Context())
 	if err != nil {
-		h.logger.Error("get announcements failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("get announcements failed", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to get announcements")
 		return
 	}
@@ -120,9 +121,9 @@ func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ann, err := h.service.Create(body.Title, body.Content)
+	ann, err := h.service.Create(r.Context(), body.Title, body.Content)
 	if err != nil {
-		h.logger.Error("create announcement failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("create announcement failed", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to create announcement")
 		return
 	}
@@ -144,8 +145,8 @@ func (h *AnnouncementHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid ID")
 		return
 	}
-	if err := h.service.Delete(id); err != nil {
-		h.logger.Error("delete announcement failed", zap.Int("id", id), zap.Error(err))
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("delete announcement failed", zap.Int("id", id), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to delete")
 		return
 	}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type AuditHandlerInterface interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+}
+
+type AuditHandler struct {
+	service services.AuditServiceInterface
+	logger  *zap.Logger
+}
+
+func NewAuditHandler(service services.AuditServiceInterface, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{service: service, logger: logger}
+}
+
+// List
+// @Summary Журнал действий администраторов
+// @Description Все изменяющие запросы к /api/admin: кто, когда, над какой сущностью, что изменилось (changes: поле → before/after), IP и request id. Новые записи первыми.
+// @Tags Журнал
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query int false "ID администратора"
+// @Param action query string false "Действие, например \"PATCH /products/{id}\""
+// @Param entity_type query string false "Тип сущности: product, category, order, user, review, announcement, file, table, catalog..."
+// @Param entity_id query string false "ID сущности"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включая (RFC3339 или YYYY-MM-DD)"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.AuditEntry}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/audit [get]
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch audit log", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch audit log")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Audit log fetched", entries)
+}
+
+// Export
+// @Summary Экспорт журнала действий в CSV
+// @Description Те же фильтры, что и у списка, но без постраничной разбивки
+// @Tags Журнал
+// @Security BearerAuth
+// @Produce text/csv
+// @Param actor_id query int false "ID администратора"
+// @Param action query string false "Действие"
+// @Param entity_type query string false "Тип сущности"
+// @Param entity_id query string false "ID сущности"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включая (RFC3339 или YYYY-MM-DD)"
+// @Success 200 {file} file "CSV файл"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/audit/export [get]
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.Export(r.Context(), filter)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to export audit log", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch audit log")
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("exporting audit log to CSV", zap.Int("entries_count", len(entries)))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment;filename=audit.csv")
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write([]string{
+		"ID", "Created At", "Actor ID", "Actor", "Action", "Entity Type", "Entity ID",
+		"Status", "IP", "Request ID", "Changes", "Request",
+	})
+
+	for _, e := range entries {
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = strconv.Itoa(*e.ActorID)
+		}
+		actorName := ""
+		if e.ActorName != nil {
+			actorName = *e.ActorName
+		}
+		entityID := ""
+		if e.EntityID != nil {
+			entityID = *e.EntityID
+		}
+
+		writer.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.Format(time.RFC3339),
+			actorID,
+			actorName,
+			e.Action,
+			e.EntityType,
+			entityID,
+			strconv.Itoa(e.Status),
+			e.IP,
+			e.RequestID,
+			string(e.Changes),
+			string(e.Request),
+		})
+	}
+}
+
+// parseAuditFilter разбирает параметры фильтра; при ошибке сам отвечает 400
+func parseAuditFilter(w http.ResponseWriter, r *http.Request) (models.AuditFilter, bool) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+	}
+
+	if v := query.Get("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			utils.ErrorJSON(w, http.StatusBadRequest, "Invalid actor_id")
+			return filter, false
+		}
+		filter.ActorID = id
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid from")
+		return filter, false
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid to")
+		return filter, false
+	}
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+	return filter, true
+}
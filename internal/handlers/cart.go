@@ -4,8 +4,10 @@ import (
 	"chechnya-product/internal/middleware"
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
@@ -50,20 +52,20 @@ func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
 
 	var req AddToCartRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("invalid AddToCart request", zap.Error(err), zap.String("owner_id", ownerID))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid AddToCart request", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if err := h.service.AddToCart(ownerID, req.ProductID, req.Quantity); err != nil {
-		h.logger.Error("add to cart failed", zap.Error(err), zap.String("owner_id", ownerID))
+	if err := h.service.AddToCart(r.Context(), ownerID, req.ProductID, req.Quantity); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("add to cart failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	cartItems, err := h.service.GetCart(ownerID)
+	cartItems, err := h.service.GetCart(r.Context(), ownerID)
 	if err != nil {
-		h.logger.Error("get cart after add failed", zap.Error(err), zap.String("owner_id", ownerID))
+		tracing.Logger(r.Context(), h.logger).Error("get cart after add failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch updated cart")
 		return
 	}
@@ -81,7 +83,7 @@ func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.logger.Info("item added to cart",
+	tracing.Logger(r.Context(), h.logger).Info("item added to cart",
 		zap.String("owner_id", ownerID),
 		zap.Int("product_id", req.ProductID),
 		zap.Int("quantity", req.Quantity),
@@ -100,9 +102,9 @@ func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
 // @Router /api/cart [get]
 func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
-	items, err := h.service.GetCart(ownerID)
+	items, err := h.service.GetCart(r.Context(), ownerID)
 	if err != nil {
-		h.logger.Error("get cart failed", zap.Error(err), zap.String("owner_id", ownerID))
+		tracing.Logger(r.Context(), h.logger).Error("get cart failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to get cart")
 		return
 	}
@@ -111,7 +113,7 @@ func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 		items = []models.CartItemResponse{}
 	}
 
-	h.logger.Info("cart retrieved", zap.String("owner_id", ownerID), zap.Int("items_count", len(items)))
+	tracing.Logger(r.Context(), h.logger).Info("cart retrieved", zap.String("owner_id", ownerID), zap.Int("items_count", len(items)))
 	var total float64
 	for _, item := range items {
 		total += item.Total
@@ -142,7 +144,7 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	productIDStr := mux.Vars(r)["product_id"]
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		h.logger.Warn("invalid product_id", zap.String("raw", productIDStr))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product_id", zap.String("raw", productIDStr))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
@@ -151,16 +153,16 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 		Quantity int `json:"quantity"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("invalid UpdateItem request", zap.Error(err), zap.String("owner_id", ownerID))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid UpdateItem request", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
-	if err := h.service.UpdateItem(ownerID, productID, req.Quantity); err != nil {
-		h.logger.Warn("update item failed", zap.Error(err), zap.String("owner_id", ownerID))
+	if err := h.service.UpdateItem(r.Context(), ownerID, productID, req.Quantity); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("update item failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	h.logger.Info("cart item updated",
+	tracing.Logger(r.Context(), h.logger).Info("cart item updated",
 		zap.String("owner_id", ownerID),
 		zap.Int("product_id", productID),
 		zap.Int("new_quantity", req.Quantity),
@@ -183,18 +185,18 @@ func (h *CartHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	productIDStr := mux.Vars(r)["product_id"]
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil {
-		h.logger.Warn("invalid product_id", zap.String("raw", productIDStr))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product_id", zap.String("raw", productIDStr))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
-	if err := h.service.DeleteItem(ownerID, productID); err != nil {
-		h.logger.Error("delete item failed", zap.Error(err), zap.String("owner_id", ownerID))
+	if err := h.service.DeleteItem(r.Context(), ownerID, productID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("delete item failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to delete item")
 		return
 	}
 
-	h.logger.Info("cart item deleted",
+	tracing.Logger(r.Context(), h.logger).Info("cart item deleted",
 		zap.String("owner_id", ownerID),
 		zap.Int("product_id", productID),
 	)
@@ -212,12 +214,12 @@ func (h *CartHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 // @Router /api/cart/clear [delete]
 func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
-	if err := h.service.ClearCart(ownerID); err != nil {
-		h.logger.Error("clear cart failed", zap.Error(err), zap.String("owner_id", ownerID))
+	if err := h.service.ClearCart(r.Context(), ownerID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("clear cart failed", zap.Error(err), zap.String("owner_id", ownerID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to clear cart")
 		return
 	}
-	h.logger.Info("cart cleared", zap.String("owner_id", ownerID))
+	tracing.Logger(r.Context(), h.logger).Info("cart cleared", zap.String("owner_id", ownerID))
 
 	utils.JSONResponse(w, http.StatusOK, "Cart cleared", nil)
 }
@@ -229,15 +231,25 @@ func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param input body []AddToCartRequest true "Список товаров для добавления"
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ"
 // @Success 201 {object} utils.SuccessResponse{data=models.CartBulkResponse} "Товары добавлены, возвращены список и сумма"
 // @Failure 400 {object} utils.ErrorResponse "Некорректные данные запроса"
 // @Failure 500 {object} utils.ErrorResponse "Ошибка сервера при получении корзины"
+// @Failure 409 {object} utils.ErrorResponse "Запрос с этим Idempotency-Key ещё выполняется"
+// @Failure 413 {object} utils.ErrorResponse "Тело запроса больше middleware.MaxJSONBody"
+// @Failure 422 {object} utils.ErrorResponse "Idempotency-Key уже использован с другим телом"
 // @Router /api/cart/bulk [post]
 func (h *CartHandler) AddBulkToCart(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 
+	r.Body = http.MaxBytesReader(w, r.Body, middleware.MaxJSONBody)
 	var items []AddToCartRequest
 	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
@@ -246,18 +258,18 @@ func (h *CartHandler) AddBulkToCart(w http.ResponseWriter, r *http.Request) {
 		if item.Quantity <= 0 {
 			continue
 		}
-		err := h.service.AddToCart(ownerID, item.ProductID, item.Quantity)
+		err := h.service.AddToCart(r.Context(), ownerID, item.ProductID, item.Quantity)
 		if err != nil {
-			h.logger.Warn("bulk add failed",
+			tracing.Logger(r.Context(), h.logger).Warn("bulk add failed",
 				zap.Int("product_id", item.ProductID),
 				zap.Error(err),
 			)
 		}
 	}
 
-	cartItems, err := h.service.GetCart(ownerID)
+	cartItems, err := h.service.GetCart(r.Context(), ownerID)
 	if err != nil {
-		h.logger.Error("get cart after bulk failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("get cart after bulk failed", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to get updated cart")
 		return
 	}
@@ -267,7 +279,7 @@ func (h *CartHandler) AddBulkToCart(w http.ResponseWriter, r *http.Request) {
 		total += item.Total
 	}
 
-	h.logger.Info("bulk items added", zap.String("owner_id", ownerID), zap.Int("count", len(cartItems)))
+	tracing.Logger(r.Context(), h.logger).Info("bulk items added", zap.String("owner_id", ownerID), zap.Int("count", len(cartItems)))
 
 	utils.JSONResponse(w, http.StatusCreated, "Items added to cart", models.CartBulkResponse{
 		Items: cartItems,
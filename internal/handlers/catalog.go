@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/spreadsheet"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Максимальный размер файла импорта каталога
+const catalogImportMaxSize = 20 << 20
+
+type CatalogHandlerInterface interface {
+	Import(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+}
+
+type CatalogHandler struct {
+	service services.CatalogServiceInterface
+	logger  *zap.Logger
+	cache   *cache.RedisCache
+}
+
+func NewCatalogHandler(service services.CatalogServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *CatalogHandler {
+	return &CatalogHandler{service: service, logger: logger, cache: cache}
+}
+
+// Import
+// @Summary Импорт каталога из CSV/XLSX (админ)
+// @Description Первая строка — заголовки: sku (артикул), name (название), description, price (цена), availability (в наличии: да/нет), category (название категории), url. Товар ищется по артикулу, затем по названию; найденный обновляется, иначе создаётся. Пустая ячейка значение не меняет. С dry_run=true ничего не сохраняется — возвращается построчный отчёт с изменениями и ошибками. Без dry_run изменения применяются одной транзакцией и только если ошибок нет.
+// @Tags Каталог
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл .csv или .xlsx"
+// @Param format query string false "csv или xlsx (по умолчанию — по расширению файла)"
+// @Param dry_run query bool false "Только проверить и показать изменения"
+// @Success 200 {object} utils.SuccessResponse{data=models.CatalogImportReport}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.SuccessResponse{data=models.CatalogImportReport}
+// @Router /api/admin/catalog/import [post]
+func (h *CatalogHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, catalogImportMaxSize+1<<20)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Файл слишком большой")
+			return
+		}
+		utils.ErrorJSON(w, http.StatusBadRequest, "Файл не получен")
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = spreadsheet.FormatByName(header.Filename)
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	report, err := h.service.Import(r.Context(), format, file, dryRun, middleware.GetUserID(r))
+	switch {
+	case errors.Is(err, services.ErrImportHasErrors):
+		utils.JSONResponse(w, http.StatusUnprocessableEntity, err.Error(), report)
+		return
+	case errors.Is(err, spreadsheet.ErrUnsupportedFormat),
+		errors.Is(err, services.ErrEmptyImport),
+		errors.Is(err, services.ErrImportKeyColumn),
+		errors.Is(err, services.ErrUnknownImportColumn):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		tracing.Logger(r.Context(), h.logger).Error("catalog import failed", zap.String("file", header.Filename), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusBadRequest, "Не удалось импортировать файл")
+		return
+	}
+
+	if report.Applied {
+		h.cache.ClearPrefix(r.Context(), "products:")
+		h.cache.ClearPrefix(r.Context(), "product:")
+		utils.JSONResponse(w, http.StatusOK, "Catalog imported", report)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Dry run finished", report)
+}
+
+// Export
+// @Summary Экспорт каталога в CSV/XLSX (админ)
+// @Description Выгружает все товары в формате, который принимает импорт
+// @Tags Каталог
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (по умолчанию) или xlsx"
+// @Success 200 {file} file "Файл каталога"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/catalog/export [get]
+func (h *CatalogHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = spreadsheet.FormatCSV
+	}
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		utils.ErrorJSON(w, http.StatusBadRequest, spreadsheet.ErrUnsupportedFormat.Error())
+		return
+	}
+
+	// Собираем файл в памяти, чтобы при ошибке ответить JSON, а не обрывком файла
+	var buf bytes.Buffer
+	if err := h.service.Export(r.Context(), format, &buf); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("catalog export failed", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось выгрузить каталог")
+		return
+	}
+
+	filename := fmt.Sprintf("catalog_%s.%s", time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Type", spreadsheet.ContentType(format))
+	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
+	w.Write(buf.Bytes())
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
+	"errors"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
@@ -13,6 +18,9 @@ import (
 
 type CategoryHandlerInterface interface {
 	GetAll(w http.ResponseWriter, r *http.Request)
+	GetTree(w http.ResponseWriter, r *http.Request)
+	GetBySlug(w http.ResponseWriter, r *http.Request)
+	Move(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
@@ -22,31 +30,67 @@ type CategoryHandlerInterface interface {
 type CategoryHandler struct {
 	service services.CategoryServiceInterface
 	logger  *zap.Logger
+	cache   *cache.RedisCache
 }
 
-func NewCategoryHandler(service services.CategoryServiceInterface, logger *zap.Logger) *CategoryHandler {
-	return &CategoryHandler{service: service, logger: logger}
+func NewCategoryHandler(service services.CategoryServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *CategoryHandler {
+	return &CategoryHandler{service: service, logger: logger, cache: cache}
 }
 
 // GetAll
 // @Summary Получить список категорий
-// @Description Возвращает все доступные категории товаров
+// @Description Возвращает все категории плоским списком (родитель — в parent_id)
 // @Tags Категории
 // @Produce json
-// @Success 200 {array} string
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Category}
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/categories [get]
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	categories, err := h.service.GetAll(r.Context())
 	if err != nil {
-		h.logger.Error("failed to fetch categories", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch categories", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch categories")
 		return
 	}
-	h.logger.Info("categories fetched", zap.Int("count", len(categories)))
+	tracing.Logger(r.Context(), h.logger).Info("categories fetched", zap.Int("count", len(categories)))
 	utils.JSONResponse(w, http.StatusOK, "Categories fetched", categories)
 }
 
+// GetTree
+// @Summary Дерево категорий
+// @Description Корневые категории с вложенными подкатегориями, на каждом уровне — по порядку сортировки
+// @Tags Категории
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CategoryNode}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/categories/tree [get]
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetTree(r.Context())
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to build category tree", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch categories")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Categories fetched", tree)
+}
+
+// GetBySlug
+// @Summary Категория по slug
+// @Tags Категории
+// @Produce json
+// @Param slug path string true "Slug категории"
+// @Success 200 {object} utils.SuccessResponse{data=models.Category}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/categories/{slug} [get]
+func (h *CategoryHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	category, err := h.service.GetBySlug(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Category fetched", category)
+}
+
 // Create
 // @Summary Создать новую категорию
 // @Description Добавляет новую категорию (только для администратора)
@@ -54,88 +98,101 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param input body utils.CategoryRequest true "Название категории"
+// @Param input body utils.CategoryRequest true "Название, родитель, slug, описание и изображение категории"
 // @Success 201 {object} models.Category
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /api/admin/categories [post]
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var body utils.CategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
-		h.logger.Warn("invalid category creation request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid category creation request", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
 		return
 	}
 
-	category, err := h.service.Create(body.Name, body.SortOrder)
+	category, err := h.service.Create(r.Context(), body)
 	if err != nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		writeCategoryError(w, err)
 		return
 	}
 	utils.JSONResponse(w, http.StatusCreated, "Category created", category)
 
-	h.logger.Info("category created", zap.String("name", category.Name), zap.Int("sortOrder", category.SortOrder))
+	tracing.Logger(r.Context(), h.logger).Info("category created", zap.String("name", category.Name), zap.Int("sortOrder", category.SortOrder))
 }
 
 // Update
 // @Summary Обновить категорию
-// @Description Изменяет название категории (только для администратора)
+// @Description Изменяет название, порядок, slug, описание или изображение категории (только для администратора). Пустая строка очищает описание и изображение.
 // @Tags Категории
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "Идентификатор категории"
-// @Param input body utils.CategoryRequest true "Новое имя категории"
-// @Success 200 {object} utils.SuccessResponse
+// @Param input body models.CategoryUpdateRequest true "Изменяемые поля"
+// @Success 200 {object} utils.SuccessResponse{data=models.Category}
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /api/admin/categories/{id} [put]
 func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(mux.Vars(r)["id"])
 
-	var body struct {
-		Name      *string `json:"name"`
-		SortOrder *int    `json:"sortOrder"`
-	}
-
+	var body models.CategoryUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		h.logger.Warn("invalid update request", zap.Int("id", id), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid update request", zap.Int("id", id), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
 		return
 	}
 
-	if body.Name == nil && body.SortOrder == nil {
+	if body.Name == nil && body.SortOrder == nil && body.Slug == nil && body.Description == nil && body.ImageURL == nil {
 		utils.ErrorJSON(w, http.StatusBadRequest, "Nothing to update")
 		return
 	}
 
-	updatedCategory, err := h.service.PartialUpdate(id, body.Name, body.SortOrder)
+	updatedCategory, err := h.service.PartialUpdate(r.Context(), id, body)
 	if err != nil {
-		h.logger.Warn("failed to update category", zap.Int("id", id), zap.Error(err))
-		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		tracing.Logger(r.Context(), h.logger).Warn("failed to update category", zap.Int("id", id), zap.Error(err))
+		writeCategoryError(w, err)
 		return
 	}
+	// Название категории хранится в кэше товаров
+	h.invalidateProducts(r)
 
-	h.logger.Info("category updated", zap.Int("id", id))
+	tracing.Logger(r.Context(), h.logger).Info("category updated", zap.Int("id", id))
 	utils.JSONResponse(w, http.StatusOK, "Category updated", updatedCategory)
 }
 
 // Delete
 // @Summary Удалить категорию
-// @Description Удаляет категорию по ID (только для администратора)
+// @Description Удаляет категорию по ID (только для администратора). Подкатегории переходят к родителю удаляемой категории. Если в категории есть товары, нужно указать reassign_to — категорию, куда их перенести, иначе удаление отклоняется. Категория попадает в корзину, откуда её можно восстановить.
 // @Tags Категории
 // @Security BearerAuth
-// @Produce plain
+// @Produce json
 // @Param id path int true "ID категории"
-// @Success 200 {string} string "Category deleted"
-// @Failure 400 {string} string "Удаление не удалось"
+// @Param reassign_to query int false "ID категории для переноса товаров"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
 // @Router /api/admin/categories/{id} [delete]
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(mux.Vars(r)["id"])
-	if err := h.service.Delete(id); err != nil {
-		h.logger.Error("failed to delete category", zap.Int("id", id), zap.Error(err))
-		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+
+	var reassignTo *int
+	if value := r.URL.Query().Get("reassign_to"); value != "" {
+		target, err := strconv.Atoi(value)
+		if err != nil {
+			utils.ErrorJSON(w, http.StatusBadRequest, "Invalid reassign_to")
+			return
+		}
+		reassignTo = &target
+	}
+
+	if err := h.service.Delete(r.Context(), id, reassignTo); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to delete category", zap.Int("id", id), zap.Error(err))
+		writeCategoryError(w, err)
 		return
 	}
-	h.logger.Info("category deleted", zap.Int("id", id))
+	h.invalidateProducts(r)
+	tracing.Logger(r.Context(), h.logger).Info("category deleted", zap.Int("id", id))
 	utils.JSONResponse(w, http.StatusOK, "Category deleted", nil)
 }
 
@@ -147,20 +204,30 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param input body []utils.CategoryRequest true "Список категорий"
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ"
 // @Success 201 {string} string "Categories created"
 // @Failure 400 {string} string "Invalid body"
+// @Failure 409 {object} utils.ErrorResponse "Запрос с этим Idempotency-Key ещё выполняется"
+// @Failure 413 {object} utils.ErrorResponse "Тело запроса больше middleware.MaxJSONBody"
+// @Failure 422 {object} utils.ErrorResponse "Idempotency-Key уже использован с другим телом"
 // @Router /api/admin/categories/bulk [post]
 func (h *CategoryHandler) CreateBulk(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, middleware.MaxJSONBody)
 	var categories []utils.CategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&categories); err != nil || len(categories) == 0 {
-		h.logger.Warn("invalid bulk create request", zap.Error(err))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Warn("invalid bulk create request", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body or empty array")
 		return
 	}
 
-	created, err := h.service.CreateBulk(categories)
+	created, err := h.service.CreateBulk(r.Context(), categories)
 	if err != nil {
-		h.logger.Error("bulk category creation failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("bulk category creation failed", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to create categories")
 		return
 	}
@@ -169,6 +236,63 @@ func (h *CategoryHandler) CreateBulk(w http.ResponseWriter, r *http.Request) {
 	for _, c := range created {
 		names = append(names, c.Name)
 	}
-	h.logger.Info("bulk categories created", zap.Int("count", len(created)), zap.Strings("names", names))
+	tracing.Logger(r.Context(), h.logger).Info("bulk categories created", zap.Int("count", len(created)), zap.Strings("names", names))
 	utils.JSONResponse(w, http.StatusCreated, "Categories created", created)
 }
+
+// Move
+// @Summary Перенести категорию
+// @Description Делает категорию подкатегорией parent_id (null — корневой). Перенос в саму себя или в свою подкатегорию запрещён.
+// @Tags Категории
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID категории"
+// @Param input body models.CategoryMoveRequest true "Новый родитель и порядок"
+// @Success 200 {object} utils.SuccessResponse{data=models.Category}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/admin/categories/{id}/move [patch]
+func (h *CategoryHandler) Move(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var body models.CategoryMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
+		return
+	}
+
+	category, err := h.service.Move(r.Context(), id, body)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to move category", zap.Int("id", id), zap.Error(err))
+		writeCategoryError(w, err)
+		return
+	}
+	// Фильтр по категории включает подкатегории — списки товаров в кэше устарели
+	h.invalidateProducts(r)
+
+	utils.JSONResponse(w, http.StatusOK, "Category moved", category)
+}
+
+func (h *CategoryHandler) invalidateProducts(r *http.Request) {
+	h.cache.ClearPrefix(r.Context(), "products:")
+	h.cache.ClearPrefix(r.Context(), "product:")
+}
+
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Category not found")
+	case errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrCategoryHasProducts),
+		errors.Is(err, services.ErrCategorySlugTaken):
+		utils.ErrorJSON(w, http.StatusConflict, err.Error())
+	default:
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"go.uber.org/zap"
 	"net/http"
@@ -32,7 +33,7 @@ func NewDashboardHandler(service services.DashboardServiceInterface, logger *zap
 func (h *DashboardHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	data, err := h.service.GetDashboardData(r.Context())
 	if err != nil {
-		h.logger.Error("failed to load dashboard", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to load dashboard", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to load dashboard")
 		return
 	}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/commerceml"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Cookie, по которой 1С передаёт сессию обмена после checkauth
+const exchangeCookieName = "exchange_session"
+
+type ExchangeHandlerInterface interface {
+	Handle(w http.ResponseWriter, r *http.Request)
+}
+
+type ExchangeHandler struct {
+	service services.ExchangeServiceInterface
+	logger  *zap.Logger
+	cache   *cache.RedisCache
+}
+
+func NewExchangeHandler(service services.ExchangeServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *ExchangeHandler {
+	return &ExchangeHandler{service: service, logger: logger, cache: cache}
+}
+
+// Handle
+// @Summary Обмен с 1С (CommerceML)
+// @Description Точка обмена по стандартному протоколу 1С-Битрикс. Ответы — text/plain: "success" или "failure\nпричина".
+// @Description type=catalog: checkauth (Basic-авторизация, логин и пароль из EXCHANGE_1C_LOGIN / EXCHANGE_1C_PASSWORD) → init → file (тело запроса — содержимое файла, может приходить частями) → import (import.xml — группы и товары, offers.xml — цены и остатки).
+// @Description type=sale: checkauth → init → query (XML с новыми заказами) → success (подтверждение, после него заказы больше не выгружаются).
+// @Tags 1С
+// @Accept octet-stream
+// @Produce plain
+// @Param type query string true "catalog или sale"
+// @Param mode query string true "checkauth, init, file, import, query или success"
+// @Param filename query string false "Имя файла для file и import"
+// @Success 200 {string} string "success"
+// @Failure 401 {string} string "failure"
+// @Failure 429 {object} utils.ErrorResponse "Слишком много попыток checkauth"
+// @Router /api/1c/exchange [get]
+// @Router /api/1c/exchange [post]
+func (h *ExchangeHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if !h.service.Enabled() {
+		h.failure(w, http.StatusNotFound, "exchange is disabled")
+		return
+	}
+
+	query := r.URL.Query()
+	kind := query.Get("type")
+	mode := query.Get("mode")
+	if kind != "catalog" && kind != "sale" {
+		h.failure(w, http.StatusBadRequest, "unknown type")
+		return
+	}
+
+	if mode == "checkauth" {
+		h.checkAuth(w, r)
+		return
+	}
+
+	var token string
+	if cookie, err := r.Cookie(exchangeCookieName); err == nil {
+		token = cookie.Value
+	}
+	valid, err := h.service.ValidSession(r.Context(), token)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("1C exchange session check failed", zap.Error(err))
+		h.failure(w, http.StatusInternalServerError, "session check failed")
+		return
+	}
+	if !valid {
+		h.failure(w, http.StatusUnauthorized, "not authorized")
+		return
+	}
+
+	switch {
+	case mode == "init":
+		if err := h.service.Init(r.Context(), kind); err != nil {
+			tracing.Logger(r.Context(), h.logger).Error("1C exchange init failed", zap.Error(err))
+			h.failure(w, http.StatusInternalServerError, "init failed")
+			return
+		}
+		h.text(w, http.StatusOK, fmt.Sprintf("zip=no\nfile_limit=%d", h.service.FileLimit()))
+
+	case mode == "file":
+		r.Body = http.MaxBytesReader(w, r.Body, h.service.FileLimit()+1)
+		filename := query.Get("filename")
+		if err := h.service.SaveFile(r.Context(), filename, r.Body); err != nil {
+			h.writeError(w, r, "1C exchange file upload failed", err)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Info("1C exchange file received", zap.String("type", kind), zap.String("file", filename))
+		h.text(w, http.StatusOK, "success")
+
+	case kind == "catalog" && mode == "import":
+		result, err := h.service.ImportFile(r.Context(), query.Get("filename"))
+		if err != nil {
+			h.writeError(w, r, "1C catalog import failed", err)
+			return
+		}
+		if result.Created+result.Updated+result.Offers > 0 {
+			h.cache.ClearPrefix(r.Context(), "products:")
+			h.cache.ClearPrefix(r.Context(), "product:")
+		}
+		h.text(w, http.StatusOK, "success")
+
+	case kind == "sale" && mode == "query":
+		data, err := h.service.QueryOrders(r.Context(), token)
+		if err != nil {
+			tracing.Logger(r.Context(), h.logger).Error("1C orders export failed", zap.Error(err))
+			h.failure(w, http.StatusInternalServerError, "orders export failed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+
+	case kind == "sale" && mode == "success":
+		if err := h.service.ConfirmOrders(r.Context(), token); err != nil {
+			h.writeError(w, r, "1C orders confirmation failed", err)
+			return
+		}
+		h.text(w, http.StatusOK, "success")
+
+	default:
+		h.failure(w, http.StatusBadRequest, "unknown mode")
+	}
+}
+
+func (h *ExchangeHandler) checkAuth(w http.ResponseWriter, r *http.Request) {
+	login, password, _ := r.BasicAuth()
+	token, err := h.service.CheckAuth(r.Context(), login, password)
+	if err != nil {
+		if errors.Is(err, services.ErrExchangeUnauthorized) {
+			tracing.Logger(r.Context(), h.logger).Warn("1C exchange auth failed", zap.String("login", login), zap.String("ip", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", `Basic realm="1c-exchange"`)
+			h.failure(w, http.StatusUnauthorized, "invalid login or password")
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("1C exchange auth error", zap.Error(err))
+		h.failure(w, http.StatusInternalServerError, "auth failed")
+		return
+	}
+	h.text(w, http.StatusOK, fmt.Sprintf("success\n%s\n%s", exchangeCookieName, token))
+}
+
+func (h *ExchangeHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr), errors.Is(err, services.ErrExchangeFileTooLarge):
+		h.failure(w, http.StatusRequestEntityTooLarge, "file is too large")
+	case errors.Is(err, services.ErrExchangeFileName),
+		errors.Is(err, services.ErrExchangeNoPending),
+		errors.Is(err, commerceml.ErrUnknownDocument):
+		h.failure(w, http.StatusBadRequest, err.Error())
+	default:
+		// Подробности — только в лог: в тексте ошибки могут быть запросы к базе и внутренние пути
+		tracing.Logger(r.Context(), h.logger).Error(msg, zap.Error(err))
+		h.failure(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func (h *ExchangeHandler) failure(w http.ResponseWriter, status int, msg string) {
+	h.text(w, status, "failure\n"+msg)
+}
+
+func (h *ExchangeHandler) text(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
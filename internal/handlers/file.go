@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/storage"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type FileHandlerInterface interface {
+	Serve(w http.ResponseWriter, r *http.Request)
+	CleanupOrphans(w http.ResponseWriter, r *http.Request)
+}
+
+type FileHandler struct {
+	service services.FileServiceInterface
+	logger  *zap.Logger
+}
+
+func NewFileHandler(service services.FileServiceInterface, logger *zap.Logger) *FileHandler {
+	return &FileHandler{service: service, logger: logger}
+}
+
+// Serve отдаёт загруженный файл из хранилища (локального или S3)
+func (h *FileHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	file, obj, err := h.service.Open(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to open file", zap.String("key", key), zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	// Имена файлов вычисляются из содержимого, поэтому файл по ссылке никогда не меняется
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, obj.Key, obj.ModTime, rs)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	io.Copy(w, file)
+}
+
+// CleanupOrphans
+// @Summary Очистить неиспользуемые файлы (админ)
+// @Description Удаляет загруженные файлы, на которые не ссылается ни один товар и которые старше периода ожидания. С dry_run=true только возвращает список.
+// @Tags Загрузка
+// @Security BearerAuth
+// @Produce json
+// @Param dry_run query bool false "Только показать, что будет удалено"
+// @Success 200 {object} utils.SuccessResponse{data=[]string}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/upload/cleanup [post]
+func (h *FileHandler) CleanupOrphans(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	removed, err := h.service.CleanupOrphans(r.Context(), dryRun)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("orphan cleanup failed", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось очистить файлы")
+		return
+	}
+
+	message := "Неиспользуемые файлы удалены"
+	if dryRun {
+		message = "Неиспользуемые файлы найдены"
+	}
+	utils.JSONResponse(w, http.StatusOK, message, removed)
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,15 +21,17 @@ import (
 
 type LogHandlerInterface interface {
 	GetLogs(w http.ResponseWriter, r *http.Request)
+	Search(w http.ResponseWriter, r *http.Request)
 }
 
 type LogHandler struct {
+	service services.LogServiceInterface
 	logger  *zap.Logger
 	logPath string
 }
 
-func NewLogHandler(logger *zap.Logger, logPath string) *LogHandler {
-	return &LogHandler{logger: logger, logPath: logPath}
+func NewLogHandler(service services.LogServiceInterface, logger *zap.Logger, logPath string) *LogHandler {
+	return &LogHandler{service: service, logger: logger, logPath: logPath}
 }
 
 // GetLogs
@@ -66,7 +76,7 @@ func (h *LogHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		h.logger.Error("failed to open log file", zap.String("path", filePath), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to open log file", zap.String("path", filePath), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Could not open log file")
 		return
 	}
@@ -79,3 +89,126 @@ func (h *LogHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	io.Copy(w, file)
 }
+
+// Search
+// @Summary      Поиск по журналу приложения
+// @Description  Ищет записи во всех хранимых файлах журнала, новые первыми. С follow=true ответ — поток NDJSON:
+// @Description  сначала последние limit подходящих записей по порядку, затем новые записи по мере появления, пока клиент не закроет соединение.
+// @Tags         Логи
+// @Security     BearerAuth
+// @Produce      json
+// @Param        level      query string false "Уровни через запятую: debug, info, warn, error"
+// @Param        from       query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param        to         query string false "Конец периода, не включая (RFC3339 или YYYY-MM-DD); в режиме follow не применяется"
+// @Param        request_id query string false "ID запроса (заголовок X-Request-ID)"
+// @Param        user_id    query int    false "ID пользователя"
+// @Param        q          query string false "Текст, который ищется во всей записи без учёта регистра"
+// @Param        limit      query int    false "Количество записей (по умолчанию 100, максимум 1000)"
+// @Param        offset     query int    false "Смещение; в режиме follow не применяется"
+// @Param        follow     query bool   false "Следить за журналом"
+// @Success      200 {object} utils.SuccessResponse{data=models.LogPage}
+// @Failure      400 {object} utils.ErrorResponse
+// @Failure      500 {object} utils.ErrorResponse
+// @Router       /api/admin/logs/search [get]
+func (h *LogHandler) Search(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseLogFilter(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("follow") == "true" {
+		h.follow(w, r, filter)
+		return
+	}
+
+	page, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, r, "failed to search logs", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Logs fetched", page)
+}
+
+// follow передаёт записи журнала потоком NDJSON. Поток живёт дольше таймаута записи сервера,
+// поэтому для этого соединения таймаут снимается. При остановке сервера поток закрывается сразу.
+func (h *LogHandler) follow(w http.ResponseWriter, r *http.Request, filter models.LogFilter) {
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	started := false
+
+	ctx, cancel := utils.UntilShutdown(r.Context())
+	defer cancel()
+	err := h.service.Follow(ctx, filter, func(entries []models.LogEntry) error {
+		if !started {
+			started = true
+			if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+				return err
+			}
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Accel-Buffering", "no")
+			w.WriteHeader(http.StatusOK)
+		}
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	})
+
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if !started {
+		h.writeError(w, r, "failed to follow logs", err)
+		return
+	}
+	tracing.Logger(r.Context(), h.logger).Warn("log stream interrupted", zap.Error(err))
+}
+
+func (h *LogHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLogLevel):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	default:
+		tracing.Logger(r.Context(), h.logger).Error(msg, zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+func parseLogFilter(w http.ResponseWriter, r *http.Request) (models.LogFilter, bool) {
+	query := r.URL.Query()
+	filter := models.LogFilter{
+		RequestID: query.Get("request_id"),
+		Query:     query.Get("q"),
+	}
+
+	if levels := query.Get("level"); levels != "" {
+		for _, level := range strings.Split(levels, ",") {
+			filter.Levels = append(filter.Levels, strings.ToLower(strings.TrimSpace(level)))
+		}
+	}
+	if value := query.Get("user_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			utils.ErrorJSON(w, http.StatusBadRequest, "Invalid user_id")
+			return filter, false
+		}
+		filter.UserID = id
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid from")
+		return filter, false
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid to")
+		return filter, false
+	}
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+	return filter, true
+}
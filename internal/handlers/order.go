@@ -1,19 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"chechnya-product/internal/middleware"
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
-	"encoding/csv"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
 )
 
 type OrderHandlerInterface interface {
@@ -26,9 +25,6 @@ type OrderHandlerInterface interface {
 	GetOrderHistory(w http.ResponseWriter, r *http.Request)
 	DeleteOrder(w http.ResponseWriter, r *http.Request)
 	GetOrderByID(w http.ResponseWriter, r *http.Request)
-	LeaveReview(w http.ResponseWriter, r *http.Request)
-	GetReview(w http.ResponseWriter, r *http.Request)
-	GetAllReview(w http.ResponseWriter, r *http.Request)
 }
 
 type OrderHandler struct {
@@ -40,40 +36,71 @@ func NewOrderHandler(service services.OrderServiceInterface, logger *zap.Logger)
 	return &OrderHandler{service: service, logger: logger}
 }
 
-type OrderReviewRequest struct {
-	Comment *string `json:"comment"`
-	Rating  *int    `json:"rating"`
-}
-
 // PlaceOrder
 // @Summary Оформить заказ
 // @Description Оформляет заказ из текущей корзины по owner_id. Можно указать координаты (latitude и longitude), чтобы рассчитать доставку.
+// @Description Цены и названия товаров берутся из каталога, price и name позиций в запросе не учитываются; quantity должно быть больше нуля.
+// @Description payment_type: cash (по умолчанию), card или online. Для online в ответе есть payment_url — на него нужно перенаправить покупателя; заказ принимается в работу после оплаты.
 // @Tags Заказ
 // @Accept json
 // @Produce json
 // @Param order body models.PlaceOrderRequest true "Данные заказа с координатами"
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ"
 // @Success 200 {object} utils.SuccessResponse{data=models.Order}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "Запрос с этим Idempotency-Key ещё выполняется"
+// @Failure 413 {object} utils.ErrorResponse "Тело запроса больше middleware.MaxJSONBody"
+// @Failure 422 {object} utils.ErrorResponse "Idempotency-Key уже использован с другим телом"
 // @Router /api/order [post]
 func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 
+	r.Body = http.MaxBytesReader(w, r.Body, middleware.MaxJSONBody)
 	var req models.PlaceOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("failed to decode order request", zap.Error(err))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Warn("failed to decode order request", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid order data")
 		return
 	}
 
-	order, err := h.service.PlaceOrder(ownerID, req) // теперь получаем заказ
+	order, err := h.service.PlaceOrder(r.Context(), ownerID, req) // теперь получаем заказ
+	if errors.Is(err, services.ErrInvalidPaymentType) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Unknown payment type: use cash, card or online")
+		return
+	}
+	if errors.Is(err, services.ErrPaymentsDisabled) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Online payment is not available")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidOrderItems) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Order must contain products with positive quantities")
+		return
+	}
+	if errors.Is(err, services.ErrProductNotFound) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Product not found")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidDeliveryFee) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid delivery fee")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidOrderTotal) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Order total must be positive for online payment")
+		return
+	}
 	if err != nil {
-		h.logger.Warn("failed to place order", zap.String("owner_id", ownerID), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("failed to place order", zap.String("owner_id", ownerID), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Failed to place order")
 		return
 	}
-	h.logger.Info("🧪 CreatedAt:", zap.Time("created_at", order.CreatedAt), zap.Int64("millis", order.CreatedAt.UnixMilli()))
+	tracing.Logger(r.Context(), h.logger).Info("🧪 CreatedAt:", zap.Time("created_at", order.CreatedAt), zap.Int64("millis", order.CreatedAt.UnixMilli()))
 
-	h.logger.Info("order placed", zap.String("owner_id", ownerID), zap.Int("order_id", order.ID))
+	tracing.Logger(r.Context(), h.logger).Info("order placed", zap.String("owner_id", ownerID), zap.Int("order_id", order.ID))
 	utils.JSONResponse(w, http.StatusOK, "Order placed successfully", order)
 }
 
@@ -88,14 +115,14 @@ func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 
-	orders, err := h.service.GetOrders(ownerID)
+	orders, err := h.service.GetOrders(r.Context(), ownerID)
 	if err != nil {
-		h.logger.Error("failed to get user orders", zap.String("owner_id", ownerID), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user orders", zap.String("owner_id", ownerID), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch user orders")
 		return
 	}
 
-	h.logger.Info("user orders retrieved", zap.String("owner_id", ownerID), zap.Int("orders_count", len(orders)))
+	tracing.Logger(r.Context(), h.logger).Info("user orders retrieved", zap.String("owner_id", ownerID), zap.Int("orders_count", len(orders)))
 	utils.JSONResponse(w, http.StatusOK, "User orders retrieved", orders)
 }
 
@@ -109,90 +136,61 @@ func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/admin/orders [get]
 func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.service.GetAllOrders()
+	orders, err := h.service.GetAllOrders(r.Context())
 	if err != nil {
-		h.logger.Error("failed to get all orders", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get all orders", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch all orders")
 		return
 	}
 
-	h.logger.Info("all orders retrieved", zap.Int("orders_count", len(orders)))
+	tracing.Logger(r.Context(), h.logger).Info("all orders retrieved", zap.Int("orders_count", len(orders)))
 	utils.JSONResponse(w, http.StatusOK, "All orders retrieved", orders)
 
 }
 
 // ExportOrdersCSV
 // @Summary Экспорт заказов в CSV (админ)
-// @Description Экспортирует все заказы в формате CSV (только для админа)
+// @Description Экспортирует заказы в формате CSV (только для админа). Без from и to выгружаются все заказы.
 // @Tags Заказ
 // @Security BearerAuth
 // @Produce text/csv
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включая (RFC3339 или YYYY-MM-DD)"
 // @Success 200 {file} file "CSV файл"
+// @Failure 400 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/admin/orders/export [get]
 func (h *OrderHandler) ExportOrdersCSV(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.service.GetAllOrders()
+	from, err := parseTimeParam(r.URL.Query().Get("from"))
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid from")
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"))
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid to")
+		return
+	}
+
+	// Выгрузка идёт в буфер, чтобы при ошибке можно было вернуть JSON, а не обрезанный файл
+	var buf bytes.Buffer
+	count, err := h.service.ExportCSV(r.Context(), &buf, from, to)
 	if err != nil {
-		h.logger.Error("failed to export orders to CSV", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to export orders to CSV", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch orders")
 		return
 	}
 
-	h.logger.Info("exporting orders to CSV", zap.Int("orders_count", len(orders)))
+	tracing.Logger(r.Context(), h.logger).Info("exporting orders to CSV", zap.Int("orders_count", count))
 
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", "attachment;filename=orders.csv")
-
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
-
-	// Заголовки
-	writer.Write([]string{
-		"Order ID", "Owner ID", "Name", "Address", "Delivery Type",
-		"Payment Type", "Total", "Created At", "Items",
-	})
-
-	// Строки
-	for _, order := range orders {
-		// Список товаров в строку
-		var itemDescriptions []string
-		for _, item := range order.Items {
-			name := "Unnamed"
-			if item.Name != nil {
-				name = *item.Name
-			}
-			itemDescriptions = append(itemDescriptions,
-				fmt.Sprintf("%s x%d (%.2f)", name, item.Quantity, item.Price))
-		}
-		itemsStr := strings.Join(itemDescriptions, "; ")
-
-		// Адрес и имя (если nil — пустая строка)
-		name := ""
-		if order.Name != nil {
-			name = *order.Name
-		}
-		address := ""
-		if order.Address != nil {
-			address = *order.Address
-		}
-
-		writer.Write([]string{
-			strconv.Itoa(order.ID),
-			order.OwnerID,
-			name,
-			address,
-			order.DeliveryType,
-			order.PaymentType,
-			utils.FormatFloat(order.Total),
-			order.CreatedAt.Format(time.RFC3339),
-			// strconv.FormatInt(order.CreatedAt.UnixMilli(), 10)
-			itemsStr,
-		})
-	}
+	w.Write(buf.Bytes())
 }
 
 // UpdateStatus обновляет статус заказа
 // @Summary Обновить статус заказа
+// @Description Неоплаченный онлайн-заказ можно только отклонить. При отклонении ожидающий платёж отменяется, оплаченный — возвращается.
 // @Tags Заказ
 // @Security BearerAuth
 // @Accept json
@@ -201,6 +199,9 @@ func (h *OrderHandler) ExportOrdersCSV(w http.ResponseWriter, r *http.Request) {
 // @Param status body models.OrderStatusRequest true "Новый статус"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} utils.ErrorResponse "Онлайн-заказ ещё не оплачен"
+// @Failure 502 {object} utils.ErrorResponse "Статус изменён, но вернуть оплату не удалось"
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/admin/orders/{id}/status [patch]
 func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
@@ -222,7 +223,19 @@ func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.UpdateStatus(orderID, req.Status); err != nil {
+	err = h.service.UpdateStatus(r.Context(), orderID, req.Status)
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Order not found")
+		return
+	case errors.Is(err, services.ErrOrderNotPaid):
+		utils.ErrorJSON(w, http.StatusConflict, "Заказ ещё не оплачен")
+		return
+	case errors.Is(err, services.ErrRefundFailed):
+		tracing.Logger(r.Context(), h.logger).Error("order rejected but refund failed", zap.Int("order_id", orderID), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusBadGateway, "Заказ отклонён, но вернуть оплату не удалось; повторите отклонение позже")
+		return
+	case err != nil:
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to update status: "+err.Error())
 		return
 	}
@@ -242,7 +255,7 @@ func (h *OrderHandler) RepeatOrder(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 	orderID, _ := strconv.Atoi(mux.Vars(r)["id"])
 
-	if err := h.service.RepeatOrder(orderID, ownerID); err != nil {
+	if err := h.service.RepeatOrder(r.Context(), orderID, ownerID); err != nil {
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -260,7 +273,7 @@ func (h *OrderHandler) RepeatOrder(w http.ResponseWriter, r *http.Request) {
 func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 
-	orders, err := h.service.GetOrderHistory(ownerID)
+	orders, err := h.service.GetOrderHistory(r.Context(), ownerID)
 	if err != nil {
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch history")
 		return
@@ -271,6 +284,7 @@ func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 
 // DeleteOrder
 // @Summary Удаление заказа по ID (только для админов)
+// @Description Заказ перемещается в корзину и пропадает из списков и статистики; его можно восстановить.
 // @Security BearerAuth
 // @Tags Заказ
 // @Param id path int true "ID заказа"
@@ -285,25 +299,26 @@ func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteOrder(orderID); err != nil {
-		h.logger.Error("ошибка удаления заказа", zap.Int("order_id", orderID), zap.Error(err))
+	if err := h.service.DeleteOrder(r.Context(), orderID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("ошибка удаления заказа", zap.Int("order_id", orderID), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Ошибка удаления: "+err.Error())
 		return
 	}
 
-	h.logger.Info("заказ удалён", zap.Int("order_id", orderID))
+	tracing.Logger(r.Context(), h.logger).Info("заказ удалён", zap.Int("order_id", orderID))
 	utils.JSONResponse(w, http.StatusOK, "Заказ удалён", nil)
 }
 
 // GetOrderByID
 // @Summary Получить заказ по ID
-// @Description Возвращает заказ с товарами по ID
+// @Description Возвращает заказ с товарами по ID. Доступен только владельцу заказа (по owner_id), для чужого заказа — 404.
 // @Tags Заказ
 // @Param id path int true "ID заказа"
 // @Produce json
 // @Success 200 {object} utils.SuccessResponse{data=models.Order}
 // @Failure 400 {object} utils.ErrorResponse "Некорректный ID"
 // @Failure 404 {object} utils.ErrorResponse "Заказ не найден"
+// @Failure 500 {object} utils.ErrorResponse "Ошибка сервера"
 // @Router /api/orders/{id} [get]
 func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
@@ -313,88 +328,17 @@ func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.service.GetOrderByID(orderID)
-	if err != nil {
+	ownerID := middleware.GetOwnerID(w, r)
+	order, err := h.service.GetOrderByID(r.Context(), orderID, ownerID)
+	if errors.Is(err, services.ErrOrderNotFound) {
 		utils.ErrorJSON(w, http.StatusNotFound, "Order not found")
 		return
 	}
-
-	utils.JSONResponse(w, http.StatusOK, "Order fetched", order)
-}
-
-// LeaveReview оставляет отзыв к заказу
-// @Summary Оставить отзыв к заказу
-// @Tags Отзывы заказов
-// @Security BearerAuth
-// @Param id path int true "ID заказа"
-// @Accept json
-// @Produce json
-// @Param review body OrderReviewRequest true "Комментарий и оценка (1–5)"
-// @Success 200 {object} utils.SuccessResponse
-// @Failure 400 {object} utils.ErrorResponse
-// @Router /api/orders/{id}/review [patch]
-func (h *OrderHandler) LeaveReview(w http.ResponseWriter, r *http.Request) {
-	orderID, _ := strconv.Atoi(mux.Vars(r)["id"])
-	var req OrderReviewRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный JSON")
-		return
-	}
-
-	claims := middleware.GetUserClaims(r)
-	if claims == nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, "Пользователь не найден")
-		return
-	}
-
-	userID, err := strconv.Atoi(strconv.Itoa(claims.UserID)) // claims.UserID должен быть строкой
 	if err != nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный userID")
-		return
-	}
-
-	if err := h.service.AddReview(orderID, req.Comment, req.Rating, userID); err != nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		tracing.Logger(r.Context(), h.logger).Error("ошибка получения заказа", zap.Int("order_id", orderID), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch order")
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, "Отзыв сохранён", nil)
-}
-
-// GetReview
-// @Summary Получить отзыв к заказу
-// @Tags Отзывы заказов
-// @Produce json
-// @Param id path int true "ID заказа"
-// @Success 200 {object} utils.SuccessResponse{data=models.OrderReview}
-// @Failure 404 {object} utils.ErrorResponse
-// @Router /api/orders/{id}/review [get]
-func (h *OrderHandler) GetReview(w http.ResponseWriter, r *http.Request) {
-	orderID, _ := strconv.Atoi(mux.Vars(r)["id"])
-
-	review, err := h.service.GetByOrderReviewID(orderID)
-	if err != nil {
-		utils.ErrorJSON(w, http.StatusNotFound, "Отзыв не найден")
-		return
-	}
-
-	utils.JSONResponse(w, http.StatusOK, "Отзыв получен", review)
-}
-
-// GetAllReview
-// @Summary Получить все отзывы на заказы
-// @Tags Отзывы заказов
-// @Security BearerAuth
-// @Produce json
-// @Success 200 {array} models.OrderReview
-// @Failure 500 {object} utils.ErrorResponse
-// @Router /api/order-reviews [get]
-func (h *OrderHandler) GetAllReview(w http.ResponseWriter, r *http.Request) {
-	reviews, err := h.service.GetAllReview()
-	if err != nil {
-		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось получить отзывы")
-		return
-	}
-	utils.JSONResponse(w, http.StatusOK, "Отзывы получены", reviews)
+	utils.JSONResponse(w, http.StatusOK, "Order fetched", order)
 }
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/payments"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Уведомления провайдеров — небольшие формы или JSON
+const maxWebhookBody = 64 << 10
+
+type PaymentHandlerInterface interface {
+	Webhook(w http.ResponseWriter, r *http.Request)
+	RetryPayment(w http.ResponseWriter, r *http.Request)
+}
+
+type PaymentHandler struct {
+	service services.PaymentServiceInterface
+	logger  *zap.Logger
+}
+
+func NewPaymentHandler(service services.PaymentServiceInterface, logger *zap.Logger) *PaymentHandler {
+	return &PaymentHandler{service: service, logger: logger}
+}
+
+// Webhook
+// @Summary Уведомление платёжного провайдера
+// @Description Провайдер сообщает об оплате, отказе или возврате. Запрос подписан: для CloudPayments — заголовок Content-HMAC, для fake — X-Signature (hex HMAC-SHA256 тела).
+// @Description Ответ {"code":0} означает, что уведомление принято; на другой ответ провайдер повторяет отправку.
+// @Tags Оплата
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} map[string]int "{"code":0}"
+// @Failure 400 {object} utils.ErrorResponse "Неразборчивое уведомление"
+// @Failure 401 {object} utils.ErrorResponse "Неверная подпись"
+// @Failure 404 {object} utils.ErrorResponse "Онлайн-оплата не настроена"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/payments/webhook [post]
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if !h.service.Enabled() {
+		utils.ErrorJSON(w, http.StatusNotFound, "Online payments are disabled")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid webhook body")
+		return
+	}
+
+	err = h.service.HandleWebhook(r.Context(), r.Header, body)
+	switch {
+	case err == nil:
+		// Провайдер ждёт именно такой ответ, а не общий формат API
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":0}`))
+	case errors.Is(err, payments.ErrInvalidSignature):
+		tracing.Logger(r.Context(), h.logger).Warn("payment webhook with invalid signature", zap.String("ip", middleware.GetClientIP(r)))
+		utils.ErrorJSON(w, http.StatusUnauthorized, "Invalid signature")
+	case errors.Is(err, services.ErrInvalidPaymentEvent):
+		tracing.Logger(r.Context(), h.logger).Warn("malformed payment webhook", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid webhook payload")
+	default:
+		tracing.Logger(r.Context(), h.logger).Error("failed to handle payment webhook", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to handle webhook")
+	}
+}
+
+// RetryPayment
+// @Summary Оплатить заказ онлайн
+// @Description Возвращает заказ со ссылкой на оплату (payment_url): действующей, если платёж ещё ожидает оплаты, или новой после неудачной попытки.
+// @Tags Оплата
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} utils.SuccessResponse{data=models.Order}
+// @Failure 400 {object} utils.ErrorResponse "Некорректный ID"
+// @Failure 404 {object} utils.ErrorResponse "Заказ не найден или онлайн-оплата не настроена"
+// @Failure 409 {object} utils.ErrorResponse "Заказ уже оплачен или не оплачивается онлайн"
+// @Failure 502 {object} utils.ErrorResponse "Платёжный провайдер недоступен"
+// @Router /api/orders/{id}/payment [post]
+func (h *PaymentHandler) RetryPayment(w http.ResponseWriter, r *http.Request) {
+	ownerID := middleware.GetOwnerID(w, r)
+	orderID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	order, err := h.service.RetryPayment(r.Context(), orderID, ownerID)
+	switch {
+	case err == nil:
+		utils.JSONResponse(w, http.StatusOK, "Payment link created", order)
+	case errors.Is(err, services.ErrPaymentsDisabled):
+		utils.ErrorJSON(w, http.StatusNotFound, "Online payments are disabled")
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, services.ErrOrderAlreadyPaid):
+		utils.ErrorJSON(w, http.StatusConflict, "Order is already paid")
+	case errors.Is(err, services.ErrPaymentNotRequired):
+		utils.ErrorJSON(w, http.StatusConflict, "Order is not paid online")
+	default:
+		tracing.Logger(r.Context(), h.logger).Error("failed to create payment", zap.Int("order_id", orderID), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusBadGateway, "Failed to create payment")
+	}
+}
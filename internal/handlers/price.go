@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type PriceHandlerInterface interface {
+	GetHistory(w http.ResponseWriter, r *http.Request)
+	GetScheduled(w http.ResponseWriter, r *http.Request)
+	Schedule(w http.ResponseWriter, r *http.Request)
+	CancelScheduled(w http.ResponseWriter, r *http.Request)
+}
+
+type PriceHandler struct {
+	service services.PriceServiceInterface
+	logger  *zap.Logger
+}
+
+func NewPriceHandler(service services.PriceServiceInterface, logger *zap.Logger) *PriceHandler {
+	return &PriceHandler{service: service, logger: logger}
+}
+
+// GetHistory
+// @Summary История цен товара (админ)
+// @Description Все изменения цены товара — новые первыми: кто, когда и откуда (admin, import, 1c, schedule) поменял цену
+// @Tags Цены
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.PriceHistoryEntry}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/price-history [get]
+func (h *PriceHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	history, err := h.service.GetHistory(r.Context(), productID, limit)
+	if err != nil {
+		h.writeError(w, r, "failed to fetch price history", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Price history fetched", history)
+}
+
+// GetScheduled
+// @Summary Запланированные цены товара (админ)
+// @Tags Цены
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param all query bool false "Показать и уже применённые"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.ScheduledPrice}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/scheduled-prices [get]
+func (h *PriceHandler) GetScheduled(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	prices, err := h.service.GetScheduled(r.Context(), productID, r.URL.Query().Get("all") == "true")
+	if err != nil {
+		h.writeError(w, r, "failed to fetch scheduled prices", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Scheduled prices fetched", prices)
+}
+
+// Schedule
+// @Summary Запланировать смену цены (админ)
+// @Description Цена будет установлена фоновой задачей в момент starts_at. old_price — зачёркнутая цена на время акции; без неё зачёркнутая цена снимается. Для акции планируются две записи: цена со скидкой на начало и обычная цена на окончание.
+// @Tags Цены
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param input body models.ScheduledPriceRequest true "Новая цена и время начала"
+// @Success 201 {object} utils.SuccessResponse{data=models.ScheduledPrice}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/scheduled-prices [post]
+func (h *PriceHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req models.ScheduledPriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	price, err := h.service.Schedule(r.Context(), productID, req, middleware.GetUserID(r))
+	if err != nil {
+		h.writeError(w, r, "failed to schedule price", err)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("price change scheduled",
+		zap.Int("product_id", productID),
+		zap.Float64("price", price.Price),
+		zap.Time("starts_at", price.StartsAt),
+	)
+	utils.JSONResponse(w, http.StatusCreated, "Price change scheduled", price)
+}
+
+// CancelScheduled
+// @Summary Отменить запланированную цену (админ)
+// @Tags Цены
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param schedule_id path int true "ID запланированной цены"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/scheduled-prices/{schedule_id} [delete]
+func (h *PriceHandler) CancelScheduled(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := utils.ParseIntParam(vars["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	scheduleID, err := utils.ParseIntParam(vars["schedule_id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid schedule ID")
+		return
+	}
+
+	if err := h.service.CancelScheduled(r.Context(), productID, scheduleID); err != nil {
+		h.writeError(w, r, "failed to cancel scheduled price", err)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("scheduled price cancelled", zap.Int("product_id", productID), zap.Int("schedule_id", scheduleID))
+	utils.JSONResponse(w, http.StatusOK, "Scheduled price cancelled", nil)
+}
+
+func (h *PriceHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrProductNotFound),
+		errors.Is(err, services.ErrScheduledPriceNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidScheduledPrice),
+		errors.Is(err, services.ErrInvalidOldPrice),
+		errors.Is(err, services.ErrScheduledPriceInPast):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	default:
+		tracing.Logger(r.Context(), h.logger).Error(msg, zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -4,18 +4,19 @@ import (
 	"chechnya-product/internal/cache"
 	"chechnya-product/internal/middleware"
 	"chechnya-product/internal/models"
+	"chechnya-product/internal/storage"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"time"
+	"strings"
 
 	"chechnya-product/internal/services"
 )
@@ -35,85 +36,72 @@ type ProductHandlerInterface interface {
 
 type ProductHandler struct {
 	service services.ProductServiceInterface
+	images  services.ProductImageServiceInterface
+	files   services.FileServiceInterface
 	logger  *zap.Logger
 	cache   *cache.RedisCache
 }
 
-func NewProductHandler(service services.ProductServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *ProductHandler {
-	return &ProductHandler{service: service, logger: logger, cache: cache}
+func NewProductHandler(
+	service services.ProductServiceInterface,
+	images services.ProductImageServiceInterface,
+	files services.FileServiceInterface,
+	logger *zap.Logger,
+	cache *cache.RedisCache,
+) *ProductHandler {
+	return &ProductHandler{service: service, images: images, files: files, logger: logger, cache: cache}
 }
 
 // GetAll
 // @Summary Получить список товаров
-// @Description Получает список товаров с возможностью фильтрации и пагинации
+// @Description Получает список товаров с фильтрацией, фасетами и пагинацией. Для бесконечной прокрутки используйте next_cursor из ответа в параметре cursor — новые товары не сдвигают уже показанные.
 // @Tags Товар
 // @Produce json
-// @Param search query string false "Поиск по названию или описанию"
-// @Param category query string false "ID категории"
+// @Param search query string false "Поиск по названию, описанию и категории (с учётом морфологии и опечаток)"
+// @Param category query string false "ID категорий через запятую (или параметр повторяется); товары подкатегорий тоже попадают в выборку"
 // @Param min_price query number false "Минимальная цена"
 // @Param max_price query number false "Максимальная цена"
-// @Param sort query string false "Сортировка (price_asc, price_desc, name_asc, name_desc, available_first)"
+// @Param sort query string false "Сортировка (price_asc, price_desc, name_asc, name_desc, available_first, rating_desc); при поиске по умолчанию — по релевантности"
 // @Param limit query int false "Ограничение количества результатов на странице"
-// @Param offset query int false "Смещение для пагинации"
+// @Param offset query int false "Смещение для пагинации (игнорируется вместе с cursor)"
+// @Param cursor query string false "Курсор следующей страницы"
 // @Param availability query bool false "Фильтр по наличию"
-// @Success 200 {object} map[string]interface{} "Результат с пагинацией"
+// @Param min_rating query number false "Минимальная средняя оценка (1–5)"
+// @Success 200 {object} map[string]interface{} "Результат с пагинацией и фасетами"
+// @Failure 400 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/products [get]
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	search := query.Get("search")
-	category := query.Get("category")
-	sort := query.Get("sort")
-	availabilityStr := query.Get("availability")
-
-	var availability *bool
-	if availabilityStr == "true" {
-		val := true
-		availability = &val
-	} else if availabilityStr == "false" {
-		val := false
-		availability = &val
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		return
 	}
-
-	limit, _ := strconv.Atoi(query.Get("limit"))
-	offset, _ := strconv.Atoi(query.Get("offset"))
-	minPrice, _ := strconv.ParseFloat(query.Get("min_price"), 64)
-	maxPrice, _ := strconv.ParseFloat(query.Get("max_price"), 64)
-
-	cacheKey := fmt.Sprintf("products:search=%s:cat=%s:min=%.2f:max=%.2f:limit=%d:offset=%d:sort=%s:avail=%s",
-		search, category, minPrice, maxPrice, limit, offset, sort, availabilityStr)
+	filter.Normalize()
 
 	ctx := r.Context()
-	var cached []models.ProductCache
-	var total int
+	var page models.ProductPage
 
-	err := h.cache.GetOrSet(ctx, cacheKey, &cached, func() (any, error) {
-		rawProducts, err := h.service.GetFilteredRaw(search, category, minPrice, maxPrice, limit, offset, sort, availability)
-		if err != nil {
-			return nil, err
-		}
-
-		totalCount, err := h.service.CountFiltered(search, category, minPrice, maxPrice, availability)
-		if err != nil {
-			return nil, err
-		}
-
-		total = totalCount
-		return models.ConvertProductsToCache(rawProducts), nil
+	err = h.cache.GetOrSet(ctx, filter.CacheKey(), &page, func() (any, error) {
+		return h.service.GetPaginated(r.Context(), filter)
 	})
+	if errors.Is(err, models.ErrInvalidCursor) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный курсор")
+		return
+	}
 	if err != nil {
-		h.logger.Error("cache fetch failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("cache fetch failed", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка при получении товаров")
 		return
 	}
 
-	products := models.ConvertCacheToProducts(cached)
+	products := models.ConvertCacheToProducts(page.Items)
 
 	result := make([]models.ProductResponse, 0) // ← Гарантированно не nil
 	for _, p := range products {
 		var categoryName string
 		if p.CategoryID.Valid {
-			name, err := h.service.GetCategoryNameByID(int(p.CategoryID.Int64))
+			name, err := h.service.GetCategoryNameByID(r.Context(), int(p.CategoryID.Int64))
 			if err == nil {
 				categoryName = name
 			}
@@ -124,16 +112,66 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	// JSON-маршалинг теперь всегда отдаст [] вместо null
 	response := map[string]interface{}{
-		"items":  result,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
+		"items":       result,
+		"total":       page.Total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+		"next_cursor": page.NextCursor,
+		"facets":      page.Facets,
 	}
 
-	h.logger.Info("products fetched (cached or fresh)", zap.Int("count", len(result)))
+	tracing.Logger(r.Context(), h.logger).Info("products fetched (cached or fresh)", zap.Int("count", len(result)))
 	utils.JSONResponse(w, http.StatusOK, "Товары получены", response)
 }
 
+// parseProductFilter читает параметры каталога из строки запроса
+func parseProductFilter(r *http.Request) (models.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := models.ProductFilter{
+		Search: query.Get("search"),
+		Sort:   query.Get("sort"),
+	}
+
+	for _, value := range query["category"] {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.Atoi(part)
+			if err != nil {
+				return filter, fmt.Errorf("Некорректный ID категории: %s", part)
+			}
+			filter.CategoryIDs = append(filter.CategoryIDs, id)
+		}
+	}
+
+	switch query.Get("availability") {
+	case "true":
+		val := true
+		filter.Availability = &val
+	case "false":
+		val := false
+		filter.Availability = &val
+	}
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+	filter.MinPrice, _ = strconv.ParseFloat(query.Get("min_price"), 64)
+	filter.MaxPrice, _ = strconv.ParseFloat(query.Get("max_price"), 64)
+	filter.MinRating, _ = strconv.ParseFloat(query.Get("min_rating"), 64)
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := models.DecodeProductCursor(cursor)
+		if err != nil {
+			return filter, fmt.Errorf("Некорректный курсор")
+		}
+		filter.Cursor = c
+	}
+
+	return filter, nil
+}
+
 // GetByID
 // @Summary Получить товар по ID
 // @Description Возвращает детали товара по его идентификатору
@@ -148,7 +186,7 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 	id, err := utils.ParseIntParam(idStr)
 	if err != nil {
-		h.logger.Warn("invalid product ID", zap.String("id", idStr))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product ID", zap.String("id", idStr))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
@@ -159,10 +197,10 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	var product models.ProductResponse
 
 	err = h.cache.GetOrSet(ctx, cacheKey, &product, func() (any, error) {
-		return h.service.GetByID(id)
+		return h.service.GetByID(r.Context(), id)
 	})
 
-	h.logger.Info("product fetched (cached or fresh)", zap.Int("id", id))
+	tracing.Logger(r.Context(), h.logger).Info("product fetched (cached or fresh)", zap.Int("id", id))
 	utils.JSONResponse(w, http.StatusOK, "Product fetched", product)
 }
 
@@ -182,23 +220,23 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) Add(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserClaims(r)
 	if claims == nil || claims.Role != "admin" {
-		h.logger.Warn("unauthorized access to add product")
+		tracing.Logger(r.Context(), h.logger).Warn("unauthorized access to add product")
 		utils.ErrorJSON(w, http.StatusForbidden, "Access denied")
 		return
 	}
 
 	var input models.ProductInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.logger.Warn("invalid product JSON", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product JSON", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	product := mapProductInputToProduct(input)
 
-	exists, err := h.service.IsProductNameExists(product.Name)
+	exists, err := h.service.IsProductNameExists(r.Context(), product.Name)
 	if err != nil {
-		h.logger.Error("failed to check product name", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to check product name", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка проверки имени товара")
 		return
 	}
@@ -207,8 +245,8 @@ func (h *ProductHandler) Add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.AddProduct(&product); err != nil {
-		h.logger.Error("failed to add product", zap.String("name", product.Name), zap.Error(err))
+	if err := h.service.AddProduct(r.Context(), &product); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to add product", zap.String("name", product.Name), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to add product")
 		return
 	}
@@ -216,7 +254,7 @@ func (h *ProductHandler) Add(w http.ResponseWriter, r *http.Request) {
 	h.cache.ClearPrefix(r.Context(), "products:")
 
 	response := utils.BuildProductResponse(&product, "")
-	h.logger.Info("product added", zap.String("name", product.Name))
+	tracing.Logger(r.Context(), h.logger).Info("product added", zap.String("name", product.Name))
 	utils.JSONResponse(w, http.StatusCreated, "Product added", response)
 }
 
@@ -237,7 +275,7 @@ func (h *ProductHandler) Add(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserClaims(r)
 	if claims == nil || claims.Role != "admin" {
-		h.logger.Warn("unauthorized access to update product")
+		tracing.Logger(r.Context(), h.logger).Warn("unauthorized access to update product")
 		utils.ErrorJSON(w, http.StatusForbidden, "Access denied")
 		return
 	}
@@ -245,27 +283,27 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 	id, err := utils.ParseIntParam(idStr)
 	if err != nil {
-		h.logger.Warn("invalid product ID for update", zap.String("id", idStr))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product ID for update", zap.String("id", idStr))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	var input models.ProductInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.logger.Warn("invalid update JSON", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid update JSON", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	product := mapProductInputToProduct(input)
 	if product.Name != "" {
-		exists, err := h.service.IsProductNameExists(product.Name)
+		exists, err := h.service.IsProductNameExists(r.Context(), product.Name)
 		if err != nil {
 			utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка проверки имени товара")
 			return
 		}
 
-		currentProduct, err := h.service.GetByID(id)
+		currentProduct, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
 			utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения текущего товара")
 			return
@@ -276,22 +314,22 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	response, err := h.service.UpdateProduct(id, &product)
+	response, err := h.service.UpdateProduct(r.Context(), id, &product, claims.UserID)
 	if err != nil {
-		h.logger.Error("failed to update product", zap.Int("id", id), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to update product", zap.Int("id", id), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to update product")
 		return
 	}
 	h.cache.ClearPrefix(r.Context(), "products:")
 	h.cache.Delete(r.Context(), fmt.Sprintf("product:%d", id))
 
-	h.logger.Info("product updated", zap.Int("id", id))
+	tracing.Logger(r.Context(), h.logger).Info("product updated", zap.Int("id", id))
 	utils.JSONResponse(w, http.StatusOK, "Product updated", response)
 }
 
 // Delete
 // @Summary Удалить товар (админ)
-// @Description Удаляет товар по его ID (только для администратора)
+// @Description Перемещает товар в корзину (только для администратора). Его можно восстановить до окончательной очистки.
 // @Tags Товар
 // @Security BearerAuth
 // @Produce plain
@@ -304,7 +342,7 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserClaims(r)
 	if claims == nil || claims.Role != "admin" {
-		h.logger.Warn("unauthorized access to add product")
+		tracing.Logger(r.Context(), h.logger).Warn("unauthorized access to add product")
 		utils.ErrorJSON(w, http.StatusForbidden, "Access denied")
 		return
 	}
@@ -312,20 +350,20 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 	id, err := utils.ParseIntParam(idStr)
 	if err != nil {
-		h.logger.Warn("invalid product ID for deletion", zap.String("id", idStr))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid product ID for deletion", zap.String("id", idStr))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
-	if err := h.service.DeleteProduct(id); err != nil {
-		h.logger.Error("failed to delete product", zap.Int("id", id), zap.Error(err))
+	if err := h.service.DeleteProduct(r.Context(), id); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to delete product", zap.Int("id", id), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to delete product")
 		return
 	}
 	h.cache.ClearPrefix(r.Context(), "products:")
 	h.cache.Delete(r.Context(), fmt.Sprintf("product:%d", id))
 
-	h.logger.Info("product deleted", zap.Int("id", id))
+	tracing.Logger(r.Context(), h.logger).Info("product deleted", zap.Int("id", id))
 	utils.JSONResponse(w, http.StatusOK, "Product deleted", nil)
 }
 
@@ -337,22 +375,32 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param input body []models.Product true "Массив товаров"
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом не выполняется повторно, возвращается первый ответ"
 // @Success 201 {array} models.ProductResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 403 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "Запрос с этим Idempotency-Key ещё выполняется"
+// @Failure 413 {object} utils.ErrorResponse "Тело запроса больше middleware.MaxJSONBody"
+// @Failure 422 {object} utils.ErrorResponse "Idempotency-Key уже использован с другим телом"
 // @Router /api/admin/products/bulk [post]
 func (h *ProductHandler) AddBulk(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserClaims(r)
 	if claims == nil || claims.Role != "admin" {
-		h.logger.Warn("unauthorized access to bulk add products")
+		tracing.Logger(r.Context(), h.logger).Warn("unauthorized access to bulk add products")
 		utils.ErrorJSON(w, http.StatusForbidden, "Access denied")
 		return
 	}
 
+	r.Body = http.MaxBytesReader(w, r.Body, middleware.MaxJSONBody)
 	var inputs []models.ProductInput
 	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil || len(inputs) == 0 {
-		h.logger.Warn("invalid bulk product JSON", zap.Error(err))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Warn("invalid bulk product JSON", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON or empty array")
 		return
 	}
@@ -366,7 +414,7 @@ func (h *ProductHandler) AddBulk(w http.ResponseWriter, r *http.Request) {
 		names = append(names, p.Name)
 	}
 	for _, name := range names {
-		exists, err := h.service.IsProductNameExists(name)
+		exists, err := h.service.IsProductNameExists(r.Context(), name)
 		if err != nil {
 			utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка проверки имени товаров")
 			return
@@ -376,15 +424,15 @@ func (h *ProductHandler) AddBulk(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	responses, err := h.service.AddProductsBulk(products)
+	responses, err := h.service.AddProductsBulk(r.Context(), products)
 	if err != nil {
-		h.logger.Error("failed to bulk add products", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to bulk add products", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to add products")
 		return
 	}
 	h.cache.ClearPrefix(r.Context(), "products:")
 
-	h.logger.Info("bulk products added", zap.Int("count", len(responses)))
+	tracing.Logger(r.Context(), h.logger).Info("bulk products added", zap.Int("count", len(responses)))
 	utils.JSONResponse(w, http.StatusCreated, "Products added", responses)
 }
 
@@ -426,6 +474,9 @@ func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	if input.Name != nil {
 		updates["name"] = *input.Name
 	}
+	if input.SKU != nil {
+		updates["sku"] = *input.SKU
+	}
 	if input.Description != nil {
 		updates["description"] = *input.Description
 	}
@@ -436,6 +487,13 @@ func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 		}
 		updates["price"] = *input.Price
 	}
+	if input.OldPrice != nil {
+		if *input.OldPrice < 0 {
+			utils.ErrorJSON(w, http.StatusBadRequest, "Old price must not be negative")
+			return
+		}
+		updates["old_price"] = *input.OldPrice
+	}
 	if input.Availability != nil {
 		updates["availability"] = *input.Availability
 	}
@@ -451,13 +509,13 @@ func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if input.Name != nil {
-		exists, err := h.service.IsProductNameExists(*input.Name)
+		exists, err := h.service.IsProductNameExists(r.Context(), *input.Name)
 		if err != nil {
 			utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка проверки имени товара")
 			return
 		}
 
-		currentProduct, err := h.service.GetByID(id)
+		currentProduct, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
 			utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения текущего товара")
 			return
@@ -469,7 +527,7 @@ func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.service.PatchProduct(id, updates); err != nil {
+	if err := h.service.PatchProduct(r.Context(), id, updates, claims.UserID); err != nil {
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to update product")
 		return
 	}
@@ -487,11 +545,15 @@ func mapProductInputToProduct(input models.ProductInput) models.Product {
 
 	product := models.Product{
 		Name:         input.Name,
+		SKU:          sql.NullString{String: input.SKU, Valid: input.SKU != ""},
 		Description:  input.Description,
 		Price:        input.Price,
 		Availability: availability,
 		Url:          sql.NullString{String: input.Url, Valid: input.Url != ""},
 	}
+	if input.OldPrice != nil && *input.OldPrice > 0 {
+		product.OldPrice = sql.NullFloat64{Float64: *input.OldPrice, Valid: true}
+	}
 
 	if input.CategoryID != nil {
 		product.CategoryID = sql.NullInt64{Int64: int64(*input.CategoryID), Valid: true}
@@ -502,123 +564,97 @@ func mapProductInputToProduct(input models.ProductInput) models.Product {
 	return product
 }
 
-// UploadImage загружает изображение и возвращает ссылку
+// UploadImage загружает изображение и возвращает ссылки на его варианты
 // @Summary Загрузить изображение
+// @Description Тип файла определяется по содержимому (JPEG, PNG, GIF, WebP). Изображение перекодируется без метаданных, дополнительно создаются уменьшенные копии.
 // @Tags Загрузка
+// @Security BearerAuth
 // @Accept multipart/form-data
 // @Produce json
 // @Param image formData file true "Файл изображения"
-// @Success 200 {object} utils.SuccessResponse{data=map[string]string}
+// @Success 200 {object} utils.SuccessResponse{data=models.UploadedImage}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
 // @Router /api/admin/upload [post]
 func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
-	// Парсим multipart
-	file, header, err := r.FormFile("image")
-	if err != nil {
-		utils.ErrorJSON(w, http.StatusBadRequest, "Файл не получен")
+	file, ok := readImageFile(w, r, h.images.MaxUploadSize())
+	if !ok {
 		return
 	}
 	defer file.Close()
 
-	// Генерируем уникальное имя
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), header.Filename)
-	savePath := filepath.Join("uploads", filename)
-
-	// Создаём файл
-	out, err := os.Create(savePath)
-	if err != nil {
-		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось сохранить файл")
-		return
-	}
-	defer out.Close()
-
-	// Копируем содержимое
-	_, err = io.Copy(out, file)
+	uploaded, err := h.images.Upload(r.Context(), file)
 	if err != nil {
-		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка при сохранении файла")
+		tracing.Logger(r.Context(), h.logger).Warn("failed to upload image", zap.Error(err))
+		writeImageError(w, err)
 		return
 	}
 
-	// Ссылка на файл
-	url := fmt.Sprintf("https://chechnya-product.ru/uploads/%s", filename)
-
-	utils.JSONResponse(w, http.StatusOK, "Изображение загружено", map[string]string{
-		"url": url,
-	})
+	tracing.Logger(r.Context(), h.logger).Info("image uploaded", zap.String("url", uploaded.URL))
+	utils.JSONResponse(w, http.StatusOK, "Изображение загружено", uploaded)
 }
 
 // DeleteImage удаляет изображение по имени файла
 // @Summary Удалить изображение
+// @Description Файл, который используется товаром, удалить нельзя
 // @Tags Загрузка
+// @Security BearerAuth
 // @Produce json
 // @Param filename path string true "Имя файла"
 // @Success 200 {object} utils.SuccessResponse
 // @Failure 400 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
-// @Router /api/admin//upload/{filename} [delete]
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/admin/upload/{filename} [delete]
 func (h *ProductHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
 	filename := mux.Vars(r)["filename"]
-	if filename == "" {
+	if !storage.ValidKey(filename) {
 		utils.ErrorJSON(w, http.StatusBadRequest, "Имя файла не указано")
 		return
 	}
 
-	filePath := filepath.Join("uploads", filename)
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	err := h.files.Delete(r.Context(), filename)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
 		utils.ErrorJSON(w, http.StatusNotFound, "Файл не найден")
 		return
-	}
-
-	if err := os.Remove(filePath); err != nil {
+	case errors.Is(err, services.ErrFileInUse):
+		utils.ErrorJSON(w, http.StatusConflict, "Файл используется товаром и не может быть удалён")
+		return
+	case err != nil:
+		tracing.Logger(r.Context(), h.logger).Error("failed to delete file", zap.String("file", filename), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось удалить файл")
 		return
 	}
 
+	tracing.Logger(r.Context(), h.logger).Info("file deleted", zap.String("file", filename))
 	utils.JSONResponse(w, http.StatusOK, "Файл удалён", nil)
 }
 
 // ListUploadedFiles возвращает список всех загруженных изображений
 // @Summary Получить список изображений
+// @Description Поле referenced показывает, используется ли файл каким-либо товаром
 // @Tags Загрузка
+// @Security BearerAuth
 // @Produce json
 // @Success 200 {array} models.UploadedFile
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/admin/upload [get]
 func (h *ProductHandler) ListUploadedFiles(w http.ResponseWriter, r *http.Request) {
-	files, err := os.ReadDir("uploads")
+	files, err := h.files.List(r.Context())
 	if err != nil {
-		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось прочитать папку")
+		tracing.Logger(r.Context(), h.logger).Error("failed to list files", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось получить список файлов")
 		return
 	}
 
-	var result []models.UploadedFile
-
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		info, err := file.Info()
-		if err != nil {
-			continue
-		}
-
-		result = append(result, models.UploadedFile{
-			Name: file.Name(),
-			URL:  fmt.Sprintf("https://chechnya-product.ru/uploads/%s", file.Name()),
-			Size: info.Size(),
-			Time: info.ModTime().Format(time.RFC3339),
-		})
-	}
-
-	utils.JSONResponse(w, http.StatusOK, "Файлы получены", result)
+	utils.JSONResponse(w, http.StatusOK, "Файлы получены", files)
 }
 
 // Проверяет, существует ли товар с таким именем,
 // и если передан id — не совпадает ли это с текущим товаром.
-func (h *ProductHandler) isDuplicateName(name string, excludeID *int) (bool, error) {
-	exists, err := h.service.IsProductNameExists(name)
+func (h *ProductHandler) isDuplicateName(ctx context.Context, name string, excludeID *int) (bool, error) {
+	exists, err := h.service.IsProductNameExists(ctx, name)
 	if err != nil {
 		return false, err
 	}
@@ -628,7 +664,7 @@ func (h *ProductHandler) isDuplicateName(name string, excludeID *int) (bool, err
 
 	// Если нужно исключить текущий товар
 	if excludeID != nil {
-		current, err := h.service.GetByID(*excludeID)
+		current, err := h.service.GetByID(ctx, *excludeID)
 		if err != nil {
 			return false, err
 		}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/imaging"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"net/http"
+)
+
+type ProductImageHandlerInterface interface {
+	GetImages(w http.ResponseWriter, r *http.Request)
+	AddImage(w http.ResponseWriter, r *http.Request)
+	SetPrimary(w http.ResponseWriter, r *http.Request)
+	Reorder(w http.ResponseWriter, r *http.Request)
+	DeleteImage(w http.ResponseWriter, r *http.Request)
+}
+
+type ProductImageHandler struct {
+	service services.ProductImageServiceInterface
+	logger  *zap.Logger
+	cache   *cache.RedisCache
+}
+
+func NewProductImageHandler(service services.ProductImageServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *ProductImageHandler {
+	return &ProductImageHandler{service: service, logger: logger, cache: cache}
+}
+
+// GetImages
+// @Summary Галерея товара
+// @Description Возвращает изображения товара: основное первым, затем по порядку сортировки
+// @Tags Изображения товара
+// @Produce json
+// @Param id path int true "ID товара"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.ProductImage}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/products/{id}/images [get]
+func (h *ProductImageHandler) GetImages(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	images, err := h.service.GetByProductID(r.Context(), productID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch product images", zap.Int("product_id", productID), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось получить изображения")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Изображения получены", images)
+}
+
+// AddImage
+// @Summary Добавить изображение в галерею (админ)
+// @Description Загружает изображение, генерирует уменьшенные копии и добавляет его в конец галереи. Первое изображение становится основным.
+// @Tags Изображения товара
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param image formData file true "Файл изображения"
+// @Success 201 {object} utils.SuccessResponse{data=models.ProductImage}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/images [post]
+func (h *ProductImageHandler) AddImage(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	file, ok := readImageFile(w, r, h.service.MaxUploadSize())
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	img, err := h.service.AddToProduct(r.Context(), productID, file)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to add product image", zap.Int("product_id", productID), zap.Error(err))
+		writeImageError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("product image added", zap.Int("product_id", productID), zap.Int("image_id", img.ID))
+	utils.JSONResponse(w, http.StatusCreated, "Изображение добавлено", img)
+}
+
+// SetPrimary
+// @Summary Сделать изображение основным (админ)
+// @Tags Изображения товара
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param image_id path int true "ID изображения"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/images/{image_id}/primary [patch]
+func (h *ProductImageHandler) SetPrimary(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := parseProductImageIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetPrimary(r.Context(), productID, imageID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to set primary image", zap.Int("product_id", productID), zap.Int("image_id", imageID), zap.Error(err))
+		writeImageError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	utils.JSONResponse(w, http.StatusOK, "Основное изображение обновлено", nil)
+}
+
+// Reorder
+// @Summary Изменить порядок изображений (админ)
+// @Description Принимает полный список ID изображений товара в новом порядке
+// @Tags Изображения товара
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param input body models.ProductImagesOrderRequest true "ID изображений в новом порядке"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.ProductImage}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/images/order [put]
+func (h *ProductImageHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req models.ProductImagesOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	images, err := h.service.Reorder(r.Context(), productID, req.ImageIDs)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to reorder product images", zap.Int("product_id", productID), zap.Error(err))
+		writeImageError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	utils.JSONResponse(w, http.StatusOK, "Порядок изображений обновлён", images)
+}
+
+// DeleteImage
+// @Summary Удалить изображение из галереи (админ)
+// @Description Удаляет изображение и его файлы. Если оно было основным, основным становится следующее.
+// @Tags Изображения товара
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param image_id path int true "ID изображения"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/products/{id}/images/{image_id} [delete]
+func (h *ProductImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := parseProductImageIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), productID, imageID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to delete product image", zap.Int("product_id", productID), zap.Int("image_id", imageID), zap.Error(err))
+		writeImageError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("product image deleted", zap.Int("product_id", productID), zap.Int("image_id", imageID))
+	utils.JSONResponse(w, http.StatusOK, "Изображение удалено", nil)
+}
+
+func (h *ProductImageHandler) invalidateProduct(r *http.Request, productID int) {
+	h.cache.ClearPrefix(r.Context(), "products:")
+	h.cache.Delete(r.Context(), fmt.Sprintf("product:%d", productID))
+}
+
+func parseProductImageIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	productID, err := utils.ParseIntParam(vars["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	imageID, err := utils.ParseIntParam(vars["image_id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid image ID")
+		return 0, 0, false
+	}
+	return productID, imageID, true
+}
+
+// readImageFile ограничивает размер тела запроса и достаёт файл из поля "image"
+func readImageFile(w http.ResponseWriter, r *http.Request, maxSize int64) (multipart.File, bool) {
+	// Запас на служебные части multipart
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Файл слишком большой")
+			return nil, false
+		}
+		utils.ErrorJSON(w, http.StatusBadRequest, "Файл не получен")
+		return nil, false
+	}
+	return file, true
+}
+
+func writeImageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge):
+		utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Файл слишком большой")
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Изображение слишком большое: не больше %d пикселей по стороне", imaging.MaxSourceSide))
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		utils.ErrorJSON(w, http.StatusBadRequest, "Неподдерживаемый формат изображения (разрешены JPEG, PNG, GIF, WebP)")
+	case errors.Is(err, imaging.ErrInvalidImage):
+		utils.ErrorJSON(w, http.StatusBadRequest, "Файл повреждён или не является изображением")
+	case errors.Is(err, services.ErrImageNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Изображение не найдено")
+	case errors.Is(err, services.ErrInvalidImagesOrder):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось обработать изображение")
+	}
+}
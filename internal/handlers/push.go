@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"chechnya-product/internal/middleware"
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
 	"github.com/SherClockHolmes/webpush-go"
@@ -49,18 +51,18 @@ func (h *PushHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("невалидный JSON", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("невалидный JSON", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Невалидный JSON")
 		return
 	}
 
-	if err := h.service.SendPush(req.Subscription, req.Message); err != nil {
-		h.logger.Error("ошибка отправки push", zap.Error(err))
+	if err := h.service.SendPush(r.Context(), req.Subscription, req.Message); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("ошибка отправки push", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка отправки push")
 		return
 	}
 
-	h.logger.Info("push отправлен")
+	tracing.Logger(r.Context(), h.logger).Info("push отправлен")
 	utils.JSONResponse(w, http.StatusOK, "Push отправлен", nil)
 }
 
@@ -80,18 +82,18 @@ func (h *PushHandler) Broadcast(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("невалидный JSON", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("невалидный JSON", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Невалидный JSON")
 		return
 	}
 
-	if err := h.service.Broadcast(req.Message); err != nil {
-		h.logger.Error("ошибка рассылки", zap.Error(err))
+	if err := h.service.Broadcast(r.Context(), req.Message); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("ошибка рассылки", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка рассылки push")
 		return
 	}
 
-	h.logger.Info("рассылка завершена")
+	tracing.Logger(r.Context(), h.logger).Info("рассылка завершена")
 	utils.JSONResponse(w, http.StatusOK, "Рассылка завершена", nil)
 }
 
@@ -108,18 +110,18 @@ func (h *PushHandler) Broadcast(w http.ResponseWriter, r *http.Request) {
 func (h *PushHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	endpoint := r.URL.Query().Get("endpoint")
 	if endpoint == "" {
-		h.logger.Warn("не указан endpoint для удаления")
+		tracing.Logger(r.Context(), h.logger).Warn("не указан endpoint для удаления")
 		utils.ErrorJSON(w, http.StatusBadRequest, "Не указан endpoint")
 		return
 	}
 
-	if err := h.service.DeleteByEndpoint(endpoint); err != nil {
-		h.logger.Error("не удалось удалить подписку", zap.String("endpoint", endpoint), zap.Error(err))
+	if err := h.service.DeleteByEndpoint(r.Context(), endpoint); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("не удалось удалить подписку", zap.String("endpoint", endpoint), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка удаления подписки")
 		return
 	}
 
-	h.logger.Info("подписка удалена", zap.String("endpoint", endpoint))
+	tracing.Logger(r.Context(), h.logger).Info("подписка удалена", zap.String("endpoint", endpoint))
 	utils.JSONResponse(w, http.StatusOK, "Подписка удалена", nil)
 }
 
@@ -139,18 +141,18 @@ func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
 
 	// Парсим JSON тело запроса
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("Ошибка декодирования подписки", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка декодирования подписки", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный запрос")
 		return
 	}
 
-	// Сохраняем подписку
-	if err := h.service.SaveSubscription(req.Subscription, req.IsAdmin); err != nil {
-		h.logger.Warn("Ошибка сохранения подписки", zap.Error(err))
+	// Сохраняем подписку; подписка авторизованного пользователя привязывается к нему
+	if err := h.service.SaveSubscription(r.Context(), req.Subscription, req.IsAdmin, middleware.GetUserIDOrZero(r)); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка сохранения подписки", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось сохранить подписку")
 		return
 	}
 
-	h.logger.Info("Подписка успешно сохранена", zap.String("endpoint", req.Subscription.Endpoint))
+	tracing.Logger(r.Context(), h.logger).Info("Подписка успешно сохранена", zap.String("endpoint", req.Subscription.Endpoint))
 	utils.JSONResponse(w, http.StatusCreated, "Подписка успешно сохранена", nil)
 }
@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"chechnya-product/internal/cache"
 	"chechnya-product/internal/middleware"
+	"chechnya-product/internal/models"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type ReviewHandlerInterface interface {
@@ -16,26 +22,37 @@ type ReviewHandlerInterface interface {
 	GetReviews(w http.ResponseWriter, r *http.Request)
 	UpdateReview(w http.ResponseWriter, r *http.Request)
 	DeleteReview(w http.ResponseWriter, r *http.Request)
+	GetRatingSummary(w http.ResponseWriter, r *http.Request)
+	AddPhoto(w http.ResponseWriter, r *http.Request)
+	DeletePhoto(w http.ResponseWriter, r *http.Request)
+	GetModerationQueue(w http.ResponseWriter, r *http.Request)
+	Moderate(w http.ResponseWriter, r *http.Request)
+	Reply(w http.ResponseWriter, r *http.Request)
+	SubmitOrderFeedback(w http.ResponseWriter, r *http.Request)
+	GetOrderFeedback(w http.ResponseWriter, r *http.Request)
+	GetAllOrderFeedback(w http.ResponseWriter, r *http.Request)
+	GetAnalytics(w http.ResponseWriter, r *http.Request)
 }
 
 type ReviewHandler struct {
 	service services.ReviewServiceInterface
 	logger  *zap.Logger
+	cache   *cache.RedisCache
 }
 
-func NewReviewHandler(service services.ReviewServiceInterface, logger *zap.Logger) *ReviewHandler {
-	return &ReviewHandler{service: service, logger: logger}
+func NewReviewHandler(service services.ReviewServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *ReviewHandler {
+	return &ReviewHandler{service: service, logger: logger, cache: cache}
 }
 
 // AddReview добавляет новый отзыв к товару
 // @Summary Добавить отзыв
-// @Description Отзыв может оставить как авторизованный, так и гость. Повторный отзыв от одного владельца невозможен.
+// @Description Отзыв может оставить как авторизованный, так и гость. Повторный отзыв от одного владельца невозможен. Отзыв покупателя с доставленным заказом помечается как "проверенная покупка". При включённой премодерации, а также если в тексте найдены ссылки или стоп-слова, отзыв публикуется после проверки администратором.
 // @Tags Отзывы
 // @Accept json
 // @Produce json
 // @Param id path int true "ID товара"
 // @Param review body models.ReviewRequest true "Оценка и комментарий"
-// @Success 201 {object} utils.SuccessResponse
+// @Success 201 {object} utils.SuccessResponse{data=models.Review}
 // @Failure 400 {object} utils.ErrorResponse
 // @Router /api/products/{id}/reviews [post]
 func (h *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
@@ -47,23 +64,28 @@ func (h *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
 		Comment string `json:"comment"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		h.logger.Warn("invalid review body", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid review body", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
 		return
 	}
 
-	if err := h.service.AddReview(ownerID, productID, body.Rating, body.Comment); err != nil {
-		h.logger.Warn("failed to add review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+	review, err := h.service.AddReview(r.Context(), ownerID, productID, body.Rating, body.Comment)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to add review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	h.logger.Info("review added", zap.String("owner_id", ownerID), zap.Int("product_id", productID), zap.Int("rating", body.Rating))
-	utils.JSONResponse(w, http.StatusCreated, "Review added", nil)
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("review added", zap.String("owner_id", ownerID), zap.Int("product_id", productID),
+		zap.Int("rating", body.Rating), zap.String("status", review.Status))
+	utils.JSONResponse(w, http.StatusCreated, reviewStatusMessage(review, "Review added"), review)
 }
 
 // GetReviews возвращает список отзывов по товару
 // @Summary Получить отзывы товара
+// @Description Только опубликованные отзывы, с фото и ответом магазина
 // @Tags Отзывы
 // @Produce json
 // @Param id path int true "ID товара"
@@ -72,26 +94,27 @@ func (h *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
 // @Router /api/products/{id}/reviews [get]
 func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 	productID, _ := strconv.Atoi(mux.Vars(r)["id"])
-	reviews, err := h.service.GetReviewsByProductID(productID)
+	reviews, err := h.service.GetReviewsByProductID(r.Context(), productID)
 	if err != nil {
-		h.logger.Error("failed to fetch reviews", zap.Error(err), zap.Int("product_id", productID))
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch reviews", zap.Error(err), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch reviews")
 		return
 	}
-	h.logger.Info("reviews fetched", zap.Int("product_id", productID), zap.Int("count", len(reviews)))
+	tracing.Logger(r.Context(), h.logger).Info("reviews fetched", zap.Int("product_id", productID), zap.Int("count", len(reviews)))
 	utils.JSONResponse(w, http.StatusOK, "Reviews fetched", reviews)
 }
 
 // UpdateReview обновляет отзыв по owner_id и product_id
 // @Summary Обновить отзыв
-// @Description Может обновить только тот, кто оставил (по owner_id)
+// @Description Может обновить только тот, кто оставил (по owner_id). Изменённый отзыв проходит модерацию заново.
 // @Tags Отзывы
 // @Accept json
 // @Produce json
 // @Param id path int true "ID товара"
 // @Param review body models.ReviewRequest true "Обновлённая оценка и комментарий"
-// @Success 200 {object} utils.SuccessResponse
+// @Success 200 {object} utils.SuccessResponse{data=models.Review}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/products/{id}/reviews [put]
 func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
@@ -103,20 +126,30 @@ func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
 		Comment string `json:"comment"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		h.logger.Warn("invalid update body", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+		tracing.Logger(r.Context(), h.logger).Warn("invalid update body", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
 		return
 	}
 
-	err := h.service.UpdateReview(ownerID, productID, body.Rating, body.Comment)
+	review, err := h.service.UpdateReview(r.Context(), ownerID, productID, body.Rating, body.Comment)
+	if errors.Is(err, services.ErrInvalidRating) {
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrReviewNotFound) {
+		utils.ErrorJSON(w, http.StatusNotFound, "Review not found")
+		return
+	}
 	if err != nil {
-		h.logger.Error("failed to update review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+		tracing.Logger(r.Context(), h.logger).Error("failed to update review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to update review")
 		return
 	}
 
-	h.logger.Info("review updated", zap.String("owner_id", ownerID), zap.Int("product_id", productID), zap.Int("rating", body.Rating))
-	utils.JSONResponse(w, http.StatusOK, "Review updated", nil)
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("review updated", zap.String("owner_id", ownerID), zap.Int("product_id", productID), zap.Int("rating", body.Rating))
+	utils.JSONResponse(w, http.StatusOK, reviewStatusMessage(review, "Review updated"), review)
 }
 
 // DeleteReview удаляет отзыв по owner_id и product_id
@@ -126,18 +159,418 @@ func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "ID товара"
 // @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/products/{id}/reviews [delete]
 func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
 	ownerID := middleware.GetOwnerID(w, r)
 	productID, _ := strconv.Atoi(mux.Vars(r)["id"])
 
-	if err := h.service.DeleteReview(ownerID, productID); err != nil {
-		h.logger.Error("failed to delete review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+	err := h.service.DeleteReview(r.Context(), ownerID, productID)
+	if errors.Is(err, services.ErrReviewNotFound) {
+		utils.ErrorJSON(w, http.StatusNotFound, "Review not found")
+		return
+	}
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to delete review", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to delete review")
 		return
 	}
 
-	h.logger.Info("review deleted", zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("review deleted", zap.String("owner_id", ownerID), zap.Int("product_id", productID))
 	utils.JSONResponse(w, http.StatusOK, "Review deleted", nil)
 }
+
+// GetRatingSummary возвращает средний рейтинг и распределение оценок товара
+// @Summary Распределение оценок товара
+// @Description Средняя оценка, количество отзывов и число отзывов с каждой оценкой от 5 до 1
+// @Tags Отзывы
+// @Produce json
+// @Param id path int true "ID товара"
+// @Success 200 {object} utils.SuccessResponse{data=models.ProductRatingSummary}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/products/{id}/rating [get]
+func (h *ReviewHandler) GetRatingSummary(w http.ResponseWriter, r *http.Request) {
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	summary, err := h.service.GetRatingSummary(r.Context(), productID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch rating summary", zap.Error(err), zap.Int("product_id", productID))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch rating")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Rating fetched", summary)
+}
+
+// AddPhoto прикрепляет фото к своему отзыву
+// @Summary Добавить фото к отзыву
+// @Description Фото обрабатывается так же, как изображения товаров (проверка формата, удаление метаданных, уменьшенные копии). Не больше 5 фото на отзыв.
+// @Tags Отзывы
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param image formData file true "Фото"
+// @Success 201 {object} utils.SuccessResponse{data=models.ReviewPhoto}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /api/products/{id}/reviews/photos [post]
+func (h *ReviewHandler) AddPhoto(w http.ResponseWriter, r *http.Request) {
+	ownerID := middleware.GetOwnerID(w, r)
+	productID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	file, ok := readImageFile(w, r, h.service.MaxPhotoSize())
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	photo, err := h.service.AddPhoto(r.Context(), ownerID, productID, file)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to add review photo", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("product_id", productID))
+		writeReviewError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	tracing.Logger(r.Context(), h.logger).Info("review photo added", zap.String("owner_id", ownerID), zap.Int("product_id", productID), zap.Int("photo_id", photo.ID))
+	utils.JSONResponse(w, http.StatusCreated, "Photo added", photo)
+}
+
+// DeletePhoto удаляет фото из своего отзыва
+// @Summary Удалить фото из отзыва
+// @Tags Отзывы
+// @Produce json
+// @Param id path int true "ID товара"
+// @Param photo_id path int true "ID фото"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/products/{id}/reviews/photos/{photo_id} [delete]
+func (h *ReviewHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	ownerID := middleware.GetOwnerID(w, r)
+	vars := mux.Vars(r)
+	productID, err := utils.ParseIntParam(vars["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	photoID, err := utils.ParseIntParam(vars["photo_id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid photo ID")
+		return
+	}
+
+	if err := h.service.DeletePhoto(r.Context(), ownerID, productID, photoID); err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to delete review photo", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("photo_id", photoID))
+		writeReviewError(w, err)
+		return
+	}
+	h.invalidateProduct(r, productID)
+
+	utils.JSONResponse(w, http.StatusOK, "Photo deleted", nil)
+}
+
+// GetModerationQueue возвращает отзывы с заданным статусом модерации
+// @Summary Очередь модерации отзывов (админ)
+// @Description По умолчанию — отзывы, ожидающие проверки, от самых старых к новым
+// @Tags Отзывы
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Статус: pending (по умолчанию), approved, rejected"
+// @Param limit query int false "Количество (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} utils.SuccessResponse{data=map[string]interface{}}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/reviews [get]
+func (h *ReviewHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	reviews, total, err := h.service.GetModerationQueue(r.Context(), query.Get("status"), limit, offset)
+	if errors.Is(err, services.ErrInvalidReviewStatus) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch moderation queue", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Reviews fetched", map[string]interface{}{
+		"items": reviews,
+		"total": total,
+	})
+}
+
+// Moderate одобряет или отклоняет отзыв
+// @Summary Модерация отзыва (админ)
+// @Description Для отклонения обязательна причина. Рейтинг товара пересчитывается сразу.
+// @Tags Отзывы
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Param input body models.ReviewModerationRequest true "Решение модератора"
+// @Success 200 {object} utils.SuccessResponse{data=models.Review}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/reviews/{id}/moderate [patch]
+func (h *ReviewHandler) Moderate(w http.ResponseWriter, r *http.Request) {
+	reviewID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req models.ReviewModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
+		return
+	}
+
+	adminID := middleware.GetUserID(r)
+	review, err := h.service.Moderate(r.Context(), reviewID, req.Status, req.Reason, adminID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to moderate review", zap.Error(err), zap.Int("review_id", reviewID))
+		writeReviewError(w, err)
+		return
+	}
+	h.invalidateProduct(r, review.ProductID)
+
+	utils.JSONResponse(w, http.StatusOK, "Review moderated", review)
+}
+
+// Reply сохраняет ответ магазина на отзыв
+// @Summary Ответить на отзыв (админ)
+// @Description Ответ показывается под отзывом. Пустой ответ удаляет его.
+// @Tags Отзывы
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID отзыва"
+// @Param input body models.ReviewReplyRequest true "Текст ответа"
+// @Success 200 {object} utils.SuccessResponse{data=models.Review}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/admin/reviews/{id}/reply [put]
+func (h *ReviewHandler) Reply(w http.ResponseWriter, r *http.Request) {
+	reviewID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
+		return
+	}
+
+	review, err := h.service.Reply(r.Context(), reviewID, req.Reply)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to reply to review", zap.Error(err), zap.Int("review_id", reviewID))
+		writeReviewError(w, err)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Reply saved", review)
+}
+
+// SubmitOrderFeedback принимает отзыв о доставленном заказе
+// @Summary Оценить заказ
+// @Description Общая оценка заказа, оценки доставки и курьера и оценки товаров из заказа одним запросом. Доступно владельцу заказа после доставки, один раз. Оценки товаров публикуются как отзывы с пометкой "проверенная покупка" и проходят модерацию.
+// @Tags Отзывы заказов
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Param input body models.OrderFeedbackRequest true "Оценки заказа и товаров"
+// @Success 201 {object} utils.SuccessResponse{data=models.OrderReview}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/orders/{id}/feedback [post]
+func (h *ReviewHandler) SubmitOrderFeedback(w http.ResponseWriter, r *http.Request) {
+	ownerID := middleware.GetOwnerID(w, r)
+	orderID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.OrderFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid body")
+		return
+	}
+
+	var userID *int
+	if id := middleware.GetUserID(r); id != 0 {
+		userID = &id
+	}
+
+	feedback, err := h.service.SubmitOrderFeedback(r.Context(), orderID, ownerID, userID, req)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("failed to submit order feedback", zap.Error(err), zap.String("owner_id", ownerID), zap.Int("order_id", orderID))
+		writeReviewError(w, err)
+		return
+	}
+
+	for _, item := range feedback.Items {
+		h.invalidateProduct(r, item.ProductID)
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, "Feedback saved", feedback)
+}
+
+// GetOrderFeedback возвращает отзыв о заказе
+// @Summary Отзыв о заказе
+// @Description Оценки заказа, доставки и курьера вместе с оценками товаров
+// @Tags Отзывы заказов
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} utils.SuccessResponse{data=models.OrderReview}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /api/orders/{id}/review [get]
+func (h *ReviewHandler) GetOrderFeedback(w http.ResponseWriter, r *http.Request) {
+	orderID, err := utils.ParseIntParam(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	feedback, err := h.service.GetOrderFeedback(r.Context(), orderID)
+	if err != nil {
+		if !errors.Is(err, services.ErrReviewNotFound) {
+			tracing.Logger(r.Context(), h.logger).Error("failed to fetch order feedback", zap.Error(err), zap.Int("order_id", orderID))
+		}
+		writeReviewError(w, err)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Feedback fetched", feedback)
+}
+
+// GetAllOrderFeedback возвращает все отзывы о заказах
+// @Summary Все отзывы о заказах
+// @Tags Отзывы заказов
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=[]models.OrderReview}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/order-reviews [get]
+func (h *ReviewHandler) GetAllOrderFeedback(w http.ResponseWriter, r *http.Request) {
+	feedback, err := h.service.GetAllOrderFeedback(r.Context())
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch order feedback", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Reviews fetched", feedback)
+}
+
+// GetAnalytics возвращает динамику оценок и худшие товары
+// @Summary Аналитика оценок (админ)
+// @Description Средние оценки товаров, заказов, доставки и курьеров по дням, неделям или месяцам (по умолчанию — по дням за последние 30 дней) и товары с самым низким рейтингом
+// @Tags Отзывы
+// @Security BearerAuth
+// @Produce json
+// @Param period query string false "day (по умолчанию), week или month"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода (RFC3339 или YYYY-MM-DD)"
+// @Success 200 {object} utils.SuccessResponse{data=models.FeedbackAnalytics}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/reviews/analytics [get]
+func (h *ReviewHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid from")
+		return
+	}
+	to, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid to")
+		return
+	}
+
+	analytics, err := h.service.GetAnalytics(r.Context(), query.Get("period"), from, to)
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidPeriod) && !errors.Is(err, services.ErrInvalidDateRange) {
+			tracing.Logger(r.Context(), h.logger).Error("failed to build review analytics", zap.Error(err))
+		}
+		writeReviewError(w, err)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Analytics fetched", analytics)
+}
+
+// parseTimeParam принимает RFC3339 или просто дату; пустая строка — без ограничения
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid time: %s", value)
+}
+
+func reviewStatusMessage(review *models.Review, published string) string {
+	if review.Status == models.ReviewStatusPending {
+		return "Review submitted for moderation"
+	}
+	return published
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Review not found")
+	case errors.Is(err, services.ErrReviewPhotoNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Photo not found")
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, services.ErrOrderFeedbackExists),
+		errors.Is(err, services.ErrReviewAlreadyExists):
+		utils.ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidReviewStatus),
+		errors.Is(err, services.ErrRejectReasonRequired),
+		errors.Is(err, services.ErrTooManyReviewPhotos),
+		errors.Is(err, services.ErrInvalidRating),
+		errors.Is(err, services.ErrOrderNotDelivered),
+		errors.Is(err, services.ErrOrderFeedbackEmpty),
+		errors.Is(err, services.ErrProductNotInOrder),
+		errors.Is(err, services.ErrInvalidPeriod),
+		errors.Is(err, services.ErrInvalidDateRange):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	default:
+		writeImageError(w, err)
+	}
+}
+
+// invalidateProduct сбрасывает кэш товара и каталога: рейтинг в них изменился
+func (h *ReviewHandler) invalidateProduct(r *http.Request, productID int) {
+	h.cache.ClearPrefix(r.Context(), "products:")
+	h.cache.Delete(r.Context(), fmt.Sprintf("product:%d", productID))
+}
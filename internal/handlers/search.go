@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type SearchHandlerInterface interface {
+	SearchProducts(w http.ResponseWriter, r *http.Request)
+	Suggest(w http.ResponseWriter, r *http.Request)
+	GetZeroResults(w http.ResponseWriter, r *http.Request)
+}
+
+type SearchHandler struct {
+	service services.SearchServiceInterface
+	logger  *zap.Logger
+}
+
+func NewSearchHandler(service services.SearchServiceInterface, logger *zap.Logger) *SearchHandler {
+	return &SearchHandler{service: service, logger: logger}
+}
+
+// SearchProducts
+// @Summary Поиск товаров
+// @Description Полнотекстовый поиск с учётом русской морфологии и опечаток по названию, описанию и категории. Результаты отсортированы по релевантности, найденные слова выделены тегом <mark>, остальной текст в name_highlight и snippet экранирован как HTML.
+// @Tags Поиск
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param limit query int false "Количество результатов (по умолчанию 20, максимум 100)"
+// @Param offset query int false "Смещение для пагинации"
+// @Success 200 {object} utils.SuccessResponse{data=models.ProductSearchResponse}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/search [get]
+func (h *SearchHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	result, err := h.service.SearchProducts(r.Context(), query.Get("q"), limit, offset)
+	if errors.Is(err, services.ErrEmptySearchQuery) {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Введите поисковый запрос")
+		return
+	}
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("product search failed", zap.String("q", query.Get("q")), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка поиска")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Результаты поиска", result)
+}
+
+// Suggest
+// @Summary Подсказки поиска
+// @Description Автодополнение по названиям товаров и категорий. Запросы короче 2 символов возвращают пустой список.
+// @Tags Поиск
+// @Produce json
+// @Param q query string true "Начало запроса"
+// @Param limit query int false "Количество подсказок (по умолчанию 10)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.SearchSuggestion}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/search/suggestions [get]
+func (h *SearchHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	suggestions, err := h.service.Suggest(r.Context(), query.Get("q"), limit)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("search suggestions failed", zap.String("q", query.Get("q")), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения подсказок")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Подсказки получены", suggestions)
+}
+
+// GetZeroResults
+// @Summary Запросы без результатов (админ)
+// @Description Самые частые поисковые запросы, по которым ничего не нашлось
+// @Tags Поиск
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Количество запросов (по умолчанию 20, максимум 100)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.SearchZeroResult}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/search/zero-results [get]
+func (h *SearchHandler) GetZeroResults(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	results, err := h.service.GetZeroResults(r.Context(), limit)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to fetch zero-result queries", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось получить запросы")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, "Запросы без результатов", results)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type SystemHandlerInterface interface {
+	Healthz(w http.ResponseWriter, r *http.Request)
+	Readyz(w http.ResponseWriter, r *http.Request)
+	Info(w http.ResponseWriter, r *http.Request)
+}
+
+type SystemHandler struct {
+	service services.SystemServiceInterface
+	logger  *zap.Logger
+}
+
+func NewSystemHandler(service services.SystemServiceInterface, logger *zap.Logger) *SystemHandler {
+	return &SystemHandler{service: service, logger: logger}
+}
+
+// Healthz
+// @Summary Проверка жизнеспособности
+// @Description Отвечает 200, пока процесс обслуживает запросы. Зависимости не проверяются — для этого есть /readyz.
+// @Tags Система
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *SystemHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	utils.JSONResponse(w, http.StatusOK, "", map[string]string{"status": "ok"})
+}
+
+// Readyz
+// @Summary Проверка готовности
+// @Description Проверяет Postgres, Redis, что все миграции из каталога применены, и запись в файловое хранилище. 503 — экземпляр не должен получать трафик.
+// @Tags Система
+// @Produce json
+// @Success 200 {object} models.ReadinessReport
+// @Failure 503 {object} models.ReadinessReport
+// @Router /readyz [get]
+func (h *SystemHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Ready(r.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	utils.JSONResponse(w, status, "", report)
+}
+
+// Info
+// @Summary Состояние экземпляра (админ)
+// @Description Версия и сборка, время работы, версия схемы, число WebSocket-клиентов, пулы соединений Postgres и Redis
+// @Tags Система
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=models.SystemInfo}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/system [get]
+func (h *SystemHandler) Info(w http.ResponseWriter, r *http.Request) {
+	info, err := h.service.Info(r.Context())
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to collect system info", zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "System info fetched", info)
+}
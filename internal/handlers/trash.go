@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type TrashHandlerInterface interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	Purge(w http.ResponseWriter, r *http.Request)
+}
+
+type TrashHandler struct {
+	service services.TrashServiceInterface
+	logger  *zap.Logger
+	cache   *cache.RedisCache
+}
+
+func NewTrashHandler(service services.TrashServiceInterface, logger *zap.Logger, cache *cache.RedisCache) *TrashHandler {
+	return &TrashHandler{service: service, logger: logger, cache: cache}
+}
+
+// List
+// @Summary Корзина (админ)
+// @Description Удалённые товары, категории и заказы, новые первыми. purge_at — когда запись будет удалена окончательно (TRASH_RETENTION_DAYS).
+// @Tags Корзина
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "product, category или order (по умолчанию — все)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.TrashItem}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /api/admin/trash [get]
+func (h *TrashHandler) List(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.List(r.Context(), r.URL.Query().Get("type"))
+	if err != nil {
+		h.writeError(w, r, "failed to fetch trash", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Trash fetched", items)
+}
+
+// Restore
+// @Summary Восстановить из корзины (админ)
+// @Description Товар восстанавливается без категории, если она тоже удалена; категория — корневой, если удалён её родитель. Если за это время появилась активная запись с тем же названием, артикулом или slug, возвращается 409.
+// @Tags Корзина
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "product, category или order"
+// @Param id path int true "ID записи"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /api/admin/trash/{type}/{id}/restore [post]
+func (h *TrashHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kind := vars["type"]
+	id, err := utils.ParseIntParam(vars["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.service.Restore(r.Context(), kind, id); err != nil {
+		h.writeError(w, r, "failed to restore from trash", err)
+		return
+	}
+
+	h.cache.ClearPrefix(r.Context(), "products:")
+	h.cache.ClearPrefix(r.Context(), "product:")
+
+	tracing.Logger(r.Context(), h.logger).Info("restored from trash", zap.String("type", kind), zap.Int("id", id))
+	utils.JSONResponse(w, http.StatusOK, "Restored", nil)
+}
+
+// Purge
+// @Summary Очистить корзину (админ)
+// @Description Сразу удаляет окончательно записи, которые лежат в корзине дольше срока хранения. Обычно это делает фоновая задача.
+// @Tags Корзина
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=models.TrashPurgeResult}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/trash/purge [post]
+func (h *TrashHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Purge(r.Context())
+	if err != nil {
+		h.writeError(w, r, "failed to purge trash", err)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, "Trash purged", result)
+}
+
+func (h *TrashHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownTrashType):
+		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrTrashItemNotFound):
+		utils.ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrRestoreConflict):
+		utils.ErrorJSON(w, http.StatusConflict, err.Error())
+	default:
+		tracing.Logger(r.Context(), h.logger).Error(msg, zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -3,12 +3,16 @@ package handlers
 import (
 	"chechnya-product/internal/middleware"
 	"chechnya-product/internal/services"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type UserHandlerInterface interface {
@@ -21,6 +25,7 @@ type UserHandlerInterface interface {
 	UpdateAddress(w http.ResponseWriter, r *http.Request)
 	GetAddress(w http.ResponseWriter, r *http.Request)
 	ClearAddress(w http.ResponseWriter, r *http.Request)
+	UnlockUser(w http.ResponseWriter, r *http.Request)
 }
 
 type UserHandler struct {
@@ -45,7 +50,7 @@ func NewUserHandler(service services.UserServiceInterface, logger *zap.Logger) *
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("Некорректный JSON при регистрации", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("Некорректный JSON при регистрации", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный JSON")
 		return
 	}
@@ -53,12 +58,12 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	oldOwnerID := middleware.GetOwnerID(w, r)
 
 	if err := utils.ValidatePhone(req.Phone); err != nil {
-		h.logger.Warn("Некорректный формат телефона", zap.String("phone", req.Phone))
+		tracing.Logger(r.Context(), h.logger).Warn("Некорректный формат телефона", zap.String("phone", req.Phone))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	user, err := h.service.Register(services.RegisterRequest{
+	user, err := h.service.Register(r.Context(), services.RegisterRequest{
 		Phone:    req.Phone,
 		Password: req.Password,
 		Username: req.Username,
@@ -66,23 +71,23 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		OwnerID:  oldOwnerID,
 	})
 	if err != nil {
-		h.logger.Warn("Ошибка регистрации", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка регистрации", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// переносим корзину если есть
-	if cartErr := h.service.TransferCart(oldOwnerID, user.OwnerID); cartErr != nil {
-		h.logger.Warn("Ошибка переноса корзины", zap.String("от", oldOwnerID), zap.String("к", user.OwnerID), zap.Error(cartErr))
+	if cartErr := h.service.TransferCart(r.Context(), oldOwnerID, user.OwnerID); cartErr != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка переноса корзины", zap.String("от", oldOwnerID), zap.String("к", user.OwnerID), zap.Error(cartErr))
 	}
 
 	middleware.SetOwnerID(w, user.OwnerID)
-	h.logger.Info("Корзина перенесена",
+	tracing.Logger(r.Context(), h.logger).Info("Корзина перенесена",
 		zap.String("от", oldOwnerID),
 		zap.String("к", user.OwnerID),
 	)
 
-	h.logger.Info("Пользователь зарегистрирован", zap.String("phone", user.Phone), zap.String("owner_id", user.OwnerID))
+	tracing.Logger(r.Context(), h.logger).Info("Пользователь зарегистрирован", zap.String("phone", user.Phone), zap.String("owner_id", user.OwnerID))
 	utils.JSONResponse(w, http.StatusCreated, "Регистрация успешна", nil)
 }
 
@@ -95,46 +100,58 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 // @Param        login body LoginRequest true "Телефон/почта и пароль"
 // @Success      200 {object} LoginResponse
 // @Failure      400 {object} utils.ErrorResponse
-// @Failure      401 {object} utils.ErrorResponse
+// @Failure      401 {object} utils.ErrorResponse "Неверные данные для входа — одинаково для неизвестного аккаунта и неверного пароля"
+// @Failure      403 {object} utils.ErrorResponse "Телефон не подтверждён"
+// @Failure      429 {object} utils.ErrorResponse "Вход временно закрыт после неудачных попыток; см. заголовок Retry-After"
 // @Router       /api/login [post]
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Warn("Некорректный JSON при входе", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("Некорректный JSON при входе", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный JSON")
 		return
 	}
 
 	if err := utils.ValidateIdentifier(req.Identifier); err != nil {
-		h.logger.Warn("Некорректный идентификатор", zap.String("identifier", req.Identifier), zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("Некорректный идентификатор", zap.String("identifier", req.Identifier), zap.Error(err))
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	oldOwnerID := middleware.GetOwnerID(w, r)
 
-	user, token, err := h.service.LoginWithUser(services.LoginRequest{
+	user, token, err := h.service.LoginWithUser(r.Context(), services.LoginRequest{
 		Identifier: req.Identifier,
 		Password:   req.Password,
+		IP:         middleware.GetClientIP(r),
+		UserAgent:  r.UserAgent(),
 	})
 	if err != nil {
-		h.logger.Warn("Ошибка входа", zap.String("identifier", req.Identifier), zap.Error(err))
-		if err.Error() == "phone not verified" {
-			utils.ErrorJSON(w, http.StatusForbidden, "Сначала подтвердите номер телефона")
-		} else {
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка входа", zap.String("identifier", req.Identifier), zap.Error(err))
+		var lockErr *services.LoginLockedError
+		switch {
+		case errors.As(err, &lockErr):
+			retryAfter := int((lockErr.RetryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			utils.ErrorJSON(w, http.StatusTooManyRequests, fmt.Sprintf("Слишком много неудачных попыток входа. Повторите через %d с", retryAfter))
+		case errors.Is(err, services.ErrInvalidCredentials):
 			utils.ErrorJSON(w, http.StatusUnauthorized, "Неверные данные для входа")
+		case errors.Is(err, services.ErrPhoneNotVerified):
+			utils.ErrorJSON(w, http.StatusForbidden, "Сначала подтвердите номер телефона")
+		default:
+			utils.ErrorJSON(w, http.StatusInternalServerError, "Не удалось выполнить вход")
 		}
 		return
 	}
 
-	if cartErr := h.service.TransferCart(oldOwnerID, user.OwnerID); cartErr != nil {
-		h.logger.Warn("Ошибка переноса корзины", zap.String("от", oldOwnerID), zap.String("к", user.OwnerID), zap.Error(cartErr))
+	if cartErr := h.service.TransferCart(r.Context(), oldOwnerID, user.OwnerID); cartErr != nil {
+		tracing.Logger(r.Context(), h.logger).Warn("Ошибка переноса корзины", zap.String("от", oldOwnerID), zap.String("к", user.OwnerID), zap.Error(cartErr))
 	}
 
 	middleware.SetOwnerID(w, user.OwnerID)
 
-	h.logger.Info("Пользователь вошёл",
+	tracing.Logger(r.Context(), h.logger).Info("Пользователь вошёл",
 		zap.String("identifier", req.Identifier),
 		zap.String("owner_id", user.OwnerID),
 	)
@@ -147,7 +164,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 // Me — получить профиль текущего пользователя
 // @Summary      Получить профиль пользователя
-// @Description  Возвращает данные профиля для авторизованного пользователя
+// @Description  Возвращает данные профиля для авторизованного пользователя и последние попытки входа в аккаунт
 // @Tags         Профиль
 // @Security     BearerAuth
 // @Produce      json
@@ -162,22 +179,30 @@ func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.GetByID(claims.UserID)
+	user, err := h.service.GetByID(r.Context(), claims.UserID)
 	if err != nil || user == nil {
-		h.logger.Warn("Пользователь не найден", zap.Int("user_id", claims.UserID))
+		tracing.Logger(r.Context(), h.logger).Warn("Пользователь не найден", zap.Int("user_id", claims.UserID))
 		utils.ErrorJSON(w, http.StatusNotFound, "Пользователь не найден")
 		return
 	}
 
-	h.logger.Info("Запрошен профиль пользователя", zap.Int("user_id", user.ID), zap.String("phone", user.Phone))
+	history, err := h.service.GetLoginHistory(r.Context(), user.ID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("Ошибка получения истории входов", zap.Int("user_id", user.ID), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения профиля")
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("Запрошен профиль пользователя", zap.Int("user_id", user.ID), zap.String("phone", user.Phone))
 	utils.JSONResponse(w, http.StatusOK, "Профиль пользователя", map[string]interface{}{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"phone":      user.Phone,
-		"role":       user.Role,
-		"isVerified": user.IsVerified,
-		"owner_id":   user.OwnerID,
+		"id":            user.ID,
+		"username":      user.Username,
+		"email":         user.Email,
+		"phone":         user.Phone,
+		"role":          user.Role,
+		"isVerified":    user.IsVerified,
+		"owner_id":      user.OwnerID,
+		"login_history": history,
 	})
 }
 
@@ -201,7 +226,7 @@ func (h *UserHandler) CreateUserByPhone(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, password, err := h.service.CreateByPhone(req.Phone)
+	user, password, err := h.service.CreateByPhone(r.Context(), req.Phone)
 	if err != nil {
 		utils.ErrorJSON(w, http.StatusBadRequest, err.Error())
 		return
@@ -222,9 +247,9 @@ func (h *UserHandler) CreateUserByPhone(w http.ResponseWriter, r *http.Request)
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /api/admin/users/all [get]
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.service.GetAllUsers()
+	users, err := h.service.GetAllUsers(r.Context())
 	if err != nil {
-		h.logger.Error("не удалось получить пользователей", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("не удалось получить пользователей", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения пользователей")
 		return
 	}
@@ -249,9 +274,9 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.GetUserByID(id)
+	user, err := h.service.GetUserByID(r.Context(), id)
 	if err != nil {
-		h.logger.Warn("пользователь не найден", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Warn("пользователь не найден", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusNotFound, "Пользователь не найден")
 		return
 	}
@@ -287,9 +312,9 @@ func (h *UserHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.UpdateAddress(claims.UserID, payload.Address)
+	err := h.service.UpdateAddress(r.Context(), claims.UserID, payload.Address)
 	if err != nil {
-		h.logger.Error("Ошибка обновления адреса", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Ошибка обновления адреса", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка обновления адреса")
 		return
 	}
@@ -314,9 +339,9 @@ func (h *UserHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	address, err := h.service.GetAddress(claims.UserID)
+	address, err := h.service.GetAddress(r.Context(), claims.UserID)
 	if err != nil {
-		h.logger.Error("Ошибка получения адреса", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Ошибка получения адреса", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка получения адреса")
 		return
 	}
@@ -341,12 +366,45 @@ func (h *UserHandler) ClearAddress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.ClearAddress(claims.UserID)
+	err := h.service.ClearAddress(r.Context(), claims.UserID)
 	if err != nil {
-		h.logger.Error("Ошибка удаления адреса", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Ошибка удаления адреса", zap.Error(err))
 		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка удаления адреса")
 		return
 	}
 
 	utils.JSONResponse(w, http.StatusOK, "Адрес удалён", nil)
 }
+
+// UnlockUser — снять блокировку входа
+// @Summary Снять блокировку входа
+// @Description Снимает блокировку или задержку входа после неудачных попыток и обнуляет их счётчик
+// @Tags Пользователи
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} utils.SuccessResponse "Блокировка снята"
+// @Failure 400 {object} utils.ErrorResponse "Некорректный ID"
+// @Failure 404 {object} utils.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /api/admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный ID")
+		return
+	}
+
+	if err := h.service.UnlockLogin(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorJSON(w, http.StatusNotFound, "Пользователь не найден")
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("Ошибка снятия блокировки входа", zap.Int("user_id", id), zap.Error(err))
+		utils.ErrorJSON(w, http.StatusInternalServerError, "Ошибка снятия блокировки")
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("Блокировка входа снята", zap.Int("user_id", id))
+	utils.JSONResponse(w, http.StatusOK, "Блокировка снята", nil)
+}
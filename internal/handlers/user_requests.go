@@ -1,5 +1,7 @@
 package handlers
 
+import "chechnya-product/internal/models"
+
 // Запрос на регистрацию
 type RegisterRequest struct {
 	Phone    string  `json:"phone"`
@@ -26,4 +28,6 @@ type UserProfileResponse struct {
 	Role       string `json:"role"`
 	IsVerified bool   `json:"isVerified"`
 	OwnerID    string `json:"owner_id"`
+	// Последние попытки входа в аккаунт, новые первыми
+	LoginHistory []models.LoginEvent `json:"login_history"`
 }
@@ -0,0 +1,183 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image is too large")
+	ErrInvalidImage    = errors.New("failed to decode image")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// Размеры (по длинной стороне) генерируемых вариантов изображения
+const (
+	OriginalMaxSide = 1600
+	MediumMaxSide   = 800
+	ThumbMaxSide    = 300
+
+	jpegQuality = 85
+
+	// Ограничения размеров исходника в пикселях. Маленький PNG может объявить 50000×50000
+	// и при декодировании занять гигабайты памяти, поэтому размеры проверяются по заголовку.
+	MaxSourceSide   = 10000
+	MaxSourcePixels = 40_000_000
+)
+
+// Допустимые MIME-типы, определяемые по содержимому файла, а не по заголовку клиента
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Variant — одна перекодированная версия изображения
+type Variant struct {
+	Data        []byte
+	Ext         string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Processed — результат обработки загруженного изображения
+type Processed struct {
+	Original Variant
+	Medium   Variant
+	Thumb    Variant
+}
+
+// Process читает не более maxSize байт, проверяет тип по сигнатуре,
+// по заголовку отсекает изображения больше MaxSourceSide / MaxSourcePixels,
+// декодирует и заново кодирует изображение (это удаляет EXIF и прочие метаданные)
+// и генерирует уменьшенные копии. Поворот из EXIF Orientation применяется к пикселям,
+// иначе после удаления метаданных снимки с телефона оказались бы повёрнуты.
+func Process(r io.Reader, maxSize int64) (*Processed, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > MaxSourceSide || config.Height > MaxSourceSide ||
+		int64(config.Width)*int64(config.Height) > MaxSourcePixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var src image.Image
+	if contentType == "image/gif" {
+		// Для GIF берём только первый кадр
+		src, err = gif.Decode(bytes.NewReader(data))
+	} else {
+		src, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	// Поворачиваются уже уменьшенные копии: длинная сторона от поворота не меняется, а пикселей меньше
+	original, err := encode(orient(resize(src, OriginalMaxSide), orientation))
+	if err != nil {
+		return nil, err
+	}
+	medium, err := encode(orient(resize(src, MediumMaxSide), orientation))
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := encode(orient(resize(src, ThumbMaxSide), orientation))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processed{Original: *original, Medium: *medium, Thumb: *thumb}, nil
+}
+
+// resize уменьшает изображение так, чтобы длинная сторона не превышала maxSide.
+// Маленькие изображения не увеличиваются.
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+
+	if w >= h {
+		h = h * maxSide / w
+		w = maxSide
+	} else {
+		w = w * maxSide / h
+		h = maxSide
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+// encode сохраняет непрозрачные изображения в JPEG, а изображения с альфа-каналом — в PNG
+func encode(img image.Image) (*Variant, error) {
+	var buf bytes.Buffer
+	v := &Variant{
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	if isOpaque(img) {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		v.Ext = ".jpg"
+		v.ContentType = "image/jpeg"
+	} else {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+		v.Ext = ".png"
+		v.ContentType = "image/png"
+	}
+
+	v.Data = buf.Bytes()
+	return v, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// pngDeclaring возвращает маленький PNG, в заголовке IHDR которого объявлены размеры width×height
+func pngDeclaring(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Сигнатура (8 байт), длина чанка (4), тип "IHDR" (4), затем ширина и высота; CRC — после 13 байт данных
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestProcessRejectsDecompressionBomb(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height uint32
+	}{
+		{"huge both sides", 50000, 50000},
+		{"side over limit", MaxSourceSide + 1, 10},
+		{"too many pixels", 9000, 9000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process(bytes.NewReader(pngDeclaring(t, tt.width, tt.height)), 1<<20)
+			if !errors.Is(err, ErrTooManyPixels) {
+				t.Fatalf("err = %v, want ErrTooManyPixels", err)
+			}
+		})
+	}
+}
+
+func TestProcessResizesVariants(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2000, 1000))); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := Process(&buf, 10<<20)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	for _, v := range []struct {
+		variant Variant
+		side    int
+	}{
+		{processed.Original, OriginalMaxSide},
+		{processed.Medium, MediumMaxSide},
+		{processed.Thumb, ThumbMaxSide},
+	} {
+		if v.variant.Width != v.side || v.variant.Height != v.side/2 {
+			t.Errorf("variant %dx%d, want %dx%d", v.variant.Width, v.variant.Height, v.side, v.side/2)
+		}
+	}
+}
+
+// quadrants — кадр 80×40 с четвертями разного цвета: по ним видно, как изображение повёрнуто
+func quadrants() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 80; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			switch {
+			case x >= 40 && y < 20:
+				c = color.RGBA{0, 255, 0, 255}
+			case x < 40 && y >= 20:
+				c = color.RGBA{0, 0, 255, 255}
+			case x >= 40 && y >= 20:
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// jpegWithOrientation кодирует quadrants в JPEG и добавляет EXIF (big-endian) с тегом Orientation
+func jpegWithOrientation(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, quadrants(), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// colorName определяет цвет четверти с учётом потерь JPEG
+func colorName(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	high := func(v uint32) bool { return v > 0x8000 }
+	switch {
+	case high(r) && high(g) && high(b):
+		return "white"
+	case high(r):
+		return "red"
+	case high(g):
+		return "green"
+	case high(b):
+		return "blue"
+	}
+	return "unknown"
+}
+
+// cornerColors — цвета левой и правой верхних четвертей варианта
+func cornerColors(t *testing.T, v Variant) (string, string) {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(v.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	return colorName(img.At(b.Dx()/4, b.Dy()/4)), colorName(img.At(b.Dx()*3/4, b.Dy()/4))
+}
+
+func TestProcessAppliesExifOrientation(t *testing.T) {
+	tests := []struct {
+		orientation   uint16
+		width, height int
+		left, right   string
+	}{
+		{1, 80, 40, "red", "green"},
+		{2, 80, 40, "green", "red"},
+		{3, 80, 40, "white", "blue"},
+		{4, 80, 40, "blue", "white"},
+		{5, 40, 80, "red", "blue"},
+		{6, 40, 80, "blue", "red"},
+		{7, 40, 80, "white", "green"},
+		{8, 40, 80, "green", "white"},
+	}
+	for _, tt := range tests {
+		processed, err := Process(bytes.NewReader(jpegWithOrientation(t, tt.orientation)), 1<<20)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		for _, v := range []Variant{processed.Original, processed.Medium, processed.Thumb} {
+			if v.Width != tt.width || v.Height != tt.height {
+				t.Fatalf("orientation %d: %dx%d, want %dx%d", tt.orientation, v.Width, v.Height, tt.width, tt.height)
+			}
+			if left, right := cornerColors(t, v); left != tt.left || right != tt.right {
+				t.Fatalf("orientation %d: top corners %s/%s, want %s/%s", tt.orientation, left, right, tt.left, tt.right)
+			}
+		}
+	}
+}
+
+// testdata/orientation6.jpg — кадр quadrants, записанный «лёжа», с little-endian EXIF Orientation=6,
+// как его сохраняет телефон, повёрнутый на 90°
+func TestProcessOrientedPhoto(t *testing.T) {
+	data, err := os.ReadFile("testdata/orientation6.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("orientation = %d, want 6", got)
+	}
+
+	processed, err := Process(bytes.NewReader(data), 1<<20)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if processed.Original.Width != 40 || processed.Original.Height != 80 {
+		t.Fatalf("original %dx%d, want 40x80", processed.Original.Width, processed.Original.Height)
+	}
+	if left, right := cornerColors(t, processed.Original); left != "blue" || right != "red" {
+		t.Fatalf("top corners %s/%s, want blue/red", left, right)
+	}
+}
+
+func TestJpegOrientationIgnoresBrokenExif(t *testing.T) {
+	valid := jpegWithOrientation(t, 6)
+	for name, data := range map[string][]byte{
+		"no exif":        valid[:2],
+		"truncated":      valid[:30],
+		"bad byte order": bytes.Replace(valid, []byte("MM"), []byte("XX"), 1),
+		"not jpeg":       []byte("GIF89a"),
+	} {
+		if got := jpegOrientation(data); got != 1 {
+			t.Errorf("%s: orientation = %d, want 1", name, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientationTag — тег Orientation в IFD0: как повернуть сохранённые пиксели для показа.
+// Телефоны пишут кадр как есть с сенсора и отмечают поворот этим тегом.
+const exifOrientationTag = 0x0112
+
+// jpegOrientation возвращает значение EXIF Orientation (1–8) из JPEG; 1 — тега нет или он повреждён
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Заполняющий байт перед маркером
+			i++
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			// Дальше конец файла или сжатые данные: EXIF идёт раньше
+			return 1
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			i += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation ищет Orientation в IFD0 TIFF-заголовка EXIF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd+2 > int64(len(tiff)) {
+		return 1
+	}
+	count := int64(order.Uint16(tiff[ifd:]))
+	for n := int64(0); n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > int64(len(tiff)) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		// Значение типа SHORT лежит прямо в поле значения записи
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient поворачивает и отражает изображение по значению EXIF Orientation так,
+// чтобы оно выглядело, как его показывает камера. Для 5–8 ширина и высота меняются местами.
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				sx, sy = w-1-x, y
+			case 3: // поворот на 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // отражение по вертикали
+				sx, sy = x, h-1-y
+			case 5: // отражение относительно главной диагонали
+				sx, sy = y, x
+			case 6: // поворот на 90° по часовой
+				sx, sy = y, h-1-x
+			case 7: // отражение относительно побочной диагонали
+				sx, sy = w-1-y, h-1-x
+			case 8: // поворот на 90° против часовой
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+	"path/filepath"
 	"time"
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
@@ -8,11 +10,29 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// Dir — каталог файлов журнала. Записи пишутся JSON-строками в файлы по виду и дате:
+// info (INFO и WARN), error (ERROR и выше) и debug, например logs/info.2026-10-19.log.
+const Dir = "logs"
+
+// MaxAge — сколько хранятся файлы журнала
+const MaxAge = 7 * 24 * time.Hour
+
+// FileKinds — виды файлов журнала
+var FileKinds = []string{"debug", "info", "error"}
+
+// TimeLayout — формат поля time в записях (zapcore.ISO8601TimeEncoder)
+const TimeLayout = "2006-01-02T15:04:05.000Z0700"
+
+// FilePath возвращает путь к файлу журнала вида kind за день date (по местному времени, как у rotatelogs)
+func FilePath(kind string, date time.Time) string {
+	return filepath.Join(Dir, fmt.Sprintf("%s.%s.log", kind, date.Format("2006-01-02")))
+}
+
 func NewLogger() (*zap.Logger, error) {
 	// 📂 Файлы для info, error, debug
 	infoWriter, err := rotatelogs.New(
-		"logs/info.%Y-%m-%d.log",
-		rotatelogs.WithMaxAge(7*24*time.Hour),
+		filepath.Join(Dir, "info.%Y-%m-%d.log"),
+		rotatelogs.WithMaxAge(MaxAge),
 		rotatelogs.WithRotationTime(24*time.Hour),
 	)
 	if err != nil {
@@ -20,8 +40,8 @@ func NewLogger() (*zap.Logger, error) {
 	}
 
 	errorWriter, err := rotatelogs.New(
-		"logs/error.%Y-%m-%d.log",
-		rotatelogs.WithMaxAge(7*24*time.Hour),
+		filepath.Join(Dir, "error.%Y-%m-%d.log"),
+		rotatelogs.WithMaxAge(MaxAge),
 		rotatelogs.WithRotationTime(24*time.Hour),
 	)
 	if err != nil {
@@ -29,8 +49,8 @@ func NewLogger() (*zap.Logger, error) {
 	}
 
 	debugWriter, err := rotatelogs.New(
-		"logs/debug.%Y-%m-%d.log",
-		rotatelogs.WithMaxAge(7*24*time.Hour),
+		filepath.Join(Dir, "debug.%Y-%m-%d.log"),
+		rotatelogs.WithMaxAge(MaxAge),
 		rotatelogs.WithRotationTime(24*time.Hour),
 	)
 	if err != nil {
@@ -0,0 +1,84 @@
+// Package metrics — метрики Prometheus, которые отдаются на /metrics.
+// Коллекторы регистрируются в стандартном реестре вместе с метриками Go-рантайма и процесса.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "chechnya"
+
+var (
+	// HTTPRequestDuration — время обработки запроса по шаблону маршрута mux, а не по фактическому пути,
+	// чтобы ID в URL не плодили временные ряды
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Время обработки HTTP-запроса.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Запросы, которые обрабатываются прямо сейчас.",
+	})
+
+	// DBQueryDuration — время запроса к Postgres до получения результата (без чтения строк),
+	// repository и method — метод репозитория, из которого выполнен запрос
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Время выполнения SQL-запроса по методам репозиториев.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "method", "status"})
+
+	// CacheRequests — обращения к кэшу в RedisCache.GetOrSet; cache — префикс ключа до двоеточия
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Обращения к кэшу Redis: hit, miss или error.",
+	}, []string{"cache", "result"})
+
+	// RateLimitRejections — запросы, отклонённые лимитом с ответом 429, по шаблону маршрута
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Запросы, отклонённые ограничением частоты.",
+	}, []string{"method", "route"})
+
+	WebSocketClients = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "clients",
+		Help:      "Подключённые WebSocket-клиенты по ролям.",
+	}, []string{"role"})
+
+	// PushNotifications — результат отправки: sent, failed или expired (подписка удалена)
+	PushNotifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "push",
+		Name:      "notifications_total",
+		Help:      "Отправленные web push-уведомления по результату.",
+	}, []string{"result"})
+
+	OrdersPlaced = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "placed_total",
+		Help:      "Оформленные заказы.",
+	})
+
+	OrderRevenue = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "revenue_rubles_total",
+		Help:      "Сумма оформленных заказов в рублях.",
+	})
+)
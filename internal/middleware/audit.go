@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"chechnya-product/internal/models"
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxAuditBody — сколько байт тела запроса и ответа сохраняется для журнала
+const maxAuditBody = 64 << 10
+
+// AuditRecorder снимает состояние сущностей и пишет журнал действий
+type AuditRecorder interface {
+	Snapshot(ctx context.Context, entityType, entityID string) json.RawMessage
+	Record(ctx context.Context, entry models.AuditEntry, before, after json.RawMessage)
+}
+
+// auditEntityTypes — первый сегмент админского маршрута и тип сущности в журнале
+var auditEntityTypes = map[string]string{
+	"products":      "product",
+	"categories":    "category",
+	"orders":        "order",
+	"users":         "user",
+	"reviews":       "review",
+	"announcements": "announcement",
+	"upload":        "file",
+	"truncate":      "table",
+	"backups":       "backup",
+}
+
+// AuditMiddleware записывает в журнал каждый изменяющий запрос администратора:
+// кто, что и над какой сущностью сделал, состояние до и после, IP и request id.
+// Подключается после JWTMiddleware, чтобы был известен администратор.
+func AuditMiddleware(recorder AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			template := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					template = t
+				}
+			}
+			template = strings.TrimPrefix(template, "/api/admin")
+
+			body := readAuditBody(r)
+			entityType, entityID := auditEntity(template, mux.Vars(r), body)
+
+			var before json.RawMessage
+			if entityID != "" {
+				before = recorder.Snapshot(r.Context(), entityType, entityID)
+			}
+
+			rec := &auditResponseWriter{ResponseWriter: w, status: http.StatusOK, capture: entityID == ""}
+			next.ServeHTTP(rec, r)
+
+			// При создании id новой сущности известен только из ответа
+			if entityID == "" && rec.status < http.StatusMultipleChoices {
+				entityID = createdID(rec.body.Bytes())
+			}
+
+			// Изменение уже выполнено, поэтому обрыв соединения клиентом не должен помешать записи в журнал
+			ctx := context.WithoutCancel(r.Context())
+			after := before
+			if entityID != "" && rec.status < http.StatusBadRequest {
+				after = recorder.Snapshot(ctx, entityType, entityID)
+			}
+
+			entry := models.AuditEntry{
+				Action:     r.Method + " " + template,
+				EntityType: entityType,
+				Request:    body,
+				Status:     rec.status,
+				IP:         GetClientIP(r),
+				RequestID:  GetRequestID(r),
+			}
+			if userID := GetUserID(r); userID > 0 {
+				entry.ActorID = &userID
+			}
+			if entityID != "" {
+				entry.EntityID = &entityID
+			}
+			recorder.Record(ctx, entry, before, after)
+		})
+	}
+}
+
+// readAuditBody читает JSON-тело запроса и возвращает его обработчику нетронутым.
+// Файлы и слишком большие тела в журнал не попадают.
+func readAuditBody(r *http.Request) json.RawMessage {
+	contentType := r.Header.Get("Content-Type")
+	if r.Body == nil || (contentType != "" && !strings.HasPrefix(contentType, "application/json")) {
+		return nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buf), r.Body))
+	if err != nil || len(buf) > maxAuditBody || !json.Valid(buf) {
+		return nil
+	}
+	return buf
+}
+
+// auditEntity определяет тип и id сущности по шаблону маршрута, например "/products/{id}/images"
+func auditEntity(template string, vars map[string]string, body json.RawMessage) (string, string) {
+	segment := strings.SplitN(strings.TrimPrefix(template, "/"), "/", 2)[0]
+
+	entityType, ok := auditEntityTypes[segment]
+	if !ok {
+		entityType = segment
+	}
+	// Восстановление из корзины: тип сущности указан в пути
+	if t := vars["type"]; t != "" {
+		entityType = t
+	}
+
+	switch {
+	case vars["id"] != "":
+		return entityType, vars["id"]
+	case vars["filename"] != "":
+		return entityType, vars["filename"]
+	case vars["name"] != "":
+		return entityType, vars["name"]
+	case segment == "truncate" && len(body) > 0:
+		var req struct {
+			Table string `json:"table"`
+		}
+		if json.Unmarshal(body, &req) == nil {
+			return entityType, req.Table
+		}
+	}
+	return entityType, ""
+}
+
+// createdID достаёт data.id из ответа вида {"message": ..., "data": {"id": 1}}
+func createdID(body []byte) string {
+	var resp struct {
+		Data struct {
+			ID json.Number `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
+	}
+	return resp.Data.ID.String()
+}
+
+// auditResponseWriter запоминает код ответа и, если нужно, начало тела
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	capture bool
+	body    bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.capture && w.body.Len() < maxAuditBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IdempotencyKeyHeader — ключ, которым клиент помечает повторы одного и того же запроса
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader выставляется в ответе, повторённом из сохранённого
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLength = 255
+
+// MaxJSONBody — предел JSON-тела для запросов под IdempotencyMiddleware; обработчики этих маршрутов
+// ограничивают тело тем же значением, поэтому запрос без ключа не проходит дальше, чем с ключом
+const MaxJSONBody = 5 << 20
+
+// maxIdempotentResponse — ответы больше этого не сохраняются, повтор такого запроса выполнится заново
+const maxIdempotentResponse = 1 << 20
+
+// IdempotencyStore хранит ответы на запросы с Idempotency-Key; реализуется cache.RedisCache
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) ([]byte, bool, error)
+	SaveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// idempotencyRecord — первый запрос с ключом: отпечаток тела и, когда он выполнен, его ответ
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	// Status 0 — первый запрос ещё выполняется
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyMiddleware защищает POST-запрос от повторного выполнения, если клиент прислал Idempotency-Key:
+// первый ответ хранится ttl и возвращается на повторы без вызова обработчика. Тот же ключ с другим телом
+// даёт 422, повтор во время выполнения первого запроса — 409, тело больше MaxJSONBody — 413. Ответы 5xx не сохраняются, чтобы запрос
+// можно было повторить. Ключи разделены по пользователю (гостю) и маршруту.
+// pendingTTL — сколько ключ считается занятым выполняющимся запросом, не меньше таймаута записи ответа.
+// Если хранилище недоступно, запрос выполняется как без ключа.
+func IdempotencyMiddleware(store IdempotencyStore, ttl, pendingTTL time.Duration, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				utils.ErrorJSON(w, http.StatusBadRequest, "Некорректный Idempotency-Key: ожидается до 255 печатных символов ASCII")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxJSONBody))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					utils.ErrorJSON(w, http.StatusRequestEntityTooLarge, "Тело запроса слишком большое")
+					return
+				}
+				utils.ErrorJSON(w, http.StatusBadRequest, "Не удалось прочитать тело запроса")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			log := tracing.Logger(r.Context(), logger)
+			storeKey := idempotencyScope(r) + ":" + r.Method + " " + routeTemplate(r) + ":" + key
+			pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+
+			existing, reserved, err := store.ReserveIdempotencyKey(r.Context(), storeKey, pending, pendingTTL)
+			if err != nil {
+				log.Warn("Idempotency store unavailable, request processed without key", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !reserved {
+				replayIdempotent(w, existing, fingerprint)
+				return
+			}
+
+			// Ответ уже начал уходить клиенту, поэтому ключ сохраняется и освобождается без учёта отмены запроса
+			ctx := context.WithoutCancel(r.Context())
+			rec := &idempotencyResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Паника в обработчике: освобождаем ключ, иначе повторы получали бы 409 до истечения pendingTTL
+				if !completed {
+					_ = store.ReleaseIdempotencyKey(ctx, storeKey)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+			completed = true
+
+			if rec.status >= http.StatusInternalServerError || rec.overflow {
+				if err := store.ReleaseIdempotencyKey(ctx, storeKey); err != nil {
+					log.Warn("Failed to release idempotency key", zap.Error(err))
+				}
+				return
+			}
+
+			record, _ := json.Marshal(idempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Location:    rec.Header().Get("Location"),
+				Body:        rec.body.Bytes(),
+			})
+			if err := store.SaveIdempotencyKey(ctx, storeKey, record, ttl); err != nil {
+				log.Warn("Failed to save idempotent response", zap.Error(err))
+			}
+		})
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса по записи первого
+func replayIdempotent(w http.ResponseWriter, existing []byte, fingerprint string) {
+	var record idempotencyRecord
+	// Пустая запись — ключ освободился между проверками, первый запрос завершился ошибкой
+	if existing != nil && json.Unmarshal(existing, &record) == nil && record.Fingerprint != fingerprint {
+		utils.ErrorJSON(w, http.StatusUnprocessableEntity, "Idempotency-Key уже использован для запроса с другим телом")
+		return
+	}
+	if record.Status == 0 {
+		w.Header().Set("Retry-After", "1")
+		utils.ErrorJSON(w, http.StatusConflict, "Запрос с этим Idempotency-Key ещё выполняется, повторите позже")
+		return
+	}
+
+	h := w.Header()
+	if record.ContentType != "" {
+		h.Set("Content-Type", record.ContentType)
+	}
+	if record.Location != "" {
+		h.Set("Location", record.Location)
+	}
+	h.Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// idempotencyScope разделяет ключи разных клиентов: один клиент не может получить чужой ответ, угадав ключ
+func idempotencyScope(r *http.Request) string {
+	if userID := GetUserID(r); userID != 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	if ownerID := peekOwnerID(r); ownerID != "" {
+		return "owner:" + ownerID
+	}
+	return "ip:" + GetClientIP(r)
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyResponseWriter копирует ответ для сохранения; overflow — ответ слишком большой и не сохраняется
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *idempotencyResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxIdempotentResponse {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
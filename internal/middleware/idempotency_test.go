@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// memIdempotencyStore — ключи в памяти, как в Redis (без истечения)
+type memIdempotencyStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (s *memIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key string, value []byte, _ time.Duration) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.values[key]; ok {
+		return existing, false, nil
+	}
+	s.values[key] = value
+	return nil, true, nil
+}
+
+func (s *memIdempotencyStore) SaveIdempotencyKey(_ context.Context, key string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *memIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	return nil
+}
+
+type idempotencyFixture struct {
+	router *mux.Router
+	calls  atomic.Int32
+	// status — код ответа обработчика; block — если задан, обработчик ждёт его закрытия
+	status int
+	block  chan struct{}
+	begun  chan struct{}
+}
+
+func newIdempotencyFixture() *idempotencyFixture {
+	f := &idempotencyFixture{router: mux.NewRouter(), status: http.StatusCreated}
+	idempotent := IdempotencyMiddleware(&memIdempotencyStore{values: map[string][]byte{}}, time.Hour, time.Minute, zap.NewNop())
+	f.router.Handle("/order", idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := f.calls.Add(1)
+		if f.block != nil {
+			f.begun <- struct{}{}
+			<-f.block
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		w.Write([]byte(`{"order":` + strconv.Itoa(int(n)) + `}`))
+	}))).Methods(http.MethodPost)
+	return f
+}
+
+func (f *idempotencyFixture) post(key, owner, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if owner != "" {
+		req.Header.Set(OwnerHeaderName, owner)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	f := newIdempotencyFixture()
+
+	first := f.post("key-1", "guest-1", `{"items":[1]}`)
+	second := f.post("key-1", "guest-1", `{"items":[1]}`)
+
+	if f.calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", f.calls.Load())
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatal("only the replayed response must carry Idempotent-Replayed")
+	}
+	if got := second.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("replay Content-Type = %q", got)
+	}
+
+	// Тот же ключ другого покупателя и запрос без ключа выполняются заново
+	f.post("key-1", "guest-2", `{"items":[1]}`)
+	f.post("", "guest-1", `{"items":[1]}`)
+	if f.calls.Load() != 3 {
+		t.Fatalf("handler calls = %d, want 3", f.calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	f := newIdempotencyFixture()
+
+	f.post("key-1", "guest-1", `{"items":[1]}`)
+	rec := f.post("key-1", "guest-1", `{"items":[2]}`)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	if f.calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", f.calls.Load())
+	}
+}
+
+func TestIdempotencyConflictWhilePending(t *testing.T) {
+	f := newIdempotencyFixture()
+	f.block, f.begun = make(chan struct{}), make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- f.post("key-1", "guest-1", `{}`) }()
+	<-f.begun
+
+	rec := f.post("key-1", "guest-1", `{}`)
+	if rec.Code != http.StatusConflict || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q; want 409 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	close(f.block)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first request status = %d, want 201", first.Code)
+	}
+	f.block = nil
+	if rec := f.post("key-1", "guest-1", `{}`); rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("after completion: status = %d, want replayed 201", rec.Code)
+	}
+}
+
+func TestIdempotencyServerErrorIsNotStored(t *testing.T) {
+	f := newIdempotencyFixture()
+	f.status = http.StatusInternalServerError
+
+	f.post("key-1", "guest-1", `{}`)
+	f.status = http.StatusCreated
+	if rec := f.post("key-1", "guest-1", `{}`); rec.Code != http.StatusCreated || f.calls.Load() != 2 {
+		t.Fatalf("retry after 5xx: status = %d, calls = %d; want 201 and 2 calls", rec.Code, f.calls.Load())
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	f := newIdempotencyFixture()
+
+	rec := f.post("key-1", "guest-1", strings.Repeat("x", MaxJSONBody+1))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+	if f.calls.Load() != 0 {
+		t.Fatal("handler must not run for an oversized body")
+	}
+	// Ключ не занят: запрос нормального размера выполняется
+	if rec := f.post("key-1", "guest-1", `{}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", rec.Code)
+	}
+}
@@ -24,6 +24,7 @@ func JWTMiddleware(jwt utils.JWTManagerInterface) func(http.Handler) http.Handle
 				return
 			}
 
+			rememberUser(r, claims.UserID)
 			ctx := context.WithValue(r.Context(), userClaimsKey, claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -58,6 +59,7 @@ func OptionalJWTMiddleware(jwt utils.JWTManagerInterface) func(http.Handler) htt
 				tokenStr := strings.TrimPrefix(auth, "Bearer ")
 				claims, err := jwt.Verify(tokenStr)
 				if err == nil && claims != nil {
+					rememberUser(r, claims.UserID)
 					ctx := context.WithValue(r.Context(), userClaimsKey, claims)
 					r = r.WithContext(ctx)
 				}
@@ -1,30 +1,59 @@
 package middleware
 
 import (
+	"chechnya-product/internal/tracing"
 	"go.uber.org/zap"
 	"net/http"
 	"time"
 )
 
+// LoggerMiddleware пишет журнал запросов: маршрут, код ответа, размер ответа, время обработки,
+// пользователя и владельца корзины. request_id и trace_id добавляет tracing.Logger.
+// Подключается снаружи RecoveryMiddleware, чтобы в журнал попадали и ответы 500 после паники.
 func LoggerMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 
-			// Получаем owner_id (user_123 или guest_xxx)
-			ownerID := GetOwnerID(w, r)
+			next.ServeHTTP(rec, r)
 
-			next.ServeHTTP(w, r)
-
-			// Логируем с owner_id
-			logger.Info("HTTP Request",
+			status := rec.status
+			if rec.hijacked {
+				status = http.StatusSwitchingProtocols
+			}
+			fields := []zap.Field{
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
-				zap.String("ip", r.RemoteAddr),
-				zap.String("user_agent", r.UserAgent()),
-				zap.String("owner_id", ownerID),
+				zap.String("route", routeTemplate(r)),
+				zap.Int("status", status),
+				zap.Int64("bytes", rec.bytes),
 				zap.Duration("duration", time.Since(start)),
-			)
+				zap.String("ip", GetClientIP(r)),
+				zap.String("user_agent", r.UserAgent()),
+			}
+
+			// Пользователь и владелец корзины известны только обработчику, поэтому берутся из requestInfo;
+			// если обработчик владельца не определял, он читается из cookie без создания гостевого id
+			ownerID := peekOwnerID(r)
+			if info := getRequestInfo(r); info != nil {
+				if info.userID > 0 {
+					fields = append(fields, zap.Int("user_id", info.userID))
+				}
+				if info.ownerID != "" {
+					ownerID = info.ownerID
+				}
+			}
+			if ownerID != "" {
+				fields = append(fields, zap.String("owner_id", ownerID))
+			}
+
+			log := tracing.Logger(r.Context(), logger)
+			if status >= http.StatusInternalServerError {
+				log.Warn("HTTP Request", fields...)
+				return
+			}
+			log.Info("HTTP Request", fields...)
 		})
 	}
 }
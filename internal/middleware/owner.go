@@ -12,6 +12,20 @@ const OwnerHeaderName = "X-Owner-ID"
 // GetOwnerID определяет ID владельца корзины: user или guest.
 // Всегда сохраняет owner_id в cookie, если он был получен.
 func GetOwnerID(w http.ResponseWriter, r *http.Request) string {
+	ownerID := resolveOwnerID(w, r)
+	rememberOwner(r, ownerID)
+	return ownerID
+}
+
+// peekOwnerID — owner_id из cookie или заголовка без создания гостевого id и установки cookie
+func peekOwnerID(r *http.Request) string {
+	if cookie, err := r.Cookie(OwnerCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return r.Header.Get(OwnerHeaderName)
+}
+
+func resolveOwnerID(w http.ResponseWriter, r *http.Request) string {
 	// 1. Если пользователь авторизован — user_x
 	userID := GetUserID(r)
 	if userID != 0 {
@@ -1,100 +1,88 @@
 package middleware
 
 import (
-	"net"
+	"chechnya-product/config"
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/metrics"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
 	"net/http"
-	"strings"
-	"sync"
+	"strconv"
 	"time"
-
-	"golang.org/x/time/rate"
 )
 
-type visitor struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// RateLimiter ведёт скользящие окна запросов; реализуется cache.RedisCache, поэтому лимиты общие для всех реплик
+type RateLimiter interface {
+	AllowRate(ctx context.Context, key string, limit int, window time.Duration) (cache.RateLimitResult, error)
 }
 
-var visitors = make(map[string]map[string]*visitor)
-var mu sync.Mutex
-
-var pathLimits = map[string]*rate.Limiter{
-	"/api/login":     rate.NewLimiter(0.1, 3),
-	"/api/register":  rate.NewLimiter(0.1, 3),
-	"/api/cart":      rate.NewLimiter(1, 2),
-	"/api/cart/bulk": rate.NewLimiter(0.5, 1),
-}
+// RateLimitMiddleware ограничивает частоту запросов по политикам из RATE_LIMITS. Окно ведётся отдельно
+// для каждого маршрута (по шаблону mux, а не по пути, чтобы /products/1 и /products/2 делили лимит)
+// и каждого клиента: авторизованного — по id пользователя, гостя — по IP.
+// Подключается после OptionalJWTMiddleware. Если Redis недоступен, запросы пропускаются.
+func RateLimitMiddleware(limiter RateLimiter, policies []config.RateLimitPolicy, logger *zap.Logger) func(http.Handler) http.Handler {
+	byRoute := make(map[string]config.RateLimitPolicy, len(policies))
+	for _, policy := range policies {
+		byRoute[policy.Method+" "+policy.Route] = policy
+	}
 
-func cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute)
-		mu.Lock()
-		for ip, pathMap := range visitors {
-			for path, v := range pathMap {
-				if time.Since(v.lastSeen) > 3*time.Minute {
-					delete(pathMap, path)
-				}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			policy, ok := findRateLimitPolicy(byRoute, r.Method, route)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
 			}
-			if len(pathMap) == 0 {
-				delete(visitors, ip)
+
+			key := r.Method + " " + route + ":" + rateLimitSubject(r)
+			result, err := limiter.AllowRate(r.Context(), key, policy.Limit, policy.Window)
+			if err != nil {
+				tracing.Logger(r.Context(), logger).Warn("Rate limiter unavailable, request allowed", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
 			}
-		}
-		mu.Unlock()
-	}
-}
 
-func init() {
-	go cleanupVisitors()
-}
+			reset := ceilSeconds(result.Reset)
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(reset))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+
+			if !result.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(r.Method, route).Inc()
+				h.Set("Retry-After", strconv.Itoa(reset))
+				utils.ErrorJSON(w, http.StatusTooManyRequests, "Too many requests")
+				return
+			}
 
-func getIP(r *http.Request) string {
-	ip := r.Header.Get("X-Real-IP")
-	if ip == "" {
-		ip = r.Header.Get("X-Forwarded-For")
-	}
-	if ip == "" {
-		ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+			next.ServeHTTP(w, r)
+		})
 	}
-	return strings.TrimSpace(ip)
 }
 
-func getLimiter(ip, path string) *rate.Limiter {
-	mu.Lock()
-	defer mu.Unlock()
-
-	if _, ok := visitors[ip]; !ok {
-		visitors[ip] = make(map[string]*visitor)
-	}
-
-	if v, ok := visitors[ip][path]; ok {
-		v.lastSeen = time.Now()
-		return v.limiter
-	}
-
-	limit, ok := pathLimits[path]
-	if !ok {
-		limit = rate.NewLimiter(1, 5)
+// findRateLimitPolicy выбирает самую точную политику: метод и маршрут, маршрут, затем политики по умолчанию "*"
+func findRateLimitPolicy(byRoute map[string]config.RateLimitPolicy, method, route string) (config.RateLimitPolicy, bool) {
+	for _, key := range []string{method + " " + route, " " + route, method + " *", " *"} {
+		if policy, ok := byRoute[key]; ok {
+			return policy, true
+		}
 	}
+	return config.RateLimitPolicy{}, false
+}
 
-	visitors[ip][path] = &visitor{
-		limiter:  rate.NewLimiter(limit.Limit(), limit.Burst()),
-		lastSeen: time.Now(),
+func rateLimitSubject(r *http.Request) string {
+	if userID := GetUserID(r); userID != 0 {
+		return "user:" + strconv.Itoa(userID)
 	}
-	return visitors[ip][path].limiter
+	return "ip:" + GetClientIP(r)
 }
 
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := getIP(r)
-		path := r.URL.Path
-
-		limiter := getLimiter(ip, path)
-
-		if !limiter.Allow() {
-			http.Error(w, "429 - Too Many Requests", http.StatusTooManyRequests)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+// ceilSeconds округляет вверх: клиент, повторивший запрос через Retry-After секунд, не должен снова получить 429
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"net/http"
 	"runtime/debug"
@@ -16,7 +17,7 @@ func RecoveryMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 					ownerID := GetOwnerID(w, r)
 
 					// Логируем с полной трассировкой
-					logger.Error("Panic recovered",
+					tracing.Logger(r.Context(), logger).Error("Panic recovered",
 						zap.Any("error", err),
 						zap.String("path", r.URL.Path),
 						zap.String("method", r.Method),
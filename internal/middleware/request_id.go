@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"chechnya-product/internal/tracing"
+	"context"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// RequestIDHeader — заголовок с id запроса. Id от клиента или прокси сохраняется, иначе создаётся новый.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength — входящий id длиннее или с посторонними символами заменяется новым,
+// чтобы в журнал не попадал произвольный текст
+const maxRequestIDLength = 64
+
+const requestInfoKey contextKey = "request_info"
+
+// requestInfo — сведения, которые выясняют внутренние обработчики (пользователь из JWT,
+// владелец корзины), а нужны журналу запросов, работающему снаружи
+type requestInfo struct {
+	userID  int
+	ownerID string
+}
+
+// RequestIDMiddleware присваивает запросу id: отдаёт его в заголовке X-Request-ID и кладёт в контекст,
+// откуда tracing.Logger добавляет его ко всем записям журнала этого запроса.
+// Подключается к роутеру первым.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := tracing.WithRequestID(r.Context(), id)
+		ctx = context.WithValue(ctx, requestInfoKey, &requestInfo{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID возвращает id текущего запроса или пустую строку вне RequestIDMiddleware
+func GetRequestID(r *http.Request) string {
+	return tracing.RequestID(r.Context())
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func getRequestInfo(r *http.Request) *requestInfo {
+	info, _ := r.Context().Value(requestInfoKey).(*requestInfo)
+	return info
+}
+
+// rememberUser и rememberOwner передают журналу запросов пользователя и владельца корзины
+func rememberUser(r *http.Request, userID int) {
+	if info := getRequestInfo(r); info != nil {
+		info.userID = userID
+	}
+}
+
+func rememberOwner(r *http.Request, ownerID string) {
+	if info := getRequestInfo(r); info != nil {
+		info.ownerID = ownerID
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Confirmation — одноразовый токен подтверждения опасной операции (очистка таблиц, восстановление из копии).
+// Токен выдаётся на первый запрос и действует только для того же администратора и того же действия.
+type Confirmation struct {
+	Action    string    `json:"action"`
+	Token     string    `json:"confirm_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BackupInfo — файл логической резервной копии
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupFormat — значение поля format в файле копии
+const BackupFormat = "chechnya-product-backup"
+
+// BackupHeader — служебная часть файла копии; сами данные лежат в поле tables
+// как массивы строк таблиц в формате to_jsonb
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int64     `json:"schema_version"` // последняя применённая миграция goose
+	CreatedAt     time.Time `json:"created_at"`
+}
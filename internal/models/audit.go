@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry — запись журнала действий администратора
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	ActorID    *int            `db:"actor_id" json:"actor_id"`
+	ActorName  *string         `db:"actor_name" json:"actor_name"`
+	Action     string          `db:"action" json:"action"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   *string         `db:"entity_id" json:"entity_id"`
+	Changes    json.RawMessage `db:"changes" json:"changes,omitempty" swaggertype:"object"`
+	Request    json.RawMessage `db:"request" json:"request,omitempty" swaggertype:"object"`
+	Status     int             `db:"status" json:"status"`
+	IP         string          `db:"ip" json:"ip"`
+	RequestID  string          `db:"request_id" json:"request_id"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AuditChange — значение поля до и после действия
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter — параметры выборки журнала; нулевые значения не ограничивают выборку
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,37 @@
+package models
+
+// Действия над строкой импорта каталога
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionError     = "error"
+)
+
+// CatalogImportReport — результат импорта (или пробного прогона) файла с товарами
+type CatalogImportReport struct {
+	DryRun    bool               `json:"dry_run"`
+	Applied   bool               `json:"applied"`
+	Total     int                `json:"total"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Rows      []CatalogImportRow `json:"rows"`
+}
+
+// CatalogImportRow — что произойдёт (или произошло) со строкой файла
+type CatalogImportRow struct {
+	Row       int           `json:"row"` // номер строки в файле, начиная с 1 (включая заголовок)
+	Key       string        `json:"key"` // артикул или название, по которому искали товар
+	Action    string        `json:"action"`
+	ProductID *int          `json:"product_id,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
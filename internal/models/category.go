@@ -1,7 +1,33 @@
 package models
 
 type Category struct {
-	ID        int    `json:"id" db:"id"`
-	Name      string `json:"name" db:"name"`
-	SortOrder int    `json:"sort_order" db:"sort_order"`
+	ID          int     `json:"id" db:"id"`
+	Name        string  `json:"name" db:"name"`
+	SortOrder   int     `json:"sort_order" db:"sort_order"`
+	ParentID    *int    `json:"parent_id" db:"parent_id"`
+	Slug        string  `json:"slug" db:"slug"`
+	Description *string `json:"description,omitempty" db:"description"`
+	ImageURL    *string `json:"image_url,omitempty" db:"image_url"`
+	ExternalID  *string `json:"external_id,omitempty" db:"external_id"`
+}
+
+// CategoryNode — категория вместе с подкатегориями для дерева каталога
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+// CategoryUpdateRequest — частичное обновление категории: меняются только переданные поля
+type CategoryUpdateRequest struct {
+	Name        *string `json:"name"`
+	SortOrder   *int    `json:"sortOrder"`
+	Slug        *string `json:"slug"`
+	Description *string `json:"description"`
+	ImageURL    *string `json:"image_url"`
+}
+
+// CategoryMoveRequest — перенос категории; parent_id = null делает её корневой
+type CategoryMoveRequest struct {
+	ParentID  *int `json:"parent_id"`
+	SortOrder *int `json:"sortOrder"`
 }
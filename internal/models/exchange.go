@@ -0,0 +1,21 @@
+package models
+
+// ExternalProduct — товар из учётной системы (1С), ещё не сопоставленный с нашим
+type ExternalProduct struct {
+	ExternalID  string
+	SKU         string
+	Name        string
+	Description string
+	CategoryID  *int
+	Deleted     bool
+}
+
+// ExchangeImportResult — итог загрузки одного файла обмена
+type ExchangeImportResult struct {
+	File       string `json:"file"`
+	Categories int    `json:"categories"`
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+	Offers     int    `json:"offers"`
+	Skipped    int    `json:"skipped"`
+}
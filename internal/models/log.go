@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// LogEntry — запись журнала приложения. Поля zap, кроме основных, попадают в Fields.
+type LogEntry struct {
+	Time      time.Time              `json:"time"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Caller    string                 `json:"caller,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogFilter — параметры поиска по журналу; нулевые значения не ограничивают выборку
+type LogFilter struct {
+	// Levels — уровни в нижнем регистре: debug, info, warn, error
+	Levels    []string
+	From      *time.Time
+	To        *time.Time
+	RequestID string
+	UserID    int
+	// Query — подстрока, которая ищется во всей записи без учёта регистра
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// LogPage — страница результатов поиска по журналу, новые записи первыми
+type LogPage struct {
+	Entries []LogEntry `json:"entries"`
+	HasMore bool       `json:"has_more"`
+}
@@ -10,8 +10,8 @@ type PlaceOrderRequest struct {
 	Name         *string     `json:"name"`
 	Address      *string     `json:"address"`
 	Items        []OrderItem `json:"items"`
-	PaymentType  string      `json:"payment_type"`
-	Status       string      `json:"status"`
+	PaymentType  string      `json:"payment_type" example:"online"` // cash, card или online; пустой — cash
+	Status       string      `json:"status"`                        // не учитывается: новый заказ всегда создаётся со статусом «новый»
 	DeliveryType string      `json:"delivery_type"`
 	CreatedAt    int64       `json:"created_at"`
 	DeliveryText string      `json:"delivery_text"`
@@ -54,21 +54,47 @@ type Order struct {
 	OrderComment *string     `json:"order_comment" db:"order_comment"`
 	Latitude     *float64    `json:"latitude" db:"latitude"`
 	Longitude    *float64    `json:"longitude" db:"longitude"`
+
+	// Онлайн-оплата; у заказов с оплатой при получении поля пустые
+	PaymentStatus   *string    `json:"payment_status,omitempty" db:"payment_status" example:"paid"`
+	PaymentURL      *string    `json:"payment_url,omitempty" db:"payment_url"`
+	PaidAt          *time.Time `json:"paid_at,omitempty" db:"paid_at"`
+	RefundedAt      *time.Time `json:"refunded_at,omitempty" db:"refunded_at"`
+	PaymentProvider *string    `json:"-" db:"payment_provider"`
+	PaymentID       *string    `json:"-" db:"payment_id"`
+	// PaymentReference — id текущей попытки оплаты, см. payments.CreateRequest.Reference
+	PaymentReference *string `json:"-" db:"payment_reference"`
+	// DeletedAt заполняется только в GetByIDWithDeleted
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`
 }
 
+// Способы оплаты заказа
+const (
+	PaymentTypeCash   = "cash"   // наличными при получении, можно указать сдачу
+	PaymentTypeCard   = "card"   // картой курьеру при получении
+	PaymentTypeOnline = "online" // онлайн через платёжного провайдера
+)
+
 // OrderStatusRequest используется при PATCH-запросе на обновление статуса
 type OrderStatusRequest struct {
 	Status string `json:"status" example:"в пути"`
 }
 
+const (
+	OrderStatusNew       = "новый"
+	OrderStatusAccepted  = "принят"
+	OrderStatusRejected  = "отклонен"
+	OrderStatusDelivered = "доставлен"
+)
+
 var AllowedOrderStatuses = map[string]bool{
-	"новый":      true,
-	"принят":     true,
-	"собирается": true,
-	"отклонен":   true,
-	"готов":      true,
-	"в пути":     true,
-	"доставлен":  true,
+	OrderStatusNew:       true,
+	OrderStatusAccepted:  true,
+	"собирается":         true,
+	OrderStatusRejected:  true,
+	"готов":              true,
+	"в пути":             true,
+	OrderStatusDelivered: true,
 }
 
 func (o *Order) MarshalJSON() ([]byte, error) {
@@ -81,13 +107,3 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 		DateOrders: o.CreatedAt.UnixMilli(),
 	})
 }
-
-type OrderReview struct {
-	ID        int     `db:"id" json:"id"`
-	OrderID   int     `db:"order_id" json:"order_id"`
-	UserID    *int    `db:"user_id" json:"user_id,omitempty"`
-	Username  *string `db:"username" json:"username,omitempty"`
-	Rating    *int    `db:"rating" json:"rating,omitempty"`
-	Comment   *string `db:"comment" json:"comment,omitempty"`
-	CreatedAt string  `json:"created_at" db:"created_at"`
-}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Источники изменения цены в истории
+const (
+	PriceSourceAdmin    = "admin"
+	PriceSourceImport   = "import"
+	PriceSource1C       = "1c"
+	PriceSourceSchedule = "schedule"
+)
+
+// PriceChange — кто и откуда меняет цену; попадает в историю цен
+type PriceChange struct {
+	ChangedBy *int
+	Source    string
+}
+
+type PriceHistoryEntry struct {
+	ID            int       `db:"id" json:"id"`
+	ProductID     int       `db:"product_id" json:"product_id"`
+	OldPrice      *float64  `db:"old_price" json:"old_price"`
+	NewPrice      float64   `db:"new_price" json:"new_price"`
+	ChangedBy     *int      `db:"changed_by" json:"changed_by,omitempty"`
+	ChangedByName *string   `db:"changed_by_name" json:"changed_by_name,omitempty"`
+	Source        string    `db:"source" json:"source"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// ScheduledPrice — цена, которая будет установлена в момент StartsAt
+type ScheduledPrice struct {
+	ID        int        `db:"id" json:"id"`
+	ProductID int        `db:"product_id" json:"product_id"`
+	Price     float64    `db:"price" json:"price"`
+	OldPrice  *float64   `db:"old_price" json:"old_price,omitempty"`
+	StartsAt  time.Time  `db:"starts_at" json:"starts_at"`
+	CreatedBy *int       `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	AppliedAt *time.Time `db:"applied_at" json:"applied_at,omitempty"`
+}
+
+// ScheduledPriceRequest — запрос на отложенное изменение цены.
+// OldPrice — зачёркнутая цена на время акции; не задана — зачёркнутая цена снимается.
+type ScheduledPriceRequest struct {
+	Price    float64   `json:"price" example:"89.90"`
+	OldPrice *float64  `json:"old_price,omitempty" example:"119.90"`
+	StartsAt time.Time `json:"starts_at" example:"2026-11-01T00:00:00Z"`
+}
@@ -6,40 +6,65 @@ import (
 )
 
 type Product struct {
-	ID           int            `db:"id" json:"id"`
-	Name         string         `db:"name" json:"name"`
-	Description  string         `db:"description" json:"description"`
-	Price        float64        `db:"price" json:"price"`
-	Availability bool           `db:"availability" json:"availability"`
-	CategoryID   sql.NullInt64  `db:"category_id" json:"category_id"`
-	Url          sql.NullString `db:"url" json:"url"`
-	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+	ID           int             `db:"id" json:"id"`
+	Name         string          `db:"name" json:"name"`
+	SKU          sql.NullString  `db:"sku" json:"sku"`
+	ExternalID   sql.NullString  `db:"external_id" json:"-"`
+	Description  string          `db:"description" json:"description"`
+	Price        float64         `db:"price" json:"price"`
+	OldPrice     sql.NullFloat64 `db:"old_price" json:"old_price"`
+	Availability bool            `db:"availability" json:"availability"`
+	CategoryID   sql.NullInt64   `db:"category_id" json:"category_id"`
+	Url          sql.NullString  `db:"url" json:"url"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	RatingAvg    float64         `db:"rating_avg" json:"rating_avg"`
+	ReviewCount  int             `db:"review_count" json:"review_count"`
+	DeletedAt    *time.Time      `db:"deleted_at" json:"-"`
 }
+
+// Discount возвращает зачёркнутую цену и цену со скидкой, если товар продаётся со скидкой
+func (p *Product) Discount() (oldPrice, salePrice *float64) {
+	if !p.OldPrice.Valid || p.OldPrice.Float64 <= p.Price {
+		return nil, nil
+	}
+	old, sale := p.OldPrice.Float64, p.Price
+	return &old, &sale
+}
+
 type ProductResponse struct {
-	ID           int     `json:"id"`
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Price        float64 `json:"price"`
-	Availability bool    `json:"availability"`
-	CategoryID   int     `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	Rating       float64 `json:"rating"`
-	Url          string  `json:"url"`
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	SKU          string         `json:"sku,omitempty"`
+	Description  string         `json:"description"`
+	Price        float64        `json:"price"`
+	OldPrice     *float64       `json:"old_price,omitempty"`
+	SalePrice    *float64       `json:"sale_price,omitempty"`
+	Availability bool           `json:"availability"`
+	CategoryID   int            `json:"category_id"`
+	CategoryName string         `json:"category_name"`
+	Rating       float64        `json:"rating"`
+	ReviewCount  int            `json:"review_count"`
+	Url          string         `json:"url"`
+	Images       []ProductImage `json:"images,omitempty"`
 }
 
 type ProductInput struct {
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Price        float64 `json:"price"`
-	Availability *bool   `json:"availability"`
-	CategoryID   *int    `json:"category_id"`
-	Url          string  `json:"url"`
+	Name         string   `json:"name"`
+	SKU          string   `json:"sku"`
+	Description  string   `json:"description"`
+	Price        float64  `json:"price"`
+	OldPrice     *float64 `json:"old_price"`
+	Availability *bool    `json:"availability"`
+	CategoryID   *int     `json:"category_id"`
+	Url          string   `json:"url"`
 }
 
 type ProductPatchInput struct {
 	Name         *string  `json:"name,omitempty"`
+	SKU          *string  `json:"sku,omitempty"`
 	Description  *string  `json:"description,omitempty"`
 	Price        *float64 `json:"price,omitempty"`
+	OldPrice     *float64 `json:"old_price,omitempty"` // 0 — убрать зачёркнутую цену
 	Availability *bool    `json:"availability,omitempty"`
 	CategoryID   *int     `json:"category_id,omitempty"`
 	Url          *string  `json:"url,omitempty"`
@@ -47,16 +72,21 @@ type ProductPatchInput struct {
 
 type ProductPatch struct {
 	Name         *string
+	SKU          *string
 	Description  *string
 	Price        *float64
+	OldPrice     *float64 // 0 снимает зачёркнутую цену
 	Availability *bool
 	CategoryID   *int
 	Url          *string
+	// PriceChange — автор изменения для истории цен
+	PriceChange PriceChange
 }
 
 type UploadedFile struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
-	Size int64  `json:"size"`
-	Time string `json:"time"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Size       int64  `json:"size"`
+	Time       string `json:"time"`
+	Referenced bool   `json:"referenced"`
 }
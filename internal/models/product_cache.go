@@ -7,14 +7,20 @@ func (p *Product) ToCache() ProductCache {
 	if p.CategoryID.Valid {
 		catID = &p.CategoryID.Int64
 	}
+	oldPrice, salePrice := p.Discount()
 	return ProductCache{
 		ID:           p.ID,
 		Name:         p.Name,
+		SKU:          p.SKU.String,
 		Description:  p.Description,
 		Price:        p.Price,
+		OldPrice:     oldPrice,
+		SalePrice:    salePrice,
 		Availability: p.Availability,
 		Url:          p.Url.String,
 		CategoryID:   catID,
+		RatingAvg:    p.RatingAvg,
+		ReviewCount:  p.ReviewCount,
 	}
 }
 
@@ -22,10 +28,16 @@ func (c *ProductCache) ToModel() Product {
 	product := Product{
 		ID:           c.ID,
 		Name:         c.Name,
+		SKU:          sql.NullString{String: c.SKU, Valid: c.SKU != ""},
 		Description:  c.Description,
 		Price:        c.Price,
 		Availability: c.Availability,
 		Url:          sql.NullString{String: c.Url, Valid: c.Url != ""},
+		RatingAvg:    c.RatingAvg,
+		ReviewCount:  c.ReviewCount,
+	}
+	if c.OldPrice != nil {
+		product.OldPrice = sql.NullFloat64{Float64: *c.OldPrice, Valid: true}
 	}
 	if c.CategoryID != nil {
 		product.CategoryID = sql.NullInt64{Int64: *c.CategoryID, Valid: true}
@@ -34,13 +46,18 @@ func (c *ProductCache) ToModel() Product {
 }
 
 type ProductCache struct {
-	ID           int     `json:"id"`
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Price        float64 `json:"price"`
-	Availability bool    `json:"availability"`
-	Url          string  `json:"url"`
-	CategoryID   *int64  `json:"category_id"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	SKU          string   `json:"sku,omitempty"`
+	Description  string   `json:"description"`
+	Price        float64  `json:"price"`
+	OldPrice     *float64 `json:"old_price,omitempty"`
+	SalePrice    *float64 `json:"sale_price,omitempty"`
+	Availability bool     `json:"availability"`
+	Url          string   `json:"url"`
+	CategoryID   *int64   `json:"category_id"`
+	RatingAvg    float64  `json:"rating_avg"`
+	ReviewCount  int      `json:"review_count"`
 }
 
 func ConvertProductsToCache(products []Product) []ProductCache {
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Допустимые значения сортировки каталога. Пустая строка — новые товары первыми
+// (или по релевантности, если задан поиск).
+var ProductSorts = map[string]bool{
+	"price_asc":       true,
+	"price_desc":      true,
+	"name_asc":        true,
+	"name_desc":       true,
+	"available_first": true,
+	"rating_desc":     true,
+}
+
+// ProductFilter — параметры выборки товаров каталога
+type ProductFilter struct {
+	Search       string
+	CategoryIDs  []int
+	MinPrice     float64
+	MaxPrice     float64
+	Availability *bool
+	MinRating    float64
+	Sort         string
+	Limit        int
+	Offset       int
+	Cursor       *ProductCursor
+}
+
+// Normalize приводит фильтр к каноническому виду, чтобы одинаковые по смыслу
+// запросы давали одинаковый ключ кэша
+func (f *ProductFilter) Normalize() {
+	f.Search = strings.Join(strings.Fields(strings.ToLower(f.Search)), " ")
+
+	if len(f.CategoryIDs) > 0 {
+		seen := make(map[int]bool, len(f.CategoryIDs))
+		ids := make([]int, 0, len(f.CategoryIDs))
+		for _, id := range f.CategoryIDs {
+			if id > 0 && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		sort.Ints(ids)
+		f.CategoryIDs = ids
+	}
+
+	if f.MinPrice < 0 {
+		f.MinPrice = 0
+	}
+	if f.MaxPrice < 0 {
+		f.MaxPrice = 0
+	}
+	if f.MinRating < 0 {
+		f.MinRating = 0
+	}
+	if f.MinRating > 5 {
+		f.MinRating = 5
+	}
+	if !ProductSorts[f.Sort] {
+		f.Sort = ""
+	}
+	if f.Limit < 0 {
+		f.Limit = 0
+	}
+	// С курсором смещение не используется
+	if f.Offset < 0 || f.Cursor != nil {
+		f.Offset = 0
+	}
+}
+
+// CacheKey строится из нормализованного фильтра; префикс "products:" сбрасывается при изменении товаров
+func (f ProductFilter) CacheKey() string {
+	categories := make([]string, 0, len(f.CategoryIDs))
+	for _, id := range f.CategoryIDs {
+		categories = append(categories, strconv.Itoa(id))
+	}
+
+	availability := ""
+	if f.Availability != nil {
+		availability = strconv.FormatBool(*f.Availability)
+	}
+
+	cursor := ""
+	if f.Cursor != nil {
+		cursor = f.Cursor.Encode()
+	}
+
+	raw := fmt.Sprintf("search=%s|cat=%s|min=%.2f|max=%.2f|avail=%s|rating=%.2f|sort=%s|limit=%d|offset=%d|cursor=%s",
+		f.Search, strings.Join(categories, ","), f.MinPrice, f.MaxPrice, availability, f.MinRating, f.Sort, f.Limit, f.Offset, cursor)
+	sum := sha256.Sum256([]byte(raw))
+	return "products:list:" + hex.EncodeToString(sum[:16])
+}
+
+// SortKey — по чему фактически упорядочена выборка (с учётом сортировки по релевантности)
+func (f ProductFilter) SortKey() string {
+	if f.Sort == "" && f.Search != "" {
+		return "relevance"
+	}
+	return f.Sort
+}
+
+// ProductCursor — позиция последнего товара страницы для keyset-пагинации.
+// Value — значение поля сортировки в текстовом виде, ID — разрешение равных значений.
+type ProductCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func (c ProductCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeProductCursor(s string) (*ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c ProductCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ProductPage — страница каталога в том виде, в котором она хранится в кэше
+type ProductPage struct {
+	Items      []ProductCache `json:"items"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor"`
+	Facets     *ProductFacets `json:"facets"`
+}
+
+// ProductFacets — количество товаров по значениям фильтров.
+// Для каждого измерения учитываются все остальные выбранные фильтры, кроме него самого.
+type ProductFacets struct {
+	Categories   []CategoryFacet     `json:"categories"`
+	Prices       []PriceFacet        `json:"prices"`
+	Availability []AvailabilityFacet `json:"availability"`
+	Ratings      []RatingFacet       `json:"ratings"`
+}
+
+type CategoryFacet struct {
+	ID    int    `db:"id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Count int    `db:"count" json:"count"`
+}
+
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"` // nil — без верхней границы
+	Count int      `json:"count"`
+}
+
+type AvailabilityFacet struct {
+	Available bool `db:"availability" json:"available"`
+	Count     int  `db:"count" json:"count"`
+}
+
+type RatingFacet struct {
+	MinRating int `json:"min_rating"` // товары со средней оценкой не ниже
+	Count     int `json:"count"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ProductImage изображение из галереи товара.
+// В базе хранятся имена файлов, публичные ссылки собираются из PUBLIC_BASE_URL.
+type ProductImage struct {
+	ID             int       `db:"id" json:"id"`
+	ProductID      int       `db:"product_id" json:"product_id"`
+	FileName       string    `db:"file_name" json:"-"`
+	MediumFileName string    `db:"medium_file_name" json:"-"`
+	ThumbFileName  string    `db:"thumb_file_name" json:"-"`
+	URL            string    `db:"-" json:"url"`
+	MediumURL      string    `db:"-" json:"medium_url"`
+	ThumbURL       string    `db:"-" json:"thumb_url"`
+	Width          int       `db:"width" json:"width"`
+	Height         int       `db:"height" json:"height"`
+	SortOrder      int       `db:"sort_order" json:"sort_order"`
+	IsPrimary      bool      `db:"is_primary" json:"is_primary"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// UploadedImage ссылки на сохранённые варианты загруженного изображения
+type UploadedImage struct {
+	URL       string `json:"url"`
+	MediumURL string `json:"medium_url"`
+	ThumbURL  string `json:"thumb_url"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+}
+
+// ProductImagesOrderRequest новый порядок изображений в галерее
+type ProductImagesOrderRequest struct {
+	ImageIDs []int `json:"image_ids" example:"3,1,2"`
+}
@@ -7,6 +7,8 @@ type Subscription struct {
 	P256dh   string
 	Auth     string
 	IsAdmin  bool
+	// UserID — пользователь, оформивший подписку; nil для гостя
+	UserID *int
 }
 
 type PushSubscriptionRequest struct {
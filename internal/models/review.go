@@ -1,15 +1,138 @@
 package models
 
+import "time"
+
+// Статусы модерации отзыва
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+var AllowedReviewStatuses = map[string]bool{
+	ReviewStatusPending:  true,
+	ReviewStatusApproved: true,
+	ReviewStatusRejected: true,
+}
+
 type Review struct {
-	ID        int    `db:"id" json:"id"`
-	OwnerID   string `db:"owner_id" json:"owner_id"`
-	ProductID int    `db:"product_id" json:"product_id"`
-	Rating    int    `db:"rating" json:"rating"`
-	Comment   string `db:"comment" json:"comment"`
-	CreatedAt string `db:"created_at" json:"created_at"`
+	ID               int           `db:"id" json:"id"`
+	OwnerID          string        `db:"owner_id" json:"owner_id"`
+	ProductID        int           `db:"product_id" json:"product_id"`
+	Rating           int           `db:"rating" json:"rating"`
+	Comment          string        `db:"comment" json:"comment"`
+	CreatedAt        time.Time     `db:"created_at" json:"created_at"`
+	OrderID          *int          `db:"order_id" json:"order_id,omitempty"`
+	Status           string        `db:"status" json:"status"`
+	RejectionReason  *string       `db:"rejection_reason" json:"rejection_reason,omitempty"`
+	ModerationNote   *string       `db:"moderation_note" json:"moderation_note,omitempty"`
+	VerifiedPurchase bool          `db:"verified_purchase" json:"verified_purchase"`
+	ModeratedAt      *time.Time    `db:"moderated_at" json:"moderated_at,omitempty"`
+	ModeratedBy      *int          `db:"moderated_by" json:"moderated_by,omitempty"`
+	AdminReply       *string       `db:"admin_reply" json:"admin_reply,omitempty"`
+	AdminReplyAt     *time.Time    `db:"admin_reply_at" json:"admin_reply_at,omitempty"`
+	Photos           []ReviewPhoto `db:"-" json:"photos"`
+}
+
+// AdminReview — отзыв в очереди модерации вместе с названием товара
+type AdminReview struct {
+	Review
+	ProductName string `db:"product_name" json:"product_name"`
+}
+
+type ReviewPhoto struct {
+	ID             int       `db:"id" json:"id"`
+	ReviewID       int       `db:"review_id" json:"review_id"`
+	FileName       string    `db:"file_name" json:"-"`
+	MediumFileName string    `db:"medium_file_name" json:"-"`
+	ThumbFileName  string    `db:"thumb_file_name" json:"-"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	URL            string    `db:"-" json:"url"`
+	MediumURL      string    `db:"-" json:"medium_url"`
+	ThumbURL       string    `db:"-" json:"thumb_url"`
+}
+
+// ProductRatingSummary — средняя оценка товара и распределение отзывов по оценкам
+type ProductRatingSummary struct {
+	ProductID    int            `json:"product_id"`
+	Average      float64        `json:"average"`
+	Count        int            `json:"count"`
+	Distribution []RatingBucket `json:"distribution"` // от 5 до 1
+}
+
+type RatingBucket struct {
+	Rating  int     `db:"rating" json:"rating"`
+	Count   int     `db:"count" json:"count"`
+	Percent float64 `db:"-" json:"percent"`
 }
 
 type ReviewRequest struct {
 	Rating  int    `json:"rating" example:"5"`
 	Comment string `json:"comment" example:"Отличный товар!"`
 }
+
+// OrderReview — отзыв о заказе в целом: общая оценка, доставка и курьер.
+// Оценки товаров из заказа хранятся как обычные отзывы (Review) с заполненным OrderID.
+type OrderReview struct {
+	ID             int       `db:"id" json:"id"`
+	OrderID        int       `db:"order_id" json:"order_id"`
+	OwnerID        string    `db:"owner_id" json:"-"` // owner_id гостя — доступ к его корзине и заказам, наружу не отдаётся
+	UserID         *int      `db:"user_id" json:"user_id,omitempty"`
+	Username       *string   `db:"username" json:"username,omitempty"`
+	Rating         *int      `db:"rating" json:"rating,omitempty"`
+	DeliveryRating *int      `db:"delivery_rating" json:"delivery_rating,omitempty"`
+	CourierRating  *int      `db:"courier_rating" json:"courier_rating,omitempty"`
+	Comment        *string   `db:"comment" json:"comment,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	Items          []Review  `db:"-" json:"items"`
+}
+
+// OrderFeedbackRequest — отзыв о доставленном заказе и его товарах одним запросом
+type OrderFeedbackRequest struct {
+	Rating         *int                `json:"rating" example:"5"` // общая оценка заказа
+	DeliveryRating *int                `json:"delivery_rating" example:"5"`
+	CourierRating  *int                `json:"courier_rating" example:"4"`
+	Comment        *string             `json:"comment" example:"Привезли быстро"`
+	Items          []OrderItemFeedback `json:"items"`
+}
+
+type OrderItemFeedback struct {
+	ProductID int    `json:"product_id" example:"12"`
+	Rating    int    `json:"rating" example:"5"`
+	Comment   string `json:"comment" example:"Свежие, вкусные"`
+}
+
+// RatingTimelinePoint — средние оценки за период (день, неделя или месяц)
+type RatingTimelinePoint struct {
+	Period       time.Time `db:"period" json:"period"`
+	ProductAvg   *float64  `db:"product_avg" json:"product_avg"`
+	ProductCount int       `db:"product_count" json:"product_count"`
+	OrderAvg     *float64  `db:"order_avg" json:"order_avg"`
+	DeliveryAvg  *float64  `db:"delivery_avg" json:"delivery_avg"`
+	CourierAvg   *float64  `db:"courier_avg" json:"courier_avg"`
+	OrderCount   int       `db:"order_count" json:"order_count"`
+}
+
+type LowestRatedProduct struct {
+	ProductID   int     `db:"product_id" json:"product_id"`
+	Name        string  `db:"name" json:"name"`
+	RatingAvg   float64 `db:"rating_avg" json:"rating_avg"`
+	ReviewCount int     `db:"review_count" json:"review_count"`
+}
+
+type FeedbackAnalytics struct {
+	Period      string                `json:"period"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Timeline    []RatingTimelinePoint `json:"timeline"`
+	LowestRated []LowestRatedProduct  `json:"lowest_rated"`
+}
+
+type ReviewModerationRequest struct {
+	Status string `json:"status" example:"rejected"` // approved | rejected
+	Reason string `json:"reason" example:"Отзыв не о товаре"`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" example:"Спасибо за отзыв!"` // пустая строка удаляет ответ
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ProductSearchHit — строка результата поиска вместе с релевантностью и подсветкой
+type ProductSearchHit struct {
+	Product
+	CategoryName  string  `db:"category_name"`
+	Rank          float64 `db:"rank"`
+	NameHighlight string  `db:"name_highlight"`
+	Snippet       string  `db:"snippet"`
+}
+
+type ProductSearchResult struct {
+	ProductResponse
+	Rank          float64 `json:"rank"`
+	NameHighlight string  `json:"name_highlight"` // экранированное HTML-название с найденными словами в <mark></mark>
+	Snippet       string  `json:"snippet"`        // фрагмент описания с подсветкой
+}
+
+type ProductSearchResponse struct {
+	Query  string                `json:"query"`
+	Items  []ProductSearchResult `json:"items"`
+	Total  int                   `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+type SearchSuggestion struct {
+	Type string `db:"type" json:"type"` // product | category
+	ID   int    `db:"id" json:"id"`
+	Text string `db:"text" json:"text"`
+}
+
+type SearchZeroResult struct {
+	Query           string    `db:"query" json:"query"`
+	Hits            int       `db:"hits" json:"hits"`
+	FirstSearchedAt time.Time `db:"first_searched_at" json:"first_searched_at"`
+	LastSearchedAt  time.Time `db:"last_searched_at" json:"last_searched_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Статусы проверок готовности
+const (
+	CheckStatusOK    = "ok"
+	CheckStatusError = "error"
+)
+
+// HealthCheck — результат проверки одной зависимости
+type HealthCheck struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ReadinessReport — ответ /readyz: готов ли экземпляр принимать трафик и что проверялось
+type ReadinessReport struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// BuildInfo — версия и сведения о сборке бинарника
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// DBPoolStats — состояние пула соединений с Postgres
+type DBPoolStats struct {
+	MaxOpen        int   `json:"max_open"`
+	Open           int   `json:"open"`
+	InUse          int   `json:"in_use"`
+	Idle           int   `json:"idle"`
+	WaitCount      int64 `json:"wait_count"`
+	WaitDurationMs int64 `json:"wait_duration_ms"`
+}
+
+// RedisPoolStats — состояние пула соединений с Redis
+type RedisPoolStats struct {
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	StaleConns uint32 `json:"stale_conns"`
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+}
+
+// SystemInfo — сводка о запущенном экземпляре для админки
+type SystemInfo struct {
+	Build            BuildInfo      `json:"build"`
+	Env              string         `json:"env"`
+	StartedAt        time.Time      `json:"started_at"`
+	UptimeSeconds    int64          `json:"uptime_seconds"`
+	Goroutines       int            `json:"goroutines"`
+	WebSocketClients int            `json:"websocket_clients"`
+	SchemaVersion    int64          `json:"schema_version"`
+	LatestMigration  int64          `json:"latest_migration"`
+	StorageDriver    string         `json:"storage_driver"`
+	Database         DBPoolStats    `json:"database"`
+	Redis            RedisPoolStats `json:"redis"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Типы записей в корзине
+const (
+	TrashTypeProduct  = "product"
+	TrashTypeCategory = "category"
+	TrashTypeOrder    = "order"
+)
+
+var AllowedTrashTypes = map[string]bool{
+	TrashTypeProduct:  true,
+	TrashTypeCategory: true,
+	TrashTypeOrder:    true,
+}
+
+// TrashItem — удалённая запись, которую ещё можно восстановить
+type TrashItem struct {
+	Type      string    `db:"type" json:"type"`
+	ID        int       `db:"id" json:"id"`
+	Title     string    `db:"title" json:"title"`
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+	// PurgeAt — когда запись будет удалена окончательно
+	PurgeAt time.Time `db:"-" json:"purge_at"`
+}
+
+// TrashPurgeResult — сколько записей окончательно удалено при очистке корзины
+type TrashPurgeResult struct {
+	Products   int64 `json:"products"`
+	Categories int64 `json:"categories"`
+	Orders     int64 `json:"orders"`
+}
@@ -20,4 +20,19 @@ type User struct {
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 	PasswordHash string    `db:"password_hash" json:"-"`
 	Address      *string   `db:"address" json:"address"`
+
+	// Защита от подбора пароля: неудачные попытки подряд и время, до которого вход закрыт
+	FailedLogins      int        `db:"failed_logins" json:"failed_logins"`
+	LastFailedLoginAt *time.Time `db:"last_failed_login_at" json:"-"`
+	LockedUntil       *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+}
+
+// LoginEvent — попытка входа в аккаунт: когда, откуда и удачная ли
+type LoginEvent struct {
+	ID        int64     `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"-"`
+	Success   bool      `db:"success" json:"success"`
+	IP        string    `db:"ip" json:"ip"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
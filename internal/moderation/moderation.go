@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Violation — найденная фильтром проблема в тексте
+type Violation struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// Filter — хук проверки пользовательского текста. Возвращает nil, если текст в порядке.
+type Filter interface {
+	Check(text string) *Violation
+}
+
+// FilterFunc позволяет использовать обычную функцию как Filter
+type FilterFunc func(text string) *Violation
+
+func (f FilterFunc) Check(text string) *Violation {
+	return f(text)
+}
+
+// Chain последовательно применяет фильтры и собирает все нарушения
+type Chain []Filter
+
+func (c Chain) Check(text string) []Violation {
+	var violations []Violation
+	for _, f := range c {
+		if v := f.Check(text); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	return violations
+}
+
+// Default — стандартный набор фильтров для отзывов
+func Default(stopWords []string) Chain {
+	chain := Chain{NewLinkFilter()}
+	if len(stopWords) > 0 {
+		chain = append(chain, NewStopWordFilter(stopWords))
+	}
+	return chain
+}
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.|t\.me/|[\p{L}0-9-]+\.(ru|com|net|org|рф|su|io|me|info)(?:$|[^\p{L}0-9])|@[a-z0-9_]{5,})`)
+
+// NewLinkFilter находит ссылки, домены и упоминания аккаунтов (частый признак спама)
+func NewLinkFilter() Filter {
+	return FilterFunc(func(text string) *Violation {
+		if linkPattern.MatchString(text) {
+			return &Violation{Rule: "link", Reason: "текст содержит ссылку"}
+		}
+		return nil
+	})
+}
+
+// NewStopWordFilter находит слова, начинающиеся с одного из корней списка.
+// Сравнение без учёта регистра, "ё" приравнивается к "е".
+func NewStopWordFilter(words []string) Filter {
+	roots := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = normalize(w); w != "" {
+			roots = append(roots, w)
+		}
+	}
+
+	return FilterFunc(func(text string) *Violation {
+		for _, token := range strings.FieldsFunc(normalize(text), isSeparator) {
+			for _, root := range roots {
+				if strings.HasPrefix(token, root) {
+					return &Violation{Rule: "stop_word", Reason: "текст содержит недопустимые слова"}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func normalize(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е")
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,174 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudPaymentsProvider работает через API CloudPayments: платёж — ссылка на оплату (orders/create),
+// уведомления Pay, Fail и Refund приходят формой и подписаны заголовком Content-HMAC.
+// Все три уведомления в личном кабинете направляются на /api/payments/webhook.
+type CloudPaymentsProvider struct {
+	apiURL    string
+	publicID  string
+	apiSecret string
+	client    *http.Client
+}
+
+func NewCloudPaymentsProvider(apiURL, publicID, apiSecret string) *CloudPaymentsProvider {
+	return &CloudPaymentsProvider{
+		apiURL:    strings.TrimRight(apiURL, "/"),
+		publicID:  publicID,
+		apiSecret: apiSecret,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *CloudPaymentsProvider) Name() string {
+	return "cloudpayments"
+}
+
+func (p *CloudPaymentsProvider) CreatePayment(ctx context.Context, req CreateRequest) (*Payment, error) {
+	var resp struct {
+		Model struct {
+			ID  string `json:"Id"`
+			URL string `json:"Url"`
+		} `json:"Model"`
+	}
+	err := p.call(ctx, "/orders/create", map[string]any{
+		"Amount":             json.Number(formatAmount(req.Amount)),
+		"Currency":           "RUB",
+		"Description":        req.Description,
+		"InvoiceId":          strconv.Itoa(req.OrderID),
+		"SuccessRedirectUrl": req.ReturnURL,
+		"FailRedirectUrl":    req.ReturnURL,
+		// JsonData возвращается в уведомлениях Pay и Fail полем Data
+		"JsonData": map[string]string{"reference": req.Reference},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Model.URL == "" {
+		return nil, fmt.Errorf("cloudpayments: orders/create returned no payment url")
+	}
+	return &Payment{ID: resp.Model.ID, ConfirmationURL: resp.Model.URL}, nil
+}
+
+func (p *CloudPaymentsProvider) Refund(ctx context.Context, paymentID string, amount float64) error {
+	transactionID, err := strconv.ParseInt(paymentID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cloudpayments: invalid transaction id %q", paymentID)
+	}
+	return p.call(ctx, "/payments/refund", map[string]any{
+		"TransactionId": transactionID,
+		"Amount":        json.Number(formatAmount(amount)),
+	}, nil)
+}
+
+// call выполняет метод API. Ошибка — и при HTTP-коде не 2xx, и при Success=false в ответе.
+func (p *CloudPaymentsProvider) call(ctx context.Context, method string, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.publicID, p.apiSecret)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudpayments %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("cloudpayments %s: unexpected status %d", method, resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("cloudpayments %s: invalid response: %w", method, err)
+	}
+	var status struct {
+		Success bool    `json:"Success"`
+		Message *string `json:"Message"`
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("cloudpayments %s: invalid response: %w", method, err)
+	}
+	if !status.Success {
+		message := "request rejected"
+		if status.Message != nil && *status.Message != "" {
+			message = *status.Message
+		}
+		return fmt.Errorf("cloudpayments %s: %s", method, message)
+	}
+	if result != nil {
+		return json.Unmarshal(raw, result)
+	}
+	return nil
+}
+
+// ParseWebhook проверяет Content-HMAC — base64(HMAC-SHA256(тело, API secret)) — и разбирает
+// уведомление Pay, Fail или Refund. Номер заказа приходит в InvoiceId.
+func (p *CloudPaymentsProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	signature := header.Get("Content-HMAC")
+	if signature == "" {
+		signature = header.Get("X-Content-HMAC")
+	}
+	got, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, sign(p.apiSecret, body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, ErrInvalidWebhook
+	}
+	orderID, err := strconv.Atoi(form.Get("InvoiceId"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: InvoiceId is not an order number", ErrInvalidWebhook)
+	}
+	amount, err := strconv.ParseFloat(form.Get("Amount"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid Amount", ErrInvalidWebhook)
+	}
+
+	event := &WebhookEvent{
+		OrderID:   orderID,
+		PaymentID: form.Get("TransactionId"),
+		Amount:    amount,
+	}
+	var data struct {
+		Reference string `json:"reference"`
+	}
+	if json.Unmarshal([]byte(form.Get("Data")), &data) == nil {
+		event.Reference = data.Reference
+	}
+	switch {
+	case form.Get("OperationType") == "Refund":
+		// В уведомлении о возврате TransactionId — id возврата, платёж — в PaymentTransactionId
+		event.Status = StatusRefunded
+		event.PaymentID = form.Get("PaymentTransactionId")
+	case form.Get("ReasonCode") != "" && form.Get("ReasonCode") != "0":
+		event.Status = StatusFailed
+		event.Reason = form.Get("Reason")
+	case form.Get("Status") == "Completed":
+		event.Status = StatusPaid
+	default:
+		// Authorized — двухстадийная оплата, её магазин не использует
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidWebhook, form.Get("Status"))
+	}
+	return event, nil
+}
@@ -0,0 +1,119 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// FakeSignatureHeader — заголовок с подписью уведомлений FakeProvider: hex(HMAC-SHA256(тело, секрет))
+const FakeSignatureHeader = "X-Signature"
+
+// FakeProvider — провайдер для разработки и тестов: платежи не уходят наружу, страница оплаты —
+// адрес возврата, а «оплату» присылают уведомлением, подписанным FakeProvider.Sign:
+//
+//	{"order_id": 1, "payment_id": "fake_1_1", "reference": "1-3f2a9c", "status": "paid", "amount": 1500}
+//
+// reference — CreateRequest.Reference, он передаётся в адресе страницы оплаты вместе с payment_id.
+type FakeProvider struct {
+	secret string
+
+	mu        sync.Mutex
+	seq       int
+	refunds   map[string]float64
+	refundErr error
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{secret: secret, refunds: make(map[string]float64)}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreatePayment(_ context.Context, req CreateRequest) (*Payment, error) {
+	p.mu.Lock()
+	p.seq++
+	id := fmt.Sprintf("fake_%d_%d", req.OrderID, p.seq)
+	p.mu.Unlock()
+
+	confirmation, err := url.Parse(req.ReturnURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid return url: %w", err)
+	}
+	query := confirmation.Query()
+	query.Set("payment_id", id)
+	query.Set("reference", req.Reference)
+	query.Set("amount", formatAmount(req.Amount))
+	confirmation.RawQuery = query.Encode()
+
+	return &Payment{ID: id, ConfirmationURL: confirmation.String()}, nil
+}
+
+func (p *FakeProvider) Refund(_ context.Context, paymentID string, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refundErr != nil {
+		return p.refundErr
+	}
+	p.refunds[paymentID] += amount
+	return nil
+}
+
+// FailRefunds заставляет следующие возвраты завершаться ошибкой err; nil снова разрешает их
+func (p *FakeProvider) FailRefunds(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refundErr = err
+}
+
+// Refunded — сколько возвращено по платежу
+func (p *FakeProvider) Refunded(paymentID string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refunds[paymentID]
+}
+
+// Sign подписывает тело уведомления для заголовка FakeSignatureHeader
+func (p *FakeProvider) Sign(body []byte) string {
+	return hex.EncodeToString(sign(p.secret, body))
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	got, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(got, sign(p.secret, body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var payload struct {
+		OrderID   int     `json:"order_id"`
+		PaymentID string  `json:"payment_id"`
+		Reference string  `json:"reference"`
+		Status    Status  `json:"status"`
+		Amount    float64 `json:"amount"`
+		Reason    string  `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.OrderID == 0 {
+		return nil, ErrInvalidWebhook
+	}
+	switch payload.Status {
+	case StatusPaid, StatusFailed, StatusRefunded:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidWebhook, payload.Status)
+	}
+
+	return &WebhookEvent{
+		OrderID:   payload.OrderID,
+		PaymentID: payload.PaymentID,
+		Status:    payload.Status,
+		Amount:    payload.Amount,
+		Reason:    payload.Reason,
+		Reference: payload.Reference,
+	}, nil
+}
@@ -0,0 +1,106 @@
+// Package payments — онлайн-оплата заказов через платёжного провайдера: создание платежа
+// со ссылкой на страницу оплаты, возвраты и разбор подписанных уведомлений (вебхуков).
+package payments
+
+import (
+	"chechnya-product/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidWebhook   = errors.New("malformed webhook payload")
+)
+
+// Status — состояние оплаты заказа
+type Status string
+
+const (
+	StatusPending  Status = "pending"  // платёж создан, покупатель ещё не оплатил
+	StatusPaid     Status = "paid"     // деньги списаны
+	StatusFailed   Status = "failed"   // оплата не прошла или платёж не удалось создать; можно повторить
+	StatusCanceled Status = "canceled" // заказ отклонён до оплаты
+	StatusRefunded Status = "refunded" // деньги возвращены
+)
+
+// CreateRequest — данные для создания платежа
+type CreateRequest struct {
+	OrderID     int
+	Amount      float64
+	Description string
+	// ReturnURL — куда провайдер вернёт покупателя после оплаты
+	ReturnURL string
+	// Reference — наш id попытки оплаты; провайдер возвращает его в уведомлениях (WebhookEvent.Reference)
+	Reference string
+}
+
+// Payment — созданный у провайдера платёж
+type Payment struct {
+	ID string
+	// ConfirmationURL — страница оплаты, на которую нужно перенаправить покупателя
+	ConfirmationURL string
+}
+
+// WebhookEvent — проверенное уведомление провайдера об изменении платежа
+type WebhookEvent struct {
+	OrderID int
+	// PaymentID — id операции у провайдера, по нему делается возврат
+	PaymentID string
+	Status    Status
+	Amount    float64
+	// Reason — причина отказа для StatusFailed
+	Reason string
+	// Reference — CreateRequest.Reference попытки, к которой относится уведомление; пустой, если провайдер его не прислал
+	Reference string
+}
+
+// PaymentProvider — платёжный провайдер
+type PaymentProvider interface {
+	// Name — название провайдера, сохраняется в заказе
+	Name() string
+	CreatePayment(ctx context.Context, req CreateRequest) (*Payment, error)
+	// Refund возвращает amount по оплаченному платежу
+	Refund(ctx context.Context, paymentID string, amount float64) error
+	// ParseWebhook проверяет подпись уведомления и разбирает его; неверная подпись — ErrInvalidSignature
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+// New создаёт провайдера по PAYMENT_PROVIDER из конфига; без провайдера возвращает nil — онлайн-оплата выключена
+func New(cfg *config.Config) (PaymentProvider, error) {
+	switch cfg.PaymentProvider {
+	case "", "none":
+		return nil, nil
+	case "fake":
+		// Уведомления fake никто, кроме нас, не подписывает — в бою это бесплатная «оплата»
+		if cfg.IsProduction() {
+			return nil, errors.New("fake payment provider is not allowed in production")
+		}
+		if cfg.PaymentAPISecret == "" {
+			return nil, errors.New("fake payment provider requires PAYMENT_API_SECRET")
+		}
+		return NewFakeProvider(cfg.PaymentAPISecret), nil
+	case "cloudpayments":
+		if cfg.PaymentPublicID == "" || cfg.PaymentAPISecret == "" {
+			return nil, errors.New("cloudpayments requires PAYMENT_PUBLIC_ID and PAYMENT_API_SECRET")
+		}
+		return NewCloudPaymentsProvider(cfg.PaymentAPIURL, cfg.PaymentPublicID, cfg.PaymentAPISecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", cfg.PaymentProvider)
+	}
+}
+
+func sign(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// formatAmount — сумма в рублях с копейками, как её ждут провайдеры
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
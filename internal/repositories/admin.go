@@ -1,14 +1,53 @@
 package repositories
 
 import (
+	"chechnya-product/internal/db"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"io"
+	"strings"
 )
 
+// truncatableTables — таблицы, которые разрешено очищать вне продакшена
+var truncatableTables = map[string]bool{
+	"announcements": true,
+	"cart_items":    true,
+	"categories":    true,
+	"products":      true,
+	"orders":        true,
+	"order_items":   true,
+	"users":         true,
+	"reviews":       true,
+}
+
+// BackupTables — таблицы с бизнес-данными в порядке зависимостей: родительские раньше дочерних.
+// Корзины, push-подписки, коды подтверждения, статистика поиска и журнал действий в копию не входят.
+var BackupTables = []string{
+	"users",
+	"categories",
+	"products",
+	"product_images",
+	"price_history",
+	"scheduled_prices",
+	"orders",
+	"order_items",
+	"reviews",
+	"review_photos",
+	"order_reviews",
+	"announcements",
+}
+
 // AdminRepoInterface интерфейс для админских операций с БД
 type AdminRepoInterface interface {
-	TruncateTable(tableName string) error
-	TruncateAllTables() error
+	IsTruncatable(tableName string) bool
+	TruncateTable(ctx context.Context, tableName string) error
+	TruncateAllTables(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int64, error)
+	DumpTable(ctx context.Context, table string, w io.Writer) error
+	RestoreTables(ctx context.Context, tables map[string]json.RawMessage) error
 }
 
 // AdminRepo реализация AdminRepoInterface
@@ -20,36 +59,29 @@ func NewAdminRepo(db *sqlx.DB) *AdminRepo {
 	return &AdminRepo{db: db}
 }
 
-func (r *AdminRepo) TruncateTable(tableName string) error {
-	allowedTables := map[string]bool{
-		"announcements": true,
-		"cart_items":    true,
-		"categories":    true,
-		"products":      true,
-		"orders":        true,
-		"order_items":   true,
-		"users":         true,
-		"reviews":       true,
-	}
+func (r *AdminRepo) IsTruncatable(tableName string) bool {
+	return truncatableTables[tableName]
+}
 
-	if !allowedTables[tableName] {
+func (r *AdminRepo) TruncateTable(ctx context.Context, tableName string) error {
+	if !truncatableTables[tableName] {
 		return fmt.Errorf("недопустимая таблица: %s", tableName)
 	}
 
-	_, err := r.db.Exec(fmt.Sprintf(`TRUNCATE TABLE %s RESTART IDENTITY CASCADE`, tableName))
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf(`TRUNCATE TABLE %s RESTART IDENTITY CASCADE`, tableName))
 	return err
 }
 
-func (r *AdminRepo) TruncateAllTables() error {
+func (r *AdminRepo) TruncateAllTables(ctx context.Context) error {
 	tables := []string{"announcements", "cart_items", "categories", "order_items", "orders", "products", "reviews"}
 
-	tx, err := r.db.Beginx()
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
 	for _, table := range tables {
-		if _, err := tx.Exec(fmt.Sprintf(`TRUNCATE TABLE %s RESTART IDENTITY CASCADE`, table)); err != nil {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`TRUNCATE TABLE %s RESTART IDENTITY CASCADE`, table)); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -57,3 +89,104 @@ func (r *AdminRepo) TruncateAllTables() error {
 
 	return tx.Commit()
 }
+
+// SchemaVersion возвращает номер последней применённой миграции goose
+func (r *AdminRepo) SchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := r.db.GetContext(ctx, &version, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch schema version: %w", err)
+	}
+	return version, nil
+}
+
+// DumpTable пишет строки таблицы JSON-массивом объектов to_jsonb, не загружая таблицу в память целиком.
+// Имя таблицы подставляется в запрос, поэтому передавать можно только значения из BackupTables.
+// Чтение большой таблицы может идти дольше обычного таймаута запроса, поэтому он снят.
+func (r *AdminRepo) DumpTable(ctx context.Context, table string, w io.Writer) error {
+	ctx = db.WithoutQueryTimeout(ctx)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`SELECT to_jsonb(t) FROM %s t ORDER BY t.id`, table))
+	if err != nil {
+		return fmt.Errorf("failed to dump %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+		sep := ",\n"
+		if first {
+			sep, first = "\n", false
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to dump %s: %w", table, err)
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// RestoreTables заменяет содержимое всех таблиц из BackupTables данными копии в одной транзакции.
+// Строки вставляются через jsonb_populate_recordset, поэтому копия не зависит от порядка колонок;
+// вычисляемые колонки пропускаются, счётчики id выставляются после максимального id.
+// TRUNCATE ... CASCADE очищает и зависимые таблицы вне копии, например корзины покупателей.
+// Вставка всей копии может идти дольше обычного таймаута запроса, поэтому он снят.
+func (r *AdminRepo) RestoreTables(ctx context.Context, tables map[string]json.RawMessage) error {
+	ctx = db.WithoutQueryTimeout(ctx)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `TRUNCATE TABLE `+strings.Join(BackupTables, ", ")+` RESTART IDENTITY CASCADE`); err != nil {
+		return fmt.Errorf("failed to truncate tables before restore: %w", err)
+	}
+
+	for _, table := range BackupTables {
+		data, ok := tables[table]
+		if !ok || len(data) == 0 {
+			continue
+		}
+
+		var columns []string
+		err := tx.SelectContext(ctx, &columns, `
+SELECT column_name FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1 AND is_generated = 'NEVER'
+ORDER BY ordinal_position`, table)
+		if err != nil {
+			return fmt.Errorf("failed to fetch columns of %s: %w", table, err)
+		}
+		for i, c := range columns {
+			columns[i] = pq.QuoteIdentifier(c)
+		}
+		list := strings.Join(columns, ", ")
+
+		query := fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM jsonb_populate_recordset(NULL::%s, $1::jsonb)`, table, list, list, table)
+		if _, err := tx.ExecContext(ctx, query, string(data)); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+
+		query = fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)`, table, table)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to reset id sequence of %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit restore: %w", err)
+	}
+	return nil
+}
@@ -2,15 +2,16 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"github.com/jmoiron/sqlx"
 )
 
 type AnnouncementRepository interface {
-	Create(title, content string) (*models.Announcement, error)
-	GetAll() ([]models.Announcement, error)
-	GetByID(id int) (*models.Announcement, error)
-	Update(id int, title, content string) error
-	Delete(id int) error
+	Create(ctx context.Context, title, content string) (*models.Announcement, error)
+	GetAll(ctx context.Context) ([]models.Announcement, error)
+	GetByID(ctx context.Context, id int) (*models.Announcement, error)
+	Update(ctx context.Context, id int, title, content string) error
+	Delete(ctx context.Context, id int) error
 }
 
 type AnnouncementRepo struct {
@@ -21,33 +22,33 @@ func NewAnnouncementRepo(db *sqlx.DB) *AnnouncementRepo {
 	return &AnnouncementRepo{db: db}
 }
 
-func (r *AnnouncementRepo) Create(title, content string) (*models.Announcement, error) {
+func (r *AnnouncementRepo) Create(ctx context.Context, title, content string) (*models.Announcement, error) {
 	var ann models.Announcement
-	err := r.db.Get(&ann, `
+	err := r.db.GetContext(ctx, &ann, `
 		INSERT INTO announcements (title, content)
 		VALUES ($1, $2) RETURNING id, title, content
 	`, title, content)
 	return &ann, err
 }
 
-func (r *AnnouncementRepo) GetAll() ([]models.Announcement, error) {
+func (r *AnnouncementRepo) GetAll(ctx context.Context) ([]models.Announcement, error) {
 	var anns []models.Announcement
-	err := r.db.Select(&anns, `SELECT * FROM announcements ORDER BY id DESC`)
+	err := r.db.SelectContext(ctx, &anns, `SELECT * FROM announcements ORDER BY id DESC`)
 	return anns, err
 }
 
-func (r *AnnouncementRepo) GetByID(id int) (*models.Announcement, error) {
+func (r *AnnouncementRepo) GetByID(ctx context.Context, id int) (*models.Announcement, error) {
 	var ann models.Announcement
-	err := r.db.Get(&ann, `SELECT * FROM announcements WHERE id = $1`, id)
+	err := r.db.GetContext(ctx, &ann, `SELECT * FROM announcements WHERE id = $1`, id)
 	return &ann, err
 }
 
-func (r *AnnouncementRepo) Update(id int, title, content string) error {
-	_, err := r.db.Exec(`UPDATE announcements SET title=$1, content=$2 WHERE id=$3`, title, content, id)
+func (r *AnnouncementRepo) Update(ctx context.Context, id int, title, content string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE announcements SET title=$1, content=$2 WHERE id=$3`, title, content, id)
 	return err
 }
 
-func (r *AnnouncementRepo) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM announcements WHERE id=$1`, id)
+func (r *AnnouncementRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM announcements WHERE id=$1`, id)
 	return err
 }
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"chechnya-product/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	Snapshot(ctx context.Context, table string, id int) (json.RawMessage, error)
+}
+
+type AuditRepo struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepo(db *sqlx.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// Create сохраняет запись; имя администратора берётся на момент действия
+func (r *AuditRepo) Create(ctx context.Context, entry *models.AuditEntry) error {
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO audit_log (actor_id, actor_name, action, entity_type, entity_id, changes, request, status, ip, request_id)
+VALUES ($1, (SELECT username FROM users WHERE id = $1), $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, actor_name, created_at`,
+		entry.ActorID, entry.Action, entry.EntityType, entry.EntityID,
+		nullJSON(entry.Changes), nullJSON(entry.Request),
+		entry.Status, entry.IP, entry.RequestID,
+	).Scan(&entry.ID, &entry.ActorName, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+// List возвращает записи журнала, новые первыми. Limit = 0 — без ограничения (для экспорта).
+func (r *AuditRepo) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.db.SelectContext(ctx, &entries, `
+SELECT id, actor_id, actor_name, action, entity_type, entity_id, changes, request, status, ip, request_id, created_at
+FROM audit_log
+WHERE ($1 = 0 OR actor_id = $1)
+  AND ($2 = '' OR action = $2)
+  AND ($3 = '' OR entity_type = $3)
+  AND ($4 = '' OR entity_id = $4)
+  AND ($5::timestamp IS NULL OR created_at >= $5)
+  AND ($6::timestamp IS NULL OR created_at < $6)
+ORDER BY created_at DESC, id DESC
+LIMIT NULLIF($7, 0) OFFSET $8`,
+		f.ActorID, f.Action, f.EntityType, f.EntityID, f.From, f.To, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Snapshot возвращает строку таблицы целиком в виде JSON, включая удалённые в корзину.
+// Имя таблицы подставляется в запрос, поэтому передавать можно только имена из кода.
+func (r *AuditRepo) Snapshot(ctx context.Context, table string, id int) (json.RawMessage, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT to_jsonb(t) FROM %s t WHERE t.id = $1`, table), id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s %d: %w", table, id, err)
+	}
+	return data, nil
+}
+
+// nullJSON — пустой JSON сохраняется как NULL. JSON передаётся строкой:
+// []byte драйвер отправил бы как bytea, и jsonb его не примет.
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -2,20 +2,21 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/jmoiron/sqlx"
 )
 
 type CartRepository interface {
-	AddItem(ownerID string, productID, quantity int) error
-	GetCartItems(ownerID string) ([]models.CartItem, error)
-	GetCartItem(ownerID string, productID int) (*models.CartItem, error)
-	UpdateQuantity(ownerID string, productID, quantity int) error
-	DeleteItem(ownerID string, productID int) error
-	ClearCart(ownerID string) error
-	TransferOwnership(from, to string) error
-	AddOrUpdate(ownerID string, productID int, quantity int) error
+	AddItem(ctx context.Context, ownerID string, productID, quantity int) error
+	GetCartItems(ctx context.Context, ownerID string) ([]models.CartItem, error)
+	GetCartItem(ctx context.Context, ownerID string, productID int) (*models.CartItem, error)
+	UpdateQuantity(ctx context.Context, ownerID string, productID, quantity int) error
+	DeleteItem(ctx context.Context, ownerID string, productID int) error
+	ClearCart(ctx context.Context, ownerID string) error
+	TransferOwnership(ctx context.Context, from, to string) error
+	AddOrUpdate(ctx context.Context, ownerID string, productID int, quantity int) error
 }
 
 type CartRepo struct {
@@ -26,8 +27,8 @@ func NewCartRepo(db *sqlx.DB) *CartRepo {
 	return &CartRepo{db: db}
 }
 
-func (r *CartRepo) AddItem(ownerID string, productID, quantity int) error {
-	_, err := r.db.Exec(`
+func (r *CartRepo) AddItem(ctx context.Context, ownerID string, productID, quantity int) error {
+	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO cart_items (owner_id, product_id, quantity)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (owner_id, product_id) DO UPDATE
@@ -36,20 +37,20 @@ func (r *CartRepo) AddItem(ownerID string, productID, quantity int) error {
 	return err
 }
 
-func (r *CartRepo) GetCartItems(ownerID string) ([]models.CartItem, error) {
+func (r *CartRepo) GetCartItems(ctx context.Context, ownerID string) ([]models.CartItem, error) {
 	const query = `
 		SELECT id, product_id, quantity
 		FROM cart_items
 		WHERE owner_id = $1
 	`
 	var items []models.CartItem
-	err := r.db.Select(&items, query, ownerID)
+	err := r.db.SelectContext(ctx, &items, query, ownerID)
 	return items, err
 }
 
-func (r *CartRepo) GetCartItem(ownerID string, productID int) (*models.CartItem, error) {
+func (r *CartRepo) GetCartItem(ctx context.Context, ownerID string, productID int) (*models.CartItem, error) {
 	var item models.CartItem
-	err := r.db.Get(&item, `
+	err := r.db.GetContext(ctx, &item, `
 		SELECT id, product_id, quantity
 		FROM cart_items
 		WHERE owner_id = $1 AND product_id = $2
@@ -61,8 +62,8 @@ func (r *CartRepo) GetCartItem(ownerID string, productID int) (*models.CartItem,
 	return &item, err
 }
 
-func (r *CartRepo) UpdateQuantity(ownerID string, productID, quantity int) error {
-	_, err := r.db.Exec(`
+func (r *CartRepo) UpdateQuantity(ctx context.Context, ownerID string, productID, quantity int) error {
+	_, err := r.db.ExecContext(ctx, `
 		UPDATE cart_items
 		SET quantity = $1
 		WHERE owner_id = $2 AND product_id = $3
@@ -70,29 +71,29 @@ func (r *CartRepo) UpdateQuantity(ownerID string, productID, quantity int) error
 	return err
 }
 
-func (r *CartRepo) DeleteItem(ownerID string, productID int) error {
-	_, err := r.db.Exec(`
+func (r *CartRepo) DeleteItem(ctx context.Context, ownerID string, productID int) error {
+	_, err := r.db.ExecContext(ctx, `
 		DELETE FROM cart_items
 		WHERE owner_id = $1 AND product_id = $2
 	`, ownerID, productID)
 	return err
 }
 
-func (r *CartRepo) ClearCart(ownerID string) error {
-	_, err := r.db.Exec(`
+func (r *CartRepo) ClearCart(ctx context.Context, ownerID string) error {
+	_, err := r.db.ExecContext(ctx, `
 	DELETE FROM cart_items
 	WHERE owner_id = $1
 	`, ownerID)
 	return err
 }
 
-func (r *CartRepo) TransferOwnership(from, to string) error {
-	_, err := r.db.Exec(`UPDATE cart_items SET owner_id = $2 WHERE owner_id = $1`, from, to)
+func (r *CartRepo) TransferOwnership(ctx context.Context, from, to string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE cart_items SET owner_id = $2 WHERE owner_id = $1`, from, to)
 	return err
 }
 
-func (r *CartRepo) AddOrUpdate(ownerID string, productID int, quantity int) error {
-	_, err := r.db.Exec(`
+func (r *CartRepo) AddOrUpdate(ctx context.Context, ownerID string, productID int, quantity int) error {
+	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO cart_items (owner_id, product_id, quantity)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (owner_id, product_id) DO UPDATE
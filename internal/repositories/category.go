@@ -2,21 +2,34 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strings"
+	"time"
 )
 
 type CategoryRepository interface {
-	GetAll() ([]models.Category, error)
-	Create(category *models.Category) error
-	Update(id int, name string, sortOrder int) error
-	Delete(id int) error
-	BeginTx() (*sqlx.Tx, error)
-	GetByNameTx(tx *sqlx.Tx, name string) (*models.Category, error)
-	CreateReturningTx(tx *sqlx.Tx, name string, sortOrder int) (*models.Category, error)
-	PartialUpdate(id int, name *string, sortOrder *int) error
-	GetByID(id int) (*models.Category, error)
+	GetAll(ctx context.Context) ([]models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, id int, name string, sortOrder int) error
+	Delete(ctx context.Context, id int, reassignTo *int) (bool, error)
+	BeginTx(ctx context.Context) (*sqlx.Tx, error)
+	GetByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (*models.Category, error)
+	CreateReturningTx(ctx context.Context, tx *sqlx.Tx, category *models.Category) error
+	PartialUpdate(ctx context.Context, id int, req models.CategoryUpdateRequest) error
+	GetByID(ctx context.Context, id int) (*models.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+	GetByName(ctx context.Context, name string) (*models.Category, error)
+	GetByExternalID(ctx context.Context, externalID string) (*models.Category, error)
+	SetExternalID(ctx context.Context, id int, externalID string) error
+	SlugExists(ctx context.Context, slug string, excludeID int) (bool, error)
+	Move(ctx context.Context, id int, parentID *int, sortOrder *int) (bool, error)
+	GetDeleted(ctx context.Context) ([]models.TrashItem, error)
+	GetDeletedByID(ctx context.Context, id int) (*models.Category, error)
+	Restore(ctx context.Context, id int) error
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
 }
 
 type CategoryRepo struct {
@@ -27,94 +40,310 @@ func NewCategoryRepo(db *sqlx.DB) *CategoryRepo {
 	return &CategoryRepo{db: db}
 }
 
-func (r *CategoryRepo) GetAll() ([]models.Category, error) {
+const categoryFields = `id, name, sort_order, parent_id, slug, description, image_url, external_id`
+
+// categoryTreeLock — ключ advisory-блокировки, под которой меняется структура дерева категорий.
+// Две встречные перестановки по отдельности цикла не создают, а вместе создают, и блокировкой строк
+// это не отловить: проверка читает всё поддерево. Поэтому перенос и удаление категорий идут по одному.
+const categoryTreeLock = 0x63617473
+
+func lockCategoryTree(ctx context.Context, tx *sqlx.Tx) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryTreeLock); err != nil {
+		return fmt.Errorf("failed to lock category tree: %w", err)
+	}
+	return nil
+}
+
+// categoryDescendantsSQL — запрос id категорий из списка (параметр arg, int[]) вместе со всеми их потомками
+func categoryDescendantsSQL(arg string) string {
+	return `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ANY(` + arg + `::int[])
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	) SELECT id FROM subtree`
+}
+
+func (r *CategoryRepo) GetAll(ctx context.Context) ([]models.Category, error) {
 	var categories []models.Category
-	err := r.db.Select(&categories, `SELECT * FROM categories ORDER BY sort_order`)
+	err := r.db.SelectContext(ctx, &categories, `SELECT `+categoryFields+` FROM categories WHERE deleted_at IS NULL ORDER BY sort_order, id`)
 	return categories, err
 }
 
-func (r *CategoryRepo) Create(category *models.Category) error {
-	err := r.db.QueryRow(
-		`INSERT INTO categories (name, sort_order) VALUES ($1, $2) RETURNING id`,
-		category.Name, category.SortOrder,
+func (r *CategoryRepo) Create(ctx context.Context, category *models.Category) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO categories (name, sort_order, parent_id, slug, description, image_url, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, category.Name, category.SortOrder, category.ParentID, category.Slug, category.Description, category.ImageURL, category.ExternalID,
 	).Scan(&category.ID)
 	return err
 }
 
-func (r *CategoryRepo) Update(id int, name string, sortOrder int) error {
-	_, err := r.db.Exec(`UPDATE categories SET name = $1, sort_order = $2 WHERE id = $3`, name, sortOrder, id)
+func (r *CategoryRepo) Update(ctx context.Context, id int, name string, sortOrder int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE categories SET name = $1, sort_order = $2 WHERE id = $3 AND deleted_at IS NULL`, name, sortOrder, id)
 	return err
 }
 
-func (r *CategoryRepo) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
-	return err
-}
+// Delete переносит категорию в корзину. Подкатегории переходят к её родителю,
+// товары — в категорию reassignTo (если она задана) в той же транзакции.
+// Без reassignTo удаляется только категория без товаров: проверка идёт в транзакции под блокировкой
+// строки категории, которую ждут и вставки товаров с этой категорией (внешний ключ).
+// false — ничего не удалено: категория или reassignTo не найдены, либо в категории есть товары.
+func (r *CategoryRepo) Delete(ctx context.Context, id int, reassignTo *int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
 
-func (r *CategoryRepo) BeginTx() (*sqlx.Tx, error) {
-	return r.db.Beginx()
-}
+	if err := lockCategoryTree(ctx, tx); err != nil {
+		return false, err
+	}
 
-func (r *CategoryRepo) GetByNameTx(tx *sqlx.Tx, name string) (*models.Category, error) {
-	var cat models.Category
-	err := tx.Get(&cat, `SELECT * FROM categories WHERE name = $1`, name)
+	var locked int
+	err = tx.GetContext(ctx, &locked, `SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return nil, err
+		return false, fmt.Errorf("failed to lock category: %w", err)
 	}
-	return &cat, nil
+
+	if reassignTo != nil {
+		err = tx.GetContext(ctx, &locked, `
+			SELECT id FROM categories WHERE id = $1 AND id <> $2 AND deleted_at IS NULL FOR SHARE
+		`, *reassignTo, id)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to check reassign target: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE products SET category_id = $1 WHERE category_id = $2`, *reassignTo, id); err != nil {
+			return false, fmt.Errorf("failed to reassign products: %w", err)
+		}
+	} else {
+		var hasProducts bool
+		err = tx.GetContext(ctx, &hasProducts, `
+			SELECT EXISTS(SELECT 1 FROM products WHERE category_id = $1 AND deleted_at IS NULL)
+		`, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to count category products: %w", err)
+		}
+		if hasProducts {
+			return false, nil
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE categories
+		SET parent_id = (SELECT parent_id FROM categories WHERE id = $1)
+		WHERE parent_id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to reattach subcategories: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE categories SET deleted_at = NOW() WHERE id = $1`, id); err != nil {
+		return false, fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *CategoryRepo) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return r.db.BeginTxx(ctx, nil)
 }
 
-func (r *CategoryRepo) CreateReturningTx(tx *sqlx.Tx, name string, sortOrder int) (*models.Category, error) {
+func (r *CategoryRepo) GetByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (*models.Category, error) {
 	var cat models.Category
-	err := tx.Get(&cat, `
-		INSERT INTO categories (name, sort_order)
-		VALUES ($1, $2)
-		RETURNING id, name, sort_order
-	`, name, sortOrder)
+	err := tx.GetContext(ctx, &cat, `SELECT `+categoryFields+` FROM categories WHERE name = $1 AND deleted_at IS NULL`, name)
 	if err != nil {
 		return nil, err
 	}
 	return &cat, nil
 }
 
-func (r *CategoryRepo) PartialUpdate(id int, name *string, sortOrder *int) error {
-	if name == nil && sortOrder == nil {
-		return fmt.Errorf("nothing to update: at least one field must be provided")
-	}
+func (r *CategoryRepo) CreateReturningTx(ctx context.Context, tx *sqlx.Tx, category *models.Category) error {
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO categories (name, sort_order, parent_id, slug, description, image_url, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, category.Name, category.SortOrder, category.ParentID, category.Slug, category.Description, category.ImageURL, category.ExternalID,
+	).Scan(&category.ID)
+}
 
+func (r *CategoryRepo) PartialUpdate(ctx context.Context, id int, req models.CategoryUpdateRequest) error {
 	query := "UPDATE categories SET "
 	args := []interface{}{}
 	setParts := []string{}
-	i := 1
 
-	if name != nil {
-		setParts = append(setParts, fmt.Sprintf("name = $%d", i))
-		args = append(args, *name)
-		i++
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, len(args)))
 	}
 
-	if sortOrder != nil {
-		setParts = append(setParts, fmt.Sprintf("sort_order = $%d", i))
-		args = append(args, *sortOrder)
-		i++
+	if req.Name != nil {
+		set("name", *req.Name)
+	}
+	if req.SortOrder != nil {
+		set("sort_order", *req.SortOrder)
+	}
+	if req.Slug != nil {
+		set("slug", *req.Slug)
+	}
+	// Пустая строка очищает описание и изображение
+	if req.Description != nil {
+		set("description", nullIfEmpty(*req.Description))
+	}
+	if req.ImageURL != nil {
+		set("image_url", nullIfEmpty(*req.ImageURL))
+	}
+
+	if len(setParts) == 0 {
+		return fmt.Errorf("nothing to update: at least one field must be provided")
 	}
 
 	query += strings.Join(setParts, ", ")
-	query += fmt.Sprintf(" WHERE id = $%d", i)
 	args = append(args, id)
+	query += fmt.Sprintf(" WHERE id = $%d AND deleted_at IS NULL", len(args))
 
-	_, err := r.db.Exec(query, args...)
+	_, err := r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (r *CategoryRepo) GetByID(id int) (*models.Category, error) {
+func (r *CategoryRepo) GetByID(ctx context.Context, id int) (*models.Category, error) {
+	var category models.Category
+	err := r.db.GetContext(ctx, &category, `SELECT `+categoryFields+` FROM categories WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepo) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.GetContext(ctx, &category, `SELECT `+categoryFields+` FROM categories WHERE slug = $1 AND deleted_at IS NULL`, slug)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepo) GetByName(ctx context.Context, name string) (*models.Category, error) {
 	var category models.Category
-	err := r.db.QueryRow(`SELECT id, name, sort_order FROM categories WHERE id = $1`, id).
-		Scan(&category.ID, &category.Name, &category.SortOrder)
+	err := r.db.GetContext(ctx, &category, `SELECT `+categoryFields+` FROM categories WHERE name = $1 AND deleted_at IS NULL`, name)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
 
+func (r *CategoryRepo) GetByExternalID(ctx context.Context, externalID string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.GetContext(ctx, &category, `SELECT `+categoryFields+` FROM categories WHERE external_id = $1 AND deleted_at IS NULL`, externalID)
 	if err != nil {
 		return nil, err
 	}
+	return &category, nil
+}
+
+func (r *CategoryRepo) SetExternalID(ctx context.Context, id int, externalID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE categories SET external_id = $1 WHERE id = $2`, externalID, id)
+	return err
+}
+
+func (r *CategoryRepo) SlugExists(ctx context.Context, slug string, excludeID int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM categories WHERE slug = $1 AND id <> $2 AND deleted_at IS NULL)`, slug, excludeID)
+	return exists, err
+}
+
+// Move переносит категорию под parentID (nil — в корень). Проверки, что категория и родитель существуют
+// и родитель не лежит в поддереве категории, входят в сам UPDATE и выполняются под блокировкой дерева.
+// false — перенос не выполнен, потому что одна из проверок не прошла.
+func (r *CategoryRepo) Move(ctx context.Context, id int, parentID *int, sortOrder *int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
 
+	if err := lockCategoryTree(ctx, tx); err != nil {
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE categories
+		SET parent_id = $1, sort_order = COALESCE($2, sort_order)
+		WHERE id = $3 AND deleted_at IS NULL
+		  AND ($1::int IS NULL OR (
+		        EXISTS (SELECT 1 FROM categories p WHERE p.id = $1 AND p.deleted_at IS NULL)
+		        AND $1 NOT IN (`+categoryDescendantsSQL("ARRAY[$3::int]")+`)
+		  ))
+	`, parentID, sortOrder, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to move category: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return false, nil
+	}
+	return true, tx.Commit()
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (r *CategoryRepo) GetDeleted(ctx context.Context) ([]models.TrashItem, error) {
+	var items []models.TrashItem
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT 'category' AS type, id, name AS title, deleted_at
+		FROM categories
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted categories: %w", err)
+	}
+	return items, nil
+}
+
+func (r *CategoryRepo) GetDeletedByID(ctx context.Context, id int) (*models.Category, error) {
+	var category models.Category
+	err := r.db.GetContext(ctx, &category, `SELECT `+categoryFields+` FROM categories WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, err
+	}
 	return &category, nil
 }
+
+// Restore возвращает категорию из корзины. Если родитель тоже удалён, категория становится корневой.
+// Подкатегории при удалении перешли к родителю и обратно не возвращаются.
+func (r *CategoryRepo) Restore(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE categories c
+		SET deleted_at = NULL,
+		    parent_id = CASE
+		        WHEN EXISTS (SELECT 1 FROM categories p WHERE p.id = c.parent_id AND p.deleted_at IS NULL) THEN c.parent_id
+		    END
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore category: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет категории, лежащие в корзине дольше срока хранения.
+// Удалённые товары, которые на них ссылаются, остаются без категории (ON DELETE SET NULL).
+func (r *CategoryRepo) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE deleted_at IS NOT NULL AND deleted_at < NOW() - make_interval(secs => $1)`, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted categories: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -22,13 +22,13 @@ func (r *DashboardRepository) GetDashboardData(ctx context.Context) (*models.Das
 	var data models.DashboardData
 
 	// Общее количество заказов
-	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders`).Scan(&data.TotalOrders)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders WHERE deleted_at IS NULL`).Scan(&data.TotalOrders)
 	if err != nil {
 		return nil, err
 	}
 
 	// Общая выручка
-	err = r.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(total), 0) FROM orders`).Scan(&data.TotalRevenue)
+	err = r.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(total), 0) FROM orders WHERE deleted_at IS NULL`).Scan(&data.TotalRevenue)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +37,7 @@ func (r *DashboardRepository) GetDashboardData(ctx context.Context) (*models.Das
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT p.id, p.name, SUM(oi.quantity) as sold
 		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL
 		JOIN products p ON p.id = oi.product_id
 		GROUP BY p.id
 		ORDER BY sold DESC
@@ -59,7 +60,7 @@ func (r *DashboardRepository) GetDashboardData(ctx context.Context) (*models.Das
 	rows, err = r.db.QueryContext(ctx, `
 		SELECT DATE(created_at), COUNT(*), SUM(total)
 		FROM orders
-		WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'
+		WHERE created_at >= CURRENT_DATE - INTERVAL '7 days' AND deleted_at IS NULL
 		GROUP BY DATE(created_at)
 		ORDER BY DATE(created_at)
 	`)
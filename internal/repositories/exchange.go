@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// ExchangeFileRepository хранит файлы обмена с 1С. 1С может присылать файл частями, и части
+// приходят на любую реплику, поэтому файл собирается в общей базе, а не на локальном диске.
+type ExchangeFileRepository interface {
+	AppendPart(ctx context.Context, name string, data []byte, limit int64) (bool, error)
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	Clear(ctx context.Context) error
+}
+
+type ExchangeFileRepo struct {
+	db *sqlx.DB
+}
+
+func NewExchangeFileRepo(db *sqlx.DB) *ExchangeFileRepo {
+	return &ExchangeFileRepo{db: db}
+}
+
+// AppendPart дописывает часть файла, если вместе с уже загруженными частями файл не превысит limit байт;
+// false — часть не сохранена из-за лимита. Части одного файла дописываются по очереди
+// (advisory-блокировка по имени), поэтому параллельные запросы не обходят лимит.
+func (r *ExchangeFileRepo) AppendPart(ctx context.Context, name string, data []byte, limit int64) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('exchange_files:' || $1))`, name); err != nil {
+		return false, fmt.Errorf("failed to lock exchange file: %w", err)
+	}
+	var size int64
+	if err := tx.GetContext(ctx, &size, `SELECT COALESCE(SUM(octet_length(data)), 0) FROM exchange_files WHERE name = $1`, name); err != nil {
+		return false, fmt.Errorf("failed to get exchange file size: %w", err)
+	}
+	if size+int64(len(data)) > limit {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO exchange_files (name, data) VALUES ($1, $2)`, name, data); err != nil {
+		return false, fmt.Errorf("failed to save exchange file part: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+// ReadFile собирает файл из частей в порядке загрузки; sql.ErrNoRows — файл не загружали
+func (r *ExchangeFileRepo) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	var parts [][]byte
+	if err := r.db.SelectContext(ctx, &parts, `SELECT data FROM exchange_files WHERE name = $1 ORDER BY id`, name); err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	var data []byte
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	return data, nil
+}
+
+// Clear удаляет файлы прошлых сеансов обмена
+func (r *ExchangeFileRepo) Clear(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM exchange_files`)
+	return err
+}
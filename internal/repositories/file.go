@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+)
+
+// Все места, где хранятся имена загруженных файлов (галереи товаров, фото отзывов, картинки категорий).
+// products.url и categories.image_url могут содержать полную ссылку, поэтому берём последний сегмент пути.
+const fileReferencesQuery = `
+	SELECT file_name AS key FROM product_images
+	UNION SELECT medium_file_name FROM product_images
+	UNION SELECT thumb_file_name FROM product_images
+	UNION SELECT file_name FROM review_photos
+	UNION SELECT medium_file_name FROM review_photos
+	UNION SELECT thumb_file_name FROM review_photos
+	UNION SELECT regexp_replace(url, '^.*/', '') FROM products WHERE url IS NOT NULL AND url <> ''
+	UNION SELECT regexp_replace(image_url, '^.*/', '') FROM categories WHERE image_url IS NOT NULL AND image_url <> ''
+`
+
+type FileRepository interface {
+	IsReferenced(ctx context.Context, key string) (bool, error)
+	GetReferencedKeys(ctx context.Context) (map[string]bool, error)
+}
+
+type FileRepo struct {
+	db *sqlx.DB
+}
+
+func NewFileRepo(db *sqlx.DB) *FileRepo {
+	return &FileRepo{db: db}
+}
+
+func (r *FileRepo) IsReferenced(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM (`+fileReferencesQuery+`) refs WHERE key = $1)`, key)
+	return exists, err
+}
+
+func (r *FileRepo) GetReferencedKeys(ctx context.Context) (map[string]bool, error) {
+	var keys []string
+	if err := r.db.SelectContext(ctx, &keys, fileReferencesQuery); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		result[key] = true
+	}
+	return result, nil
+}
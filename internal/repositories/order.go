@@ -2,24 +2,34 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
 )
 
 type OrderRepository interface {
-	CreateOrder(ownerID string, total float64) (int, error)
-	GetByOwnerID(ownerID string) ([]models.Order, error)
-	GetAll() ([]models.Order, error)
-	UpdateStatus(orderID int, status string) error
-	GetByID(orderID int) (*models.Order, error)
-	GetOrderItems(orderID int) ([]models.OrderItem, error)
-	GetWithItemsByOwnerID(ownerID string) ([]models.Order, error)
-	CreateFullOrder(ownerID string, req models.PlaceOrderRequest, total float64) (int, error)
-	GetAllWithItems() ([]models.Order, error)
-	DeleteOrder(orderID int) error
-	AddReview(orderID int, comment *string, rating *int, userID int) error
-	GetReviewByOrderID(orderID int) (*models.OrderReview, error)
-	GetAllOrderReviews() ([]models.OrderReview, error)
+	CreateOrder(ctx context.Context, ownerID string, total float64) (int, error)
+	GetByOwnerID(ctx context.Context, ownerID string) ([]models.Order, error)
+	GetAll(ctx context.Context) ([]models.Order, error)
+	UpdateStatus(ctx context.Context, orderID int, status string) error
+	GetByID(ctx context.Context, orderID int) (*models.Order, error)
+	GetOrderItems(ctx context.Context, orderID int) ([]models.OrderItem, error)
+	GetWithItemsByOwnerID(ctx context.Context, ownerID string) ([]models.Order, error)
+	CreateFullOrder(ctx context.Context, ownerID string, req models.PlaceOrderRequest, total float64) (int, error)
+	GetAllWithItems(ctx context.Context) ([]models.Order, error)
+	GetWithItemsBetween(ctx context.Context, from, to *time.Time) ([]models.Order, error)
+	DeleteOrder(ctx context.Context, orderID int) error
+	GetNotExported(ctx context.Context) ([]models.Order, error)
+	MarkExported(ctx context.Context, orderIDs []int) error
+	GetDeleted(ctx context.Context) ([]models.TrashItem, error)
+	Restore(ctx context.Context, id int) (bool, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
+	SetPayment(ctx context.Context, orderID int, provider, reference, paymentID, paymentURL, status string) (bool, error)
+	UpdatePaymentStatus(ctx context.Context, orderID int, from []string, to, paymentID string) (bool, error)
+	FailPayment(ctx context.Context, orderID int, reference string) (bool, error)
+	GetByIDWithDeleted(ctx context.Context, orderID int) (*models.Order, error)
 }
 
 type OrderRepo struct {
@@ -33,12 +43,13 @@ func NewOrderRepo(db *sqlx.DB) *OrderRepo {
 const orderFields = `
 	id, owner_id, total, created_at, status,
 	name, address, delivery_type, payment_type, change_for,
-	delivery_fee, delivery_text, order_comment
+	delivery_fee, delivery_text, order_comment,
+	payment_provider, payment_id, payment_status, payment_url, paid_at, refunded_at, payment_reference
 `
 
-func (r *OrderRepo) CreateOrder(ownerID string, total float64) (int, error) {
+func (r *OrderRepo) CreateOrder(ctx context.Context, ownerID string, total float64) (int, error) {
 	var orderID int
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		INSERT INTO orders (owner_id, total)
 		VALUES ($1, $2)
 		RETURNING id
@@ -46,25 +57,25 @@ func (r *OrderRepo) CreateOrder(ownerID string, total float64) (int, error) {
 	return orderID, err
 }
 
-func (r *OrderRepo) GetByOwnerID(ownerID string) ([]models.Order, error) {
+func (r *OrderRepo) GetByOwnerID(ctx context.Context, ownerID string) ([]models.Order, error) {
 	var orders []models.Order
-	query := fmt.Sprintf("SELECT %s FROM orders WHERE owner_id = $1 ORDER BY created_at DESC", orderFields)
-	err := r.db.Select(&orders, query, ownerID)
+	query := fmt.Sprintf("SELECT %s FROM orders WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC", orderFields)
+	err := r.db.SelectContext(ctx, &orders, query, ownerID)
 
 	return orders, err
 }
 
-func (r *OrderRepo) GetAll() ([]models.Order, error) {
+func (r *OrderRepo) GetAll(ctx context.Context) ([]models.Order, error) {
 	var orders []models.Order
-	query := fmt.Sprintf("SELECT %s FROM orders ORDER BY created_at DESC", orderFields)
-	err := r.db.Select(&orders, query)
+	query := fmt.Sprintf("SELECT %s FROM orders WHERE deleted_at IS NULL ORDER BY created_at DESC", orderFields)
+	err := r.db.SelectContext(ctx, &orders, query)
 
 	return orders, err
 
 }
 
-func (r *OrderRepo) UpdateStatus(orderID int, status string) error {
-	res, err := r.db.Exec(`UPDATE orders SET status = $1 WHERE id = $2`, status, orderID)
+func (r *OrderRepo) UpdateStatus(ctx context.Context, orderID int, status string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE orders SET status = $1 WHERE id = $2 AND deleted_at IS NULL`, status, orderID)
 	if err != nil {
 		return err
 	}
@@ -81,14 +92,15 @@ func (r *OrderRepo) UpdateStatus(orderID int, status string) error {
 	return nil
 }
 
-func (r *OrderRepo) GetByID(orderID int) (*models.Order, error) {
+func (r *OrderRepo) GetByID(ctx context.Context, orderID int) (*models.Order, error) {
 	var order models.Order
-	err := r.db.Get(&order, `
+	err := r.db.GetContext(ctx, &order, `
     SELECT id, owner_id, total, created_at, status, name, address,
        delivery_type, payment_type, change_for, delivery_fee, delivery_text,
-       order_comment
+       order_comment,
+       payment_provider, payment_id, payment_status, payment_url, paid_at, refunded_at, payment_reference
 	FROM orders 
-	WHERE id = $1
+	WHERE id = $1 AND deleted_at IS NULL
 
 `, orderID)
 
@@ -98,27 +110,27 @@ func (r *OrderRepo) GetByID(orderID int) (*models.Order, error) {
 	return &order, nil
 }
 
-func (r *OrderRepo) GetOrderItems(orderID int) ([]models.OrderItem, error) {
+func (r *OrderRepo) GetOrderItems(ctx context.Context, orderID int) ([]models.OrderItem, error) {
 	var items []models.OrderItem
-	err := r.db.Select(&items, `
-		SELECT order_id, product_id, product_name, quantity, price
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT order_id, COALESCE(product_id, 0) AS product_id, product_name, quantity, price
 		FROM order_items
 		WHERE order_id = $1
 	`, orderID)
 	return items, err
 }
 
-func (r *OrderRepo) GetWithItemsByOwnerID(ownerID string) ([]models.Order, error) {
+func (r *OrderRepo) GetWithItemsByOwnerID(ctx context.Context, ownerID string) ([]models.Order, error) {
 	var orders []models.Order
 
-	query := fmt.Sprintf("SELECT %s FROM orders WHERE owner_id = $1 ORDER BY created_at DESC", orderFields)
-	err := r.db.Select(&orders, query, ownerID)
+	query := fmt.Sprintf("SELECT %s FROM orders WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC", orderFields)
+	err := r.db.SelectContext(ctx, &orders, query, ownerID)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range orders {
-		items, err := r.GetOrderItems(orders[i].ID)
+		items, err := r.GetOrderItems(ctx, orders[i].ID)
 		if err != nil {
 			return nil, err
 		}
@@ -128,14 +140,14 @@ func (r *OrderRepo) GetWithItemsByOwnerID(ownerID string) ([]models.Order, error
 	return orders, nil
 }
 
-func (r *OrderRepo) CreateFullOrder(ownerID string, req models.PlaceOrderRequest, total float64) (int, error) {
-	tx, err := r.db.Beginx()
+func (r *OrderRepo) CreateFullOrder(ctx context.Context, ownerID string, req models.PlaceOrderRequest, total float64) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 
 	var orderID int
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
 	INSERT INTO orders (
 		owner_id, total, status, created_at,
 		delivery_type, payment_type, change_for,
@@ -151,7 +163,7 @@ func (r *OrderRepo) CreateFullOrder(ownerID string, req models.PlaceOrderRequest
 `,
 		ownerID,
 		total,
-		req.Status,
+		models.OrderStatusNew,
 		req.DeliveryType,
 		req.PaymentType,
 		req.ChangeFor,
@@ -168,7 +180,7 @@ func (r *OrderRepo) CreateFullOrder(ownerID string, req models.PlaceOrderRequest
 	}
 
 	for _, item := range req.Items {
-		_, err := tx.Exec(`
+		_, err := tx.ExecContext(ctx, `
 			INSERT INTO order_items (order_id, product_id, quantity, product_name, price)
 			VALUES ($1, $2, $3, $4, $5)
 		`, orderID, item.ProductID, item.Quantity, item.Name, item.Price)
@@ -186,17 +198,26 @@ func (r *OrderRepo) CreateFullOrder(ownerID string, req models.PlaceOrderRequest
 	return orderID, nil
 }
 
-func (r *OrderRepo) GetAllWithItems() ([]models.Order, error) {
+func (r *OrderRepo) GetAllWithItems(ctx context.Context) ([]models.Order, error) {
+	return r.GetWithItemsBetween(ctx, nil, nil)
+}
+
+// GetWithItemsBetween возвращает заказы с товарами, созданные в [from, to); nil — без ограничения
+func (r *OrderRepo) GetWithItemsBetween(ctx context.Context, from, to *time.Time) ([]models.Order, error) {
 	var orders []models.Order
 
-	query := fmt.Sprintf("SELECT %s FROM orders ORDER BY created_at DESC", orderFields)
-	err := r.db.Select(&orders, query)
+	query := fmt.Sprintf(`SELECT %s FROM orders
+WHERE deleted_at IS NULL
+  AND ($1::timestamp IS NULL OR created_at >= $1)
+  AND ($2::timestamp IS NULL OR created_at < $2)
+ORDER BY created_at DESC`, orderFields)
+	err := r.db.SelectContext(ctx, &orders, query, from, to)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range orders {
-		items, err := r.GetOrderItems(orders[i].ID)
+		items, err := r.GetOrderItems(ctx, orders[i].ID)
 		if err != nil {
 			return nil, err
 		}
@@ -206,62 +227,156 @@ func (r *OrderRepo) GetAllWithItems() ([]models.Order, error) {
 	return orders, nil
 }
 
-func (r *OrderRepo) DeleteOrder(orderID int) error {
-	tx, err := r.db.Beginx()
+// DeleteOrder переносит заказ в корзину; вместе с позициями он удаляется PurgeDeleted
+func (r *OrderRepo) DeleteOrder(ctx context.Context, orderID int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE orders SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, orderID)
 	if err != nil {
 		return err
 	}
 
-	// Удалим сначала товары заказа (связанные строки)
-	if _, err := tx.Exec(`DELETE FROM order_items WHERE order_id = $1`, orderID); err != nil {
-		tx.Rollback()
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
 		return err
 	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("order with id %d not found", orderID)
+	}
+	return nil
+}
+
+// GetNotExported возвращает заказы с товарами, которые ещё не выгружались в 1С
+func (r *OrderRepo) GetNotExported(ctx context.Context) ([]models.Order, error) {
+	var orders []models.Order
+
+	query := fmt.Sprintf("SELECT %s FROM orders WHERE exported_at IS NULL AND deleted_at IS NULL ORDER BY id", orderFields)
+	if err := r.db.SelectContext(ctx, &orders, query); err != nil {
+		return nil, fmt.Errorf("failed to fetch orders for export: %w", err)
+	}
+
+	for i := range orders {
+		items, err := r.GetOrderItems(ctx, orders[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		orders[i].Items = items
+	}
+
+	return orders, nil
+}
 
-	// Удалим сам заказ
-	res, err := tx.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+func (r *OrderRepo) MarkExported(ctx context.Context, orderIDs []int) error {
+	if len(orderIDs) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		ids = append(ids, int64(id))
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE orders SET exported_at = NOW() WHERE id = ANY($1::int[])`, pq.Array(ids))
 	if err != nil {
-		tx.Rollback()
-		return err
+		return fmt.Errorf("failed to mark orders exported: %w", err)
 	}
+	return nil
+}
 
-	rowsAffected, err := res.RowsAffected()
+func (r *OrderRepo) GetDeleted(ctx context.Context) ([]models.TrashItem, error) {
+	var items []models.TrashItem
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT 'order' AS type, id,
+		       'Заказ №' || id || COALESCE(', ' || name, '') || ' — ' || total AS title,
+		       deleted_at
+		FROM orders
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, fmt.Errorf("failed to fetch deleted orders: %w", err)
 	}
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("order with id %d not found", orderID)
+	return items, nil
+}
+
+func (r *OrderRepo) Restore(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE orders SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore order: %w", err)
 	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+// PurgeDeleted окончательно удаляет заказы из корзины старше olderThan; позиции удаляются каскадно
+func (r *OrderRepo) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM orders WHERE deleted_at IS NOT NULL AND deleted_at < NOW() - make_interval(secs => $1)`, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted orders: %w", err)
+	}
+	return res.RowsAffected()
+}
 
-	return tx.Commit()
+// SetPayment сохраняет созданный у провайдера платёж. Оплаченный или возвращённый платёж
+// не перезаписывается — тогда возвращается false.
+func (r *OrderRepo) SetPayment(ctx context.Context, orderID int, provider, reference, paymentID, paymentURL, status string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE orders
+		SET payment_provider = $2, payment_reference = NULLIF($3, ''), payment_id = NULLIF($4, ''),
+		    payment_url = NULLIF($5, ''), payment_status = $6
+		WHERE id = $1 AND (payment_status IS NULL OR payment_status NOT IN ('paid', 'refunded'))
+	`, orderID, provider, reference, paymentID, paymentURL, status)
+	if err != nil {
+		return false, fmt.Errorf("failed to save order payment: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
 }
 
-func (r *OrderRepo) AddReview(orderID int, comment *string, rating *int, userID int) error {
-	_, err := r.db.Exec(`
-		INSERT INTO order_reviews (order_id, comment, rating, user_id)
-		VALUES ($1, $2, $3, $4)
-	`, orderID, comment, rating, userID)
-	return err
+// UpdatePaymentStatus переводит оплату заказа в статус to, только если текущий статус входит в from;
+// false — заказ уже в другом статусе. Непустой paymentID заменяет сохранённый: по нему делается возврат.
+// Заказы в корзине тоже обновляются — деньги по ним всё равно движутся.
+func (r *OrderRepo) UpdatePaymentStatus(ctx context.Context, orderID int, from []string, to, paymentID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE orders
+		SET payment_status = $3::varchar,
+		    payment_id = COALESCE(NULLIF($4, ''), payment_id),
+		    paid_at = CASE WHEN $3::varchar = 'paid' THEN COALESCE(paid_at, NOW()) ELSE paid_at END,
+		    refunded_at = CASE $3::varchar WHEN 'refunded' THEN NOW() WHEN 'paid' THEN NULL ELSE refunded_at END
+		WHERE id = $1 AND payment_status = ANY($2)
+	`, orderID, pq.Array(from), to, paymentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update payment status: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
 }
 
-func (r *OrderRepo) GetReviewByOrderID(orderID int) (*models.OrderReview, error) {
-	var review models.OrderReview
-	err := r.db.Get(&review, `SELECT * FROM order_reviews WHERE order_id = $1`, orderID)
+// FailPayment помечает ожидающую оплату неудавшейся, только если уведомление относится к текущей попытке
+func (r *OrderRepo) FailPayment(ctx context.Context, orderID int, reference string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE orders
+		SET payment_status = 'failed'
+		WHERE id = $1 AND payment_status = 'pending' AND payment_reference = $2
+	`, orderID, reference)
 	if err != nil {
-		return nil, err
+		return false, fmt.Errorf("failed to update payment status: %w", err)
 	}
-	return &review, nil
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
 }
 
-func (r *OrderRepo) GetAllOrderReviews() ([]models.OrderReview, error) {
-	var reviews []models.OrderReview
-	err := r.db.Select(&reviews, `
-		SELECT orr.id, orr.order_id, orr.user_id, u.username, orr.rating, orr.comment, orr.created_at
-		FROM order_reviews orr
-		LEFT JOIN users u ON orr.user_id = u.id
-		ORDER BY orr.created_at DESC
-	`)
-	return reviews, err
+// GetByIDWithDeleted возвращает заказ вместе с удалёнными в корзину: оплата по ним всё равно приходит
+func (r *OrderRepo) GetByIDWithDeleted(ctx context.Context, orderID int) (*models.Order, error) {
+	var order models.Order
+	query := fmt.Sprintf("SELECT %s, deleted_at FROM orders WHERE id = $1", orderFields)
+	if err := r.db.GetContext(ctx, &order, query, orderID); err != nil {
+		return nil, err
+	}
+	return &order, nil
 }
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"chechnya-product/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type PriceRepository interface {
+	GetHistory(ctx context.Context, productID, limit int) ([]models.PriceHistoryEntry, error)
+	CreateScheduled(ctx context.Context, price *models.ScheduledPrice) error
+	GetScheduled(ctx context.Context, productID int, includeApplied bool) ([]models.ScheduledPrice, error)
+	DeleteScheduled(ctx context.Context, productID, id int) (bool, error)
+	ApplyDue(ctx context.Context, now time.Time) ([]int, error)
+}
+
+type PriceRepo struct {
+	db *sqlx.DB
+}
+
+func NewPriceRepo(db *sqlx.DB) *PriceRepo {
+	return &PriceRepo{db: db}
+}
+
+func (r *PriceRepo) GetHistory(ctx context.Context, productID, limit int) ([]models.PriceHistoryEntry, error) {
+	var history []models.PriceHistoryEntry
+	err := r.db.SelectContext(ctx, &history, `
+SELECT h.id, h.product_id, h.old_price, h.new_price, h.changed_by, u.username AS changed_by_name, h.source, h.created_at
+FROM price_history h
+LEFT JOIN users u ON u.id = h.changed_by
+WHERE h.product_id = $1
+ORDER BY h.created_at DESC, h.id DESC
+LIMIT $2`, productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price history: %w", err)
+	}
+	return history, nil
+}
+
+func (r *PriceRepo) CreateScheduled(ctx context.Context, price *models.ScheduledPrice) error {
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO scheduled_prices (product_id, price, old_price, starts_at, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at`,
+		price.ProductID, price.Price, price.OldPrice, price.StartsAt, price.CreatedBy,
+	).Scan(&price.ID, &price.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled price: %w", err)
+	}
+	return nil
+}
+
+func (r *PriceRepo) GetScheduled(ctx context.Context, productID int, includeApplied bool) ([]models.ScheduledPrice, error) {
+	var prices []models.ScheduledPrice
+	err := r.db.SelectContext(ctx, &prices, `
+SELECT id, product_id, price, old_price, starts_at, created_by, created_at, applied_at
+FROM scheduled_prices
+WHERE product_id = $1 AND ($2 OR applied_at IS NULL)
+ORDER BY starts_at, id`, productID, includeApplied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch scheduled prices: %w", err)
+	}
+	return prices, nil
+}
+
+// DeleteScheduled отменяет ещё не применённое изменение цены
+func (r *PriceRepo) DeleteScheduled(ctx context.Context, productID, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM scheduled_prices WHERE id = $1 AND product_id = $2 AND applied_at IS NULL`, id, productID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete scheduled price: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+// ApplyDue применяет все наступившие изменения цен и возвращает id затронутых товаров.
+// Строки блокируются с SKIP LOCKED, поэтому несколько экземпляров API не применят одно изменение дважды.
+func (r *PriceRepo) ApplyDue(ctx context.Context, now time.Time) ([]int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var due []models.ScheduledPrice
+	err = tx.SelectContext(ctx, &due, `
+SELECT id, product_id, price, old_price, starts_at, created_by, created_at, applied_at
+FROM scheduled_prices
+WHERE applied_at IS NULL AND starts_at <= $1
+ORDER BY starts_at, id
+FOR UPDATE SKIP LOCKED`, now)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to fetch due prices: %w", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[int]bool)
+	productIDs := make([]int, 0, len(due))
+	for _, p := range due {
+		change := models.PriceChange{ChangedBy: p.CreatedBy, Source: models.PriceSourceSchedule}
+		if err := recordPriceChange(ctx, tx, p.ProductID, p.Price, change); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE products SET price = $1, old_price = $2 WHERE id = $3 AND deleted_at IS NULL`, p.Price, p.OldPrice, p.ProductID); err != nil {
+			return nil, fmt.Errorf("failed to apply scheduled price %d: %w", p.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE scheduled_prices SET applied_at = $1 WHERE id = $2`, now, p.ID); err != nil {
+			return nil, fmt.Errorf("failed to mark scheduled price %d applied: %w", p.ID, err)
+		}
+		if !seen[p.ProductID] {
+			seen[p.ProductID] = true
+			productIDs = append(productIDs, p.ProductID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return productIDs, nil
+}
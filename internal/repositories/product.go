@@ -2,44 +2,46 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
-	"strconv"
+	"github.com/lib/pq"
 	"strings"
+	"time"
 )
 
 type ProductRepository interface {
-	BeginTx() (*sqlx.Tx, error)
-	GetAll() ([]models.Product, error)
-	Create(product *models.Product) error
-	Delete(id int) error
-	Update(id int, product *models.Product) error
-	GetByID(id int) (*models.Product, error)
-	GetFiltered(
-		search, category string,
-		minPrice, maxPrice float64,
-		limit, offset int,
-		sort string,
-		availability *bool,
-	) ([]models.Product, error)
-	GetCategories() ([]string, error)
-	GetCategoryNameByID(categoryID int) (string, error)
-	GetByName(name string) (*models.Product, error)
-	CreateTx(tx *sqlx.Tx, p *models.Product) error
-	GetByNameTx(tx *sqlx.Tx, name string) (*models.Product, error)
-	GetByIDTx(tx *sqlx.Tx, id int) (*models.Product, error)
-	GetCategoryNameByIDTx(tx *sqlx.Tx, categoryID int) (string, error)
-	UpdateTx(tx *sqlx.Tx, id int, p *models.Product) error
-	UpdateAvailabilityTx(tx *sqlx.Tx, id int, availability bool) error
-	GetAverageRating(productID int) (float64, error)
-	PatchProduct(id int, patch models.ProductPatch) error
-	CountFiltered(
-		search, category string,
-		minPrice, maxPrice float64,
-		availability *bool,
-	) (int, error)
-	IsProductNameExists(name string) (bool, error)
+	BeginTx(ctx context.Context) (*sqlx.Tx, error)
+	GetAll(ctx context.Context) ([]models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, product *models.Product, change models.PriceChange) error
+	GetByID(ctx context.Context, id int) (*models.Product, error)
+	GetFiltered(ctx context.Context, filter models.ProductFilter) ([]models.Product, *models.ProductCursor, error)
+	GetFacets(ctx context.Context, filter models.ProductFilter) (*models.ProductFacets, error)
+	GetCategories(ctx context.Context) ([]string, error)
+	GetCategoryNameByID(ctx context.Context, categoryID int) (string, error)
+	GetByName(ctx context.Context, name string) (*models.Product, error)
+	CreateTx(ctx context.Context, tx *sqlx.Tx, p *models.Product) error
+	GetByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (*models.Product, error)
+	GetByIDTx(ctx context.Context, tx *sqlx.Tx, id int) (*models.Product, error)
+	GetCategoryNameByIDTx(ctx context.Context, tx *sqlx.Tx, categoryID int) (string, error)
+	UpdateTx(ctx context.Context, tx *sqlx.Tx, id int, p *models.Product, change models.PriceChange) error
+	PatchProductTx(ctx context.Context, tx *sqlx.Tx, id int, patch models.ProductPatch) error
+	UpdateAvailabilityTx(ctx context.Context, tx *sqlx.Tx, id int, availability bool) error
+	GetAverageRating(ctx context.Context, productID int) (float64, error)
+	PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error
+	CountFiltered(ctx context.Context, filter models.ProductFilter) (int, error)
+	IsProductNameExists(ctx context.Context, name string) (bool, error)
+	GetBySKU(ctx context.Context, sku string) (*models.Product, error)
+	GetByExternalID(ctx context.Context, externalID string) (*models.Product, error)
+	SetExternalID(ctx context.Context, id int, externalID string) error
+	GetExternalIDs(ctx context.Context, ids []int) (map[int]string, error)
+	GetDeleted(ctx context.Context) ([]models.TrashItem, error)
+	GetDeletedByID(ctx context.Context, id int) (*models.Product, error)
+	Restore(ctx context.Context, id int) error
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
 }
 
 type ProductRepo struct {
@@ -50,32 +52,35 @@ func NewProductRepo(db *sqlx.DB) *ProductRepo {
 	return &ProductRepo{db: db}
 }
 
-func (r *ProductRepo) BeginTx() (*sqlx.Tx, error) {
-	return r.db.Beginx()
+func (r *ProductRepo) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return r.db.BeginTxx(ctx, nil)
 }
 
-func (r *ProductRepo) GetAll() ([]models.Product, error) {
+func (r *ProductRepo) GetAll(ctx context.Context) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Select(&products, `SELECT * FROM products`)
+	err := r.db.SelectContext(ctx, &products, `SELECT * FROM products WHERE deleted_at IS NULL`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch all products: %w", err)
 	}
 	return products, nil
 }
 
-func (r *ProductRepo) Create(product *models.Product) error {
+func (r *ProductRepo) Create(ctx context.Context, product *models.Product) error {
 	query := `
-INSERT INTO products (name, description, price, availability, category_id, url)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO products (name, description, price, old_price, availability, category_id, url, sku, external_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING id
 `
-	err := r.db.QueryRow(query,
+	err := r.db.QueryRowContext(ctx, query,
 		product.Name,
 		product.Description,
 		product.Price,
+		product.OldPrice,
 		product.Availability,
 		product.CategoryID,
 		product.Url,
+		product.SKU,
+		product.ExternalID,
 	).Scan(&product.ID)
 
 	if err != nil {
@@ -84,8 +89,9 @@ RETURNING id
 	return nil
 }
 
-func (r *ProductRepo) Delete(id int) error {
-	result, err := r.db.Exec(`DELETE FROM products WHERE id = $1`, id)
+// Delete переносит товар в корзину; окончательно он удаляется PurgeDeleted
+func (r *ProductRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE products SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
@@ -96,13 +102,23 @@ func (r *ProductRepo) Delete(id int) error {
 	return nil
 }
 
-func (r *ProductRepo) Update(id int, product *models.Product) error {
+func (r *ProductRepo) Update(ctx context.Context, id int, product *models.Product, change models.PriceChange) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := recordPriceChange(ctx, tx, id, product.Price, change); err != nil {
+		return err
+	}
+
 	query := `
 UPDATE products
-SET name = $1, description = $2, price = $3, availability = $4, category_id = $5, url = $6
-WHERE id = $7
+SET name = $1, description = $2, price = $3, old_price = $4, availability = $5, category_id = $6, url = $7, sku = $8
+WHERE id = $9 AND deleted_at IS NULL
 `
-	result, err := r.db.Exec(query, product.Name, product.Description, product.Price, product.Availability, product.CategoryID, id, product.Url)
+	result, err := tx.ExecContext(ctx, query, product.Name, product.Description, product.Price, product.OldPrice, product.Availability, product.CategoryID, product.Url, product.SKU, id)
 
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
@@ -111,166 +127,258 @@ WHERE id = $7
 	if rows == 0 {
 		return fmt.Errorf("product not found")
 	}
-	return nil
+	return tx.Commit()
 }
 
-func (r *ProductRepo) GetByID(id int) (*models.Product, error) {
+func (r *ProductRepo) GetByID(ctx context.Context, id int) (*models.Product, error) {
 	var p models.Product
-	err := r.db.Get(&p, `SELECT * FROM products WHERE id = $1`, id)
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product by id: %w", err)
 	}
 	return &p, nil
 }
 
-func (r *ProductRepo) GetFiltered(
-	search, category string,
-	minPrice, maxPrice float64,
-	limit, offset int,
-	sort string,
-	availability *bool,
-) ([]models.Product, error) {
-	query := `SELECT * FROM products WHERE 1=1`
-	args := []interface{}{}
-	i := 1
-
-	if search != "" {
-		query += fmt.Sprintf(" AND (LOWER(name) LIKE $%d OR LOWER(description) LIKE $%d)", i, i)
-		args = append(args, "%"+strings.ToLower(search)+"%")
-		i++
-	}
-	if category != "" {
-		query += fmt.Sprintf(" AND category_id = $%d", i)
-		categoryID, err := strconv.Atoi(category) // если нужно из строки
-		if err != nil {
-			return nil, fmt.Errorf("invalid category ID")
+func (r *ProductRepo) GetFiltered(ctx context.Context, filter models.ProductFilter) ([]models.Product, *models.ProductCursor, error) {
+	q := buildProductQuery(filter, "")
+	sortExpr, desc := productSortExpr(filter.SortKey(), q.searchArg)
+
+	if filter.Cursor != nil {
+		if err := q.applyCursor(filter.Cursor, filter.SortKey(), sortExpr, desc); err != nil {
+			return nil, nil, err
 		}
-		args = append(args, categoryID)
-		i++
 	}
-	if availability != nil {
-		query += fmt.Sprintf(" AND availability = $%d", i)
-		args = append(args, *availability)
-		i++
+
+	cursorValue := "products.id::text"
+	orderBy := "products.id DESC"
+	if sortExpr != "" {
+		cursorValue = sortExpr + "::text"
+		direction := "ASC"
+		if desc {
+			direction = "DESC"
+		}
+		orderBy = sortExpr + " " + direction + ", products.id DESC"
 	}
 
-	if minPrice > 0 {
-		query += fmt.Sprintf(" AND price >= $%d", i)
-		args = append(args, minPrice)
-		i++
+	query := `SELECT products.*, ` + cursorValue + ` AS cursor_value FROM products` + q.whereSQL() + ` ORDER BY ` + orderBy
+
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница
+	if filter.Limit > 0 {
+		query += " LIMIT " + q.arg(filter.Limit+1)
 	}
-	if maxPrice > 0 {
-		query += fmt.Sprintf(" AND price <= $%d", i)
-		args = append(args, maxPrice)
-		i++
+	if filter.Offset > 0 {
+		query += " OFFSET " + q.arg(filter.Offset)
 	}
 
-	sortMap := map[string]string{
-		"price_asc":       "price ASC",
-		"price_desc":      "price DESC",
-		"name_asc":        "name ASC",
-		"name_desc":       "name DESC",
-		"available_first": "availability DESC",
+	var rows []productRow
+	if err := r.db.SelectContext(ctx, &rows, query, q.args...); err != nil {
+		return nil, nil, fmt.Errorf("failed to filter products: %w", err)
 	}
-	if orderBy, ok := sortMap[sort]; ok {
-		query += " ORDER BY " + orderBy
-	} else {
-		query += " ORDER BY id DESC"
+
+	var next *models.ProductCursor
+	if filter.Limit > 0 && len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		last := rows[len(rows)-1]
+		next = &models.ProductCursor{Sort: filter.SortKey(), Value: last.CursorValue, ID: last.ID}
 	}
 
-	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d", i)
-		args = append(args, limit)
-		i++
+	products := make([]models.Product, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, row.Product)
 	}
-	if offset > 0 {
-		query += fmt.Sprintf(" OFFSET $%d", i)
-		args = append(args, offset)
+	return products, next, nil
+}
+
+// Границы ценовых диапазонов для фасетов: [0, 100), [100, 500), ..., [5000, ∞)
+var priceFacetBounds = []float64{100, 500, 1000, 5000}
+
+// Пороги средней оценки для фасетов: "4 и выше", "3 и выше" и т.д.
+var ratingFacetThresholds = []int{4, 3, 2, 1}
+
+// GetFacets считает количество товаров по категориям, ценовым диапазонам, наличию и рейтингу.
+// Для каждого измерения его собственный фильтр не учитывается, чтобы можно было выбрать несколько значений.
+func (r *ProductRepo) GetFacets(ctx context.Context, filter models.ProductFilter) (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{}
+
+	q := buildProductQuery(filter, facetCategory)
+	err := r.db.SelectContext(ctx, &facets.Categories, `
+		SELECT c.id, c.name, COUNT(*) AS count
+		FROM products
+		JOIN categories c ON c.id = products.category_id`+q.whereSQL()+`
+		GROUP BY c.id, c.name, c.sort_order
+		ORDER BY c.sort_order, c.name
+	`, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count category facets: %w", err)
 	}
 
-	var products []models.Product
-	err := r.db.Select(&products, query, args...)
+	q = buildProductQuery(filter, facetPrice)
+	var buckets []struct {
+		Bucket int `db:"bucket"`
+		Count  int `db:"count"`
+	}
+	err = r.db.SelectContext(ctx, &buckets, `
+		SELECT width_bucket(products.price, `+q.arg(pq.Array(priceFacetBounds))+`::numeric[]) AS bucket, COUNT(*) AS count
+		FROM products`+q.whereSQL()+`
+		GROUP BY bucket
+	`, q.args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter products: %w", err)
+		return nil, fmt.Errorf("failed to count price facets: %w", err)
 	}
-	return products, nil
+	counts := make(map[int]int, len(buckets))
+	for _, b := range buckets {
+		counts[b.Bucket] = b.Count
+	}
+	for i := 0; i <= len(priceFacetBounds); i++ {
+		facet := models.PriceFacet{Count: counts[i]}
+		if i > 0 {
+			facet.Min = priceFacetBounds[i-1]
+		}
+		if i < len(priceFacetBounds) {
+			max := priceFacetBounds[i]
+			facet.Max = &max
+		}
+		facets.Prices = append(facets.Prices, facet)
+	}
+
+	q = buildProductQuery(filter, facetAvailability)
+	err = r.db.SelectContext(ctx, &facets.Availability, `
+		SELECT products.availability, COUNT(*) AS count
+		FROM products`+q.whereSQL()+`
+		GROUP BY products.availability
+		ORDER BY products.availability DESC
+	`, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count availability facets: %w", err)
+	}
+
+	q = buildProductQuery(filter, facetRating)
+	columns := make([]string, 0, len(ratingFacetThresholds))
+	for _, t := range ratingFacetThresholds {
+		columns = append(columns, fmt.Sprintf("COUNT(*) FILTER (WHERE products.rating_avg >= %d)", t))
+	}
+	ratingCounts := make([]int, len(ratingFacetThresholds))
+	dest := make([]interface{}, len(ratingCounts))
+	for i := range ratingCounts {
+		dest[i] = &ratingCounts[i]
+	}
+	err = r.db.QueryRowContext(ctx, `SELECT `+strings.Join(columns, ", ")+` FROM products`+q.whereSQL(), q.args...).Scan(dest...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rating facets: %w", err)
+	}
+	for i, t := range ratingFacetThresholds {
+		facets.Ratings = append(facets.Ratings, models.RatingFacet{MinRating: t, Count: ratingCounts[i]})
+	}
+
+	if facets.Categories == nil {
+		facets.Categories = []models.CategoryFacet{}
+	}
+	if facets.Availability == nil {
+		facets.Availability = []models.AvailabilityFacet{}
+	}
+	return facets, nil
 }
 
-func (r *ProductRepo) GetCategories() ([]string, error) {
+func (r *ProductRepo) GetCategories(ctx context.Context) ([]string, error) {
 	var names []string
-	err := r.db.Select(&names, `SELECT name FROM categories ORDER BY name ASC`)
+	err := r.db.SelectContext(ctx, &names, `SELECT name FROM categories WHERE deleted_at IS NULL ORDER BY name ASC`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch categories: %w", err)
 	}
 	return names, nil
 }
 
-func (r *ProductRepo) GetCategoryNameByID(categoryID int) (string, error) {
+func (r *ProductRepo) GetCategoryNameByID(ctx context.Context, categoryID int) (string, error) {
 	var name string
-	err := r.db.Get(&name, `SELECT name FROM categories WHERE id = $1`, categoryID)
+	err := r.db.GetContext(ctx, &name, `SELECT name FROM categories WHERE id = $1`, categoryID)
 	return name, err
 }
 
-func (r *ProductRepo) GetByName(name string) (*models.Product, error) {
+func (r *ProductRepo) GetByName(ctx context.Context, name string) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Get(&product, `SELECT * FROM products WHERE name = $1`, name)
+	err := r.db.GetContext(ctx, &product, `SELECT * FROM products WHERE name = $1 AND deleted_at IS NULL`, name)
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepo) CreateTx(tx *sqlx.Tx, p *models.Product) error {
-	query := `INSERT INTO products (name, description, price, availability, category_id, url)
-	          VALUES ($1, $2, $3, $4, $5, $6)
+func (r *ProductRepo) CreateTx(ctx context.Context, tx *sqlx.Tx, p *models.Product) error {
+	query := `INSERT INTO products (name, description, price, old_price, availability, category_id, url, sku)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	          RETURNING id`
 
-	return tx.QueryRow(query, p.Name, p.Description, p.Price, p.Availability, p.CategoryID, p.Url).Scan(&p.ID)
+	return tx.QueryRowContext(ctx, query, p.Name, p.Description, p.Price, p.OldPrice, p.Availability, p.CategoryID, p.Url, p.SKU).Scan(&p.ID)
 }
 
-func (r *ProductRepo) GetByNameTx(tx *sqlx.Tx, name string) (*models.Product, error) {
+func (r *ProductRepo) GetByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (*models.Product, error) {
 	var p models.Product
-	err := tx.Get(&p, `SELECT * FROM products WHERE name = $1`, name)
+	err := tx.GetContext(ctx, &p, `SELECT * FROM products WHERE name = $1 AND deleted_at IS NULL`, name)
 	if err != nil {
 		return nil, err
 	}
 	return &p, nil
 }
 
-func (r *ProductRepo) GetByIDTx(tx *sqlx.Tx, id int) (*models.Product, error) {
+func (r *ProductRepo) GetByIDTx(ctx context.Context, tx *sqlx.Tx, id int) (*models.Product, error) {
 	var p models.Product
-	err := tx.Get(&p, `SELECT * FROM products WHERE id = $1`, id)
+	err := tx.GetContext(ctx, &p, `SELECT * FROM products WHERE id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
 		return nil, err
 	}
 	return &p, nil
 }
-func (r *ProductRepo) GetCategoryNameByIDTx(tx *sqlx.Tx, categoryID int) (string, error) {
+func (r *ProductRepo) GetCategoryNameByIDTx(ctx context.Context, tx *sqlx.Tx, categoryID int) (string, error) {
 	var name string
-	err := tx.Get(&name, `SELECT name FROM categories WHERE id = $1`, categoryID)
+	err := tx.GetContext(ctx, &name, `SELECT name FROM categories WHERE id = $1`, categoryID)
 	return name, err
 }
 
-func (r *ProductRepo) UpdateTx(tx *sqlx.Tx, id int, p *models.Product) error {
-	_, err := tx.Exec(`UPDATE products SET name=$1, description=$2, price=$3, availability=$4, category_id=$5 WHERE id=$6`,
-		p.Name, p.Description, p.Price, p.Availability, p.CategoryID, id)
+func (r *ProductRepo) UpdateTx(ctx context.Context, tx *sqlx.Tx, id int, p *models.Product, change models.PriceChange) error {
+	if err := recordPriceChange(ctx, tx, id, p.Price, change); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE products SET name=$1, description=$2, price=$3, old_price=$4, availability=$5, category_id=$6, sku=$7 WHERE id=$8 AND deleted_at IS NULL`,
+		p.Name, p.Description, p.Price, p.OldPrice, p.Availability, p.CategoryID, p.SKU, id)
 	return err
 }
-func (r *ProductRepo) UpdateAvailabilityTx(tx *sqlx.Tx, id int, availability bool) error {
-	_, err := tx.Exec(`UPDATE products SET availability = $1 WHERE id = $2`, availability, id)
+func (r *ProductRepo) UpdateAvailabilityTx(ctx context.Context, tx *sqlx.Tx, id int, availability bool) error {
+	_, err := tx.ExecContext(ctx, `UPDATE products SET availability = $1 WHERE id = $2`, availability, id)
 	return err
 }
 
-func (r *ProductRepo) GetAverageRating(productID int) (float64, error) {
+func (r *ProductRepo) GetAverageRating(ctx context.Context, productID int) (float64, error) {
 	var avg sql.NullFloat64
-	err := r.db.Get(&avg, `SELECT AVG(rating) FROM reviews WHERE product_id = $1`, productID)
+	err := r.db.GetContext(ctx, &avg, `SELECT rating_avg FROM products WHERE id = $1`, productID)
 	if err != nil || !avg.Valid {
 		return 0, nil
 	}
 	return avg.Float64, nil
 }
 
-func (r *ProductRepo) PatchProduct(id int, patch models.ProductPatch) error {
+func (r *ProductRepo) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error {
+	if patch.Price == nil {
+		return patchProduct(ctx, r.db, id, patch)
+	}
+
+	// Изменение цены пишется в историю той же транзакцией
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := patchProduct(ctx, tx, id, patch); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ProductRepo) PatchProductTx(ctx context.Context, tx *sqlx.Tx, id int, patch models.ProductPatch) error {
+	return patchProduct(ctx, tx, id, patch)
+}
+
+func patchProduct(ctx context.Context, db sqlx.ExecerContext, id int, patch models.ProductPatch) error {
 	setParts := []string{}
 	args := []interface{}{}
 	argID := 1
@@ -280,6 +388,12 @@ func (r *ProductRepo) PatchProduct(id int, patch models.ProductPatch) error {
 		args = append(args, *patch.Name)
 		argID++
 	}
+	if patch.SKU != nil {
+		// Пустой артикул снимается
+		setParts = append(setParts, fmt.Sprintf("sku = NULLIF($%d, '')", argID))
+		args = append(args, *patch.SKU)
+		argID++
+	}
 	if patch.Description != nil {
 		setParts = append(setParts, fmt.Sprintf("description = $%d", argID))
 		args = append(args, *patch.Description)
@@ -290,6 +404,11 @@ func (r *ProductRepo) PatchProduct(id int, patch models.ProductPatch) error {
 		args = append(args, *patch.Price)
 		argID++
 	}
+	if patch.OldPrice != nil {
+		setParts = append(setParts, fmt.Sprintf("old_price = NULLIF($%d, 0)", argID))
+		args = append(args, *patch.OldPrice)
+		argID++
+	}
 	if patch.Availability != nil {
 		setParts = append(setParts, fmt.Sprintf("availability = $%d", argID))
 		args = append(args, *patch.Availability)
@@ -310,57 +429,147 @@ func (r *ProductRepo) PatchProduct(id int, patch models.ProductPatch) error {
 		return nil
 	}
 
-	query := fmt.Sprintf(`UPDATE products SET %s WHERE id = $%d`, strings.Join(setParts, ", "), argID)
+	if patch.Price != nil {
+		if err := recordPriceChange(ctx, db, id, *patch.Price, patch.PriceChange); err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprintf(`UPDATE products SET %s WHERE id = $%d AND deleted_at IS NULL`, strings.Join(setParts, ", "), argID)
 	args = append(args, id)
 
-	_, err := r.db.Exec(query, args...)
+	_, err := db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (r *ProductRepo) CountFiltered(
-	search, category string,
-	minPrice, maxPrice float64,
-	availability *bool,
-) (int, error) {
-	query := `SELECT COUNT(*) FROM products WHERE 1=1`
-	args := []interface{}{}
-	i := 1
+// recordPriceChange пишет в историю смену цены товара. Вызывается до UPDATE:
+// старая цена берётся из таблицы, а если цена не меняется, запись не создаётся.
+func recordPriceChange(ctx context.Context, db sqlx.ExecerContext, productID int, newPrice float64, change models.PriceChange) error {
+	source := change.Source
+	if source == "" {
+		source = models.PriceSourceAdmin
+	}
+	_, err := db.ExecContext(ctx, `
+INSERT INTO price_history (product_id, old_price, new_price, changed_by, source)
+SELECT id, price, $2, $3, $4 FROM products WHERE id = $1 AND deleted_at IS NULL AND price <> $2`,
+		productID, newPrice, change.ChangedBy, source)
+	if err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepo) CountFiltered(ctx context.Context, filter models.ProductFilter) (int, error) {
+	q := buildProductQuery(filter, "")
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM products`+q.whereSQL(), q.args...)
+	return total, err
+}
+
+func (r *ProductRepo) IsProductNameExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM products WHERE name = $1 AND deleted_at IS NULL)`
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&exists)
+	return exists, err
+}
+
+func (r *ProductRepo) GetBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	var p models.Product
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE sku = $1 AND deleted_at IS NULL`, sku)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProductRepo) GetByExternalID(ctx context.Context, externalID string) (*models.Product, error) {
+	var p models.Product
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE external_id = $1 AND deleted_at IS NULL`, externalID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
 
-	if search != "" {
-		query += fmt.Sprintf(" AND (LOWER(name) LIKE $%d OR LOWER(description) LIKE $%d)", i, i)
-		args = append(args, "%"+strings.ToLower(search)+"%")
-		i++
+func (r *ProductRepo) SetExternalID(ctx context.Context, id int, externalID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE products SET external_id = $1 WHERE id = $2`, externalID, id)
+	return err
+}
+
+// GetExternalIDs возвращает идентификаторы 1С для товаров, у которых они есть
+func (r *ProductRepo) GetExternalIDs(ctx context.Context, ids []int) (map[int]string, error) {
+	result := make(map[int]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
 	}
-	if category != "" {
-		query += fmt.Sprintf(" AND category_id = $%d", i)
-		categoryID, _ := strconv.Atoi(category)
-		args = append(args, categoryID)
-		i++
+
+	productIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		productIDs = append(productIDs, int64(id))
 	}
-	if availability != nil {
-		query += fmt.Sprintf(" AND availability = $%d", i)
-		args = append(args, *availability)
-		i++
+
+	var rows []struct {
+		ID         int    `db:"id"`
+		ExternalID string `db:"external_id"`
 	}
-	if minPrice > 0 {
-		query += fmt.Sprintf(" AND price >= $%d", i)
-		args = append(args, minPrice)
-		i++
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT id, external_id FROM products
+		WHERE id = ANY($1::int[]) AND external_id IS NOT NULL
+	`, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch external ids: %w", err)
 	}
-	if maxPrice > 0 {
-		query += fmt.Sprintf(" AND price <= $%d", i)
-		args = append(args, maxPrice)
-		i++
+	for _, row := range rows {
+		result[row.ID] = row.ExternalID
 	}
+	return result, nil
+}
 
-	var total int
-	err := r.db.Get(&total, query, args...)
-	return total, err
+func (r *ProductRepo) GetDeleted(ctx context.Context) ([]models.TrashItem, error) {
+	var items []models.TrashItem
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT 'product' AS type, id, name AS title, deleted_at
+		FROM products
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted products: %w", err)
+	}
+	return items, nil
 }
 
-func (r *ProductRepo) IsProductNameExists(name string) (bool, error) {
-	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM products WHERE name = $1)`
-	err := r.db.QueryRow(query, name).Scan(&exists)
-	return exists, err
+func (r *ProductRepo) GetDeletedByID(ctx context.Context, id int) (*models.Product, error) {
+	var p models.Product
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Restore возвращает товар из корзины. Если его категория тоже удалена, товар остаётся без категории.
+func (r *ProductRepo) Restore(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE products p
+		SET deleted_at = NULL,
+		    category_id = CASE
+		        WHEN EXISTS (SELECT 1 FROM categories c WHERE c.id = p.category_id AND c.deleted_at IS NULL) THEN p.category_id
+		    END
+		WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет товары, лежащие в корзине дольше срока хранения
+func (r *ProductRepo) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM products WHERE deleted_at IS NOT NULL AND deleted_at < NOW() - make_interval(secs => $1)`, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted products: %w", err)
+	}
+	return res.RowsAffected()
 }
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"chechnya-product/internal/models"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// Измерения фильтра, которые не учитываются при подсчёте собственных фасетов
+const (
+	facetCategory     = "category"
+	facetPrice        = "price"
+	facetAvailability = "availability"
+	facetRating       = "rating"
+)
+
+// productRow — товар вместе со значением поля сортировки для курсора следующей страницы
+type productRow struct {
+	models.Product
+	CursorValue string `db:"cursor_value"`
+}
+
+// productQuery накапливает условия WHERE и аргументы запроса по каталогу
+type productQuery struct {
+	where     []string
+	args      []interface{}
+	searchArg int // номер параметра с поисковым запросом, 0 — поиска нет
+}
+
+func (q *productQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *productQuery) whereSQL() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.where, " AND ")
+}
+
+// buildProductQuery переводит фильтр в условия WHERE; exclude — измерение, которое нужно пропустить
+func buildProductQuery(f models.ProductFilter, exclude string) *productQuery {
+	// Товары из корзины в каталоге не показываются
+	q := &productQuery{where: []string{"products.deleted_at IS NULL"}}
+
+	if f.Search != "" {
+		q.arg(f.Search)
+		q.searchArg = len(q.args)
+		q.where = append(q.where, productSearchCondition(q.searchArg))
+	}
+	if len(f.CategoryIDs) > 0 && exclude != facetCategory {
+		ids := make([]int64, 0, len(f.CategoryIDs))
+		for _, id := range f.CategoryIDs {
+			ids = append(ids, int64(id))
+		}
+		// Вместе с выбранными категориями учитываются все их подкатегории
+		q.where = append(q.where, "products.category_id IN ("+categoryDescendantsSQL(q.arg(pq.Array(ids)))+")")
+	}
+	if f.Availability != nil && exclude != facetAvailability {
+		q.where = append(q.where, "products.availability = "+q.arg(*f.Availability))
+	}
+	if f.MinRating > 0 && exclude != facetRating {
+		q.where = append(q.where, "products.rating_avg >= "+q.arg(f.MinRating))
+	}
+	if exclude != facetPrice {
+		if f.MinPrice > 0 {
+			q.where = append(q.where, "products.price >= "+q.arg(f.MinPrice))
+		}
+		if f.MaxPrice > 0 {
+			q.where = append(q.where, "products.price <= "+q.arg(f.MaxPrice))
+		}
+	}
+
+	return q
+}
+
+// productSortExpr возвращает выражение сортировки и направление.
+// Пустое выражение — сортировка по умолчанию (новые первыми).
+// Во всех случаях последним ключом идёт products.id DESC, чтобы порядок был однозначным.
+func productSortExpr(sortKey string, searchArg int) (string, bool) {
+	switch sortKey {
+	case "price_asc":
+		return "products.price", false
+	case "price_desc":
+		return "products.price", true
+	case "name_asc":
+		return "products.name", false
+	case "name_desc":
+		return "products.name", true
+	case "available_first":
+		return "products.availability", true
+	case "rating_desc":
+		return "products.rating_avg", true
+	case "relevance":
+		if searchArg > 0 {
+			return productSearchRank(searchArg), true
+		}
+	}
+	return "", false
+}
+
+// applyCursor добавляет условие "после последнего товара предыдущей страницы".
+// В отличие от OFFSET, новые товары не сдвигают уже показанные.
+func (q *productQuery) applyCursor(c *models.ProductCursor, sortKey, sortExpr string, desc bool) error {
+	if c.Sort != sortKey {
+		return models.ErrInvalidCursor
+	}
+
+	if sortExpr == "" {
+		q.where = append(q.where, "products.id < "+q.arg(c.ID))
+		return nil
+	}
+
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	value := q.arg(c.Value)
+	id := q.arg(c.ID)
+	q.where = append(q.where, fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND products.id < %[4]s))", sortExpr, op, value, id))
+	return nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"chechnya-product/internal/models"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+type ProductImageRepository interface {
+	Create(ctx context.Context, img *models.ProductImage) error
+	GetByID(ctx context.Context, id int) (*models.ProductImage, error)
+	GetByProductID(ctx context.Context, productID int) ([]models.ProductImage, error)
+	NextSortOrder(ctx context.Context, productID int) (int, error)
+	Delete(ctx context.Context, id int) error
+	SetPrimary(ctx context.Context, productID, imageID int, url string) error
+	ClearProductURL(ctx context.Context, productID int) error
+	UpdateSortOrder(ctx context.Context, productID int, imageIDs []int) error
+}
+
+type ProductImageRepo struct {
+	db *sqlx.DB
+}
+
+func NewProductImageRepo(db *sqlx.DB) *ProductImageRepo {
+	return &ProductImageRepo{db: db}
+}
+
+func (r *ProductImageRepo) Create(ctx context.Context, img *models.ProductImage) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO product_images (product_id, file_name, medium_file_name, thumb_file_name, width, height, sort_order, is_primary)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, img.ProductID, img.FileName, img.MediumFileName, img.ThumbFileName, img.Width, img.Height, img.SortOrder, img.IsPrimary,
+	).Scan(&img.ID, &img.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create product image: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductImageRepo) GetByID(ctx context.Context, id int) (*models.ProductImage, error) {
+	var img models.ProductImage
+	err := r.db.GetContext(ctx, &img, `SELECT * FROM product_images WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (r *ProductImageRepo) GetByProductID(ctx context.Context, productID int) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.SelectContext(ctx, &images, `
+		SELECT * FROM product_images
+		WHERE product_id = $1
+		ORDER BY is_primary DESC, sort_order, id
+	`, productID)
+	return images, err
+}
+
+func (r *ProductImageRepo) NextSortOrder(ctx context.Context, productID int) (int, error) {
+	var next int
+	err := r.db.GetContext(ctx, &next, `SELECT COALESCE(MAX(sort_order) + 1, 0) FROM product_images WHERE product_id = $1`, productID)
+	return next, err
+}
+
+func (r *ProductImageRepo) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM product_images WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product image: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("product image not found")
+	}
+	return nil
+}
+
+// SetPrimary делает изображение основным и синхронизирует products.url,
+// чтобы старые клиенты продолжали получать ссылку на картинку
+func (r *ProductImageRepo) SetPrimary(ctx context.Context, productID, imageID int, url string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE product_images SET is_primary = FALSE WHERE product_id = $1 AND is_primary`, productID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE product_images SET is_primary = TRUE WHERE id = $1 AND product_id = $2`, imageID, productID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		tx.Rollback()
+		return fmt.Errorf("product image not found")
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET url = $1 WHERE id = $2`, url, productID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ProductImageRepo) ClearProductURL(ctx context.Context, productID int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE products SET url = NULL WHERE id = $1`, productID)
+	return err
+}
+
+func (r *ProductImageRepo) UpdateSortOrder(ctx context.Context, productID int, imageIDs []int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range imageIDs {
+		res, err := tx.ExecContext(ctx, `UPDATE product_images SET sort_order = $1 WHERE id = $2 AND product_id = $3`, i, id, productID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			tx.Rollback()
+			return fmt.Errorf("image %d does not belong to product %d", id, productID)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,14 +2,16 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"github.com/jmoiron/sqlx"
 	"log"
 )
 
 type PushRepositoryInterface interface {
-	SaveSubscription(sub models.Subscription) error
-	GetAllSubscriptions() ([]models.Subscription, error)
-	DeleteByEndpoint(endpoint string) error
+	SaveSubscription(ctx context.Context, sub models.Subscription) error
+	GetAllSubscriptions(ctx context.Context) ([]models.Subscription, error)
+	GetUserSubscriptions(ctx context.Context, userID int) ([]models.Subscription, error)
+	DeleteByEndpoint(ctx context.Context, endpoint string) error
 }
 
 type PushRepository struct {
@@ -20,13 +22,14 @@ func NewPushRepo(db *sqlx.DB) *PushRepository {
 	return &PushRepository{db: db}
 }
 
-func (r *PushRepository) SaveSubscription(sub models.Subscription) error {
-	_, err := r.db.Exec(`
-	INSERT INTO push_subscriptions (endpoint, p256dh, auth, is_admin)
-	VALUES ($1, $2, $3, $4)
+func (r *PushRepository) SaveSubscription(ctx context.Context, sub models.Subscription) error {
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO push_subscriptions (endpoint, p256dh, auth, is_admin, user_id)
+	VALUES ($1, $2, $3, $4, $5)
 	ON CONFLICT (endpoint)
-	DO UPDATE SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, is_admin = EXCLUDED.is_admin;
-`, sub.Endpoint, sub.P256dh, sub.Auth, sub.IsAdmin)
+	DO UPDATE SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, is_admin = EXCLUDED.is_admin,
+	              user_id = COALESCE(EXCLUDED.user_id, push_subscriptions.user_id);
+`, sub.Endpoint, sub.P256dh, sub.Auth, sub.IsAdmin, sub.UserID)
 
 	if err != nil {
 		log.Println("❌ Ошибка при сохранении подписки:", err)
@@ -34,8 +37,8 @@ func (r *PushRepository) SaveSubscription(sub models.Subscription) error {
 	return err
 }
 
-func (r *PushRepository) GetAllSubscriptions() ([]models.Subscription, error) {
-	rows, err := r.db.Query(`SELECT endpoint, p256dh, auth, is_admin FROM push_subscriptions`)
+func (r *PushRepository) GetAllSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT endpoint, p256dh, auth, is_admin FROM push_subscriptions`)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +55,26 @@ func (r *PushRepository) GetAllSubscriptions() ([]models.Subscription, error) {
 	return subs, nil
 }
 
-func (r *PushRepository) DeleteByEndpoint(endpoint string) error {
-	_, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
+// GetUserSubscriptions возвращает подписки устройств, на которых пользователь подписывался после входа
+func (r *PushRepository) GetUserSubscriptions(ctx context.Context, userID int) ([]models.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT endpoint, p256dh, auth, is_admin, user_id FROM push_subscriptions WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	for rows.Next() {
+		sub := models.Subscription{}
+		if err := rows.Scan(&sub.Endpoint, &sub.P256dh, &sub.Auth, &sub.IsAdmin, &sub.UserID); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *PushRepository) DeleteByEndpoint(ctx context.Context, endpoint string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
 	return err
 }
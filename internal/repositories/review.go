@@ -2,15 +2,38 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
+	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
 )
 
 type ReviewRepository interface {
-	Create(review *models.Review) error
-	GetByProductID(productID int) ([]models.Review, error)
-	Exists(ownerID string, productID int) (bool, error)
-	Update(ownerID string, productID, rating int, comment string) error
-	Delete(ownerID string, productID int) error
+	Create(ctx context.Context, review *models.Review) error
+	GetByID(ctx context.Context, id int) (*models.Review, error)
+	GetByOwner(ctx context.Context, ownerID string, productID int) (*models.Review, error)
+	GetByProductID(ctx context.Context, productID int) ([]models.Review, error)
+	GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.AdminReview, int, error)
+	Exists(ctx context.Context, ownerID string, productID int) (bool, error)
+	Update(ctx context.Context, review *models.Review) error
+	Delete(ctx context.Context, ownerID string, productID int) error
+	SetStatus(ctx context.Context, id int, status string, reason *string, adminID int) error
+	SetReply(ctx context.Context, id int, reply *string) error
+	IsVerifiedPurchase(ctx context.Context, ownerID string, productID int) (bool, error)
+	AddPhoto(ctx context.Context, photo *models.ReviewPhoto) error
+	GetPhotos(ctx context.Context, reviewIDs []int) ([]models.ReviewPhoto, error)
+	GetPhotoByID(ctx context.Context, id int) (*models.ReviewPhoto, error)
+	DeletePhoto(ctx context.Context, id int) error
+	RefreshProductRating(ctx context.Context, productID int) error
+	GetRatingDistribution(ctx context.Context, productID int) ([]models.RatingBucket, error)
+	CreateOrderFeedback(ctx context.Context, feedback *models.OrderReview, items []models.Review) error
+	OrderFeedbackExists(ctx context.Context, orderID int) (bool, error)
+	GetOrderFeedback(ctx context.Context, orderID int) (*models.OrderReview, error)
+	GetAllOrderFeedback(ctx context.Context) ([]models.OrderReview, error)
+	GetByOrderID(ctx context.Context, orderID int) ([]models.Review, error)
+	GetRatingTimeline(ctx context.Context, period string, from, to time.Time) ([]models.RatingTimelinePoint, error)
+	GetLowestRatedProducts(ctx context.Context, minReviews, limit int) ([]models.LowestRatedProduct, error)
 }
 type ReviewRepo struct {
 	db *sqlx.DB
@@ -20,38 +43,348 @@ func NewReviewRepo(db *sqlx.DB) *ReviewRepo {
 	return &ReviewRepo{db: db}
 }
 
-func (r *ReviewRepo) Create(review *models.Review) error {
-	_, err := r.db.Exec(`
-		INSERT INTO reviews (owner_id, product_id, rating, comment)
-		VALUES ($1, $2, $3, $4)
-	`, review.OwnerID, review.ProductID, review.Rating, review.Comment)
-	return err
+const insertReviewQuery = `
+	INSERT INTO reviews (owner_id, product_id, order_id, rating, comment, status, moderation_note, verified_purchase)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at
+`
+
+func (r *ReviewRepo) Create(ctx context.Context, review *models.Review) error {
+	return r.db.QueryRowContext(ctx, insertReviewQuery,
+		review.OwnerID, review.ProductID, review.OrderID, review.Rating, review.Comment,
+		review.Status, review.ModerationNote, review.VerifiedPurchase,
+	).Scan(&review.ID, &review.CreatedAt)
+}
+
+func (r *ReviewRepo) GetByID(ctx context.Context, id int) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.GetContext(ctx, &review, `SELECT * FROM reviews WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *ReviewRepo) GetByOwner(ctx context.Context, ownerID string, productID int) (*models.Review, error) {
+	var review models.Review
+	err := r.db.GetContext(ctx, &review, `SELECT * FROM reviews WHERE owner_id = $1 AND product_id = $2`, ownerID, productID)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
 }
 
-func (r *ReviewRepo) GetByProductID(productID int) ([]models.Review, error) {
+// GetByProductID возвращает только опубликованные (одобренные) отзывы
+func (r *ReviewRepo) GetByProductID(ctx context.Context, productID int) ([]models.Review, error) {
 	var reviews []models.Review
-	err := r.db.Select(&reviews, `SELECT * FROM reviews WHERE product_id = $1 ORDER BY created_at DESC`, productID)
+	err := r.db.SelectContext(ctx, &reviews, `
+		SELECT * FROM reviews
+		WHERE product_id = $1 AND status = 'approved'
+		ORDER BY created_at DESC
+	`, productID)
 	return reviews, err
 }
 
-func (r *ReviewRepo) Exists(ownerID string, productID int) (bool, error) {
+// GetByStatus — очередь модерации: сначала самые старые, чтобы никто не ждал дольше других
+func (r *ReviewRepo) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.AdminReview, int, error) {
+	var reviews []models.AdminReview
+	err := r.db.SelectContext(ctx, &reviews, `
+		SELECT r.*, p.name AS product_name
+		FROM reviews r
+		JOIN products p ON p.id = r.product_id
+		WHERE r.status = $1
+		ORDER BY r.created_at ASC, r.id ASC
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch reviews by status: %w", err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM reviews WHERE status = $1`, status); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews by status: %w", err)
+	}
+	return reviews, total, nil
+}
+
+func (r *ReviewRepo) Exists(ctx context.Context, ownerID string, productID int) (bool, error) {
 	var count int
-	err := r.db.Get(&count, `
+	err := r.db.GetContext(ctx, &count, `
 		SELECT COUNT(*) FROM reviews WHERE owner_id = $1 AND product_id = $2
 	`, ownerID, productID)
 	return count > 0, err
 }
 
-func (r *ReviewRepo) Update(ownerID string, productID, rating int, comment string) error {
-	_, err := r.db.Exec(`
-		UPDATE reviews SET rating=$1, comment=$2 WHERE owner_id=$3 AND product_id=$4
-	`, rating, comment, ownerID, productID)
+// Update сохраняет изменения автора; решение модератора сбрасывается
+func (r *ReviewRepo) Update(ctx context.Context, review *models.Review) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE reviews
+		SET rating = $1, comment = $2, status = $3, moderation_note = $4, verified_purchase = $5,
+		    rejection_reason = NULL, moderated_at = NULL, moderated_by = NULL
+		WHERE id = $6
+	`, review.Rating, review.Comment, review.Status, review.ModerationNote, review.VerifiedPurchase, review.ID)
 	return err
 }
 
-func (r *ReviewRepo) Delete(ownerID string, productID int) error {
-	_, err := r.db.Exec(`
+func (r *ReviewRepo) Delete(ctx context.Context, ownerID string, productID int) error {
+	_, err := r.db.ExecContext(ctx, `
 		DELETE FROM reviews WHERE owner_id=$1 AND product_id=$2
 	`, ownerID, productID)
 	return err
 }
+
+func (r *ReviewRepo) SetStatus(ctx context.Context, id int, status string, reason *string, adminID int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE reviews
+		SET status = $1, rejection_reason = $2, moderated_at = NOW(), moderated_by = $3
+		WHERE id = $4
+	`, status, reason, adminID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update review status: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+func (r *ReviewRepo) SetReply(ctx context.Context, id int, reply *string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE reviews
+		SET admin_reply = $1, admin_reply_at = CASE WHEN $1::text IS NULL THEN NULL ELSE NOW() END
+		WHERE id = $2
+	`, reply, id)
+	if err != nil {
+		return fmt.Errorf("failed to save review reply: %w", err)
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+// IsVerifiedPurchase — есть ли у владельца доставленный заказ с этим товаром
+func (r *ReviewRepo) IsVerifiedPurchase(ctx context.Context, ownerID string, productID int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.owner_id = $1 AND oi.product_id = $2 AND o.status = $3
+		)
+	`, ownerID, productID, models.OrderStatusDelivered)
+	return exists, err
+}
+
+func (r *ReviewRepo) AddPhoto(ctx context.Context, photo *models.ReviewPhoto) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO review_photos (review_id, file_name, medium_file_name, thumb_file_name)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, photo.ReviewID, photo.FileName, photo.MediumFileName, photo.ThumbFileName,
+	).Scan(&photo.ID, &photo.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add review photo: %w", err)
+	}
+	return nil
+}
+
+func (r *ReviewRepo) GetPhotos(ctx context.Context, reviewIDs []int) ([]models.ReviewPhoto, error) {
+	ids := make([]int64, 0, len(reviewIDs))
+	for _, id := range reviewIDs {
+		ids = append(ids, int64(id))
+	}
+
+	var photos []models.ReviewPhoto
+	err := r.db.SelectContext(ctx, &photos, `
+		SELECT * FROM review_photos
+		WHERE review_id = ANY($1::int[])
+		ORDER BY review_id, id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review photos: %w", err)
+	}
+	return photos, nil
+}
+
+func (r *ReviewRepo) GetPhotoByID(ctx context.Context, id int) (*models.ReviewPhoto, error) {
+	var photo models.ReviewPhoto
+	if err := r.db.GetContext(ctx, &photo, `SELECT * FROM review_photos WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *ReviewRepo) DeletePhoto(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM review_photos WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete review photo: %w", err)
+	}
+	return nil
+}
+
+// RefreshProductRating пересчитывает средний рейтинг и количество отзывов товара.
+// Учитываются только одобренные отзывы; пересчёт идёт по всем отзывам,
+// поэтому значение не расходится с таблицей reviews.
+func (r *ReviewRepo) RefreshProductRating(ctx context.Context, productID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE products p
+		SET rating_avg = COALESCE(agg.rating_avg, 0),
+		    review_count = agg.review_count
+		FROM (
+			SELECT ROUND(AVG(rating), 2) AS rating_avg, COUNT(*) AS review_count
+			FROM reviews
+			WHERE product_id = $1 AND rating IS NOT NULL AND status = 'approved'
+		) agg
+		WHERE p.id = $1
+	`, productID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh product rating: %w", err)
+	}
+	return nil
+}
+
+func (r *ReviewRepo) GetRatingDistribution(ctx context.Context, productID int) ([]models.RatingBucket, error) {
+	var buckets []models.RatingBucket
+	err := r.db.SelectContext(ctx, &buckets, `
+		SELECT rating, COUNT(*) AS count
+		FROM reviews
+		WHERE product_id = $1 AND rating IS NOT NULL AND status = 'approved'
+		GROUP BY rating
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rating distribution: %w", err)
+	}
+	return buckets, nil
+}
+
+// CreateOrderFeedback сохраняет отзыв о заказе и отзывы о его товарах в одной транзакции
+func (r *ReviewRepo) CreateOrderFeedback(ctx context.Context, feedback *models.OrderReview, items []models.Review) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO order_reviews (order_id, owner_id, user_id, rating, delivery_rating, courier_rating, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, feedback.OrderID, feedback.OwnerID, feedback.UserID, feedback.Rating,
+		feedback.DeliveryRating, feedback.CourierRating, feedback.Comment,
+	).Scan(&feedback.ID, &feedback.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create order review: %w", err)
+	}
+
+	for i := range items {
+		item := &items[i]
+		err := tx.QueryRowContext(ctx, insertReviewQuery,
+			item.OwnerID, item.ProductID, item.OrderID, item.Rating, item.Comment,
+			item.Status, item.ModerationNote, item.VerifiedPurchase,
+		).Scan(&item.ID, &item.CreatedAt)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create review for product %d: %w", item.ProductID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReviewRepo) OrderFeedbackExists(ctx context.Context, orderID int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM order_reviews WHERE order_id = $1)`, orderID)
+	return exists, err
+}
+
+const orderReviewFields = `
+	orr.id, orr.order_id, orr.owner_id, orr.user_id, u.username, orr.rating,
+	orr.delivery_rating, orr.courier_rating, orr.comment, orr.created_at
+`
+
+func (r *ReviewRepo) GetOrderFeedback(ctx context.Context, orderID int) (*models.OrderReview, error) {
+	var feedback models.OrderReview
+	err := r.db.GetContext(ctx, &feedback, `
+		SELECT `+orderReviewFields+`
+		FROM order_reviews orr
+		LEFT JOIN users u ON orr.user_id = u.id
+		WHERE orr.order_id = $1
+		ORDER BY orr.id
+		LIMIT 1
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+func (r *ReviewRepo) GetAllOrderFeedback(ctx context.Context) ([]models.OrderReview, error) {
+	var feedback []models.OrderReview
+	err := r.db.SelectContext(ctx, &feedback, `
+		SELECT `+orderReviewFields+`
+		FROM order_reviews orr
+		LEFT JOIN users u ON orr.user_id = u.id
+		ORDER BY orr.created_at DESC
+	`)
+	return feedback, err
+}
+
+// GetByOrderID возвращает опубликованные отзывы о товарах, оставленные вместе с отзывом о заказе
+func (r *ReviewRepo) GetByOrderID(ctx context.Context, orderID int) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.SelectContext(ctx, &reviews, `SELECT * FROM reviews WHERE order_id = $1 AND status = 'approved' ORDER BY id`, orderID)
+	return reviews, err
+}
+
+// GetRatingTimeline считает средние оценки товаров и заказов по периодам (day, week, month)
+func (r *ReviewRepo) GetRatingTimeline(ctx context.Context, period string, from, to time.Time) ([]models.RatingTimelinePoint, error) {
+	var points []models.RatingTimelinePoint
+	err := r.db.SelectContext(ctx, &points, `
+		WITH product_stats AS (
+			SELECT date_trunc($1, created_at) AS period,
+			       ROUND(AVG(rating), 2)::float8 AS product_avg,
+			       COUNT(*) AS product_count
+			FROM reviews
+			WHERE status = 'approved' AND created_at >= $2 AND created_at < $3
+			GROUP BY 1
+		), order_stats AS (
+			SELECT date_trunc($1, created_at) AS period,
+			       ROUND(AVG(rating), 2)::float8 AS order_avg,
+			       ROUND(AVG(delivery_rating), 2)::float8 AS delivery_avg,
+			       ROUND(AVG(courier_rating), 2)::float8 AS courier_avg,
+			       COUNT(*) AS order_count
+			FROM order_reviews
+			WHERE created_at >= $2 AND created_at < $3
+			GROUP BY 1
+		)
+		SELECT COALESCE(p.period, o.period) AS period,
+		       p.product_avg, COALESCE(p.product_count, 0) AS product_count,
+		       o.order_avg, o.delivery_avg, o.courier_avg, COALESCE(o.order_count, 0) AS order_count
+		FROM product_stats p
+		FULL OUTER JOIN order_stats o ON o.period = p.period
+		ORDER BY 1
+	`, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rating timeline: %w", err)
+	}
+	return points, nil
+}
+
+// GetLowestRatedProducts — товары с самым низким рейтингом среди тех, у кого достаточно отзывов
+func (r *ReviewRepo) GetLowestRatedProducts(ctx context.Context, minReviews, limit int) ([]models.LowestRatedProduct, error) {
+	var products []models.LowestRatedProduct
+	err := r.db.SelectContext(ctx, &products, `
+		SELECT id AS product_id, name, rating_avg, review_count
+		FROM products
+		WHERE review_count >= $1
+		ORDER BY rating_avg ASC, review_count DESC
+		LIMIT $2
+	`, minReviews, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lowest rated products: %w", err)
+	}
+	return products, nil
+}
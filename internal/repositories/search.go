@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"chechnya-product/internal/models"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"html"
+	"strings"
+)
+
+// Минимальная похожесть слова (pg_trgm word_similarity), при которой считаем, что это опечатка
+const searchWordSimilarity = 0.4
+
+// productSearchCondition — условие поиска товара по запросу в параметре $n:
+// полнотекстовый поиск с русской морфологией по названию и описанию,
+// нечёткое совпадение названия по триграммам и совпадение по названию категории.
+func productSearchCondition(n int) string {
+	return fmt.Sprintf(`(
+		to_tsvector('russian', products.name || ' ' || COALESCE(products.description, '')) @@ websearch_to_tsquery('russian', $%[1]d)
+		OR LOWER(products.name) %% LOWER($%[1]d)
+		OR word_similarity(LOWER($%[1]d), LOWER(products.name)) >= %[2]g
+		OR products.category_id IN (
+			SELECT c.id FROM categories c
+			WHERE c.deleted_at IS NULL
+			  AND (to_tsvector('russian', c.name) @@ websearch_to_tsquery('russian', $%[1]d)
+			       OR LOWER(c.name) %% LOWER($%[1]d))
+		)
+	)`, n, searchWordSimilarity)
+}
+
+// productSearchRank — релевантность товара запросу из параметра $n.
+// Совпадение по словам важнее похожести по триграммам.
+// Приводим к float8, чтобы значение из курсора пагинации сравнивалось точно.
+func productSearchRank(n int) string {
+	return fmt.Sprintf(`(
+		ts_rank_cd(to_tsvector('russian', products.name || ' ' || COALESCE(products.description, '')), websearch_to_tsquery('russian', $%[1]d)) * 2
+		+ word_similarity(LOWER($%[1]d), LOWER(products.name))
+	)::float8`, n)
+}
+
+// ts_headline размечает найденные слова символами из области частного использования Unicode,
+// а не сразу <mark>: текст товара приходит как есть и должен быть экранирован до вставки тегов,
+// иначе разметка из названия или описания попадёт клиенту живым HTML
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// highlightHTML экранирует текст ts_headline и заменяет маркеры подсветки на <mark></mark>
+func highlightHTML(s string) string {
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(html.EscapeString(s))
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+type SearchRepository interface {
+	SearchProducts(ctx context.Context, query string, limit, offset int) ([]models.ProductSearchHit, error)
+	CountProducts(ctx context.Context, query string) (int, error)
+	Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error)
+	LogZeroResult(ctx context.Context, query string) error
+	GetZeroResults(ctx context.Context, limit int) ([]models.SearchZeroResult, error)
+}
+
+type SearchRepo struct {
+	db *sqlx.DB
+}
+
+func NewSearchRepo(db *sqlx.DB) *SearchRepo {
+	return &SearchRepo{db: db}
+}
+
+func (r *SearchRepo) SearchProducts(ctx context.Context, query string, limit, offset int) ([]models.ProductSearchHit, error) {
+	sqlQuery := fmt.Sprintf(`
+		SELECT products.*,
+		       COALESCE((SELECT c.name FROM categories c WHERE c.id = products.category_id), '') AS category_name,
+		       %[1]s AS rank,
+		       ts_headline('russian', products.name, websearch_to_tsquery('russian', $1),
+		                   'StartSel=%[3]s, StopSel=%[4]s, HighlightAll=true') AS name_highlight,
+		       ts_headline('russian', COALESCE(products.description, ''), websearch_to_tsquery('russian', $1),
+		                   'StartSel=%[3]s, StopSel=%[4]s, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+		FROM products
+		WHERE products.deleted_at IS NULL AND %[2]s
+		ORDER BY rank DESC, products.id DESC
+		LIMIT $2 OFFSET $3
+	`, productSearchRank(1), productSearchCondition(1), highlightStart, highlightStop)
+
+	var hits []models.ProductSearchHit
+	if err := r.db.SelectContext(ctx, &hits, sqlQuery, query, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	for i := range hits {
+		hits[i].NameHighlight = highlightHTML(hits[i].NameHighlight)
+		hits[i].Snippet = highlightHTML(hits[i].Snippet)
+	}
+	return hits, nil
+}
+
+func (r *SearchRepo) CountProducts(ctx context.Context, query string) (int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM products WHERE products.deleted_at IS NULL AND `+productSearchCondition(1), query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+	return total, nil
+}
+
+// Suggest подбирает названия товаров и категорий для автодополнения:
+// сначала совпадения по началу названия, затем по похожести
+func (r *SearchRepo) Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error) {
+	prefix := escapeLike(strings.ToLower(query))
+	sqlQuery := fmt.Sprintf(`
+		SELECT type, id, text FROM (
+			SELECT 'category' AS type, c.id, c.name AS text,
+			       LOWER(c.name) LIKE $2 || '%%' AS is_prefix,
+			       word_similarity(LOWER($1), LOWER(c.name)) AS score
+			FROM categories c
+			WHERE c.deleted_at IS NULL
+			  AND (LOWER(c.name) LIKE '%%' || $2 || '%%'
+			       OR word_similarity(LOWER($1), LOWER(c.name)) >= %[1]g)
+			UNION ALL
+			SELECT 'product', p.id, p.name,
+			       LOWER(p.name) LIKE $2 || '%%',
+			       word_similarity(LOWER($1), LOWER(p.name))
+			FROM products p
+			WHERE p.deleted_at IS NULL
+			  AND (LOWER(p.name) LIKE '%%' || $2 || '%%'
+			       OR word_similarity(LOWER($1), LOWER(p.name)) >= %[1]g)
+		) s
+		ORDER BY is_prefix DESC, score DESC, type, text
+		LIMIT $3
+	`, searchWordSimilarity)
+
+	var suggestions []models.SearchSuggestion
+	if err := r.db.SelectContext(ctx, &suggestions, sqlQuery, query, prefix, limit); err != nil {
+		return nil, fmt.Errorf("failed to fetch search suggestions: %w", err)
+	}
+	return suggestions, nil
+}
+
+func (r *SearchRepo) LogZeroResult(ctx context.Context, query string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO search_zero_results (query) VALUES ($1)
+		ON CONFLICT (query) DO UPDATE
+		SET hits = search_zero_results.hits + 1, last_searched_at = NOW()
+	`, query)
+	if err != nil {
+		return fmt.Errorf("failed to log zero-result query: %w", err)
+	}
+	return nil
+}
+
+func (r *SearchRepo) GetZeroResults(ctx context.Context, limit int) ([]models.SearchZeroResult, error) {
+	var results []models.SearchZeroResult
+	err := r.db.SelectContext(ctx, &results, `
+		SELECT * FROM search_zero_results
+		ORDER BY hits DESC, last_searched_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch zero-result queries: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	in := `<img src=x onerror=alert(1)> ` + highlightStart + `мёд` + highlightStop + ` & "соты"`
+	want := `&lt;img src=x onerror=alert(1)&gt; <mark>мёд</mark> &amp; &#34;соты&#34;`
+	if got := highlightHTML(in); got != want {
+		t.Fatalf("highlightHTML() = %q, want %q", got, want)
+	}
+}
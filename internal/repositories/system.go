@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+)
+
+type SystemRepository interface {
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+type SystemRepo struct {
+	db *sqlx.DB
+}
+
+func NewSystemRepo(db *sqlx.DB) *SystemRepo {
+	return &SystemRepo{db: db}
+}
+
+func (r *SystemRepo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Stats — статистика пула соединений с базой
+func (r *SystemRepo) Stats() sql.DBStats {
+	return r.db.Stats()
+}
@@ -2,28 +2,39 @@ package repositories
 
 import (
 	"chechnya-product/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // Интерфейс для работы с пользователями в БД
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id int) (*models.User, error)
-	GetByPhone(phone string) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	GetByOwnerID(ownerID string) (*models.User, error)
-	FindByPhoneOrEmail(identifier string) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
-	GetUserByID(id int) (*models.User, error)
-	UpdateAddress(userID int, address *string) error
-	GetAddress(userID int) (*string, error)
-	ClearAddress(userID int) error
-	GetUsernameByID(id string) (string, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByPhone(ctx context.Context, phone string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByOwnerID(ctx context.Context, ownerID string) (*models.User, error)
+	FindByPhoneOrEmail(ctx context.Context, identifier string) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdateAddress(ctx context.Context, userID int, address *string) error
+	GetAddress(ctx context.Context, userID int) (*string, error)
+	ClearAddress(ctx context.Context, userID int) error
+	GetUsernameByID(ctx context.Context, id string) (string, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+
+	GetLoginLock(ctx context.Context, userID int) (time.Duration, error)
+	RecordLoginAttempt(ctx context.Context, userID int, window time.Duration, maxAttempts int) (int, error)
+	DelayLogin(ctx context.Context, userID int, delay time.Duration) error
+	LockLogin(ctx context.Context, userID int, duration time.Duration) error
+	ResetLoginFailures(ctx context.Context, userID int) error
+	AddLoginEvent(ctx context.Context, event models.LoginEvent) error
+	GetLoginHistory(ctx context.Context, userID, limit int) ([]models.LoginEvent, error)
 }
 
 // Репозиторий пользователей
@@ -36,11 +47,11 @@ func NewUserRepo(db *sqlx.DB) *UserRepo {
 }
 
 // Создать нового пользователя
-func (r *UserRepo) Create(user *models.User) error {
+func (r *UserRepo) Create(ctx context.Context, user *models.User) error {
 	query := `INSERT INTO users (username, email, phone, password_hash, role, is_verified, owner_id)
 	          VALUES (:username, :email, :phone, :password_hash, :role, :is_verified, :owner_id)
 	          RETURNING id, created_at`
-	rows, err := r.db.NamedQuery(query, user)
+	rows, err := r.db.NamedQueryContext(ctx, query, user)
 	if err != nil {
 		return fmt.Errorf("Не удалось создать пользователя: %w", err)
 	}
@@ -55,9 +66,9 @@ func (r *UserRepo) Create(user *models.User) error {
 }
 
 // Получить пользователя по ID
-func (r *UserRepo) GetByID(id int) (*models.User, error) {
+func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE id = $1", id)
+	err := r.db.GetContext(ctx, &user, "SELECT * FROM users WHERE id = $1", id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Пользователь не найден
@@ -68,9 +79,9 @@ func (r *UserRepo) GetByID(id int) (*models.User, error) {
 }
 
 // Получить пользователя по телефону
-func (r *UserRepo) GetByPhone(phone string) (*models.User, error) {
+func (r *UserRepo) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE phone = $1", phone)
+	err := r.db.GetContext(ctx, &user, "SELECT * FROM users WHERE phone = $1", phone)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -81,9 +92,9 @@ func (r *UserRepo) GetByPhone(phone string) (*models.User, error) {
 }
 
 // Получить пользователя по email
-func (r *UserRepo) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE email = $1", email)
+	err := r.db.GetContext(ctx, &user, "SELECT * FROM users WHERE email = $1", email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -94,9 +105,9 @@ func (r *UserRepo) GetByEmail(email string) (*models.User, error) {
 }
 
 // Получить пользователя по username
-func (r *UserRepo) GetByUsername(username string) (*models.User, error) {
+func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE username = $1", username)
+	err := r.db.GetContext(ctx, &user, "SELECT * FROM users WHERE username = $1", username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -107,9 +118,9 @@ func (r *UserRepo) GetByUsername(username string) (*models.User, error) {
 }
 
 // Получить пользователя по owner_id
-func (r *UserRepo) GetByOwnerID(ownerID string) (*models.User, error) {
+func (r *UserRepo) GetByOwnerID(ctx context.Context, ownerID string) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE owner_id = $1", ownerID)
+	err := r.db.GetContext(ctx, &user, "SELECT * FROM users WHERE owner_id = $1", ownerID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -120,30 +131,30 @@ func (r *UserRepo) GetByOwnerID(ownerID string) (*models.User, error) {
 }
 
 // Поиск пользователя по телефону, email или username
-func (r *UserRepo) FindByPhoneOrEmail(identifier string) (*models.User, error) {
+func (r *UserRepo) FindByPhoneOrEmail(ctx context.Context, identifier string) (*models.User, error) {
 	if strings.Contains(identifier, "@") {
-		return r.GetByEmail(identifier)
+		return r.GetByEmail(ctx, identifier)
 	}
 	if strings.HasPrefix(identifier, "+") {
-		return r.GetByPhone(identifier)
+		return r.GetByPhone(ctx, identifier)
 	}
-	return r.GetByUsername(identifier)
+	return r.GetByUsername(ctx, identifier)
 }
 
-func (r *UserRepo) GetAllUsers() ([]models.User, error) {
+func (r *UserRepo) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Select(&users, `
-		SELECT id, username, email, phone, role, is_verified, owner_id, created_at, password_hash
+	err := r.db.SelectContext(ctx, &users, `
+		SELECT id, username, email, phone, role, is_verified, owner_id, created_at, password_hash, failed_logins, locked_until
 		FROM users
 		ORDER BY created_at DESC
 	`)
 	return users, err
 }
 
-func (r *UserRepo) GetUserByID(id int) (*models.User, error) {
+func (r *UserRepo) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	err := r.db.Get(&user, `
-		SELECT id, username, email, phone, role, is_verified, owner_id, created_at, password_hash
+	err := r.db.GetContext(ctx, &user, `
+		SELECT id, username, email, phone, role, is_verified, owner_id, created_at, password_hash, failed_logins, locked_until
 		FROM users
 		WHERE id = $1
 	`, id)
@@ -153,18 +164,18 @@ func (r *UserRepo) GetUserByID(id int) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *UserRepo) UpdateAddress(userID int, address *string) error {
+func (r *UserRepo) UpdateAddress(ctx context.Context, userID int, address *string) error {
 	query := `UPDATE users SET address = $1 WHERE id = $2`
-	_, err := r.db.Exec(query, address, userID)
+	_, err := r.db.ExecContext(ctx, query, address, userID)
 	if err != nil {
 		return fmt.Errorf("не удалось обновить адрес: %w", err)
 	}
 	return nil
 }
 
-func (r *UserRepo) GetAddress(userID int) (*string, error) {
+func (r *UserRepo) GetAddress(ctx context.Context, userID int) (*string, error) {
 	var address *string
-	err := r.db.Get(&address, "SELECT address FROM users WHERE id = $1", userID)
+	err := r.db.GetContext(ctx, &address, "SELECT address FROM users WHERE id = $1", userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -174,17 +185,140 @@ func (r *UserRepo) GetAddress(userID int) (*string, error) {
 	return address, nil
 }
 
-func (r *UserRepo) ClearAddress(userID int) error {
+func (r *UserRepo) ClearAddress(ctx context.Context, userID int) error {
 	query := `UPDATE users SET address = NULL WHERE id = $1`
-	_, err := r.db.Exec(query, userID)
+	_, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("не удалось очистить адрес: %w", err)
 	}
 	return nil
 }
 
-func (r *UserRepo) GetUsernameByID(id string) (string, error) {
+func (r *UserRepo) GetUsernameByID(ctx context.Context, id string) (string, error) {
 	var username string
-	err := r.db.Get(&username, `SELECT username FROM users WHERE id = $1`, id)
+	err := r.db.GetContext(ctx, &username, `SELECT username FROM users WHERE id = $1`, id)
 	return username, err
 }
+
+// UpdatePassword заменяет хэш пароля пользователя
+func (r *UserRepo) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить пароль: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("пользователь %d не найден", userID)
+	}
+	return nil
+}
+
+// GetLoginLock возвращает, сколько ещё закрыт вход в аккаунт; 0 — вход открыт.
+// Время сравнивается на стороне базы, как и записывается.
+func (r *UserRepo) GetLoginLock(ctx context.Context, userID int) (time.Duration, error) {
+	var seconds float64
+	err := r.db.GetContext(ctx, &seconds, `
+		SELECT COALESCE(EXTRACT(EPOCH FROM locked_until - NOW()), 0)::float8
+		FROM users
+		WHERE id = $1 AND locked_until > NOW()
+	`, userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось проверить блокировку входа: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RecordLoginAttempt засчитывает попытку входа неудачной ещё до проверки пароля и возвращает
+// число неудач подряд; при верном пароле счётчик сбрасывает ResetLoginFailures. Проверка блокировки
+// и учёт попытки — один UPDATE, поэтому параллельные попытки не проскакивают мимо блокировки.
+// 0 — вход закрыт, попытка не учтена. Счёт начинается заново, если с прошлой неудачи прошло
+// больше window или предыдущая серия закончилась блокировкой (maxAttempts неудач).
+func (r *UserRepo) RecordLoginAttempt(ctx context.Context, userID int, window time.Duration, maxAttempts int) (int, error) {
+	var failures int
+	err := r.db.GetContext(ctx, &failures, `
+		UPDATE users
+		SET failed_logins = CASE
+		        WHEN last_failed_login_at IS NULL OR last_failed_login_at < NOW() - make_interval(secs => $2)
+		             OR failed_logins >= $3 THEN 1
+		        ELSE failed_logins + 1
+		    END,
+		    last_failed_login_at = NOW()
+		WHERE id = $1 AND (locked_until IS NULL OR locked_until <= NOW())
+		RETURNING failed_logins
+	`, userID, window.Seconds(), maxAttempts)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось учесть попытку входа: %w", err)
+	}
+	return failures, nil
+}
+
+// DelayLogin закрывает вход на delay, не сбрасывая счётчик неудач. Уже стоящая более длинная
+// блокировка не сокращается.
+func (r *UserRepo) DelayLogin(ctx context.Context, userID int, delay time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET locked_until = GREATEST(locked_until, NOW() + make_interval(secs => $2))
+		WHERE id = $1
+	`, userID, delay.Seconds())
+	if err != nil {
+		return fmt.Errorf("не удалось задержать вход: %w", err)
+	}
+	return nil
+}
+
+// LockLogin блокирует вход на duration; как и DelayLogin, не сокращает уже стоящую блокировку.
+// Счётчик не трогается: следующая попытка после блокировки начнёт его заново (см. RecordLoginAttempt).
+func (r *UserRepo) LockLogin(ctx context.Context, userID int, duration time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET locked_until = GREATEST(locked_until, NOW() + make_interval(secs => $2))
+		WHERE id = $1
+	`, userID, duration.Seconds())
+	if err != nil {
+		return fmt.Errorf("не удалось заблокировать вход: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginFailures снимает блокировку и обнуляет счётчик неудач
+func (r *UserRepo) ResetLoginFailures(ctx context.Context, userID int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET failed_logins = 0, last_failed_login_at = NULL, locked_until = NULL
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("не удалось снять блокировку входа: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddLoginEvent записывает попытку входа в историю
+func (r *UserRepo) AddLoginEvent(ctx context.Context, event models.LoginEvent) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO login_history (user_id, success, ip, user_agent)
+		VALUES ($1, $2, $3, $4)
+	`, event.UserID, event.Success, event.IP, event.UserAgent)
+	if err != nil {
+		return fmt.Errorf("не удалось записать историю входа: %w", err)
+	}
+	return nil
+}
+
+// GetLoginHistory возвращает последние limit попыток входа, новые первыми
+func (r *UserRepo) GetLoginHistory(ctx context.Context, userID, limit int) ([]models.LoginEvent, error) {
+	events := []models.LoginEvent{}
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, user_id, success, ip, user_agent, created_at
+		FROM login_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	return events, err
+}
@@ -13,19 +13,24 @@ func RegisterPublicRoutes(
 	r *mux.Router,
 	user handlers.UserHandlerInterface,
 	product handlers.ProductHandlerInterface,
+	productImage handlers.ProductImageHandlerInterface,
+	search handlers.SearchHandlerInterface,
 	category handlers.CategoryHandlerInterface,
 	cart handlers.CartHandlerInterface,
 	order handlers.OrderHandlerInterface,
 	announcement handlers.AnnouncementHandlerInterface,
 	review handlers.ReviewHandlerInterface,
 	push handlers.PushHandlerInterface,
+	payment handlers.PaymentHandlerInterface,
 	jwt utils.JWTManagerInterface,
+	rateLimit mux.MiddlewareFunc,
+	idempotent mux.MiddlewareFunc,
 ) {
 	public := r.PathPrefix("/api").Subrouter()
 
-	public.Use(middleware.RateLimitMiddleware)
-
+	// Лимит считается после разбора токена: авторизованных ограничиваем по пользователю, а не по IP
 	public.Use(middleware.OptionalJWTMiddleware(jwt))
+	public.Use(rateLimit)
 
 	// Аутентификация и регистрация
 	public.HandleFunc("/register", user.Register).Methods(http.MethodPost)
@@ -34,25 +39,35 @@ func RegisterPublicRoutes(
 	// Товары и категории
 	public.HandleFunc("/products", product.GetAll).Methods(http.MethodGet)
 	public.HandleFunc("/products/{id}", product.GetByID).Methods(http.MethodGet)
+	public.HandleFunc("/products/{id}/images", productImage.GetImages).Methods(http.MethodGet)
 	public.HandleFunc("/categories", category.GetAll).Methods(http.MethodGet)
+	public.HandleFunc("/categories/tree", category.GetTree).Methods(http.MethodGet)
+	public.HandleFunc("/categories/{slug}", category.GetBySlug).Methods(http.MethodGet)
+
+	// Поиск
+	public.HandleFunc("/search", search.SearchProducts).Methods(http.MethodGet)
+	public.HandleFunc("/search/suggestions", search.Suggest).Methods(http.MethodGet)
 
 	// Корзина
 	public.HandleFunc("/cart", cart.AddToCart).Methods(http.MethodPost)
 	public.HandleFunc("/cart", cart.GetCart).Methods(http.MethodGet)
 	public.HandleFunc("/cart/clear", cart.ClearCart).Methods(http.MethodDelete)
-	public.HandleFunc("/cart/bulk", cart.AddBulkToCart).Methods(http.MethodPost)
+	public.Handle("/cart/bulk", idempotent(http.HandlerFunc(cart.AddBulkToCart))).Methods(http.MethodPost)
 	public.HandleFunc("/cart/{product_id}", cart.UpdateItem).Methods(http.MethodPut)
 	public.HandleFunc("/cart/{product_id}", cart.DeleteItem).Methods(http.MethodDelete)
 
 	// Заказы
-	public.HandleFunc("/order", order.PlaceOrder).Methods(http.MethodPost)
+	// Повтор оформления с тем же Idempotency-Key возвращает уже созданный заказ, а не второй
+	public.Handle("/order", idempotent(http.HandlerFunc(order.PlaceOrder))).Methods(http.MethodPost)
 	public.HandleFunc("/orders", order.GetUserOrders).Methods(http.MethodGet)
-	public.HandleFunc("/order-reviews", order.GetAllReview).Methods(http.MethodGet)
-	public.HandleFunc("/orders/{id}/review", order.LeaveReview).Methods(http.MethodPatch)
-	public.HandleFunc("/orders/{id}/review", order.GetReview).Methods(http.MethodGet)
+	public.HandleFunc("/order-reviews", review.GetAllOrderFeedback).Methods(http.MethodGet)
+	public.HandleFunc("/orders/{id}/feedback", review.SubmitOrderFeedback).Methods(http.MethodPost)
+	// Старый адрес для оценки заказа, принимает тот же формат
+	public.HandleFunc("/orders/{id}/review", review.SubmitOrderFeedback).Methods(http.MethodPatch)
+	public.HandleFunc("/orders/{id}/review", review.GetOrderFeedback).Methods(http.MethodGet)
 	public.HandleFunc("/orders/{id}/repeat", order.RepeatOrder).Methods(http.MethodPost)
+	public.HandleFunc("/orders/{id}/payment", payment.RetryPayment).Methods(http.MethodPost)
 	public.HandleFunc("/orders/history", order.GetOrderHistory).Methods(http.MethodGet)
-	public.HandleFunc("/orders/{id}/status", order.UpdateStatus).Methods(http.MethodPatch)
 	public.HandleFunc("/orders/{id}", order.GetOrderByID).Methods(http.MethodGet)
 
 	// Объявления
@@ -64,6 +79,9 @@ func RegisterPublicRoutes(
 	public.HandleFunc("/products/{id}/reviews", review.AddReview).Methods(http.MethodPost)
 	public.HandleFunc("/products/{id}/reviews", review.UpdateReview).Methods(http.MethodPut)
 	public.HandleFunc("/products/{id}/reviews", review.DeleteReview).Methods(http.MethodDelete)
+	public.HandleFunc("/products/{id}/reviews/photos", review.AddPhoto).Methods(http.MethodPost)
+	public.HandleFunc("/products/{id}/reviews/photos/{photo_id}", review.DeletePhoto).Methods(http.MethodDelete)
+	public.HandleFunc("/products/{id}/rating", review.GetRatingSummary).Methods(http.MethodGet)
 
 	public.HandleFunc("/push/send", push.SendNotification).Methods(http.MethodPost)
 	public.HandleFunc("/push/subscribe", push.Subscribe).Methods(http.MethodPost)
@@ -72,6 +90,22 @@ func RegisterPublicRoutes(
 
 }
 
+// RegisterExchangeRoutes — обмен с 1С. Авторизация своя (Basic + cookie сессии), а не JWT.
+// Лимит запросов действует только на checkauth — подбор пароля; остальные шаги требуют сессию,
+// а загрузка каталога частями не должна упираться в лимит.
+func RegisterExchangeRoutes(r *mux.Router, exchange handlers.ExchangeHandlerInterface, rateLimit mux.MiddlewareFunc) {
+	r.Handle("/api/1c/exchange", rateLimit(http.HandlerFunc(exchange.Handle))).
+		Methods(http.MethodGet, http.MethodPost).
+		Queries("mode", "checkauth")
+	r.HandleFunc("/api/1c/exchange", exchange.Handle).Methods(http.MethodGet, http.MethodPost)
+}
+
+// RegisterPaymentRoutes — уведомления платёжного провайдера. Подлинность проверяется подписью тела,
+// поэтому они идут мимо JWT и лимитов: провайдер повторяет отклонённые уведомления.
+func RegisterPaymentRoutes(r *mux.Router, payment handlers.PaymentHandlerInterface) {
+	r.HandleFunc("/api/payments/webhook", payment.Webhook).Methods(http.MethodPost)
+}
+
 func RegisterPrivateRoutes(
 	r *mux.Router,
 	user handlers.UserHandlerInterface,
@@ -90,50 +124,112 @@ func RegisterAdminRoutes(
 	r *mux.Router,
 	user handlers.UserHandlerInterface,
 	product handlers.ProductHandlerInterface,
+	productImage handlers.ProductImageHandlerInterface,
+	price handlers.PriceHandlerInterface,
 	order handlers.OrderHandlerInterface,
 	category handlers.CategoryHandlerInterface,
+	catalog handlers.CatalogHandlerInterface,
+	files handlers.FileHandlerInterface,
+	search handlers.SearchHandlerInterface,
+	review handlers.ReviewHandlerInterface,
 	logs handlers.LogHandlerInterface,
 	dashboard handlers.DashboardHandlerInterface,
+	trash handlers.TrashHandlerInterface,
+	audit handlers.AuditHandlerInterface,
+	system handlers.SystemHandlerInterface,
+	auditRecorder middleware.AuditRecorder,
 	jwt utils.JWTManagerInterface,
 	announcement handlers.AnnouncementHandlerInterface,
 	adminInterface handlers.AdminInterface,
+	idempotent mux.MiddlewareFunc,
 ) {
 	admin := r.PathPrefix("/api/admin").Subrouter()
 	admin.Use(middleware.JWTMiddleware(jwt))
 	admin.Use(middleware.OnlyAdmin())
+	// Все изменяющие запросы администраторов попадают в журнал
+	admin.Use(middleware.AuditMiddleware(auditRecorder))
 
 	admin.HandleFunc("/truncate", adminInterface.TruncateTableHandler).Methods(http.MethodPost)
 	admin.HandleFunc("/truncate/all", adminInterface.TruncateAllTablesHandler).Methods(http.MethodPost)
 
+	// Резервные копии
+	admin.HandleFunc("/backups", adminInterface.ListBackups).Methods(http.MethodGet)
+	admin.HandleFunc("/backups", adminInterface.CreateBackup).Methods(http.MethodPost)
+	admin.HandleFunc("/backups/upload", adminInterface.UploadBackup).Methods(http.MethodPost)
+	admin.HandleFunc("/backups/{name}", adminInterface.DownloadBackup).Methods(http.MethodGet)
+	admin.HandleFunc("/backups/{name}", adminInterface.DeleteBackup).Methods(http.MethodDelete)
+	admin.HandleFunc("/backups/{name}/restore", adminInterface.RestoreBackup).Methods(http.MethodPost)
+
 	admin.HandleFunc("/users", user.CreateUserByPhone).Methods(http.MethodPost)
 	admin.HandleFunc("/users/all", user.GetAllUsers).Methods(http.MethodGet)
 	admin.HandleFunc("/users/{id}", user.GetUserByID).Methods(http.MethodGet)
+	admin.HandleFunc("/users/{id}/unlock", user.UnlockUser).Methods(http.MethodPost)
 
 	// Управление товарами
 	admin.HandleFunc("/upload", product.UploadImage).Methods(http.MethodPost)
 	admin.HandleFunc("/upload", product.ListUploadedFiles).Methods(http.MethodGet)
+	admin.HandleFunc("/upload/cleanup", files.CleanupOrphans).Methods(http.MethodPost)
 	admin.HandleFunc("/upload/{filename}", product.DeleteImage).Methods(http.MethodDelete)
 
 	admin.HandleFunc("/products", product.Add).Methods(http.MethodPost)
-	admin.HandleFunc("/products/bulk", product.AddBulk).Methods(http.MethodPost)
+	admin.Handle("/products/bulk", idempotent(http.HandlerFunc(product.AddBulk))).Methods(http.MethodPost)
 
 	admin.HandleFunc("/products/{id}", product.Update).Methods(http.MethodPut)
 	admin.HandleFunc("/products/{id}", product.Patch).Methods(http.MethodPatch)
 	admin.HandleFunc("/products/{id}", product.Delete).Methods(http.MethodDelete)
 
+	// Галерея товара
+	admin.HandleFunc("/products/{id}/images", productImage.AddImage).Methods(http.MethodPost)
+	admin.HandleFunc("/products/{id}/images/order", productImage.Reorder).Methods(http.MethodPut)
+	admin.HandleFunc("/products/{id}/images/{image_id}/primary", productImage.SetPrimary).Methods(http.MethodPatch)
+	admin.HandleFunc("/products/{id}/images/{image_id}", productImage.DeleteImage).Methods(http.MethodDelete)
+
+	// Цены: история и отложенные изменения
+	admin.HandleFunc("/products/{id}/price-history", price.GetHistory).Methods(http.MethodGet)
+	admin.HandleFunc("/products/{id}/scheduled-prices", price.GetScheduled).Methods(http.MethodGet)
+	admin.HandleFunc("/products/{id}/scheduled-prices", price.Schedule).Methods(http.MethodPost)
+	admin.HandleFunc("/products/{id}/scheduled-prices/{schedule_id}", price.CancelScheduled).Methods(http.MethodDelete)
+
+	// Модерация отзывов
+	admin.HandleFunc("/reviews", review.GetModerationQueue).Methods(http.MethodGet)
+	admin.HandleFunc("/reviews/analytics", review.GetAnalytics).Methods(http.MethodGet)
+	admin.HandleFunc("/reviews/{id}/moderate", review.Moderate).Methods(http.MethodPatch)
+	admin.HandleFunc("/reviews/{id}/reply", review.Reply).Methods(http.MethodPut)
+
+	// Аналитика поиска
+	admin.HandleFunc("/search/zero-results", search.GetZeroResults).Methods(http.MethodGet)
+
 	// Управление заказами
 	admin.HandleFunc("/orders", order.GetAllOrders).Methods(http.MethodGet)
 	admin.HandleFunc("/orders/export", order.ExportOrdersCSV).Methods(http.MethodGet)
+	admin.HandleFunc("/orders/{id}/status", order.UpdateStatus).Methods(http.MethodPatch)
 	admin.HandleFunc("/orders/{id}", order.DeleteOrder).Methods(http.MethodDelete)
 
+	// Корзина: восстановление удалённых товаров, категорий и заказов
+	admin.HandleFunc("/trash", trash.List).Methods(http.MethodGet)
+	admin.HandleFunc("/trash/purge", trash.Purge).Methods(http.MethodPost)
+	admin.HandleFunc("/trash/{type}/{id}/restore", trash.Restore).Methods(http.MethodPost)
+
 	// Управление категориями
 	admin.HandleFunc("/categories", category.Create).Methods(http.MethodPost)
-	admin.HandleFunc("/categories/bulk", category.CreateBulk).Methods(http.MethodPost)
+	admin.Handle("/categories/bulk", idempotent(http.HandlerFunc(category.CreateBulk))).Methods(http.MethodPost)
 	admin.HandleFunc("/categories/{id}", category.Update).Methods(http.MethodPut)
 	admin.HandleFunc("/categories/{id}", category.Delete).Methods(http.MethodDelete)
+	admin.HandleFunc("/categories/{id}/move", category.Move).Methods(http.MethodPatch)
+
+	// Импорт и экспорт каталога
+	admin.HandleFunc("/catalog/import", catalog.Import).Methods(http.MethodPost)
+	admin.HandleFunc("/catalog/export", catalog.Export).Methods(http.MethodGet)
+
+	// Журнал действий администраторов
+	admin.HandleFunc("/audit", audit.List).Methods(http.MethodGet)
+	admin.HandleFunc("/audit/export", audit.Export).Methods(http.MethodGet)
+
+	admin.HandleFunc("/system", system.Info).Methods(http.MethodGet)
 
 	// Просмотр логов
 	admin.HandleFunc("/logs", logs.GetLogs).Methods(http.MethodGet)
+	admin.HandleFunc("/logs/search", logs.Search).Methods(http.MethodGet)
 
 	// Дэшборд
 	admin.HandleFunc("/dashboard", dashboard.GetDashboard).Methods(http.MethodGet)
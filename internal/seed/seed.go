@@ -0,0 +1,51 @@
+// Package seed заполняет базу демонстрационным каталогом для разработки
+package seed
+
+import (
+	"bytes"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/spreadsheet"
+	"chechnya-product/internal/utils"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// Категории демо-каталога в порядке показа
+var categories = []string{
+	"Овощи и фрукты",
+	"Молочные продукты",
+	"Мясо и птица",
+	"Хлеб и выпечка",
+	"Бакалея",
+	"Напитки",
+}
+
+//go:embed catalog.csv
+var catalogCSV []byte
+
+// Run создаёт недостающие категории и загружает товары через обычный импорт каталога.
+// Товары ищутся по артикулу, поэтому повторный запуск ничего не дублирует.
+func Run(ctx context.Context, categoryService services.CategoryServiceInterface, catalogService services.CatalogServiceInterface) (*models.CatalogImportReport, error) {
+	existing, err := categoryService.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	names := make(map[string]bool, len(existing))
+	for _, c := range existing {
+		names[strings.ToLower(c.Name)] = true
+	}
+
+	for i, name := range categories {
+		if names[strings.ToLower(name)] {
+			continue
+		}
+		if _, err := categoryService.Create(ctx, utils.CategoryRequest{Name: name, SortOrder: i + 1}); err != nil {
+			return nil, fmt.Errorf("failed to create category %q: %w", name, err)
+		}
+	}
+
+	return catalogService.Import(ctx, spreadsheet.FormatCSV, bytes.NewReader(catalogCSV), false, 0)
+}
@@ -1,24 +1,387 @@
 package services
 
-import "chechnya-product/internal/repositories"
+import (
+	"bufio"
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// confirmationTTL — сколько действует токен подтверждения опасной операции
+const confirmationTTL = 5 * time.Minute
+
+const backupVersion = 1
+
+var (
+	ErrTruncateDisabled      = errors.New("table truncation is disabled in production")
+	ErrUnknownTable          = errors.New("table cannot be truncated")
+	ErrInvalidConfirmation   = errors.New("confirmation token is invalid or expired")
+	ErrBackupNotFound        = errors.New("backup not found")
+	ErrInvalidBackup         = errors.New("file is not a valid backup")
+	ErrBackupSchemaMismatch  = errors.New("backup was made with a different database schema version")
+	ErrBackupUnexpectedTable = errors.New("backup contains an unexpected table")
+)
+
+// backupNamePattern — имена файлов копий; другие имена не принимаются, чтобы нельзя было выйти из каталога
+var backupNamePattern = regexp.MustCompile(`^backup-\d{8}-\d{6}(-upload)?\.json$`)
 
 type AdminServiceInterface interface {
-	TruncateTable(tableName string) error
-	TruncateAllTables() error
+	TruncateTable(ctx context.Context, tableName string, userID int, token string) (*models.Confirmation, error)
+	TruncateAllTables(ctx context.Context, userID int, token string) (*models.Confirmation, error)
+	CreateBackup(ctx context.Context) (*models.BackupInfo, error)
+	ListBackups(ctx context.Context) ([]models.BackupInfo, error)
+	OpenBackup(ctx context.Context, name string) (*os.File, error)
+	UploadBackup(ctx context.Context, r io.Reader) (*models.BackupInfo, error)
+	DeleteBackup(ctx context.Context, name string) error
+	RestoreBackup(ctx context.Context, name string, userID int, token string) (*models.Confirmation, error)
+}
+
+// ConfirmationStore хранит выданные токены подтверждения с истечением по TTL. Токены лежат в Redis,
+// а не в памяти процесса: подтверждение может прийти на другой экземпляр API или после перезапуска.
+type ConfirmationStore interface {
+	SaveConfirmation(ctx context.Context, token string, value []byte, ttl time.Duration) error
+	// TakeConfirmation возвращает и удаляет значение токена; nil — токен неизвестен или истёк
+	TakeConfirmation(ctx context.Context, token string) ([]byte, error)
 }
 
 type AdminService struct {
-	repo repositories.AdminRepoInterface
+	repo          repositories.AdminRepoInterface
+	confirmations ConfirmationStore
+	cfg           *config.Config
+	logger        *zap.Logger
+}
+
+// pendingConfirmation — выданный, но ещё не использованный токен
+type pendingConfirmation struct {
+	UserID int    `json:"user_id"`
+	Action string `json:"action"`
+}
+
+func NewAdminService(repo repositories.AdminRepoInterface, confirmations ConfirmationStore, cfg *config.Config, logger *zap.Logger) *AdminService {
+	return &AdminService{
+		repo:          repo,
+		confirmations: confirmations,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// TruncateTable очищает таблицу. Без токена возвращает подтверждение, которое нужно
+// прислать повторным запросом; очистка выполняется только вне продакшена.
+func (s *AdminService) TruncateTable(ctx context.Context, tableName string, userID int, token string) (*models.Confirmation, error) {
+	if s.cfg.IsProduction() {
+		return nil, ErrTruncateDisabled
+	}
+	if !s.repo.IsTruncatable(tableName) {
+		return nil, ErrUnknownTable
+	}
+
+	action := "truncate:" + tableName
+	if token == "" {
+		return s.requestConfirmation(ctx, userID, action)
+	}
+	if err := s.confirm(ctx, userID, action, token); err != nil {
+		return nil, err
+	}
+	return nil, s.repo.TruncateTable(ctx, tableName)
+}
+
+func (s *AdminService) TruncateAllTables(ctx context.Context, userID int, token string) (*models.Confirmation, error) {
+	if s.cfg.IsProduction() {
+		return nil, ErrTruncateDisabled
+	}
+
+	const action = "truncate:all"
+	if token == "" {
+		return s.requestConfirmation(ctx, userID, action)
+	}
+	if err := s.confirm(ctx, userID, action, token); err != nil {
+		return nil, err
+	}
+	return nil, s.repo.TruncateAllTables(ctx)
+}
+
+// CreateBackup выгружает бизнес-таблицы в JSON-файл в каталоге копий.
+// Файл пишется во временный и переименовывается, чтобы в списке не появлялись недописанные копии.
+func (s *AdminService) CreateBackup(ctx context.Context) (*models.BackupInfo, error) {
+	version, err := s.repo.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.cfg.BackupDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := "backup-" + now.Format("20060102-150405") + ".json"
+	path := filepath.Join(s.cfg.BackupDir, name)
+
+	tmp, err := os.CreateTemp(s.cfg.BackupDir, ".backup-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.writeBackup(ctx, tmp, models.BackupHeader{
+		Format:        models.BackupFormat,
+		Version:       backupVersion,
+		SchemaVersion: version,
+		CreatedAt:     now,
+	}); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	tracing.Logger(ctx, s.logger).Info("backup created", zap.String("name", name), zap.Int64("schema_version", version))
+	return s.backupInfo(name)
+}
+
+// writeBackup пишет заголовок и таблицы одним JSON-объектом
+func (s *AdminService) writeBackup(ctx context.Context, f *os.File, header models.BackupHeader) error {
+	w := bufio.NewWriter(f)
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// Заголовок без закрывающей скобки, дальше дописывается поле tables
+	w.Write(headerJSON[:len(headerJSON)-1])
+	w.WriteString(`,"tables":{`)
+	for i, table := range repositories.BackupTables {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		fmt.Fprintf(w, "\n%q:", table)
+		if err := s.repo.DumpTable(ctx, table, w); err != nil {
+			return err
+		}
+	}
+	w.WriteString("}}\n")
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
 }
 
-func NewAdminService(repo repositories.AdminRepoInterface) *AdminService {
-	return &AdminService{repo: repo}
+func (s *AdminService) ListBackups(ctx context.Context) ([]models.BackupInfo, error) {
+	entries, err := os.ReadDir(s.cfg.BackupDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup dir: %w", err)
+	}
+
+	backups := make([]models.BackupInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !backupNamePattern.MatchString(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, models.BackupInfo{Name: e.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
 }
 
-func (s *AdminService) TruncateTable(tableName string) error {
-	return s.repo.TruncateTable(tableName)
+func (s *AdminService) OpenBackup(ctx context.Context, name string) (*os.File, error) {
+	path, err := s.backupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	return f, err
 }
 
-func (s *AdminService) TruncateAllTables() error {
-	return s.repo.TruncateAllTables()
+// UploadBackup сохраняет загруженную копию, предварительно проверив её целиком
+func (s *AdminService) UploadBackup(ctx context.Context, r io.Reader) (*models.BackupInfo, error) {
+	if err := os.MkdirAll(s.cfg.BackupDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.cfg.BackupDir, ".upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to save uploaded backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to save uploaded backup: %w", err)
+	}
+	if _, _, err := readBackup(tmp.Name()); err != nil {
+		return nil, err
+	}
+
+	name := "backup-" + time.Now().UTC().Format("20060102-150405") + "-upload.json"
+	if err := os.Rename(tmp.Name(), filepath.Join(s.cfg.BackupDir, name)); err != nil {
+		return nil, fmt.Errorf("failed to save uploaded backup: %w", err)
+	}
+	return s.backupInfo(name)
+}
+
+func (s *AdminService) DeleteBackup(ctx context.Context, name string) error {
+	path, err := s.backupPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrBackupNotFound
+		}
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	return nil
+}
+
+// RestoreBackup заменяет бизнес-данные содержимым копии. Копия должна быть снята
+// на той же версии схемы. Как и очистка, требует подтверждения токеном, но доступна и в продакшене.
+func (s *AdminService) RestoreBackup(ctx context.Context, name string, userID int, token string) (*models.Confirmation, error) {
+	path, err := s.backupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	header, tables, err := readBackup(path)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.repo.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if header.SchemaVersion != version {
+		return nil, fmt.Errorf("%w: backup %d, database %d", ErrBackupSchemaMismatch, header.SchemaVersion, version)
+	}
+
+	action := "restore:" + name
+	if token == "" {
+		return s.requestConfirmation(ctx, userID, action)
+	}
+	if err := s.confirm(ctx, userID, action, token); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RestoreTables(ctx, tables); err != nil {
+		return nil, err
+	}
+	tracing.Logger(ctx, s.logger).Warn("database restored from backup", zap.String("name", name), zap.Int("user_id", userID))
+	return nil, nil
+}
+
+// readBackup читает и проверяет файл копии
+func readBackup(path string) (*models.BackupHeader, map[string]json.RawMessage, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	var backup struct {
+		models.BackupHeader
+		Tables map[string]json.RawMessage `json:"tables"`
+	}
+	if err := json.NewDecoder(bufio.NewReader(f)).Decode(&backup); err != nil {
+		return nil, nil, ErrInvalidBackup
+	}
+	if backup.Format != models.BackupFormat || backup.Version != backupVersion || backup.Tables == nil {
+		return nil, nil, ErrInvalidBackup
+	}
+
+	known := make(map[string]bool, len(repositories.BackupTables))
+	for _, t := range repositories.BackupTables {
+		known[t] = true
+	}
+	for table := range backup.Tables {
+		if !known[table] {
+			return nil, nil, fmt.Errorf("%w: %s", ErrBackupUnexpectedTable, table)
+		}
+	}
+	return &backup.BackupHeader, backup.Tables, nil
+}
+
+func (s *AdminService) backupPath(name string) (string, error) {
+	if !backupNamePattern.MatchString(name) {
+		return "", ErrBackupNotFound
+	}
+	return filepath.Join(s.cfg.BackupDir, name), nil
+}
+
+func (s *AdminService) backupInfo(name string) (*models.BackupInfo, error) {
+	info, err := os.Stat(filepath.Join(s.cfg.BackupDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+	return &models.BackupInfo{Name: name, Size: info.Size(), CreatedAt: info.ModTime()}, nil
+}
+
+// requestConfirmation выдаёт одноразовый токен для действия
+func (s *AdminService) requestConfirmation(ctx context.Context, userID int, action string) (*models.Confirmation, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	value, err := json.Marshal(pendingConfirmation{UserID: userID, Action: action})
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(confirmationTTL)
+	if err := s.confirmations.SaveConfirmation(ctx, token, value, confirmationTTL); err != nil {
+		return nil, fmt.Errorf("failed to save confirmation token: %w", err)
+	}
+
+	return &models.Confirmation{Action: action, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// confirm проверяет и гасит токен: он подходит только тому же администратору и тому же действию
+func (s *AdminService) confirm(ctx context.Context, userID int, action, token string) error {
+	value, err := s.confirmations.TakeConfirmation(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to check confirmation token: %w", err)
+	}
+	if value == nil {
+		return ErrInvalidConfirmation
+	}
+
+	var pending pendingConfirmation
+	if err := json.Unmarshal(value, &pending); err != nil {
+		return ErrInvalidConfirmation
+	}
+	if pending.UserID != userID || pending.Action != action {
+		return ErrInvalidConfirmation
+	}
+	return nil
 }
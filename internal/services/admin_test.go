@@ -0,0 +1,92 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/repositories"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memConfirmationStore — токены подтверждения в памяти; общий для нескольких сервисов, как Redis для экземпляров API
+type memConfirmationStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (s *memConfirmationStore) SaveConfirmation(_ context.Context, token string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[token] = value
+	return nil
+}
+
+func (s *memConfirmationStore) TakeConfirmation(_ context.Context, token string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value := s.values[token]
+	delete(s.values, token)
+	return value, nil
+}
+
+// truncateRepo считает очистки таблиц
+type truncateRepo struct {
+	repositories.AdminRepoInterface
+
+	truncated []string
+}
+
+func (r *truncateRepo) IsTruncatable(table string) bool {
+	return table == "orders" || table == "products"
+}
+
+func (r *truncateRepo) TruncateTable(_ context.Context, table string) error {
+	r.truncated = append(r.truncated, table)
+	return nil
+}
+
+func TestAdminConfirmation(t *testing.T) {
+	ctx := context.Background()
+	store := &memConfirmationStore{values: map[string][]byte{}}
+	repo := &truncateRepo{}
+	cfg := &config.Config{Env: "test"}
+	// Токен выдаёт один экземпляр, подтверждение приходит на другой
+	issuer := NewAdminService(repo, store, cfg, zap.NewNop())
+	other := NewAdminService(repo, store, cfg, zap.NewNop())
+
+	confirmation, err := issuer.TruncateTable(ctx, "orders", 1, "")
+	if err != nil || confirmation == nil || confirmation.Token == "" {
+		t.Fatalf("request confirmation = %+v, %v", confirmation, err)
+	}
+	if len(repo.truncated) != 0 {
+		t.Fatal("table truncated without confirmation")
+	}
+
+	// Чужой администратор или другое действие гасят токен, ничего не очищая
+	if _, err := other.TruncateTable(ctx, "products", 1, confirmation.Token); !errors.Is(err, ErrInvalidConfirmation) {
+		t.Fatalf("other action: err = %v, want ErrInvalidConfirmation", err)
+	}
+	if _, err := other.TruncateTable(ctx, "orders", 1, confirmation.Token); !errors.Is(err, ErrInvalidConfirmation) {
+		t.Fatalf("used token: err = %v, want ErrInvalidConfirmation", err)
+	}
+
+	confirmation, _ = issuer.TruncateTable(ctx, "orders", 1, "")
+	if _, err := other.TruncateTable(ctx, "orders", 2, confirmation.Token); !errors.Is(err, ErrInvalidConfirmation) {
+		t.Fatalf("other admin: err = %v, want ErrInvalidConfirmation", err)
+	}
+
+	confirmation, _ = issuer.TruncateTable(ctx, "orders", 1, "")
+	if _, err := other.TruncateTable(ctx, "orders", 1, confirmation.Token); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if _, err := other.TruncateTable(ctx, "orders", 1, confirmation.Token); !errors.Is(err, ErrInvalidConfirmation) {
+		t.Fatalf("token reuse: err = %v, want ErrInvalidConfirmation", err)
+	}
+	if len(repo.truncated) != 1 || repo.truncated[0] != "orders" {
+		t.Fatalf("truncated = %v, want [orders]", repo.truncated)
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
 	"chechnya-product/internal/ws"
+	"context"
 )
 
 type AnnouncementServiceInterface interface {
-	GetAll() ([]models.Announcement, error)
-	GetByID(id int) (*models.Announcement, error)
-	Create(title, content string) (*models.Announcement, error)
-	Update(id int, title, content string) error
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]models.Announcement, error)
+	GetByID(ctx context.Context, id int) (*models.Announcement, error)
+	Create(ctx context.Context, title, content string) (*models.Announcement, error)
+	Update(ctx context.Context, id int, title, content string) error
+	Delete(ctx context.Context, id int) error
 }
 
 type AnnouncementService struct {
@@ -23,12 +24,12 @@ func NewAnnouncementService(repo repositories.AnnouncementRepository, hub *ws.Hu
 	return &AnnouncementService{repo: repo, hub: hub}
 }
 
-func (s *AnnouncementService) GetByID(id int) (*models.Announcement, error) {
-	return s.repo.GetByID(id)
+func (s *AnnouncementService) GetByID(ctx context.Context, id int) (*models.Announcement, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *AnnouncementService) Create(title, content string) (*models.Announcement, error) {
-	ann, err := s.repo.Create(title, content)
+func (s *AnnouncementService) Create(ctx context.Context, title, content string) (*models.Announcement, error) {
+	ann, err := s.repo.Create(ctx, title, content)
 	if err != nil {
 		return nil, err
 	}
@@ -36,14 +37,14 @@ func (s *AnnouncementService) Create(title, content string) (*models.Announcemen
 	return ann, nil
 }
 
-func (s *AnnouncementService) GetAll() ([]models.Announcement, error) {
-	return s.repo.GetAll()
+func (s *AnnouncementService) GetAll(ctx context.Context) ([]models.Announcement, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *AnnouncementService) Update(id int, title, content string) error {
-	return s.repo.Update(id, title, content)
+func (s *AnnouncementService) Update(ctx context.Context, id int, title, content string) error {
+	return s.repo.Update(ctx, id, title, content)
 }
 
-func (s *AnnouncementService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *AnnouncementService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Сколько записей журнала отдаётся по умолчанию и максимум на страницу
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// auditTables — сущности, для которых в журнал пишется состояние до и после действия
+var auditTables = map[string]string{
+	"product":      "products",
+	"category":     "categories",
+	"order":        "orders",
+	"user":         "users",
+	"review":       "reviews",
+	"announcement": "announcements",
+}
+
+// Поля, значения которых не попадают в журнал
+var auditSecretFields = map[string]bool{
+	"password":      true,
+	"password_hash": true,
+	"new_password":  true,
+	"token":         true,
+	"confirm_token": true,
+	"secret":        true,
+	"code":          true,
+}
+
+const auditRedacted = "***"
+
+type AuditServiceInterface interface {
+	Snapshot(ctx context.Context, entityType, entityID string) json.RawMessage
+	Record(ctx context.Context, entry models.AuditEntry, before, after json.RawMessage)
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	Export(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type AuditService struct {
+	repo   repositories.AuditRepository
+	logger *zap.Logger
+}
+
+func NewAuditService(repo repositories.AuditRepository, logger *zap.Logger) *AuditService {
+	return &AuditService{repo: repo, logger: logger}
+}
+
+// Snapshot возвращает текущее состояние сущности без секретных полей.
+// Для сущностей без таблицы или при ошибке возвращается nil — журнал не должен мешать самому действию.
+func (s *AuditService) Snapshot(ctx context.Context, entityType, entityID string) json.RawMessage {
+	table, ok := auditTables[entityType]
+	if !ok {
+		return nil
+	}
+	id, err := strconv.Atoi(entityID)
+	if err != nil {
+		return nil
+	}
+
+	data, err := s.repo.Snapshot(ctx, table, id)
+	if err != nil {
+		tracing.Logger(ctx, s.logger).Warn("failed to snapshot entity for audit", zap.String("entity_type", entityType), zap.Int("id", id), zap.Error(err))
+		return nil
+	}
+	return redactJSON(data)
+}
+
+// Record сохраняет действие вместе с разницей между состояниями до и после.
+// Ошибки только логируются: действие уже выполнено, и ответ клиенту от журнала не зависит.
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry, before, after json.RawMessage) {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		tracing.Logger(ctx, s.logger).Warn("failed to diff audit snapshots", zap.String("action", entry.Action), zap.Error(err))
+	}
+	entry.Changes = changes
+	entry.Request = redactJSON(entry.Request)
+
+	if err := s.repo.Create(ctx, &entry); err != nil {
+		tracing.Logger(ctx, s.logger).Error("failed to write audit entry",
+			zap.String("action", entry.Action),
+			zap.String("entity_type", entry.EntityType),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.list(ctx, filter)
+}
+
+// Export возвращает все записи, подходящие под фильтр, без постраничной разбивки
+func (s *AuditService) Export(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	filter.Limit = 0
+	filter.Offset = 0
+	return s.list(ctx, filter)
+}
+
+func (s *AuditService) list(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	filter.Action = strings.TrimSpace(filter.Action)
+	filter.EntityType = strings.TrimSpace(filter.EntityType)
+	filter.EntityID = strings.TrimSpace(filter.EntityID)
+
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		return []models.AuditEntry{}, nil
+	}
+	return entries, nil
+}
+
+// auditDiff сравнивает верхнеуровневые поля двух JSON-объектов.
+// Для создания before пустой, для удаления — after, и в разницу попадают все поля.
+func auditDiff(before, after json.RawMessage) (json.RawMessage, error) {
+	if len(before) == 0 && len(after) == 0 {
+		return nil, nil
+	}
+
+	var old, cur map[string]interface{}
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &old); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &cur); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for key, value := range old {
+		if !reflect.DeepEqual(value, cur[key]) {
+			changes[key] = models.AuditChange{Before: value, After: cur[key]}
+		}
+	}
+	for key, value := range cur {
+		if _, seen := old[key]; !seen {
+			changes[key] = models.AuditChange{Before: nil, After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+// redactJSON заменяет значения секретных полей на всех уровнях вложенности.
+// Невалидный JSON не сохраняется вовсе.
+func redactJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if auditSecretFields[strings.ToLower(key)] {
+				v[key] = auditRedacted
+				continue
+			}
+			v[key] = redactValue(inner)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactValue(inner)
+		}
+	}
+	return value
+}
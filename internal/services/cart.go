@@ -3,18 +3,19 @@ package services
 import (
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 )
 
 type CartServiceInterface interface {
-	AddToCart(ownerID string, productID, quantity int) error
-	GetCart(ownerID string) ([]models.CartItemResponse, error)
-	UpdateItem(ownerID string, productID, quantity int) error
-	DeleteItem(ownerID string, productID int) error
-	ClearCart(ownerID string) error
-	TransferCart(fromOwnerID, toOwnerID string) error
+	AddToCart(ctx context.Context, ownerID string, productID, quantity int) error
+	GetCart(ctx context.Context, ownerID string) ([]models.CartItemResponse, error)
+	UpdateItem(ctx context.Context, ownerID string, productID, quantity int) error
+	DeleteItem(ctx context.Context, ownerID string, productID int) error
+	ClearCart(ctx context.Context, ownerID string) error
+	TransferCart(ctx context.Context, fromOwnerID, toOwnerID string) error
 }
 
 var (
@@ -32,12 +33,12 @@ func NewCartService(repo repositories.CartRepository, productRepo repositories.P
 	return &CartService{repo: repo, productRepo: productRepo}
 }
 
-func (s *CartService) AddToCart(ownerID string, productID, quantity int) error {
+func (s *CartService) AddToCart(ctx context.Context, ownerID string, productID, quantity int) error {
 	if quantity <= 0 {
 		return ErrInvalidCartQuantity
 	}
 
-	product, err := s.productRepo.GetByID(productID)
+	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		return fmt.Errorf("fetch product: %w", err)
 	}
@@ -48,11 +49,11 @@ func (s *CartService) AddToCart(ownerID string, productID, quantity int) error {
 		return ErrProductOutOfStock
 	}
 
-	return s.repo.AddItem(ownerID, productID, quantity)
+	return s.repo.AddItem(ctx, ownerID, productID, quantity)
 }
 
-func (s *CartService) GetCart(ownerID string) ([]models.CartItemResponse, error) {
-	items, err := s.repo.GetCartItems(ownerID)
+func (s *CartService) GetCart(ctx context.Context, ownerID string) ([]models.CartItemResponse, error) {
+	items, err := s.repo.GetCartItems(ctx, ownerID)
 	log.Println("[OWNER]", ownerID)
 
 	if err != nil {
@@ -62,7 +63,7 @@ func (s *CartService) GetCart(ownerID string) ([]models.CartItemResponse, error)
 	result := make([]models.CartItemResponse, 0)
 
 	for _, item := range items {
-		product, err := s.productRepo.GetByID(item.ProductID)
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
 		if err != nil || product == nil {
 			continue
 		}
@@ -79,12 +80,12 @@ func (s *CartService) GetCart(ownerID string) ([]models.CartItemResponse, error)
 	return result, nil
 }
 
-func (s *CartService) UpdateItem(ownerID string, productID, quantity int) error {
+func (s *CartService) UpdateItem(ctx context.Context, ownerID string, productID, quantity int) error {
 	if quantity < 0 {
 		return ErrInvalidCartQuantity
 	}
 
-	product, err := s.productRepo.GetByID(productID)
+	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		return fmt.Errorf("fetch product: %w", err)
 	}
@@ -95,17 +96,17 @@ func (s *CartService) UpdateItem(ownerID string, productID, quantity int) error
 		return ErrProductOutOfStock
 	}
 
-	return s.repo.UpdateQuantity(ownerID, productID, quantity)
+	return s.repo.UpdateQuantity(ctx, ownerID, productID, quantity)
 }
 
-func (s *CartService) DeleteItem(ownerID string, productID int) error {
-	return s.repo.DeleteItem(ownerID, productID)
+func (s *CartService) DeleteItem(ctx context.Context, ownerID string, productID int) error {
+	return s.repo.DeleteItem(ctx, ownerID, productID)
 }
 
-func (s *CartService) ClearCart(ownerID string) error {
-	return s.repo.ClearCart(ownerID)
+func (s *CartService) ClearCart(ctx context.Context, ownerID string) error {
+	return s.repo.ClearCart(ctx, ownerID)
 }
 
-func (s *CartService) TransferCart(fromOwnerID, toOwnerID string) error {
-	return s.repo.TransferOwnership(fromOwnerID, toOwnerID)
+func (s *CartService) TransferCart(ctx context.Context, fromOwnerID, toOwnerID string) error {
+	return s.repo.TransferOwnership(ctx, fromOwnerID, toOwnerID)
 }
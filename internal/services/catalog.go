@@ -0,0 +1,506 @@
+package services
+
+import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/spreadsheet"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrEmptyImport         = errors.New("file has no rows to import")
+	ErrImportKeyColumn     = errors.New("file must have a sku or name column")
+	ErrUnknownImportColumn = errors.New("unknown column")
+	ErrImportHasErrors     = errors.New("import has invalid rows, nothing was changed")
+)
+
+// Колонки файла каталога. Первое имя — каноническое (используется при экспорте),
+// остальные — допустимые варианты заголовка при импорте.
+var catalogColumns = []struct {
+	field   string
+	aliases []string
+}{
+	{"sku", []string{"артикул", "код"}},
+	{"name", []string{"название", "наименование", "товар"}},
+	{"description", []string{"описание"}},
+	{"price", []string{"цена"}},
+	{"availability", []string{"available", "наличие", "в наличии"}},
+	{"category", []string{"категория"}},
+	{"url", []string{"image", "изображение", "фото"}},
+}
+
+type CatalogServiceInterface interface {
+	Import(ctx context.Context, format string, r io.Reader, dryRun bool, userID int) (*models.CatalogImportReport, error)
+	Export(ctx context.Context, format string, w io.Writer) error
+}
+
+type CatalogService struct {
+	products   repositories.ProductRepository
+	categories repositories.CategoryRepository
+	logger     *zap.Logger
+}
+
+func NewCatalogService(products repositories.ProductRepository, categories repositories.CategoryRepository, logger *zap.Logger) *CatalogService {
+	return &CatalogService{products: products, categories: categories, logger: logger}
+}
+
+// importRow — разобранная строка файла и то, что с ней нужно сделать
+type importRow struct {
+	report  *models.CatalogImportRow
+	create  *models.Product
+	id      int
+	patch   models.ProductPatch
+	changed bool
+}
+
+// catalogIndex — текущее состояние каталога и значения, уже занятые строками файла
+type catalogIndex struct {
+	bySKU         map[string]*models.Product
+	byName        map[string]*models.Product
+	categories    map[string]int // название в нижнем регистре → id
+	categoryNames map[int64]string
+	claimedSKU    map[string]int // артикул → номер строки файла, которая его назначает
+	claimedKey    map[string]int
+	claimedID     map[int]int // id товара → строка файла, которая его меняет
+}
+
+// Import создаёт и обновляет товары из CSV/XLSX. Товар ищется по артикулу, затем по названию.
+// Пустая ячейка значение не меняет. Изменения применяются в одной транзакции и только
+// если во всех строках нет ошибок; при dryRun возвращается только отчёт.
+func (s *CatalogService) Import(ctx context.Context, format string, r io.Reader, dryRun bool, userID int) (*models.CatalogImportReport, error) {
+	rows, err := spreadsheet.Read(format, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, ErrEmptyImport
+	}
+
+	columns, err := parseCatalogHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CatalogImportReport{DryRun: dryRun, Rows: []models.CatalogImportRow{}}
+	parsed := make([]importRow, 0, len(rows)-1)
+	for i, cells := range rows[1:] {
+		values := rowValues(columns, cells)
+		if len(values) == 0 {
+			continue
+		}
+		row := s.planRow(i+2, values, index)
+		parsed = append(parsed, row)
+
+		report.Total++
+		switch row.report.Action {
+		case models.ImportActionCreate:
+			report.Created++
+		case models.ImportActionUpdate:
+			report.Updated++
+		case models.ImportActionUnchanged:
+			report.Unchanged++
+		case models.ImportActionError:
+			report.Failed++
+		}
+	}
+	if report.Total == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	if dryRun || report.Failed > 0 {
+		for _, row := range parsed {
+			report.Rows = append(report.Rows, *row.report)
+		}
+		if report.Failed > 0 && !dryRun {
+			return report, ErrImportHasErrors
+		}
+		return report, nil
+	}
+
+	change := adminPriceChange(userID)
+	change.Source = models.PriceSourceImport
+	if err := s.apply(ctx, parsed, change); err != nil {
+		return nil, err
+	}
+	for _, row := range parsed {
+		report.Rows = append(report.Rows, *row.report)
+	}
+	report.Applied = true
+
+	tracing.Logger(ctx, s.logger).Info("catalog imported",
+		zap.Int("created", report.Created),
+		zap.Int("updated", report.Updated),
+		zap.Int("unchanged", report.Unchanged),
+	)
+	return report, nil
+}
+
+// Export выгружает каталог в том же формате, который принимает Import
+func (s *CatalogService) Export(ctx context.Context, format string, w io.Writer) error {
+	products, err := s.products.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	categoryNames := make(map[int64]string, len(categories))
+	for _, c := range categories {
+		categoryNames[int64(c.ID)] = c.Name
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	header := make([]string, 0, len(catalogColumns))
+	for _, c := range catalogColumns {
+		header = append(header, c.field)
+	}
+	rows := [][]string{header}
+	for _, p := range products {
+		category := ""
+		if p.CategoryID.Valid {
+			category = categoryNames[p.CategoryID.Int64]
+		}
+		rows = append(rows, []string{
+			p.SKU.String,
+			p.Name,
+			p.Description,
+			utils.FormatFloat(p.Price),
+			formatAvailability(p.Availability),
+			category,
+			p.Url.String,
+		})
+	}
+
+	return spreadsheet.Write(format, w, rows)
+}
+
+func (s *CatalogService) loadIndex(ctx context.Context) (*catalogIndex, error) {
+	products, err := s.products.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+
+	index := &catalogIndex{
+		bySKU:         make(map[string]*models.Product, len(products)),
+		byName:        make(map[string]*models.Product, len(products)),
+		categories:    make(map[string]int, len(categories)),
+		categoryNames: make(map[int64]string, len(categories)),
+		claimedSKU:    make(map[string]int),
+		claimedKey:    make(map[string]int),
+		claimedID:     make(map[int]int),
+	}
+	for i := range products {
+		p := &products[i]
+		if p.SKU.Valid && p.SKU.String != "" {
+			index.bySKU[p.SKU.String] = p
+		}
+		index.byName[p.Name] = p
+	}
+	for _, c := range categories {
+		index.categories[strings.ToLower(c.Name)] = c.ID
+		index.categoryNames[int64(c.ID)] = c.Name
+	}
+	return index, nil
+}
+
+// planRow сравнивает строку файла с каталогом и решает, что с ней делать
+func (s *CatalogService) planRow(line int, values map[string]string, index *catalogIndex) importRow {
+	report := &models.CatalogImportRow{Row: line}
+	row := importRow{report: report}
+	fail := func(format string, args ...interface{}) {
+		report.Errors = append(report.Errors, fmt.Sprintf(format, args...))
+	}
+
+	sku, hasSKU := values["sku"]
+	name, hasName := values["name"]
+
+	var existing *models.Product
+	switch {
+	case hasSKU:
+		report.Key = sku
+		existing = index.bySKU[sku]
+		if existing == nil && hasName {
+			// Товар без артикула в базе — находим по названию и присваиваем артикул
+			if p := index.byName[name]; p != nil && !p.SKU.Valid {
+				existing = p
+			}
+		}
+	case hasName:
+		report.Key = name
+		existing = index.byName[name]
+	default:
+		fail("row has neither sku nor name")
+	}
+
+	if report.Key != "" {
+		if prev, ok := index.claimedKey[report.Key]; ok {
+			fail("duplicate of row %d", prev)
+		}
+		index.claimedKey[report.Key] = line
+	}
+
+	var price *float64
+	if v, ok := values["price"]; ok {
+		p, err := parsePrice(v)
+		if err != nil {
+			fail("invalid price %q", v)
+		} else {
+			price = &p
+		}
+	}
+	var availability *bool
+	if v, ok := values["availability"]; ok {
+		a, err := parseAvailability(v)
+		if err != nil {
+			fail("invalid availability %q", v)
+		} else {
+			availability = &a
+		}
+	}
+	var categoryID *int
+	if v, ok := values["category"]; ok {
+		if id, found := index.categories[strings.ToLower(v)]; found {
+			categoryID = &id
+		} else {
+			fail("category %q not found", v)
+		}
+	}
+
+	if hasSKU {
+		if owner := index.bySKU[sku]; owner != nil && owner != existing {
+			fail("sku %q already belongs to product %d", sku, owner.ID)
+		}
+		if prev, ok := index.claimedSKU[sku]; ok && prev != line {
+			fail("sku %q is also used in row %d", sku, prev)
+		}
+		index.claimedSKU[sku] = line
+	}
+	if hasName {
+		if owner := index.byName[name]; owner != nil && owner != existing {
+			fail("name %q already belongs to product %d", name, owner.ID)
+		}
+	}
+
+	if existing == nil {
+		product := &models.Product{Availability: true}
+		if hasSKU {
+			product.SKU = sql.NullString{String: sku, Valid: true}
+		}
+		product.Name = name
+		product.Description = values["description"]
+		if price != nil {
+			product.Price = *price
+		}
+		if availability != nil {
+			product.Availability = *availability
+		}
+		if categoryID != nil {
+			product.CategoryID = sql.NullInt64{Int64: int64(*categoryID), Valid: true}
+		}
+		if url, ok := values["url"]; ok {
+			product.Url = sql.NullString{String: url, Valid: true}
+		}
+		if err := validateProduct(product); err != nil {
+			fail("%s", err.Error())
+		}
+
+		row.create = product
+		report.Action = models.ImportActionCreate
+		if len(report.Errors) > 0 {
+			report.Action = models.ImportActionError
+		}
+		return row
+	}
+
+	id := existing.ID
+	row.id = id
+	report.ProductID = &id
+	if prev, ok := index.claimedID[id]; ok {
+		fail("product %d is already changed by row %d", id, prev)
+	}
+	index.claimedID[id] = line
+
+	change := func(field, old, new string) {
+		report.Changes = append(report.Changes, models.FieldChange{Field: field, Old: old, New: new})
+		row.changed = true
+	}
+	if hasSKU && sku != existing.SKU.String {
+		row.patch.SKU = &sku
+		change("sku", existing.SKU.String, sku)
+	}
+	if hasName && name != existing.Name {
+		row.patch.Name = &name
+		change("name", existing.Name, name)
+	}
+	if v, ok := values["description"]; ok && v != existing.Description {
+		row.patch.Description = &v
+		change("description", existing.Description, v)
+	}
+	if price != nil && math.Abs(*price-existing.Price) >= 0.005 {
+		if *price <= 0 {
+			fail("product price must be positive")
+		}
+		row.patch.Price = price
+		change("price", utils.FormatFloat(existing.Price), utils.FormatFloat(*price))
+	}
+	if availability != nil && *availability != existing.Availability {
+		row.patch.Availability = availability
+		change("availability", formatAvailability(existing.Availability), formatAvailability(*availability))
+	}
+	if categoryID != nil && (!existing.CategoryID.Valid || int(existing.CategoryID.Int64) != *categoryID) {
+		row.patch.CategoryID = categoryID
+		old := ""
+		if existing.CategoryID.Valid {
+			old = index.categoryNames[existing.CategoryID.Int64]
+		}
+		change("category", old, index.categoryNames[int64(*categoryID)])
+	}
+	if v, ok := values["url"]; ok && v != existing.Url.String {
+		row.patch.Url = &v
+		change("url", existing.Url.String, v)
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		report.Action = models.ImportActionError
+	case row.changed:
+		report.Action = models.ImportActionUpdate
+	default:
+		report.Action = models.ImportActionUnchanged
+	}
+	return row
+}
+
+// apply записывает все изменения в одной транзакции
+func (s *CatalogService) apply(ctx context.Context, rows []importRow, change models.PriceChange) error {
+	tx, err := s.products.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	for _, row := range rows {
+		switch {
+		case row.create != nil:
+			if err := s.products.CreateTx(ctx, tx, row.create); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("row %d: failed to create product: %w", row.report.Row, err)
+			}
+			id := row.create.ID
+			row.report.ProductID = &id
+		case row.changed:
+			row.patch.PriceChange = change
+			if err := s.products.PatchProductTx(ctx, tx, row.id, row.patch); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("row %d: failed to update product: %w", row.report.Row, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// parseCatalogHeader сопоставляет заголовки колонок с полями товара
+func parseCatalogHeader(header []string) (map[string]int, error) {
+	aliases := make(map[string]string)
+	for _, c := range catalogColumns {
+		aliases[c.field] = c.field
+		for _, a := range c.aliases {
+			aliases[a] = c.field
+		}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, title := range header {
+		title = strings.ToLower(strings.TrimSpace(title))
+		if title == "" {
+			continue
+		}
+		field, ok := aliases[title]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownImportColumn, header[i])
+		}
+		columns[field] = i
+	}
+
+	_, hasSKU := columns["sku"]
+	_, hasName := columns["name"]
+	if !hasSKU && !hasName {
+		return nil, ErrImportKeyColumn
+	}
+	return columns, nil
+}
+
+// rowValues возвращает непустые значения строки по полям
+func rowValues(columns map[string]int, cells []string) map[string]string {
+	values := make(map[string]string, len(columns))
+	for field, i := range columns {
+		if i >= len(cells) {
+			continue
+		}
+		if v := strings.TrimSpace(cells[i]); v != "" {
+			values[field] = v
+		}
+	}
+	return values
+}
+
+// parsePrice понимает "1 299,50" и "1299.50"; NaN и бесконечность ценой не считаются
+func parsePrice(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(v)
+	price, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0, fmt.Errorf("invalid price: %s", v)
+	}
+	return price, nil
+}
+
+func parseAvailability(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "1", "true", "yes", "да", "+", "есть":
+		return true, nil
+	case "0", "false", "no", "нет", "-":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid availability: %s", v)
+}
+
+func formatAvailability(v bool) string {
+	if v {
+		return "да"
+	}
+	return "нет"
+}
@@ -3,18 +3,38 @@ package services
 import (
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"strings"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved into itself or its subcategory")
+	ErrCategoryHasProducts    = errors.New("category still has products; pass reassign_to to move them")
+	ErrInvalidReassignTarget  = errors.New("products cannot be reassigned to the deleted category")
+	ErrInvalidCategorySlug    = errors.New("slug may contain only latin letters, digits and dashes")
+	ErrCategorySlugTaken      = errors.New("slug is already used by another category")
 )
 
 type CategoryServiceInterface interface {
-	GetAll() ([]models.Category, error)
-	Create(name string, sortOrder int) (*models.Category, error)
-	Update(id int, name string, sortOrder int) error
-	Delete(id int) error
-	CreateBulk(categories []utils.CategoryRequest) ([]models.Category, error)
-	PartialUpdate(id int, name *string, sortOrder *int) (*models.Category, error)
+	GetAll(ctx context.Context) ([]models.Category, error)
+	GetTree(ctx context.Context) ([]models.CategoryNode, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+	Create(ctx context.Context, req utils.CategoryRequest) (*models.Category, error)
+	Update(ctx context.Context, id int, name string, sortOrder int) error
+	Delete(ctx context.Context, id int, reassignTo *int) error
+	CreateBulk(ctx context.Context, categories []utils.CategoryRequest) ([]models.Category, error)
+	PartialUpdate(ctx context.Context, id int, req models.CategoryUpdateRequest) (*models.Category, error)
+	Move(ctx context.Context, id int, req models.CategoryMoveRequest) (*models.Category, error)
+	GetByExternalID(ctx context.Context, externalID string) (*models.Category, error)
+	SyncExternal(ctx context.Context, externalID, name string, parentID *int) (*models.Category, error)
 }
 
 type CategoryService struct {
@@ -26,31 +46,90 @@ func NewCategoryService(repo repositories.CategoryRepository, logger *zap.Logger
 	return &CategoryService{repo: repo, logger: logger}
 }
 
-func (s *CategoryService) GetAll() ([]models.Category, error) {
-	return s.repo.GetAll()
+func (s *CategoryService) GetAll(ctx context.Context) ([]models.Category, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *CategoryService) Create(name string, sortOrder int) (*models.Category, error) {
-	category := &models.Category{
-		Name:      name,
-		SortOrder: sortOrder,
+// GetTree собирает дерево категорий; на каждом уровне порядок — по sort_order
+func (s *CategoryService) GetTree(ctx context.Context) ([]models.CategoryNode, error) {
+	categories, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Category, len(categories))
+	known := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	roots := make([]models.Category, 0)
+	for _, c := range categories {
+		if c.ParentID == nil || !known[*c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(level []models.Category) []models.CategoryNode
+	build = func(level []models.Category) []models.CategoryNode {
+		nodes := make([]models.CategoryNode, 0, len(level))
+		for _, c := range level {
+			nodes = append(nodes, models.CategoryNode{Category: c, Children: build(children[c.ID])})
+		}
+		return nodes
 	}
-	if err := s.repo.Create(category); err != nil {
+	return build(roots), nil
+}
+
+func (s *CategoryService) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	category, err := s.repo.GetBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+func (s *CategoryService) Create(ctx context.Context, req utils.CategoryRequest) (*models.Category, error) {
+	category, err := s.newCategory(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, category); err != nil {
 		return nil, err
 	}
 	return category, nil
 }
 
-func (s *CategoryService) Update(id int, name string, sortOrder int) error {
-	return s.repo.Update(id, name, sortOrder)
+func (s *CategoryService) Update(ctx context.Context, id int, name string, sortOrder int) error {
+	return s.repo.Update(ctx, id, name, sortOrder)
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+// Delete удаляет категорию. Если в ней есть товары, удаление возможно только
+// с переносом товаров в другую категорию (reassignTo). Подкатегории переходят к родителю.
+func (s *CategoryService) Delete(ctx context.Context, id int, reassignTo *int) error {
+	if reassignTo != nil && *reassignTo == id {
+		return ErrInvalidReassignTarget
+	}
+
+	// Проверки и удаление идут в одной транзакции репозитория; здесь только выясняется, какая не прошла
+	deleted, err := s.repo.Delete(ctx, id, reassignTo)
+	if err != nil || deleted {
+		return err
+	}
+	if _, err := s.getByID(ctx, id); err != nil {
+		return err
+	}
+	if reassignTo != nil {
+		if _, err := s.getByID(ctx, *reassignTo); err != nil {
+			return err
+		}
+	}
+	return ErrCategoryHasProducts
 }
 
-func (s *CategoryService) CreateBulk(categories []utils.CategoryRequest) ([]models.Category, error) {
-	tx, err := s.repo.BeginTx()
+func (s *CategoryService) CreateBulk(ctx context.Context, categories []utils.CategoryRequest) ([]models.Category, error) {
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
@@ -68,25 +147,34 @@ func (s *CategoryService) CreateBulk(categories []utils.CategoryRequest) ([]mode
 		}
 	}()
 
+	// Slug'и, выданные в этой транзакции, ещё не видны в базе
+	usedSlugs := make(map[string]bool)
+
 	for _, cat := range categories {
 		if cat.Name == "" {
 			txErr = fmt.Errorf("category name cannot be empty")
 			return nil, txErr
 		}
 
-		existing, err := s.repo.GetByNameTx(tx, cat.Name)
+		existing, err := s.repo.GetByNameTx(ctx, tx, cat.Name)
 		if err == nil && existing != nil {
-			s.logger.Info("category already exists, skipping", zap.String("name", cat.Name))
+			tracing.Logger(ctx, s.logger).Info("category already exists, skipping", zap.String("name", cat.Name))
 			continue
 		}
 
-		newCat, err := s.repo.CreateReturningTx(tx, cat.Name, cat.SortOrder)
+		newCat, err := s.newCategory(ctx, cat, usedSlugs)
 		if err != nil {
+			txErr = fmt.Errorf("invalid category '%s': %w", cat.Name, err)
+			return nil, txErr
+		}
+		usedSlugs[newCat.Slug] = true
+
+		if err := s.repo.CreateReturningTx(ctx, tx, newCat); err != nil {
 			txErr = fmt.Errorf("failed to create category '%s': %w", cat.Name, err)
 			return nil, txErr
 		}
 
-		s.logger.Info("category created", zap.String("name", newCat.Name), zap.Int("id", newCat.ID))
+		tracing.Logger(ctx, s.logger).Info("category created", zap.String("name", newCat.Name), zap.Int("id", newCat.ID))
 		created = append(created, *newCat)
 	}
 
@@ -97,16 +185,183 @@ func (s *CategoryService) CreateBulk(categories []utils.CategoryRequest) ([]mode
 	return created, nil
 }
 
-func (s *CategoryService) PartialUpdate(id int, name *string, sortOrder *int) (*models.Category, error) {
-	err := s.repo.PartialUpdate(id, name, sortOrder)
+func (s *CategoryService) PartialUpdate(ctx context.Context, id int, req models.CategoryUpdateRequest) (*models.Category, error) {
+	if _, err := s.getByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if req.Slug != nil {
+		slug, err := s.checkSlug(ctx, *req.Slug, id)
+		if err != nil {
+			return nil, err
+		}
+		req.Slug = &slug
+	}
+
+	if err := s.repo.PartialUpdate(ctx, id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Move переносит категорию под другого родителя (или в корень).
+// Перенос в саму себя или в собственную подкатегорию запрещён — это создало бы цикл.
+func (s *CategoryService) Move(ctx context.Context, id int, req models.CategoryMoveRequest) (*models.Category, error) {
+	// Проверка на цикл и запись идут в одной транзакции репозитория; здесь только выясняется, какая не прошла
+	moved, err := s.repo.Move(ctx, id, req.ParentID, req.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, s.moveError(ctx, id, req.ParentID)
+	}
+
+	tracing.Logger(ctx, s.logger).Info("category moved", zap.Int("id", id), zap.Any("parent_id", req.ParentID))
+	return s.repo.GetByID(ctx, id)
+}
+
+// moveError объясняет, почему перенос не выполнен. Без родителя перенос отклоняется только
+// для отсутствующей категории, поэтому parentID здесь всегда задан, если категория есть.
+func (s *CategoryService) moveError(ctx context.Context, id int, parentID *int) error {
+	if _, err := s.getByID(ctx, id); err != nil {
+		return err
+	}
+	if parentID == nil {
+		return ErrCategoryNotFound
+	}
+	if _, err := s.getByID(ctx, *parentID); errors.Is(err, ErrCategoryNotFound) {
+		return ErrParentCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+	return ErrCategoryCycle
+}
+
+func (s *CategoryService) GetByExternalID(ctx context.Context, externalID string) (*models.Category, error) {
+	category, err := s.repo.GetByExternalID(ctx, externalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// SyncExternal создаёт или обновляет категорию по группе из учётной системы.
+// Категория ищется по внешнему идентификатору, затем по названию.
+func (s *CategoryService) SyncExternal(ctx context.Context, externalID, name string, parentID *int) (*models.Category, error) {
+	category, err := s.repo.GetByExternalID(ctx, externalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		category, err = s.repo.GetByName(ctx, name)
+		if err == nil {
+			err = s.repo.SetExternalID(ctx, category.ID, externalID)
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		category, err = s.newCategory(ctx, utils.CategoryRequest{Name: name, ParentID: parentID}, nil)
+		if err != nil {
+			return nil, err
+		}
+		category.ExternalID = &externalID
+		if err := s.repo.Create(ctx, category); err != nil {
+			return nil, err
+		}
+		return category, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	updated, err := s.repo.GetByID(id)
+	if name != "" && name != category.Name {
+		if err := s.repo.PartialUpdate(ctx, category.ID, models.CategoryUpdateRequest{Name: &name}); err != nil {
+			return nil, err
+		}
+		category.Name = name
+	}
+
+	sameParent := (parentID == nil && category.ParentID == nil) ||
+		(parentID != nil && category.ParentID != nil && *parentID == *category.ParentID)
+	if !sameParent {
+		moved, err := s.Move(ctx, category.ID, models.CategoryMoveRequest{ParentID: parentID})
+		if err != nil {
+			return nil, err
+		}
+		category = moved
+	}
+	return category, nil
+}
+
+// newCategory проверяет запрос и подбирает свободный slug; reserved — slug'и, уже занятые,
+// но ещё не сохранённые в базе (при массовом создании)
+func (s *CategoryService) newCategory(ctx context.Context, req utils.CategoryRequest, reserved map[string]bool) (*models.Category, error) {
+	if req.ParentID != nil {
+		if _, err := s.getByID(ctx, *req.ParentID); errors.Is(err, ErrCategoryNotFound) {
+			return nil, ErrParentCategoryNotFound
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	var slug string
+	var err error
+	if req.Slug == "" {
+		slug, err = s.uniqueSlug(ctx, req.Name, reserved)
+	} else if slug, err = s.checkSlug(ctx, req.Slug, 0); err == nil && reserved[slug] {
+		err = ErrCategorySlugTaken
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return updated, nil
+	return &models.Category{
+		Name:        req.Name,
+		SortOrder:   req.SortOrder,
+		ParentID:    req.ParentID,
+		Slug:        slug,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+	}, nil
+}
+
+// uniqueSlug строит slug из названия и добавляет номер, если такой уже занят
+func (s *CategoryService) uniqueSlug(ctx context.Context, name string, reserved map[string]bool) (string, error) {
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+	slug := base
+	for i := 2; ; i++ {
+		taken := reserved[slug]
+		if !taken {
+			var err error
+			if taken, err = s.repo.SlugExists(ctx, slug, 0); err != nil {
+				return "", err
+			}
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (s *CategoryService) checkSlug(ctx context.Context, slug string, categoryID int) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" || utils.Slugify(slug) != slug {
+		return "", ErrInvalidCategorySlug
+	}
+	taken, err := s.repo.SlugExists(ctx, slug, categoryID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", ErrCategorySlugTaken
+	}
+	return slug, nil
+}
+
+func (s *CategoryService) getByID(ctx context.Context, id int) (*models.Category, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
 }
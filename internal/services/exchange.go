@@ -0,0 +1,376 @@
+package services
+
+import (
+	"bytes"
+	"chechnya-product/config"
+	"chechnya-product/internal/commerceml"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// exchangeSessionTTL — сколько живёт сессия обмена после checkauth
+const exchangeSessionTTL = 30 * time.Minute
+
+var (
+	ErrExchangeDisabled     = errors.New("1C exchange is not configured")
+	ErrExchangeUnauthorized = errors.New("invalid exchange credentials")
+	ErrExchangeFileName     = errors.New("invalid exchange file name")
+	ErrExchangeFileTooLarge = errors.New("exchange file is too large")
+	ErrExchangeNoPending    = errors.New("no orders are waiting for confirmation")
+)
+
+type ExchangeServiceInterface interface {
+	Enabled() bool
+	CheckAuth(ctx context.Context, login, password string) (string, error)
+	ValidSession(ctx context.Context, token string) (bool, error)
+	FileLimit() int64
+	Init(ctx context.Context, kind string) error
+	SaveFile(ctx context.Context, name string, r io.Reader) error
+	ImportFile(ctx context.Context, name string) (*models.ExchangeImportResult, error)
+	QueryOrders(ctx context.Context, token string) ([]byte, error)
+	ConfirmOrders(ctx context.Context, token string) error
+}
+
+// ExchangeSessionStore хранит сессии обмена и заказы, отданные сессии в последнем sale/query
+// и ещё не подтверждённые 1С. Реализуется cache.RedisCache: запросы одного сеанса обмена
+// могут прийти на разные экземпляры API.
+type ExchangeSessionStore interface {
+	CreateExchangeSession(ctx context.Context, token string, ttl time.Duration) error
+	// TouchExchangeSession продлевает сессию; false — сессии нет или она истекла
+	TouchExchangeSession(ctx context.Context, token string, ttl time.Duration) (bool, error)
+	SetExchangePending(ctx context.Context, token string, orderIDs []int, ttl time.Duration) error
+	// TakeExchangePending возвращает и удаляет заказы сессии; nil — выгрузки не было
+	TakeExchangePending(ctx context.Context, token string) ([]int, error)
+}
+
+// ExchangeService реализует обмен с 1С по протоколу CommerceML:
+// загрузку каталога и предложений и выгрузку заказов
+type ExchangeService struct {
+	products   ProductServiceInterface
+	categories CategoryServiceInterface
+	orders     OrderServiceInterface
+	files      repositories.ExchangeFileRepository
+	sessions   ExchangeSessionStore
+	login      string
+	password   string
+	fileLimit  int64
+	priceType  string
+	logger     *zap.Logger
+}
+
+func NewExchangeService(products ProductServiceInterface, categories CategoryServiceInterface, orders OrderServiceInterface, files repositories.ExchangeFileRepository, sessions ExchangeSessionStore, cfg *config.Config, logger *zap.Logger) *ExchangeService {
+	return &ExchangeService{
+		products:   products,
+		categories: categories,
+		orders:     orders,
+		files:      files,
+		sessions:   sessions,
+		login:      cfg.Exchange1CLogin,
+		password:   cfg.Exchange1CPassword,
+		fileLimit:  int64(cfg.Exchange1CFileLimitMB) << 20,
+		priceType:  cfg.Exchange1CPriceType,
+		logger:     logger,
+	}
+}
+
+// Enabled — обмен включён, только если заданы логин и пароль
+func (s *ExchangeService) Enabled() bool {
+	return s.login != "" && s.password != ""
+}
+
+func (s *ExchangeService) FileLimit() int64 {
+	return s.fileLimit
+}
+
+// CheckAuth проверяет учётные данные 1С и открывает сессию обмена
+func (s *ExchangeService) CheckAuth(ctx context.Context, login, password string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrExchangeDisabled
+	}
+	loginOK := subtle.ConstantTimeCompare([]byte(login), []byte(s.login)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.password)) == 1
+	if !loginOK || !passwordOK {
+		return "", ErrExchangeUnauthorized
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate exchange session: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.sessions.CreateExchangeSession(ctx, token, exchangeSessionTTL); err != nil {
+		return "", fmt.Errorf("failed to save exchange session: %w", err)
+	}
+	return token, nil
+}
+
+// ValidSession проверяет сессию и продлевает её
+func (s *ExchangeService) ValidSession(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	ok, err := s.sessions.TouchExchangeSession(ctx, token, exchangeSessionTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to check exchange session: %w", err)
+	}
+	return ok, nil
+}
+
+// Init готовит новый сеанс обмена. Перед загрузкой каталога удаляются файлы прошлого сеанса.
+func (s *ExchangeService) Init(ctx context.Context, kind string) error {
+	if kind != "catalog" {
+		return nil
+	}
+	if err := s.files.Clear(ctx); err != nil {
+		return fmt.Errorf("failed to clean exchange files: %w", err)
+	}
+	return nil
+}
+
+// exchangeFileName приводит имя файла обмена к виду "import_files/ab/img.jpg", отклоняя выход за пределы обмена
+func exchangeFileName(name string) (string, error) {
+	name = strings.ReplaceAll(strings.TrimSpace(name), `\`, "/")
+	clean := path.Clean("/" + name)
+	if name == "" || clean == "/" || strings.Contains(name, "..") {
+		return "", ErrExchangeFileName
+	}
+	return strings.TrimPrefix(clean, "/"), nil
+}
+
+// SaveFile дописывает очередную часть файла: 1С может присылать большой файл несколькими запросами.
+// Лимит считается по всему файлу, а не по части; часть, которая его превышает, не сохраняется.
+func (s *ExchangeService) SaveFile(ctx context.Context, name string, r io.Reader) error {
+	name, err := exchangeFileName(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.fileLimit+1))
+	if err != nil {
+		return fmt.Errorf("failed to read exchange file: %w", err)
+	}
+	if int64(len(data)) > s.fileLimit {
+		return ErrExchangeFileTooLarge
+	}
+	saved, err := s.files.AppendPart(ctx, name, data, s.fileLimit)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrExchangeFileTooLarge
+	}
+	return nil
+}
+
+// ImportFile загружает ранее переданный файл: import*.xml (группы и товары) или offers*.xml (цены и остатки)
+func (s *ExchangeService) ImportFile(ctx context.Context, name string) (*models.ExchangeImportResult, error) {
+	name, err := exchangeFileName(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.files.ReadFile(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s not uploaded", ErrExchangeFileName, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange file: %w", err)
+	}
+
+	doc, err := commerceml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ExchangeImportResult{File: name}
+	groups := make(map[string]int)
+	if doc.Classifier != nil {
+		if err := s.importGroups(ctx, doc.Classifier.Groups, nil, groups, result); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Catalog != nil {
+		if err := s.importProducts(ctx, doc.Catalog.Products, groups, result); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Offers != nil {
+		if err := s.importOffers(ctx, doc.Offers.Offers, result); err != nil {
+			return nil, err
+		}
+	}
+
+	tracing.Logger(ctx, s.logger).Info("1C exchange file imported",
+		zap.String("file", name),
+		zap.Int("categories", result.Categories),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("offers", result.Offers),
+		zap.Int("skipped", result.Skipped),
+	)
+	return result, nil
+}
+
+func (s *ExchangeService) importGroups(ctx context.Context, list []commerceml.Group, parentID *int, ids map[string]int, result *models.ExchangeImportResult) error {
+	for _, g := range list {
+		if g.ID == "" || strings.TrimSpace(g.Name) == "" {
+			result.Skipped++
+			continue
+		}
+		category, err := s.categories.SyncExternal(ctx, g.ID, strings.TrimSpace(g.Name), parentID)
+		if err != nil {
+			return fmt.Errorf("group %s: %w", g.ID, err)
+		}
+		ids[g.ID] = category.ID
+		result.Categories++
+
+		id := category.ID
+		if err := s.importGroups(ctx, g.Groups, &id, ids, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExchangeService) importProducts(ctx context.Context, list []commerceml.Product, groups map[string]int, result *models.ExchangeImportResult) error {
+	for _, p := range list {
+		if p.ID == "" || strings.TrimSpace(p.Name) == "" {
+			result.Skipped++
+			continue
+		}
+		item := models.ExternalProduct{
+			ExternalID:  p.ID,
+			SKU:         strings.TrimSpace(p.SKU),
+			Name:        strings.TrimSpace(p.Name),
+			Description: strings.TrimSpace(p.Description),
+			Deleted:     p.Deleted(),
+		}
+		if len(p.GroupIDs) > 0 {
+			categoryID, err := s.groupCategory(ctx, p.GroupIDs[0], groups)
+			if err != nil {
+				return fmt.Errorf("product %s: %w", p.ID, err)
+			}
+			item.CategoryID = categoryID
+		}
+
+		product, created, err := s.products.SyncExternal(ctx, item)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", p.ID, err)
+		}
+		switch {
+		case product == nil:
+			result.Skipped++
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+	return nil
+}
+
+// groupCategory находит категорию группы: среди загруженных в этом файле или ранее
+func (s *ExchangeService) groupCategory(ctx context.Context, groupID string, groups map[string]int) (*int, error) {
+	if id, ok := groups[groupID]; ok {
+		return &id, nil
+	}
+	category, err := s.categories.GetByExternalID(ctx, groupID)
+	if errors.Is(err, ErrCategoryNotFound) {
+		tracing.Logger(ctx, s.logger).Warn("1C group not found, product left without category", zap.String("group", groupID))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	groups[groupID] = category.ID
+	return &category.ID, nil
+}
+
+func (s *ExchangeService) importOffers(ctx context.Context, list []commerceml.Offer, result *models.ExchangeImportResult) error {
+	for _, o := range list {
+		price, err := o.PriceValue(s.priceType)
+		if err != nil {
+			return err
+		}
+		quantity, err := o.QuantityValue()
+		if err != nil {
+			return err
+		}
+
+		found, err := s.products.UpdateOffer(ctx, o.ProductID(), strings.TrimSpace(o.SKU), price, quantity)
+		if err != nil {
+			return fmt.Errorf("offer %s: %w", o.ID, err)
+		}
+		if !found {
+			tracing.Logger(ctx, s.logger).Warn("1C offer for unknown product", zap.String("offer", o.ID))
+			result.Skipped++
+			continue
+		}
+		result.Offers++
+	}
+	return nil
+}
+
+// QueryOrders формирует выгрузку новых заказов. Заказы считаются выгруженными
+// только после подтверждения (mode=success), поэтому повторный запрос отдаст их снова.
+func (s *ExchangeService) QueryOrders(ctx context.Context, token string) ([]byte, error) {
+	orders, err := s.orders.GetNotExported(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := make([]int, 0, len(orders))
+	productIDs := make([]int, 0)
+	for _, o := range orders {
+		orderIDs = append(orderIDs, o.ID)
+		for _, item := range o.Items {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	externalIDs, err := s.products.GetExternalIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	data, err := commerceml.BuildOrders(orders, externalIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessions.SetExchangePending(ctx, token, orderIDs, exchangeSessionTTL); err != nil {
+		return nil, fmt.Errorf("failed to save exported orders: %w", err)
+	}
+	return data, nil
+}
+
+// ConfirmOrders отмечает заказы из последней выгрузки сессии как переданные в 1С
+func (s *ExchangeService) ConfirmOrders(ctx context.Context, token string) error {
+	pending, err := s.sessions.TakeExchangePending(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to load exported orders: %w", err)
+	}
+	if pending == nil {
+		return ErrExchangeNoPending
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := s.orders.MarkExported(ctx, pending); err != nil {
+		return err
+	}
+	tracing.Logger(ctx, s.logger).Info("orders exported to 1C", zap.Ints("order_ids", pending))
+	return nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memExchangeFiles — части файлов обмена, как в ExchangeFileRepo
+type memExchangeFiles struct {
+	mu    sync.Mutex
+	parts map[string][][]byte
+}
+
+func (f *memExchangeFiles) AppendPart(_ context.Context, name string, data []byte, limit int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.size(name)+int64(len(data)) > limit {
+		return false, nil
+	}
+	f.parts[name] = append(f.parts[name], data)
+	return true, nil
+}
+
+func (f *memExchangeFiles) ReadFile(_ context.Context, name string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.parts[name]) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	var data []byte
+	for _, part := range f.parts[name] {
+		data = append(data, part...)
+	}
+	return data, nil
+}
+
+func (f *memExchangeFiles) Clear(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.parts = map[string][][]byte{}
+	return nil
+}
+
+func (f *memExchangeFiles) size(name string) int64 {
+	var size int64
+	for _, part := range f.parts[name] {
+		size += int64(len(part))
+	}
+	return size
+}
+
+// memExchangeSessions — сессии обмена, как в Redis; TTL не моделируется
+type memExchangeSessions struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	pending  map[string][]int
+}
+
+func (s *memExchangeSessions) CreateExchangeSession(_ context.Context, token string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[token] = true
+	return nil
+}
+
+func (s *memExchangeSessions) TouchExchangeSession(_ context.Context, token string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[token], nil
+}
+
+func (s *memExchangeSessions) SetExchangePending(_ context.Context, token string, orderIDs []int, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[token] = orderIDs
+	return nil
+}
+
+func (s *memExchangeSessions) TakeExchangePending(_ context.Context, token string) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orderIDs := s.pending[token]
+	delete(s.pending, token)
+	return orderIDs, nil
+}
+
+// exchangeOrders — заказы, ожидающие выгрузки в 1С
+type exchangeOrders struct {
+	OrderServiceInterface
+
+	orders   []models.Order
+	exported []int
+}
+
+func (o *exchangeOrders) GetNotExported(context.Context) ([]models.Order, error) {
+	return o.orders, nil
+}
+
+func (o *exchangeOrders) MarkExported(_ context.Context, orderIDs []int) error {
+	o.exported = append(o.exported, orderIDs...)
+	return nil
+}
+
+type exchangeProducts struct {
+	ProductServiceInterface
+}
+
+func (exchangeProducts) GetExternalIDs(context.Context, []int) (map[int]string, error) {
+	return map[int]string{}, nil
+}
+
+func newExchangeService(files *memExchangeFiles, orders OrderServiceInterface, limitMB int) *ExchangeService {
+	sessions := &memExchangeSessions{sessions: map[string]bool{}, pending: map[string][]int{}}
+	cfg := &config.Config{Exchange1CLogin: "1c", Exchange1CPassword: "secret", Exchange1CFileLimitMB: limitMB}
+	return NewExchangeService(exchangeProducts{}, nil, orders, files, sessions, cfg, zap.NewNop())
+}
+
+func TestExchangeSaveFileLimitsTotalSize(t *testing.T) {
+	files := &memExchangeFiles{parts: map[string][][]byte{}}
+	service := newExchangeService(files, nil, 1)
+	ctx := context.Background()
+	chunk := strings.Repeat("x", 400<<10)
+
+	// Две части по 400 КБ укладываются в лимит 1 МБ
+	for i := 0; i < 2; i++ {
+		if err := service.SaveFile(ctx, "import.xml", strings.NewReader(chunk)); err != nil {
+			t.Fatalf("chunk %d: %v", i+1, err)
+		}
+	}
+
+	// Третья часть сама по себе меньше лимита, но вместе с уже загруженными его превышает
+	if err := service.SaveFile(ctx, "import.xml", strings.NewReader(chunk)); !errors.Is(err, ErrExchangeFileTooLarge) {
+		t.Fatalf("chunk 3: err = %v, want ErrExchangeFileTooLarge", err)
+	}
+	if size := files.size("import.xml"); size != 2*int64(len(chunk)) {
+		t.Fatalf("file size = %d, want %d: rejected chunk must not be kept", size, 2*len(chunk))
+	}
+
+	// Остаток до лимита дописать можно
+	rest := strings.Repeat("x", 1<<20-2*len(chunk))
+	if err := service.SaveFile(ctx, "import.xml", strings.NewReader(rest)); err != nil {
+		t.Fatalf("last chunk: %v", err)
+	}
+	if err := service.SaveFile(ctx, "import.xml", strings.NewReader("x")); !errors.Is(err, ErrExchangeFileTooLarge) {
+		t.Fatalf("byte over limit: err = %v, want ErrExchangeFileTooLarge", err)
+	}
+}
+
+func TestExchangeFileNames(t *testing.T) {
+	files := &memExchangeFiles{parts: map[string][][]byte{}}
+	service := newExchangeService(files, nil, 1)
+	ctx := context.Background()
+
+	for _, name := range []string{"", "/", "../import.xml", "import_files/../../etc/passwd"} {
+		if err := service.SaveFile(ctx, name, strings.NewReader("x")); !errors.Is(err, ErrExchangeFileName) {
+			t.Fatalf("%q: err = %v, want ErrExchangeFileName", name, err)
+		}
+	}
+
+	// Вложенные пути 1С (картинки товаров) сохраняются под нормализованным именем
+	if err := service.SaveFile(ctx, `\import_files\ab\img.jpg`, strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files.parts["import_files/ab/img.jpg"]; !ok {
+		t.Fatalf("stored names = %v", files.parts)
+	}
+
+	if _, err := service.ImportFile(ctx, "offers.xml"); !errors.Is(err, ErrExchangeFileName) {
+		t.Fatalf("import of missing file: err = %v, want ErrExchangeFileName", err)
+	}
+}
+
+func TestExchangeSessionsAndOrderConfirmation(t *testing.T) {
+	orders := &exchangeOrders{orders: []models.Order{{ID: 1}, {ID: 2}}}
+	service := newExchangeService(&memExchangeFiles{parts: map[string][][]byte{}}, orders, 1)
+	ctx := context.Background()
+
+	if _, err := service.CheckAuth(ctx, "1c", "wrong"); !errors.Is(err, ErrExchangeUnauthorized) {
+		t.Fatalf("wrong password: err = %v", err)
+	}
+	token, err := service.CheckAuth(ctx, "1c", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for candidate, want := range map[string]bool{token: true, "": false, "unknown": false} {
+		if ok, err := service.ValidSession(ctx, candidate); err != nil || ok != want {
+			t.Fatalf("ValidSession(%q) = %v, %v; want %v", candidate, ok, err, want)
+		}
+	}
+
+	// Подтверждение без выгрузки — ошибка протокола
+	if err := service.ConfirmOrders(ctx, token); !errors.Is(err, ErrExchangeNoPending) {
+		t.Fatalf("confirm before query: err = %v", err)
+	}
+	if _, err := service.QueryOrders(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ConfirmOrders(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders.exported) != 2 {
+		t.Fatalf("exported = %v, want orders 1 and 2", orders.exported)
+	}
+	// Повторное подтверждение не отмечает заказы ещё раз
+	if err := service.ConfirmOrders(ctx, token); !errors.Is(err, ErrExchangeNoPending) {
+		t.Fatalf("second confirm: err = %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/storage"
+	"chechnya-product/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrFileInUse = errors.New("file is still used by products")
+
+type FileServiceInterface interface {
+	Store(ctx context.Context, data []byte, ext, contentType string) (string, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, *storage.Object, error)
+	List(ctx context.Context) ([]models.UploadedFile, error)
+	Delete(ctx context.Context, key string) error
+	DeleteIfUnreferenced(ctx context.Context, key string)
+	CleanupOrphans(ctx context.Context, dryRun bool) ([]string, error)
+	RunOrphanCleanup(ctx context.Context, interval time.Duration)
+	URL(key string) string
+	Driver() string
+}
+
+type FileService struct {
+	storage     storage.Storage
+	repo        repositories.FileRepository
+	gracePeriod time.Duration
+	logger      *zap.Logger
+}
+
+func NewFileService(storage storage.Storage, repo repositories.FileRepository, gracePeriod time.Duration, logger *zap.Logger) *FileService {
+	return &FileService{storage: storage, repo: repo, gracePeriod: gracePeriod, logger: logger}
+}
+
+// Store сохраняет файл под именем, вычисленным из содержимого.
+// Если такой файл уже есть, повторно он не загружается, но его время обновляется:
+// иначе очистка сирот удалила бы старый непривязанный файл раньше, чем его привяжут.
+func (s *FileService) Store(ctx context.Context, data []byte, ext, contentType string) (string, error) {
+	key := storage.ContentKey(data, ext)
+
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check file: %w", err)
+	}
+	if exists {
+		err := s.storage.Touch(ctx, key)
+		if err == nil {
+			tracing.Logger(ctx, s.logger).Debug("file already stored, skipping upload", zap.String("key", key))
+			return key, nil
+		}
+		// Файл могли удалить между проверкой и обновлением — тогда загружаем заново
+		if !errors.Is(err, storage.ErrNotFound) {
+			return "", fmt.Errorf("failed to refresh file: %w", err)
+		}
+	}
+
+	if err := s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *FileService) Open(ctx context.Context, key string) (io.ReadCloser, *storage.Object, error) {
+	return s.storage.Get(ctx, key)
+}
+
+func (s *FileService) List(ctx context.Context) ([]models.UploadedFile, error) {
+	objects, err := s.storage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced, err := s.repo.GetReferencedKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file references: %w", err)
+	}
+
+	result := make([]models.UploadedFile, 0, len(objects))
+	for _, obj := range objects {
+		result = append(result, models.UploadedFile{
+			Name:       obj.Key,
+			URL:        s.storage.URL(obj.Key),
+			Size:       obj.Size,
+			Time:       obj.ModTime.Format(time.RFC3339),
+			Referenced: referenced[obj.Key],
+		})
+	}
+	return result, nil
+}
+
+// Delete удаляет файл, если на него не ссылается ни один товар
+func (s *FileService) Delete(ctx context.Context, key string) error {
+	used, err := s.repo.IsReferenced(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to check file references: %w", err)
+	}
+	if used {
+		return ErrFileInUse
+	}
+	return s.storage.Delete(ctx, key)
+}
+
+// DeleteIfUnreferenced тихо удаляет файл, если он больше нигде не используется
+// (одинаковые изображения хранятся одним файлом и могут принадлежать разным товарам)
+func (s *FileService) DeleteIfUnreferenced(ctx context.Context, key string) {
+	if key == "" || strings.Contains(key, "://") {
+		return
+	}
+	err := s.Delete(ctx, key)
+	if err != nil && !errors.Is(err, ErrFileInUse) && !errors.Is(err, storage.ErrNotFound) {
+		tracing.Logger(ctx, s.logger).Warn("failed to remove file", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// CleanupOrphans удаляет файлы, на которые никто не ссылается.
+// Недавние файлы не трогаем: их могли только что загрузить и ещё не привязать к товару.
+func (s *FileService) CleanupOrphans(ctx context.Context, dryRun bool) ([]string, error) {
+	objects, err := s.storage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced, err := s.repo.GetReferencedKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file references: %w", err)
+	}
+
+	threshold := time.Now().Add(-s.gracePeriod)
+	orphans := make([]string, 0)
+	for _, obj := range objects {
+		if referenced[obj.Key] || obj.ModTime.After(threshold) {
+			continue
+		}
+		if !dryRun {
+			// Список мог устареть, пока шла проверка: файл успели привязать или загрузить повторно
+			if s.usedSince(ctx, obj.Key, threshold) {
+				continue
+			}
+			if err := s.storage.Delete(ctx, obj.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				tracing.Logger(ctx, s.logger).Warn("failed to remove orphan file", zap.String("key", obj.Key), zap.Error(err))
+				continue
+			}
+		}
+		orphans = append(orphans, obj.Key)
+	}
+
+	tracing.Logger(ctx, s.logger).Info("orphan files cleanup finished",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("checked", len(objects)),
+		zap.Int("orphans", len(orphans)),
+	)
+	return orphans, nil
+}
+
+// usedSince перепроверяет кандидата на удаление перед самым удалением: есть ли на файл ссылка
+// и не обновлялся ли он после threshold. При ошибке проверки файл не удаляется.
+func (s *FileService) usedSince(ctx context.Context, key string, threshold time.Time) bool {
+	referenced, err := s.repo.IsReferenced(ctx, key)
+	if err != nil || referenced {
+		return true
+	}
+	r, obj, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return !errors.Is(err, storage.ErrNotFound)
+	}
+	r.Close()
+	return obj.ModTime.After(threshold)
+}
+
+// RunOrphanCleanup периодически запускает очистку осиротевших файлов до отмены ctx
+func (s *FileService) RunOrphanCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CleanupOrphans(ctx, false); err != nil {
+				tracing.Logger(ctx, s.logger).Error("orphan files cleanup failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *FileService) URL(key string) string {
+	return s.storage.URL(key)
+}
+
+func (s *FileService) Driver() string {
+	return s.storage.Driver()
+}
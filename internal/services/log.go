@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	applog "chechnya-product/internal/logger"
+	"chechnya-product/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сколько записей журнала отдаётся по умолчанию и максимум на страницу
+const (
+	defaultLogLimit = 100
+	maxLogLimit     = 1000
+)
+
+// logFollowInterval — как часто режим follow проверяет файлы журнала на новые записи
+const logFollowInterval = time.Second
+
+// logLevelKinds — в какой файл журнала попадает уровень; DPANIC, PANIC и FATAL ищутся вместе с error
+var logLevelKinds = map[string]string{
+	"debug": "debug",
+	"info":  "info",
+	"warn":  "info",
+	"error": "error",
+}
+
+var ErrInvalidLogLevel = errors.New("unknown log level: use debug, info, warn or error")
+
+type LogServiceInterface interface {
+	Search(ctx context.Context, filter models.LogFilter) (*models.LogPage, error)
+	Follow(ctx context.Context, filter models.LogFilter, emit func([]models.LogEntry) error) error
+}
+
+// LogService ищет по JSON-файлам журнала, которые пишет логгер приложения
+type LogService struct{}
+
+func NewLogService() *LogService {
+	return &LogService{}
+}
+
+// Search ищет записи от новых к старым. Файлы читаются по дням, начиная с последнего, и чтение
+// останавливается, как только набрана нужная страница. Без from поиск идёт по всем хранимым дням.
+func (s *LogService) Search(ctx context.Context, filter models.LogFilter) (*models.LogPage, error) {
+	m, err := newLogMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.search(ctx, m, logLimit(filter.Limit), max(filter.Offset, 0), nil)
+}
+
+// Follow отдаёт хвост журнала — последние limit подходящих записей — и затем новые записи по мере
+// появления, пока не отменён ctx. emit вызывается сразу с хвостом (возможно пустым), затем с каждой
+// порцией новых записей; записи внутри порции идут по времени. Фильтр to не применяется.
+func (s *LogService) Follow(ctx context.Context, filter models.LogFilter, emit func([]models.LogEntry) error) error {
+	m, err := newLogMatcher(filter)
+	if err != nil {
+		return err
+	}
+	m.to = nil
+
+	// Запоминаем, докуда дописаны сегодняшние файлы: записи до этой точки отдаются хвостом, после — потоком
+	offsets := make(map[string]int64)
+	for _, kind := range m.kinds {
+		path := applog.FilePath(kind, time.Now())
+		offsets[path] = 0
+		if info, err := os.Stat(path); err == nil {
+			offsets[path] = info.Size()
+		}
+	}
+
+	tail, err := s.search(ctx, m, logLimit(filter.Limit), 0, offsets)
+	if err != nil {
+		return err
+	}
+	entries := tail.Entries
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if err := emit(entries); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// После полуночи логгер переходит на новые файлы: вчерашние дочитываются последний раз и забываются
+		today := make(map[string]bool, len(m.kinds))
+		for _, kind := range m.kinds {
+			path := applog.FilePath(kind, time.Now())
+			today[path] = true
+			if _, ok := offsets[path]; !ok {
+				offsets[path] = 0
+			}
+		}
+
+		var batch []models.LogEntry
+		for path, offset := range offsets {
+			entries, next, err := readLogFile(path, offset, -1, m.match)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, entries...)
+			offsets[path] = next
+			if !today[path] {
+				delete(offsets, path)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		sort.SliceStable(batch, func(i, j int) bool { return batch[i].Time.Before(batch[j].Time) })
+		if err := emit(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// search набирает offset+limit+1 записей, идя по дням от новых к старым. sizes ограничивает,
+// до какой позиции читать файл, — так хвост в Follow не пересекается с потоком новых записей.
+func (s *LogService) search(ctx context.Context, m *logMatcher, limit, offset int, sizes map[string]int64) (*models.LogPage, error) {
+	last := time.Now()
+	if m.to != nil && m.to.Before(last) {
+		last = m.to.Local()
+	}
+	first := last.Add(-applog.MaxAge)
+	if m.from != nil && m.from.After(first) {
+		first = m.from.Local()
+	}
+
+	need := offset + limit + 1
+	var found []models.LogEntry
+	for day := startOfDay(last); !day.Before(startOfDay(first)); day = day.AddDate(0, 0, -1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var dayEntries []models.LogEntry
+		for _, kind := range m.kinds {
+			path := applog.FilePath(kind, day)
+			limitBytes := int64(-1)
+			if size, ok := sizes[path]; ok {
+				limitBytes = size
+			}
+			entries, _, err := readLogFile(path, 0, limitBytes, m.match)
+			if err != nil {
+				return nil, err
+			}
+			dayEntries = append(dayEntries, entries...)
+		}
+		sort.SliceStable(dayEntries, func(i, j int) bool { return dayEntries[i].Time.After(dayEntries[j].Time) })
+
+		found = append(found, dayEntries...)
+		if len(found) >= need {
+			break
+		}
+	}
+
+	page := &models.LogPage{Entries: []models.LogEntry{}}
+	if offset < len(found) {
+		page.Entries = found[offset:min(offset+limit, len(found))]
+	}
+	page.HasMore = len(found) > offset+limit
+	return page, nil
+}
+
+func logLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLogLimit
+	}
+	return min(limit, maxLogLimit)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// readLogFile читает файл с позиции from до позиции limit (limit < 0 — до конца) и возвращает
+// подходящие записи и позицию после последней полной строки. Строка, которую логгер ещё
+// не дописал, остаётся до следующего чтения. Отсутствующий файл — не ошибка: за день могло не быть записей.
+func readLogFile(path string, from, limit int64, match func(line []byte, entry *models.LogEntry) bool) ([]models.LogEntry, int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, from, nil
+	}
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	// Файл мог быть пересоздан — тогда читаем его сначала
+	if info, err := f.Stat(); err == nil && info.Size() < from {
+		from = 0
+	}
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		return nil, from, fmt.Errorf("failed to read log file: %w", err)
+	}
+	var src io.Reader = f
+	if limit >= 0 {
+		src = io.LimitReader(f, max(limit-from, 0))
+	}
+
+	reader := bufio.NewReaderSize(src, 64<<10)
+	pos := from
+	var entries []models.LogEntry
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, pos, fmt.Errorf("failed to read log file: %w", err)
+		}
+		pos += int64(len(line))
+
+		if entry, ok := parseLogEntry(line); ok && match(line, &entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, pos, nil
+}
+
+// parseLogEntry разбирает JSON-строку журнала; основные поля zap выносятся в поля LogEntry
+func parseLogEntry(line []byte) (models.LogEntry, bool) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return models.LogEntry{}, false
+	}
+
+	var entry models.LogEntry
+	if value, ok := fields["time"].(string); ok {
+		entry.Time, _ = time.Parse(applog.TimeLayout, value)
+	}
+	entry.Level, _ = fields["level"].(string)
+	entry.Level = strings.ToLower(entry.Level)
+	entry.Message, _ = fields["message"].(string)
+	entry.Caller, _ = fields["caller"].(string)
+	entry.RequestID, _ = fields["request_id"].(string)
+	for _, key := range []string{"time", "level", "message", "caller", "request_id"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry, true
+}
+
+// logMatcher проверяет записи на соответствие фильтру
+type logMatcher struct {
+	kinds     []string
+	levels    map[string]bool
+	from, to  *time.Time
+	requestID string
+	userID    string
+	query     []byte
+}
+
+func newLogMatcher(filter models.LogFilter) (*logMatcher, error) {
+	m := &logMatcher{
+		from:      filter.From,
+		to:        filter.To,
+		requestID: filter.RequestID,
+		query:     bytes.ToLower([]byte(filter.Query)),
+	}
+	if filter.UserID > 0 {
+		m.userID = strconv.Itoa(filter.UserID)
+	}
+
+	if len(filter.Levels) == 0 {
+		m.kinds = applog.FileKinds
+		return m, nil
+	}
+	m.levels = make(map[string]bool, len(filter.Levels))
+	kinds := make(map[string]bool)
+	for _, level := range filter.Levels {
+		kind, ok := logLevelKinds[level]
+		if !ok {
+			return nil, ErrInvalidLogLevel
+		}
+		m.levels[level] = true
+		if !kinds[kind] {
+			kinds[kind] = true
+			m.kinds = append(m.kinds, kind)
+		}
+	}
+	return m, nil
+}
+
+// logLevelGroup сводит DPANIC, PANIC и FATAL к error — по отдельности их не ищут
+func logLevelGroup(level string) string {
+	switch level {
+	case "dpanic", "panic", "fatal":
+		return "error"
+	}
+	return level
+}
+
+func (m *logMatcher) match(line []byte, entry *models.LogEntry) bool {
+	if m.levels != nil && !m.levels[logLevelGroup(entry.Level)] {
+		return false
+	}
+	if m.from != nil && entry.Time.Before(*m.from) {
+		return false
+	}
+	if m.to != nil && !entry.Time.Before(*m.to) {
+		return false
+	}
+	if m.requestID != "" && entry.RequestID != m.requestID {
+		return false
+	}
+	if m.userID != "" && fmt.Sprint(entry.Fields["user_id"]) != m.userID {
+		return false
+	}
+	if len(m.query) > 0 && !bytes.Contains(bytes.ToLower(line), m.query) {
+		return false
+	}
+	return true
+}
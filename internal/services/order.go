@@ -1,27 +1,46 @@
 package services
 
 import (
+	"chechnya-product/internal/metrics"
 	"chechnya-product/internal/models"
+	"chechnya-product/internal/payments"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
 	"chechnya-product/internal/ws"
+	"context"
+	"database/sql"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidOrderItems  = errors.New("order must contain products with positive quantities")
+	ErrInvalidDeliveryFee = errors.New("delivery fee must be a non-negative number")
+	ErrInvalidOrderTotal  = errors.New("order total must be positive for online payment")
 )
 
 type OrderServiceInterface interface {
-	PlaceOrder(ownerID string, req models.PlaceOrderRequest) (*models.Order, error)
-	GetOrders(ownerID string) ([]models.Order, error)
-	GetAllOrders() ([]models.Order, error)
-	UpdateStatus(orderID int, status string) error
-	RepeatOrder(orderID int, ownerID string) error
-	GetOrderHistory(ownerID string) ([]models.Order, error)
-	DeleteOrder(orderID int) error
-	GetOrderByID(orderID int) (*models.Order, error)
-	AddReview(orderID int, comment *string, rating *int, userID int) error
-	GetByOrderReviewID(orderID int) (*models.OrderReview, error)
-	GetAllReview() ([]models.OrderReview, error)
+	PlaceOrder(ctx context.Context, ownerID string, req models.PlaceOrderRequest) (*models.Order, error)
+	GetOrders(ctx context.Context, ownerID string) ([]models.Order, error)
+	GetAllOrders(ctx context.Context) ([]models.Order, error)
+	ExportCSV(ctx context.Context, w io.Writer, from, to *time.Time) (int, error)
+	UpdateStatus(ctx context.Context, orderID int, status string) error
+	RepeatOrder(ctx context.Context, orderID int, ownerID string) error
+	GetOrderHistory(ctx context.Context, ownerID string) ([]models.Order, error)
+	DeleteOrder(ctx context.Context, orderID int) error
+	GetOrderByID(ctx context.Context, orderID int, ownerID string) (*models.Order, error)
+	GetNotExported(ctx context.Context) ([]models.Order, error)
+	MarkExported(ctx context.Context, orderIDs []int) error
 }
 
 type OrderService struct {
@@ -30,7 +49,9 @@ type OrderService struct {
 	productRepo repositories.ProductRepository
 	userRepo    repositories.UserRepository
 	pushService PushServiceInterface
+	payments    PaymentServiceInterface
 	hub         *ws.Hub
+	tasks       *utils.TaskGroup
 	logger      *zap.Logger
 }
 
@@ -40,7 +61,9 @@ func NewOrderService(
 	productRepo repositories.ProductRepository,
 	userRepo repositories.UserRepository,
 	pushService PushServiceInterface,
+	payments PaymentServiceInterface,
 	hub *ws.Hub,
+	tasks *utils.TaskGroup,
 	logger *zap.Logger,
 ) *OrderService {
 	return &OrderService{
@@ -49,7 +72,9 @@ func NewOrderService(
 		productRepo: productRepo,
 		userRepo:    userRepo,
 		pushService: pushService,
+		payments:    payments,
 		hub:         hub,
+		tasks:       tasks,
 		logger:      logger,
 	}
 }
@@ -61,17 +86,34 @@ const (
 	maxDistanceKm = 200.0 // максимум расстояния
 )
 
-func (s *OrderService) PlaceOrder(ownerID string, req models.PlaceOrderRequest) (*models.Order, error) {
-	// 1. Считаем сумму заказа
-	var total float64
-	for _, item := range req.Items {
-		if item.Price != nil {
-			total += *item.Price * float64(item.Quantity)
+func (s *OrderService) PlaceOrder(ctx context.Context, ownerID string, req models.PlaceOrderRequest) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.PlaceOrder", trace.WithAttributes(
+		attribute.Int("order.items", len(req.Items)),
+		attribute.String("order.delivery_type", req.DeliveryType),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	paymentType := strings.ToLower(strings.TrimSpace(req.PaymentType))
+	switch paymentType {
+	case "":
+		paymentType = models.PaymentTypeCash
+	case models.PaymentTypeCash, models.PaymentTypeCard:
+	case models.PaymentTypeOnline:
+		if s.payments == nil || !s.payments.Enabled() {
+			return nil, ErrPaymentsDisabled
 		}
+	default:
+		return nil, ErrInvalidPaymentType
+	}
+	req.PaymentType = paymentType
+	// Статус ведёт только администратор: присланный клиентом «доставлен» давал бы отметку о покупке и право на отзыв
+	req.Status = models.OrderStatusNew
+	// Сдача бывает только при оплате наличными
+	if paymentType != models.PaymentTypeCash {
+		req.ChangeFor = nil
 	}
-	total += req.DeliveryFee
 
-	// 2. Если есть координаты, пересчитаем доставку
+	// 1. Если есть координаты, пересчитаем доставку
 	if req.Latitude != nil && req.Longitude != nil {
 		distance := utils.CalculateDistanceKm(warehouseLat, warehouseLon, *req.Latitude, *req.Longitude)
 		if distance > maxDistanceKm {
@@ -79,43 +121,81 @@ func (s *OrderService) PlaceOrder(ownerID string, req models.PlaceOrderRequest)
 		}
 		req.DeliveryFee = pricePerKm * distance
 	}
+	if req.DeliveryFee < 0 || math.IsNaN(req.DeliveryFee) || math.IsInf(req.DeliveryFee, 0) {
+		return nil, ErrInvalidDeliveryFee
+	}
+
+	// 2. Считаем сумму заказа по ценам из каталога: цена и название из запроса не используются
+	req.Items, err = s.priceItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	total := req.DeliveryFee
+	for _, item := range req.Items {
+		total += *item.Price * float64(item.Quantity)
+	}
+	// Онлайн-платёж сверяется с суммой заказа, поэтому нулевой заказ нельзя «оплатить»
+	if paymentType == models.PaymentTypeOnline && total <= 0 {
+		return nil, ErrInvalidOrderTotal
+	}
 
 	// 3. Создаём заказ
-	orderID, err := s.orderRepo.CreateFullOrder(ownerID, req, total)
+	orderID, err := s.orderRepo.CreateFullOrder(ctx, ownerID, req, total)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось создать заказ: %w", err)
 	}
 
 	// 4. Очищаем корзину
-	if err := s.cartRepo.ClearCart(ownerID); err != nil {
+	if err := s.cartRepo.ClearCart(ctx, ownerID); err != nil {
 		return nil, fmt.Errorf("заказ создан, но не удалось очистить корзину: %w", err)
 	}
 
 	// 5. Получаем заказ и товары
-	order, err := s.orderRepo.GetByID(orderID)
+	order, err := s.orderRepo.GetByID(ctx, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("заказ создан, но не удалось получить данные: %w", err)
 	}
 
-	items, err := s.orderRepo.GetOrderItems(orderID)
+	items, err := s.orderRepo.GetOrderItems(ctx, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("заказ создан, но не удалось получить товары: %w", err)
 	}
 	order.Items = items
+	span.SetAttributes(attribute.Int("order.id", order.ID), attribute.Float64("order.total", order.Total))
+
+	// 6. Онлайн-оплата: в ответе будет ссылка на страницу оплаты. Если провайдер недоступен,
+	// заказ всё равно остаётся — оплату можно повторить через POST /api/orders/{id}/payment
+	if paymentType == models.PaymentTypeOnline {
+		if err := s.payments.StartPayment(ctx, order); err != nil {
+			span.RecordError(err)
+			tracing.Logger(ctx, s.logger).Warn("failed to start order payment", zap.Int("order_id", order.ID), zap.Error(err))
+		}
+	}
+
+	metrics.OrdersPlaced.Inc()
+	if order.Total > 0 {
+		metrics.OrderRevenue.Add(order.Total)
+	}
+
+	// 8. Push-уведомление для админов; при остановке сервера отправка дожидается завершения.
+	// Отправка продолжает трассу заказа, но не отменяется вместе с запросом.
+	taskCtx := context.WithoutCancel(ctx)
+	s.tasks.Go(func() {
+		taskCtx, span := tracing.Start(taskCtx, "OrderService.notifyAdmins", trace.WithAttributes(attribute.Int("order.id", orderID)))
+		defer span.End()
 
-	// 7. Push-уведомление для админов
-	go func(orderID int) {
 		username := ownerID
-		if name, err := s.userRepo.GetUsernameByID(ownerID); err == nil && name != "" {
+		if name, err := s.userRepo.GetUsernameByID(taskCtx, ownerID); err == nil && name != "" {
 			username = name
 		}
 		msg := fmt.Sprintf("📦 Новый заказ #%d от %s", orderID, username)
-		if err := s.pushService.SendPushToAdmins(msg); err != nil {
-			s.logger.Warn("❌ Не удалось отправить push администраторам", zap.Error(err))
+		if err := s.pushService.SendPushToAdmins(taskCtx, msg); err != nil {
+			span.RecordError(err)
+			tracing.Logger(taskCtx, s.logger).Warn("❌ Не удалось отправить push администраторам", zap.Error(err))
 		}
-	}(order.ID)
+	})
 
-	// 6. WebSocket уведомление
+	// 7. WebSocket уведомление
 	if s.hub != nil {
 		s.hub.BroadcastNewOrder(*order)
 	}
@@ -123,8 +203,37 @@ func (s *OrderService) PlaceOrder(ownerID string, req models.PlaceOrderRequest)
 	return order, nil
 }
 
-func (s *OrderService) GetOrders(ownerID string) ([]models.Order, error) {
-	orders, err := s.orderRepo.GetWithItemsByOwnerID(ownerID)
+// priceItems проверяет позиции заказа и подставляет в них текущие цену и название товара
+func (s *OrderService) priceItems(ctx context.Context, items []models.OrderItem) ([]models.OrderItem, error) {
+	if len(items) == 0 {
+		return nil, ErrInvalidOrderItems
+	}
+
+	priced := make([]models.OrderItem, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidOrderItems
+		}
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %d", ErrProductNotFound, item.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, price := product.Name, product.Price
+		priced = append(priced, models.OrderItem{
+			ProductID: product.ID,
+			Name:      &name,
+			Quantity:  item.Quantity,
+			Price:     &price,
+		})
+	}
+	return priced, nil
+}
+
+func (s *OrderService) GetOrders(ctx context.Context, ownerID string) ([]models.Order, error) {
+	orders, err := s.orderRepo.GetWithItemsByOwnerID(ctx, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,62 +243,148 @@ func (s *OrderService) GetOrders(ownerID string) ([]models.Order, error) {
 	return orders, nil
 }
 
-func (s *OrderService) GetAllOrders() ([]models.Order, error) {
-	return s.orderRepo.GetAllWithItems()
+func (s *OrderService) GetAllOrders(ctx context.Context) ([]models.Order, error) {
+	return s.orderRepo.GetAllWithItems(ctx)
+}
+
+// ExportCSV выгружает заказы, созданные в [from, to), и возвращает их количество
+func (s *OrderService) ExportCSV(ctx context.Context, w io.Writer, from, to *time.Time) (int, error) {
+	orders, err := s.orderRepo.GetWithItemsBetween(ctx, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch orders: %w", err)
+	}
+
+	writer := csv.NewWriter(w)
+
+	// Заголовки
+	writer.Write([]string{
+		"Order ID", "Owner ID", "Name", "Address", "Delivery Type",
+		"Payment Type", "Payment Status", "Total", "Created At", "Items",
+	})
+
+	// Строки
+	for _, order := range orders {
+		// Список товаров в строку
+		var itemDescriptions []string
+		for _, item := range order.Items {
+			name := "Unnamed"
+			if item.Name != nil {
+				name = *item.Name
+			}
+			var price float64
+			if item.Price != nil {
+				price = *item.Price
+			}
+			itemDescriptions = append(itemDescriptions,
+				fmt.Sprintf("%s x%d (%.2f)", name, item.Quantity, price))
+		}
+		itemsStr := strings.Join(itemDescriptions, "; ")
+
+		// Адрес и имя (если nil — пустая строка)
+		name := ""
+		if order.Name != nil {
+			name = *order.Name
+		}
+		address := ""
+		if order.Address != nil {
+			address = *order.Address
+		}
+
+		writer.Write([]string{
+			strconv.Itoa(order.ID),
+			order.OwnerID,
+			name,
+			address,
+			order.DeliveryType,
+			order.PaymentType,
+			string(paymentStatus(&order)),
+			utils.FormatFloat(order.Total),
+			order.CreatedAt.Format(time.RFC3339),
+			itemsStr,
+		})
+	}
+
+	writer.Flush()
+	return len(orders), writer.Error()
 }
 
-func (s *OrderService) UpdateStatus(orderID int, status string) error {
+func (s *OrderService) UpdateStatus(ctx context.Context, orderID int, status string) error {
 	if !models.AllowedOrderStatuses[status] {
 		return fmt.Errorf("недопустимый статус")
 	}
 
-	err := s.orderRepo.UpdateStatus(orderID, status)
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOrderNotFound
+	}
 	if err != nil {
 		return err
 	}
+	// Онлайн-заказ берётся в работу только после оплаты; новым или отклонённым он может быть и без неё
+	if order.PaymentType == models.PaymentTypeOnline && paymentStatus(order) != payments.StatusPaid &&
+		status != models.OrderStatusNew && status != models.OrderStatusRejected {
+		return ErrOrderNotPaid
+	}
+
+	if err := s.orderRepo.UpdateStatus(ctx, orderID, status); err != nil {
+		return err
+	}
+	order.Status = status
 
-	order, err := s.orderRepo.GetByID(orderID)
+	// При отклонении ожидающий платёж отменяется, а оплаченный возвращается. Повторное отклонение
+	// заказа, по которому возврат не прошёл, пробует вернуть деньги ещё раз.
+	if status == models.OrderStatusRejected && s.payments != nil {
+		if err := s.payments.Settle(ctx, order); err != nil {
+			return err
+		}
+	}
+
+	updated, err := s.orderRepo.GetByID(ctx, orderID)
 	if err == nil && s.hub != nil {
-		s.hub.BroadcastStatusUpdate(*order)
+		s.hub.BroadcastStatusUpdate(*updated)
 	}
 
 	return nil
 }
 
-func (s *OrderService) RepeatOrder(orderID int, ownerID string) error {
-	order, err := s.orderRepo.GetByID(orderID)
+func (s *OrderService) RepeatOrder(ctx context.Context, orderID int, ownerID string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
 	if err != nil || order.OwnerID != ownerID {
 		return errors.New("invalid order")
 	}
 
-	items, err := s.orderRepo.GetOrderItems(orderID)
+	items, err := s.orderRepo.GetOrderItems(ctx, orderID)
 	if err != nil {
 		return err
 	}
 
 	for _, item := range items {
-		if err := s.cartRepo.AddOrUpdate(ownerID, item.ProductID, item.Quantity); err != nil {
+		if err := s.cartRepo.AddOrUpdate(ctx, ownerID, item.ProductID, item.Quantity); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *OrderService) GetOrderHistory(ownerID string) ([]models.Order, error) {
-	return s.orderRepo.GetWithItemsByOwnerID(ownerID)
+func (s *OrderService) GetOrderHistory(ctx context.Context, ownerID string) ([]models.Order, error) {
+	return s.orderRepo.GetWithItemsByOwnerID(ctx, ownerID)
 }
 
-func (s *OrderService) DeleteOrder(orderID int) error {
-	return s.orderRepo.DeleteOrder(orderID)
+func (s *OrderService) DeleteOrder(ctx context.Context, orderID int) error {
+	return s.orderRepo.DeleteOrder(ctx, orderID)
 }
 
-func (s *OrderService) GetOrderByID(orderID int) (*models.Order, error) {
-	order, err := s.orderRepo.GetByID(orderID)
+// GetOrderByID — заказ покупателя; чужой заказ не отдаём, в нём ссылка на оплату и адрес
+func (s *OrderService) GetOrderByID(ctx context.Context, orderID int, ownerID string) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && order.OwnerID != ownerID) {
+		return nil, ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	items, err := s.orderRepo.GetOrderItems(orderID)
+	items, err := s.orderRepo.GetOrderItems(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -198,26 +393,11 @@ func (s *OrderService) GetOrderByID(orderID int) (*models.Order, error) {
 	return order, nil
 }
 
-func (s *OrderService) AddReview(orderID int, comment *string, rating *int, userID int) error {
-	if rating != nil && (*rating < 1 || *rating > 5) {
-		return fmt.Errorf("рейтинг должен быть от 1 до 5")
-	}
-
-	order, err := s.orderRepo.GetByID(orderID)
-	if err != nil {
-		return fmt.Errorf("заказ не найден")
-	}
-	if order.Status != "доставлен" {
-		return fmt.Errorf("оставлять отзыв можно только после доставки")
-	}
-
-	return s.orderRepo.AddReview(orderID, comment, rating, userID)
-}
-
-func (s *OrderService) GetByOrderReviewID(orderID int) (*models.OrderReview, error) {
-	return s.orderRepo.GetReviewByOrderID(orderID)
+// GetNotExported — заказы, которые ещё не забрала учётная система
+func (s *OrderService) GetNotExported(ctx context.Context) ([]models.Order, error) {
+	return s.orderRepo.GetNotExported(ctx)
 }
 
-func (s *OrderService) GetAllReview() ([]models.OrderReview, error) {
-	return s.orderRepo.GetAllOrderReviews()
+func (s *OrderService) MarkExported(ctx context.Context, orderIDs []int) error {
+	return s.orderRepo.MarkExported(ctx, orderIDs)
 }
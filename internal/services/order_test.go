@@ -0,0 +1,224 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/payments"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func (r *memOrderRepo) CreateFullOrder(_ context.Context, ownerID string, req models.PlaceOrderRequest, total float64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := len(r.orders) + 1
+	r.orders[id] = &models.Order{
+		ID: id, OwnerID: ownerID, Total: total, Status: req.Status,
+		PaymentType: req.PaymentType, Items: append([]models.OrderItem(nil), req.Items...),
+	}
+	return id, nil
+}
+
+func (r *memOrderRepo) GetOrderItems(_ context.Context, id int) ([]models.OrderItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.orders[id].Items, nil
+}
+
+// catalogRepo — товары каталога по id
+type catalogRepo struct {
+	repositories.ProductRepository
+
+	products map[int]models.Product
+}
+
+func (r *catalogRepo) GetByID(_ context.Context, id int) (*models.Product, error) {
+	p, ok := r.products[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get product by id: %w", sql.ErrNoRows)
+	}
+	return &p, nil
+}
+
+type noopCartRepo struct {
+	repositories.CartRepository
+}
+
+func (noopCartRepo) ClearCart(context.Context, string) error {
+	return nil
+}
+
+type orderFixture struct {
+	repo    *memOrderRepo
+	push    *countingPush
+	tasks   *utils.TaskGroup
+	service *OrderService
+}
+
+func newOrderFixture(t *testing.T) *orderFixture {
+	t.Helper()
+	f := &orderFixture{
+		repo:  &memOrderRepo{orders: map[int]*models.Order{}},
+		push:  &countingPush{},
+		tasks: utils.NewTaskGroup(),
+	}
+	products := &catalogRepo{products: map[int]models.Product{
+		1: {ID: 1, Name: "Мёд", Price: 500},
+		2: {ID: 2, Name: "Орехи", Price: 300},
+		3: {ID: 3, Name: "Подарок", Price: 0},
+	}}
+	paymentService := NewPaymentService(payments.NewFakeProvider("test-secret"), f.repo, f.push, nil, f.tasks,
+		&config.Config{PaymentReturnURL: "https://shop.test/orders/%d"}, zap.NewNop())
+	f.service = NewOrderService(noopCartRepo{}, f.repo, products, orderUserRepo{}, f.push, paymentService, nil, f.tasks, zap.NewNop())
+	return f
+}
+
+// orderUserRepo — у заказов в тестах нет зарегистрированных покупателей
+type orderUserRepo struct {
+	repositories.UserRepository
+}
+
+func (orderUserRepo) GetUsernameByID(context.Context, string) (string, error) {
+	return "", sql.ErrNoRows
+}
+
+func price(v float64) *float64 {
+	return &v
+}
+
+func TestPlaceOrderUsesCatalogPrices(t *testing.T) {
+	f := newOrderFixture(t)
+
+	order, err := f.service.PlaceOrder(context.Background(), "guest", models.PlaceOrderRequest{
+		PaymentType: models.PaymentTypeOnline,
+		DeliveryFee: 100,
+		Items: []models.OrderItem{
+			{ProductID: 1, Quantity: 2, Price: price(0.01)},
+			{ProductID: 2, Quantity: 1, Price: price(0.01)},
+		},
+	})
+	f.tasks.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if order.Total != 1400 {
+		t.Fatalf("total = %v, want 1400 from catalog prices", order.Total)
+	}
+	if got := *order.Items[0].Price; got != 500 {
+		t.Fatalf("item price = %v, want 500", got)
+	}
+}
+
+func TestPlaceOrderRejectsInvalidOrders(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.PlaceOrderRequest
+		want error
+	}{
+		{"no items", models.PlaceOrderRequest{}, ErrInvalidOrderItems},
+		{"zero quantity", models.PlaceOrderRequest{Items: []models.OrderItem{{ProductID: 1}}}, ErrInvalidOrderItems},
+		{"negative quantity", models.PlaceOrderRequest{Items: []models.OrderItem{{ProductID: 1, Quantity: -3}}}, ErrInvalidOrderItems},
+		{"unknown product", models.PlaceOrderRequest{Items: []models.OrderItem{{ProductID: 99, Quantity: 1}}}, ErrProductNotFound},
+		{"negative delivery", models.PlaceOrderRequest{DeliveryFee: -1000, Items: []models.OrderItem{{ProductID: 1, Quantity: 1}}}, ErrInvalidDeliveryFee},
+		{"free online order", models.PlaceOrderRequest{PaymentType: models.PaymentTypeOnline, Items: []models.OrderItem{{ProductID: 3, Quantity: 1}}}, ErrInvalidOrderTotal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOrderFixture(t)
+			if _, err := f.service.PlaceOrder(context.Background(), "guest", tt.req); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if len(f.repo.orders) != 0 {
+				t.Fatal("rejected order was stored")
+			}
+		})
+	}
+}
+
+// ctxPush ждёт сигнала и запоминает, был ли к этому моменту отменён контекст отправки
+type ctxPush struct {
+	PushServiceInterface
+
+	wait     chan struct{}
+	sent     int
+	canceled bool
+}
+
+func (p *ctxPush) SendPushToAdmins(ctx context.Context, _ string) error {
+	<-p.wait
+	p.sent++
+	p.canceled = ctx.Err() != nil
+	return ctx.Err()
+}
+
+func TestPlaceOrderNotifiesAdminsAfterRequestEnds(t *testing.T) {
+	f := newOrderFixture(t)
+	push := &ctxPush{wait: make(chan struct{})}
+	f.service.pushService = push
+
+	// Отправка идёт в фоне и не должна зависеть от контекста уже завершённого запроса
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := f.service.PlaceOrder(ctx, "guest", models.PlaceOrderRequest{
+		Items: []models.OrderItem{{ProductID: 1, Quantity: 1}},
+	}); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	cancel()
+	close(push.wait)
+	f.tasks.Wait(context.Background())
+
+	if push.sent != 1 || push.canceled {
+		t.Fatalf("admin push sent = %d, canceled = %v; want one push with a live context", push.sent, push.canceled)
+	}
+}
+
+func TestPlaceOrderIgnoresClientStatus(t *testing.T) {
+	for _, paymentType := range []string{models.PaymentTypeCash, models.PaymentTypeCard, models.PaymentTypeOnline} {
+		f := newOrderFixture(t)
+		order, err := f.service.PlaceOrder(context.Background(), "guest", models.PlaceOrderRequest{
+			PaymentType: paymentType,
+			Status:      models.OrderStatusDelivered,
+			Items:       []models.OrderItem{{ProductID: 1, Quantity: 1}},
+		})
+		f.tasks.Wait(context.Background())
+		if err != nil {
+			t.Fatalf("%s: PlaceOrder: %v", paymentType, err)
+		}
+		if order.Status != models.OrderStatusNew {
+			t.Fatalf("%s: status = %q, want %q", paymentType, order.Status, models.OrderStatusNew)
+		}
+	}
+}
+
+func TestGetOrderByIDHidesForeignOrders(t *testing.T) {
+	f := newOrderFixture(t)
+	placed, err := f.service.PlaceOrder(context.Background(), "guest", models.PlaceOrderRequest{
+		PaymentType: models.PaymentTypeOnline,
+		Items:       []models.OrderItem{{ProductID: 1, Quantity: 1}},
+	})
+	f.tasks.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	if _, err := f.service.GetOrderByID(context.Background(), placed.ID, "someone-else"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("foreign order: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := f.service.GetOrderByID(context.Background(), placed.ID+1, "guest"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("missing order: err = %v, want ErrOrderNotFound", err)
+	}
+	order, err := f.service.GetOrderByID(context.Background(), placed.ID, "guest")
+	if err != nil {
+		t.Fatalf("own order: %v", err)
+	}
+	if order.PaymentURL == nil {
+		t.Fatal("owner should see the payment link")
+	}
+}
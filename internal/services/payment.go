@@ -0,0 +1,352 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/payments"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"chechnya-product/internal/ws"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Допустимая погрешность при сравнении суммы платежа с суммой заказа
+const paymentAmountEpsilon = 0.005
+
+var (
+	ErrPaymentsDisabled    = errors.New("online payments are not configured")
+	ErrInvalidPaymentType  = errors.New("unknown payment type")
+	ErrPaymentNotRequired  = errors.New("order does not need online payment")
+	ErrOrderAlreadyPaid    = errors.New("order is already paid")
+	ErrOrderNotPaid        = errors.New("order is not paid yet")
+	ErrRefundFailed        = errors.New("failed to refund payment")
+	ErrInvalidPaymentEvent = errors.New("invalid payment notification")
+)
+
+type PaymentServiceInterface interface {
+	Enabled() bool
+	StartPayment(ctx context.Context, order *models.Order) error
+	RetryPayment(ctx context.Context, orderID int, ownerID string) (*models.Order, error)
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) error
+	Settle(ctx context.Context, order *models.Order) error
+}
+
+// PaymentService ведёт онлайн-оплату заказа: pending после создания платежа,
+// paid или failed по уведомлению провайдера, canceled или refunded при отклонении заказа.
+// Переходы делаются условным UPDATE, поэтому повторные и одновременные уведомления безопасны.
+type PaymentService struct {
+	provider    payments.PaymentProvider
+	orderRepo   repositories.OrderRepository
+	pushService PushServiceInterface
+	hub         *ws.Hub
+	tasks       *utils.TaskGroup
+	returnURL   string
+	logger      *zap.Logger
+}
+
+// NewPaymentService — provider == nil выключает онлайн-оплату
+func NewPaymentService(
+	provider payments.PaymentProvider,
+	orderRepo repositories.OrderRepository,
+	pushService PushServiceInterface,
+	hub *ws.Hub,
+	tasks *utils.TaskGroup,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *PaymentService {
+	return &PaymentService{
+		provider:    provider,
+		orderRepo:   orderRepo,
+		pushService: pushService,
+		hub:         hub,
+		tasks:       tasks,
+		returnURL:   cfg.PaymentReturnURL,
+		logger:      logger,
+	}
+}
+
+func (s *PaymentService) Enabled() bool {
+	return s != nil && s.provider != nil
+}
+
+// StartPayment создаёт платёж у провайдера и сохраняет его в заказе; order дополняется ссылкой на оплату.
+// Если провайдер не ответил, оплата помечается failed — покупатель может повторить её через RetryPayment.
+func (s *PaymentService) StartPayment(ctx context.Context, order *models.Order) error {
+	if !s.Enabled() {
+		return ErrPaymentsDisabled
+	}
+
+	reference, err := paymentReference(order.ID)
+	if err != nil {
+		return err
+	}
+	payment, err := s.provider.CreatePayment(ctx, payments.CreateRequest{
+		OrderID:     order.ID,
+		Amount:      order.Total,
+		Description: fmt.Sprintf("Заказ №%d", order.ID),
+		ReturnURL:   s.orderReturnURL(order.ID),
+		Reference:   reference,
+	})
+	if err != nil {
+		if _, saveErr := s.orderRepo.SetPayment(ctx, order.ID, s.provider.Name(), reference, "", "", string(payments.StatusFailed)); saveErr != nil {
+			tracing.Logger(ctx, s.logger).Error("failed to mark payment failed", zap.Int("order_id", order.ID), zap.Error(saveErr))
+		}
+		setOrderPayment(order, s.provider.Name(), "", "", payments.StatusFailed)
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	saved, err := s.orderRepo.SetPayment(ctx, order.ID, s.provider.Name(), reference, payment.ID, payment.ConfirmationURL, string(payments.StatusPending))
+	if err != nil {
+		return err
+	}
+	if !saved {
+		// Пока создавался новый платёж, пришло уведомление об оплате предыдущего
+		return ErrOrderAlreadyPaid
+	}
+	setOrderPayment(order, s.provider.Name(), payment.ID, payment.ConfirmationURL, payments.StatusPending)
+	return nil
+}
+
+// RetryPayment выдаёт ссылку на оплату заказа покупателя: действующую, если платёж ещё ожидает оплаты,
+// или новую после неудачной попытки
+func (s *PaymentService) RetryPayment(ctx context.Context, orderID int, ownerID string) (*models.Order, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentsDisabled
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && order.OwnerID != ownerID) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentType != models.PaymentTypeOnline || order.Status == models.OrderStatusRejected {
+		return nil, ErrPaymentNotRequired
+	}
+
+	switch paymentStatus(order) {
+	case payments.StatusPaid, payments.StatusRefunded:
+		return nil, ErrOrderAlreadyPaid
+	case payments.StatusPending:
+		if order.PaymentURL != nil {
+			return order, nil
+		}
+	}
+
+	if err := s.StartPayment(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// HandleWebhook проверяет и применяет уведомление провайдера. Ошибка подписи — payments.ErrInvalidSignature,
+// неразборчивое уведомление — ErrInvalidPaymentEvent; уведомление, которое уже было применено, не ошибка.
+func (s *PaymentService) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	if !s.Enabled() {
+		return ErrPaymentsDisabled
+	}
+
+	event, err := s.provider.ParseWebhook(header, body)
+	if errors.Is(err, payments.ErrInvalidWebhook) {
+		return fmt.Errorf("%w: %v", ErrInvalidPaymentEvent, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	log := tracing.Logger(ctx, s.logger).With(
+		zap.Int("order_id", event.OrderID),
+		zap.String("payment_id", event.PaymentID),
+		zap.String("payment_status", string(event.Status)),
+	)
+
+	switch event.Status {
+	case payments.StatusPaid:
+		return s.markPaid(ctx, event, log)
+	case payments.StatusFailed:
+		// Отказ применяется только к текущей попытке: запоздавший отказ по прежней не должен затереть новый платёж
+		if event.Reference == "" {
+			log.Warn("payment failure without attempt reference ignored", zap.String("reason", event.Reason))
+			return nil
+		}
+		updated, err := s.orderRepo.FailPayment(ctx, event.OrderID, event.Reference)
+		if err != nil {
+			return err
+		}
+		log.Info("payment failed", zap.String("reason", event.Reason), zap.String("reference", event.Reference), zap.Bool("applied", updated))
+	case payments.StatusRefunded:
+		// Возврат, сделанный в кабинете провайдера; наш собственный уже записан в Settle
+		updated, err := s.orderRepo.UpdatePaymentStatus(ctx, event.OrderID,
+			[]string{string(payments.StatusPaid)}, string(payments.StatusRefunded), "")
+		if err != nil {
+			return err
+		}
+		log.Info("payment refunded", zap.Bool("applied", updated))
+	default:
+		return fmt.Errorf("%w: unexpected status %q", ErrInvalidPaymentEvent, event.Status)
+	}
+	return nil
+}
+
+// markPaid записывает оплату. Заказ ищется и среди удалённых в корзину: деньги по нему списаны,
+// поэтому оплата сохраняется и сразу возвращается — как и по отклонённому заказу.
+func (s *PaymentService) markPaid(ctx context.Context, event *payments.WebhookEvent, log *zap.Logger) error {
+	order, err := s.orderRepo.GetByIDWithDeleted(ctx, event.OrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Error("payment for unknown order")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if event.Amount+paymentAmountEpsilon < order.Total {
+		// Не подтверждаем заказ, оплаченный не полностью: это разбирает администратор
+		log.Error("payment amount is less than order total", zap.Float64("amount", event.Amount), zap.Float64("total", order.Total))
+		return nil
+	}
+
+	updated, err := s.orderRepo.UpdatePaymentStatus(ctx, event.OrderID, []string{
+		string(payments.StatusPending), string(payments.StatusFailed), string(payments.StatusCanceled),
+	}, string(payments.StatusPaid), event.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	order, err = s.orderRepo.GetByIDWithDeleted(ctx, event.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// Заказ отклонили или удалили, пока покупатель платил: деньги сразу возвращаются.
+	// Проверка идёт и для повторного уведомления — так провайдер, повторяя его, повторяет и неудавшийся возврат.
+	if paymentStatus(order) == payments.StatusPaid && (order.Status == models.OrderStatusRejected || order.DeletedAt != nil) {
+		log.Warn("payment for rejected or deleted order, refunding", zap.Bool("deleted", order.DeletedAt != nil))
+		if err := s.refund(ctx, order); err != nil {
+			s.notifyAdmins(ctx, fmt.Sprintf("⚠️ Заказ #%d оплачен после отмены, но вернуть деньги не удалось — верните оплату вручную", order.ID))
+			return err
+		}
+		return nil
+	}
+
+	if !updated {
+		log.Info("payment notification already applied")
+		return nil
+	}
+	log.Info("order paid", zap.Float64("amount", event.Amount))
+
+	if s.hub != nil {
+		s.hub.BroadcastStatusUpdate(*order)
+	}
+	s.notifyAdmins(ctx, fmt.Sprintf("💳 Заказ #%d оплачен онлайн", order.ID))
+	return nil
+}
+
+// notifyAdmins отправляет push администраторам в фоне, не отменяясь вместе с запросом
+func (s *PaymentService) notifyAdmins(ctx context.Context, msg string) {
+	taskCtx := context.WithoutCancel(ctx)
+	s.tasks.Go(func() {
+		if err := s.pushService.SendPushToAdmins(taskCtx, msg); err != nil {
+			tracing.Logger(taskCtx, s.logger).Warn("❌ Не удалось отправить push администраторам", zap.Error(err))
+		}
+	})
+}
+
+// Settle закрывает оплату отклонённого заказа: неоплаченный платёж отменяется, оплаченный возвращается.
+// Для заказов без онлайн-оплаты ничего не делает.
+func (s *PaymentService) Settle(ctx context.Context, order *models.Order) error {
+	if !s.Enabled() || order.PaymentStatus == nil {
+		return nil
+	}
+
+	canceled, err := s.orderRepo.UpdatePaymentStatus(ctx, order.ID, []string{
+		string(payments.StatusPending), string(payments.StatusFailed),
+	}, string(payments.StatusCanceled), "")
+	if err != nil {
+		return err
+	}
+	if canceled {
+		return nil
+	}
+
+	// Отменять нечего — платёж уже прошёл (или уже отменён либо возвращён, тогда refund ничего не сделает)
+	order, err = s.orderRepo.GetByID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	return s.refund(ctx, order)
+}
+
+// refund возвращает оплату заказа. Статус refunded ставится до запроса к провайдеру,
+// чтобы одновременные Settle и уведомление об оплате не вернули деньги дважды;
+// если провайдер отказал, статус откатывается на paid.
+func (s *PaymentService) refund(ctx context.Context, order *models.Order) error {
+	if paymentStatus(order) != payments.StatusPaid || order.PaymentID == nil {
+		return nil
+	}
+
+	claimed, err := s.orderRepo.UpdatePaymentStatus(ctx, order.ID,
+		[]string{string(payments.StatusPaid)}, string(payments.StatusRefunded), "")
+	if err != nil || !claimed {
+		return err
+	}
+
+	log := tracing.Logger(ctx, s.logger).With(zap.Int("order_id", order.ID), zap.String("payment_id", *order.PaymentID))
+	if err := s.provider.Refund(ctx, *order.PaymentID, order.Total); err != nil {
+		if _, revertErr := s.orderRepo.UpdatePaymentStatus(ctx, order.ID,
+			[]string{string(payments.StatusRefunded)}, string(payments.StatusPaid), ""); revertErr != nil {
+			log.Error("failed to revert payment status after refund error", zap.Error(revertErr))
+		}
+		log.Error("refund failed", zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	log.Info("payment refunded", zap.Float64("amount", order.Total))
+	return nil
+}
+
+// paymentReference — новый id попытки оплаты заказа
+func paymentReference(orderID int) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate payment reference: %w", err)
+	}
+	return fmt.Sprintf("%d-%s", orderID, hex.EncodeToString(buf)), nil
+}
+
+func (s *PaymentService) orderReturnURL(orderID int) string {
+	if strings.Contains(s.returnURL, "%d") {
+		return fmt.Sprintf(s.returnURL, orderID)
+	}
+	return s.returnURL
+}
+
+func paymentStatus(order *models.Order) payments.Status {
+	if order.PaymentStatus == nil {
+		return ""
+	}
+	return payments.Status(*order.PaymentStatus)
+}
+
+func setOrderPayment(order *models.Order, provider, paymentID, paymentURL string, status payments.Status) {
+	statusValue := string(status)
+	order.PaymentProvider = &provider
+	order.PaymentStatus = &statusValue
+	order.PaymentID = nil
+	order.PaymentURL = nil
+	if paymentID != "" {
+		order.PaymentID = &paymentID
+	}
+	if paymentURL != "" {
+		order.PaymentURL = &paymentURL
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/payments"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memOrderRepo — заказы в памяти; условные переходы оплаты повторяют OrderRepo.UpdatePaymentStatus
+type memOrderRepo struct {
+	repositories.OrderRepository
+
+	mu     sync.Mutex
+	orders map[int]*models.Order
+}
+
+func (r *memOrderRepo) GetByID(_ context.Context, id int) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok || order.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (r *memOrderRepo) GetByIDWithDeleted(_ context.Context, id int) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (r *memOrderRepo) UpdateStatus(_ context.Context, id int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[id].Status = status
+	return nil
+}
+
+func (r *memOrderRepo) SetPayment(_ context.Context, id int, provider, reference, paymentID, paymentURL, status string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[id]
+	if current := order.PaymentStatus; current != nil && (*current == "paid" || *current == "refunded") {
+		return false, nil
+	}
+	order.PaymentProvider, order.PaymentReference, order.PaymentID, order.PaymentURL, order.PaymentStatus =
+		&provider, &reference, &paymentID, &paymentURL, &status
+	return true, nil
+}
+
+func (r *memOrderRepo) FailPayment(_ context.Context, id int, reference string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[id]
+	if order.PaymentStatus == nil || *order.PaymentStatus != "pending" ||
+		order.PaymentReference == nil || *order.PaymentReference != reference {
+		return false, nil
+	}
+	failed := "failed"
+	order.PaymentStatus = &failed
+	return true, nil
+}
+
+func (r *memOrderRepo) UpdatePaymentStatus(_ context.Context, id int, from []string, to, paymentID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[id]
+	if order.PaymentStatus == nil || !slices.Contains(from, *order.PaymentStatus) {
+		return false, nil
+	}
+	order.PaymentStatus = &to
+	if paymentID != "" {
+		order.PaymentID = &paymentID
+	}
+	return true, nil
+}
+
+func (r *memOrderRepo) reference(id int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.orders[id].PaymentReference
+}
+
+func (r *memOrderRepo) paymentStatus(id int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if status := r.orders[id].PaymentStatus; status != nil {
+		return *status
+	}
+	return ""
+}
+
+// countingPush считает уведомления администраторам
+type countingPush struct {
+	PushServiceInterface
+
+	mu     sync.Mutex
+	admins int
+}
+
+func (p *countingPush) SendPushToAdmins(context.Context, string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.admins++
+	return nil
+}
+
+type paymentFixture struct {
+	provider *payments.FakeProvider
+	repo     *memOrderRepo
+	push     *countingPush
+	tasks    *utils.TaskGroup
+	payments *PaymentService
+	orders   *OrderService
+}
+
+// newPaymentFixture — онлайн-заказ №1 на 100 ₽ с созданным, но не оплаченным платежом
+func newPaymentFixture(t *testing.T) *paymentFixture {
+	t.Helper()
+	f := &paymentFixture{
+		provider: payments.NewFakeProvider("test-secret"),
+		repo: &memOrderRepo{orders: map[int]*models.Order{
+			1: {ID: 1, OwnerID: "owner", Total: 100, Status: models.OrderStatusNew, PaymentType: models.PaymentTypeOnline},
+		}},
+		push:  &countingPush{},
+		tasks: utils.NewTaskGroup(),
+	}
+	cfg := &config.Config{PaymentReturnURL: "https://shop.test/orders/%d"}
+	f.payments = NewPaymentService(f.provider, f.repo, f.push, nil, f.tasks, cfg, zap.NewNop())
+	f.orders = NewOrderService(nil, f.repo, nil, nil, f.push, f.payments, nil, f.tasks, zap.NewNop())
+
+	order, _ := f.repo.GetByID(context.Background(), 1)
+	if err := f.payments.StartPayment(context.Background(), order); err != nil {
+		t.Fatalf("StartPayment: %v", err)
+	}
+	if order.PaymentURL == nil || *order.PaymentURL == "" {
+		t.Fatal("StartPayment did not return a payment url")
+	}
+	return f
+}
+
+// webhook отправляет уведомление fake-провайдера о текущей попытке, подписанное его секретом
+func (f *paymentFixture) webhook(t *testing.T, status payments.Status, amount float64) error {
+	t.Helper()
+	return f.webhookFor(t, f.repo.reference(1), status, amount)
+}
+
+func (f *paymentFixture) webhookFor(t *testing.T, reference string, status payments.Status, amount float64) error {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{
+		"order_id":   1,
+		"payment_id": "tx-1",
+		"reference":  reference,
+		"status":     status,
+		"amount":     amount,
+	})
+	header := http.Header{}
+	header.Set(payments.FakeSignatureHeader, f.provider.Sign(body))
+	err := f.payments.HandleWebhook(context.Background(), header, body)
+	f.tasks.Wait(context.Background())
+	return err
+}
+
+func TestPaymentWebhookRejectsBadSignature(t *testing.T) {
+	f := newPaymentFixture(t)
+
+	body := []byte(`{"order_id":1,"payment_id":"tx-1","status":"paid","amount":100}`)
+	header := http.Header{}
+	header.Set(payments.FakeSignatureHeader, payments.NewFakeProvider("other-secret").Sign(body))
+
+	err := f.payments.HandleWebhook(context.Background(), header, body)
+	if !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Fatalf("err = %v, want ErrInvalidSignature", err)
+	}
+	if got := f.repo.paymentStatus(1); got != "pending" {
+		t.Fatalf("payment status = %q, want pending", got)
+	}
+}
+
+func TestPaymentWebhookIgnoresUnderpayment(t *testing.T) {
+	f := newPaymentFixture(t)
+
+	if err := f.webhook(t, payments.StatusPaid, 99.5); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if got := f.repo.paymentStatus(1); got != "pending" {
+		t.Fatalf("payment status = %q, want pending", got)
+	}
+	if err := f.orders.UpdateStatus(context.Background(), 1, models.OrderStatusAccepted); !errors.Is(err, ErrOrderNotPaid) {
+		t.Fatalf("accepting underpaid order: err = %v, want ErrOrderNotPaid", err)
+	}
+}
+
+func TestPaymentWebhookDuplicatePaid(t *testing.T) {
+	f := newPaymentFixture(t)
+
+	for i := 0; i < 2; i++ {
+		if err := f.webhook(t, payments.StatusPaid, 100); err != nil {
+			t.Fatalf("webhook %d: %v", i+1, err)
+		}
+	}
+	if got := f.repo.paymentStatus(1); got != "paid" {
+		t.Fatalf("payment status = %q, want paid", got)
+	}
+	if f.push.admins != 1 {
+		t.Fatalf("admin notifications = %d, want 1", f.push.admins)
+	}
+	if err := f.orders.UpdateStatus(context.Background(), 1, models.OrderStatusAccepted); err != nil {
+		t.Fatalf("accepting paid order: %v", err)
+	}
+}
+
+func TestRejectPaidOrderRefundsOnce(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+
+	if err := f.webhook(t, payments.StatusPaid, 100); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	// Повторное отклонение и уведомление провайдера о том же возврате не должны вернуть деньги ещё раз
+	for i := 0; i < 2; i++ {
+		if err := f.orders.UpdateStatus(ctx, 1, models.OrderStatusRejected); err != nil {
+			t.Fatalf("reject %d: %v", i+1, err)
+		}
+	}
+	if err := f.webhook(t, payments.StatusRefunded, 100); err != nil {
+		t.Fatalf("refund webhook: %v", err)
+	}
+
+	if got := f.provider.Refunded("tx-1"); got != 100 {
+		t.Fatalf("refunded = %v, want 100", got)
+	}
+	if got := f.repo.paymentStatus(1); got != "refunded" {
+		t.Fatalf("payment status = %q, want refunded", got)
+	}
+}
+
+func TestRefundFailureRestoresPaid(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+
+	if err := f.webhook(t, payments.StatusPaid, 100); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	f.provider.FailRefunds(errors.New("provider is down"))
+
+	if err := f.orders.UpdateStatus(ctx, 1, models.OrderStatusRejected); !errors.Is(err, ErrRefundFailed) {
+		t.Fatalf("err = %v, want ErrRefundFailed", err)
+	}
+	if got := f.repo.paymentStatus(1); got != "paid" {
+		t.Fatalf("payment status = %q, want paid", got)
+	}
+
+	// Повторное отклонение после восстановления провайдера возвращает деньги
+	f.provider.FailRefunds(nil)
+	if err := f.orders.UpdateStatus(ctx, 1, models.OrderStatusRejected); err != nil {
+		t.Fatalf("retry reject: %v", err)
+	}
+	if got := f.provider.Refunded("tx-1"); got != 100 {
+		t.Fatalf("refunded = %v, want 100", got)
+	}
+}
+
+func TestPaymentWebhookFailureForStaleAttempt(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+
+	stale := f.repo.reference(1)
+	if err := f.webhook(t, payments.StatusFailed, 100); err != nil {
+		t.Fatalf("failure webhook: %v", err)
+	}
+	if _, err := f.payments.RetryPayment(ctx, 1, "owner"); err != nil {
+		t.Fatalf("RetryPayment: %v", err)
+	}
+	if f.repo.reference(1) == stale {
+		t.Fatal("retry must start a new attempt")
+	}
+
+	// Повтор отказа по прежней попытке и отказ без ссылки на попытку не трогают новый платёж
+	for _, reference := range []string{stale, ""} {
+		if err := f.webhookFor(t, reference, payments.StatusFailed, 100); err != nil {
+			t.Fatalf("stale failure webhook: %v", err)
+		}
+		if got := f.repo.paymentStatus(1); got != "pending" {
+			t.Fatalf("payment status after failure for %q = %q, want pending", reference, got)
+		}
+	}
+}
+
+func TestPaymentForDeletedOrderIsRefunded(t *testing.T) {
+	f := newPaymentFixture(t)
+	deletedAt := time.Now()
+	f.repo.orders[1].DeletedAt = &deletedAt
+	f.provider.FailRefunds(errors.New("provider is down"))
+
+	// Возврат не прошёл: оплата записана, администраторы предупреждены, провайдер повторит уведомление
+	if err := f.webhook(t, payments.StatusPaid, 100); !errors.Is(err, ErrRefundFailed) {
+		t.Fatalf("err = %v, want ErrRefundFailed", err)
+	}
+	if got := f.repo.paymentStatus(1); got != "paid" {
+		t.Fatalf("payment status = %q, want paid", got)
+	}
+	if f.push.admins != 1 {
+		t.Fatalf("admin notifications = %d, want 1", f.push.admins)
+	}
+
+	f.provider.FailRefunds(nil)
+	if err := f.webhook(t, payments.StatusPaid, 100); err != nil {
+		t.Fatalf("repeated webhook: %v", err)
+	}
+	if got := f.provider.Refunded("tx-1"); got != 100 {
+		t.Fatalf("refunded = %v, want 100", got)
+	}
+	if got := f.repo.paymentStatus(1); got != "refunded" {
+		t.Fatalf("payment status = %q, want refunded", got)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Сколько записей истории цен отдаётся по умолчанию и максимум
+const (
+	defaultPriceHistoryLimit = 50
+	maxPriceHistoryLimit     = 500
+)
+
+var (
+	ErrInvalidScheduledPrice  = errors.New("price must be positive")
+	ErrInvalidOldPrice        = errors.New("old price must be greater than the new price")
+	ErrScheduledPriceInPast   = errors.New("starts_at must be in the future")
+	ErrScheduledPriceNotFound = errors.New("scheduled price not found or already applied")
+)
+
+type PriceServiceInterface interface {
+	GetHistory(ctx context.Context, productID, limit int) ([]models.PriceHistoryEntry, error)
+	Schedule(ctx context.Context, productID int, req models.ScheduledPriceRequest, userID int) (*models.ScheduledPrice, error)
+	GetScheduled(ctx context.Context, productID int, includeApplied bool) ([]models.ScheduledPrice, error)
+	CancelScheduled(ctx context.Context, productID, id int) error
+	ApplyDue(ctx context.Context) (int, error)
+	RunScheduler(ctx context.Context, interval time.Duration)
+}
+
+type PriceService struct {
+	repo     repositories.PriceRepository
+	products repositories.ProductRepository
+	cache    *cache.RedisCache
+	logger   *zap.Logger
+}
+
+func NewPriceService(repo repositories.PriceRepository, products repositories.ProductRepository, cache *cache.RedisCache, logger *zap.Logger) *PriceService {
+	return &PriceService{repo: repo, products: products, cache: cache, logger: logger}
+}
+
+// adminPriceChange — изменение цены администратором через API
+func adminPriceChange(userID int) models.PriceChange {
+	change := models.PriceChange{Source: models.PriceSourceAdmin}
+	if userID > 0 {
+		change.ChangedBy = &userID
+	}
+	return change
+}
+
+func (s *PriceService) GetHistory(ctx context.Context, productID, limit int) ([]models.PriceHistoryEntry, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultPriceHistoryLimit
+	}
+	if limit > maxPriceHistoryLimit {
+		limit = maxPriceHistoryLimit
+	}
+
+	history, err := s.repo.GetHistory(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return []models.PriceHistoryEntry{}, nil
+	}
+	return history, nil
+}
+
+// Schedule планирует смену цены. Чтобы провести акцию, планируются две записи:
+// цена со скидкой (с old_price) на начало и обычная цена на окончание.
+func (s *PriceService) Schedule(ctx context.Context, productID int, req models.ScheduledPriceRequest, userID int) (*models.ScheduledPrice, error) {
+	if req.Price <= 0 {
+		return nil, ErrInvalidScheduledPrice
+	}
+	if req.OldPrice != nil && *req.OldPrice <= req.Price {
+		return nil, ErrInvalidOldPrice
+	}
+	if !req.StartsAt.After(time.Now()) {
+		return nil, ErrScheduledPriceInPast
+	}
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	price := &models.ScheduledPrice{
+		ProductID: productID,
+		Price:     req.Price,
+		OldPrice:  req.OldPrice,
+		StartsAt:  req.StartsAt.UTC(),
+	}
+	if userID > 0 {
+		price.CreatedBy = &userID
+	}
+	if err := s.repo.CreateScheduled(ctx, price); err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+func (s *PriceService) GetScheduled(ctx context.Context, productID int, includeApplied bool) ([]models.ScheduledPrice, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	prices, err := s.repo.GetScheduled(ctx, productID, includeApplied)
+	if err != nil {
+		return nil, err
+	}
+	if prices == nil {
+		return []models.ScheduledPrice{}, nil
+	}
+	return prices, nil
+}
+
+func (s *PriceService) CancelScheduled(ctx context.Context, productID, id int) error {
+	deleted, err := s.repo.DeleteScheduled(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrScheduledPriceNotFound
+	}
+	return nil
+}
+
+// ApplyDue применяет наступившие изменения цен и сбрасывает кэш затронутых товаров
+func (s *PriceService) ApplyDue(ctx context.Context) (int, error) {
+	productIDs, err := s.repo.ApplyDue(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	if len(productIDs) == 0 {
+		return 0, nil
+	}
+
+	s.cache.ClearPrefix(ctx, "products:")
+	for _, id := range productIDs {
+		s.cache.Delete(ctx, fmt.Sprintf("product:%d", id))
+	}
+
+	tracing.Logger(ctx, s.logger).Info("scheduled prices applied", zap.Ints("product_ids", productIDs))
+	return len(productIDs), nil
+}
+
+// RunScheduler периодически применяет отложенные изменения цен до отмены ctx
+func (s *PriceService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ApplyDue(ctx); err != nil {
+				tracing.Logger(ctx, s.logger).Error("failed to apply scheduled prices", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *PriceService) checkProduct(ctx context.Context, productID int) error {
+	_, err := s.products.GetByID(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	return err
+}
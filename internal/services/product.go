@@ -3,65 +3,52 @@ package services
 import (
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"math"
 	"strings"
 )
 
 type ProductServiceInterface interface {
-	GetAll() ([]models.Product, error)
-	GetByID(id int) (*models.ProductResponse, error)
-	AddProduct(product *models.Product) error
-	UpdateProduct(id int, product *models.Product) (*models.ProductResponse, error)
-	DeleteProduct(id int) error
-	GetFiltered(
-		search, category string,
-		minPrice, maxPrice float64,
-		limit, offset int,
-		sort string,
-		availability *bool,
-	) ([]models.ProductResponse, error)
-	GetFilteredRaw(
-		search, category string,
-		minPrice, maxPrice float64,
-		limit, offset int,
-		sort string,
-		availability *bool,
-	) ([]models.Product, error)
-	GetCategoryNameByID(id int) (string, error)
-	AddProductsBulk(products []models.Product) ([]models.ProductResponse, error)
-	PatchProduct(id int, updates map[string]interface{}) error
-	GetPaginated(
-		search, category string,
-		minPrice, maxPrice float64,
-		limit, offset int,
-		sort string,
-		availability *bool,
-	) ([]models.ProductResponse, int, error)
-	CountFiltered(
-		search, category string,
-		minPrice, maxPrice float64,
-		availability *bool,
-	) (int, error)
-	IsProductNameExists(name string) (bool, error)
+	GetAll(ctx context.Context) ([]models.Product, error)
+	GetByID(ctx context.Context, id int) (*models.ProductResponse, error)
+	AddProduct(ctx context.Context, product *models.Product) error
+	UpdateProduct(ctx context.Context, id int, product *models.Product, userID int) (*models.ProductResponse, error)
+	DeleteProduct(ctx context.Context, id int) error
+	GetFiltered(ctx context.Context, filter models.ProductFilter) ([]models.ProductResponse, error)
+	GetFilteredRaw(ctx context.Context, filter models.ProductFilter) ([]models.Product, error)
+	GetCategoryNameByID(ctx context.Context, id int) (string, error)
+	AddProductsBulk(ctx context.Context, products []models.Product) ([]models.ProductResponse, error)
+	PatchProduct(ctx context.Context, id int, updates map[string]interface{}, userID int) error
+	GetPaginated(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error)
+	CountFiltered(ctx context.Context, filter models.ProductFilter) (int, error)
+	IsProductNameExists(ctx context.Context, name string) (bool, error)
+	SyncExternal(ctx context.Context, item models.ExternalProduct) (*models.Product, bool, error)
+	UpdateOffer(ctx context.Context, externalID, sku string, price *float64, quantity *float64) (bool, error)
+	GetExternalIDs(ctx context.Context, ids []int) (map[int]string, error)
 }
 
 type ProductService struct {
 	repo   repositories.ProductRepository
+	images ProductImageServiceInterface
 	logger *zap.Logger
 }
 
-func NewProductService(repo repositories.ProductRepository, logger *zap.Logger) *ProductService {
-	return &ProductService{repo: repo, logger: logger}
+func NewProductService(repo repositories.ProductRepository, images ProductImageServiceInterface, logger *zap.Logger) *ProductService {
+	return &ProductService{repo: repo, images: images, logger: logger}
 }
 
-func (s *ProductService) GetCategoryNameByID(id int) (string, error) {
-	return s.repo.GetCategoryNameByID(id)
+func (s *ProductService) GetCategoryNameByID(ctx context.Context, id int) (string, error) {
+	return s.repo.GetCategoryNameByID(ctx, id)
 }
 
-func (s *ProductService) GetAll() ([]models.Product, error) {
-	products, err := s.repo.GetAll()
+func (s *ProductService) GetAll(ctx context.Context) ([]models.Product, error) {
+	products, err := s.repo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +58,8 @@ func (s *ProductService) GetAll() ([]models.Product, error) {
 	return products, nil
 }
 
-func (s *ProductService) GetByID(id int) (*models.ProductResponse, error) {
-	product, err := s.repo.GetByID(id)
+func (s *ProductService) GetByID(ctx context.Context, id int) (*models.ProductResponse, error) {
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch product: %w", err)
 	}
@@ -82,7 +69,7 @@ func (s *ProductService) GetByID(id int) (*models.ProductResponse, error) {
 
 	var categoryName string
 	if product.CategoryID.Valid {
-		categoryName, err = s.repo.GetCategoryNameByID(int(product.CategoryID.Int64))
+		categoryName, err = s.repo.GetCategoryNameByID(ctx, int(product.CategoryID.Int64))
 		if err != nil {
 			categoryName = ""
 		}
@@ -91,22 +78,30 @@ func (s *ProductService) GetByID(id int) (*models.ProductResponse, error) {
 	}
 
 	response := utils.BuildProductResponse(product, categoryName)
+
+	images, err := s.images.GetByProductID(ctx, product.ID)
+	if err != nil {
+		tracing.Logger(ctx, s.logger).Warn("failed to fetch product images", zap.Int("product_id", product.ID), zap.Error(err))
+	} else {
+		response.Images = images
+	}
+
 	return &response, nil
 }
 
-func (s *ProductService) AddProduct(product *models.Product) error {
+func (s *ProductService) AddProduct(ctx context.Context, product *models.Product) error {
 	if err := validateProduct(product); err != nil {
 		return err
 	}
-	return s.repo.Create(product)
+	return s.repo.Create(ctx, product)
 }
 
-func (s *ProductService) UpdateProduct(id int, product *models.Product) (*models.ProductResponse, error) {
+func (s *ProductService) UpdateProduct(ctx context.Context, id int, product *models.Product, userID int) (*models.ProductResponse, error) {
 	if err := validateProduct(product); err != nil {
 		return nil, err
 	}
 
-	tx, err := s.repo.BeginTx()
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -117,12 +112,12 @@ func (s *ProductService) UpdateProduct(id int, product *models.Product) (*models
 		}
 	}()
 
-	if err := s.repo.UpdateTx(tx, id, product); err != nil {
+	if err := s.repo.UpdateTx(ctx, tx, id, product, adminPriceChange(userID)); err != nil {
 		_ = tx.Rollback()
 		return nil, err
 	}
 
-	updated, err := s.repo.GetByIDTx(tx, id)
+	updated, err := s.repo.GetByIDTx(ctx, tx, id)
 	if err != nil || updated == nil {
 		_ = tx.Rollback()
 		return nil, fmt.Errorf("failed to fetch updated product")
@@ -130,7 +125,7 @@ func (s *ProductService) UpdateProduct(id int, product *models.Product) (*models
 
 	var categoryName string
 	if updated.CategoryID.Valid {
-		categoryName, err = s.repo.GetCategoryNameByIDTx(tx, int(updated.CategoryID.Int64))
+		categoryName, err = s.repo.GetCategoryNameByIDTx(ctx, tx, int(updated.CategoryID.Int64))
 		if err != nil {
 			categoryName = ""
 		}
@@ -149,24 +144,19 @@ func validateProduct(p *models.Product) error {
 	if strings.TrimSpace(p.Name) == "" {
 		return fmt.Errorf("product name is required")
 	}
-	if p.Price <= 0 {
+	if math.IsNaN(p.Price) || math.IsInf(p.Price, 0) || p.Price <= 0 {
 		return fmt.Errorf("product price must be positive")
 	}
 	return nil
 }
 
-func (s *ProductService) DeleteProduct(id int) error {
-	return s.repo.Delete(id)
+func (s *ProductService) DeleteProduct(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
 
-func (s *ProductService) GetFiltered(
-	search, category string,
-	minPrice, maxPrice float64,
-	limit, offset int,
-	sort string,
-	availability *bool,
-) ([]models.ProductResponse, error) {
-	products, err := s.repo.GetFiltered(search, category, minPrice, maxPrice, limit, offset, sort, availability)
+func (s *ProductService) GetFiltered(ctx context.Context, filter models.ProductFilter) ([]models.ProductResponse, error) {
+	filter.Normalize()
+	products, _, err := s.repo.GetFiltered(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +165,7 @@ func (s *ProductService) GetFiltered(
 	for _, p := range products {
 		var categoryName string
 		if p.CategoryID.Valid {
-			categoryName, err = s.repo.GetCategoryNameByID(int(p.CategoryID.Int64))
+			categoryName, err = s.repo.GetCategoryNameByID(ctx, int(p.CategoryID.Int64))
 			if err != nil {
 				categoryName = ""
 			}
@@ -190,20 +180,16 @@ func (s *ProductService) GetFiltered(
 	return result, nil
 }
 
-func (s *ProductService) GetFilteredRaw(
-	search, category string,
-	minPrice, maxPrice float64,
-	limit, offset int,
-	sort string,
-	availability *bool,
-) ([]models.Product, error) {
-	return s.repo.GetFiltered(search, category, minPrice, maxPrice, limit, offset, sort, availability)
+func (s *ProductService) GetFilteredRaw(ctx context.Context, filter models.ProductFilter) ([]models.Product, error) {
+	filter.Normalize()
+	products, _, err := s.repo.GetFiltered(ctx, filter)
+	return products, err
 }
 
-func (s *ProductService) AddProductsBulk(products []models.Product) ([]models.ProductResponse, error) {
+func (s *ProductService) AddProductsBulk(ctx context.Context, products []models.Product) ([]models.ProductResponse, error) {
 	var responses []models.ProductResponse
 
-	tx, err := s.repo.BeginTx()
+	tx, err := s.repo.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
@@ -220,14 +206,14 @@ func (s *ProductService) AddProductsBulk(products []models.Product) ([]models.Pr
 			return nil, err
 		}
 
-		existing, err := s.repo.GetByNameTx(tx, p.Name)
+		existing, err := s.repo.GetByNameTx(ctx, tx, p.Name)
 		if err == nil && existing != nil {
 			existing.Availability = p.Availability
-			if err := s.repo.UpdateAvailabilityTx(tx, existing.ID, p.Availability); err != nil {
+			if err := s.repo.UpdateAvailabilityTx(ctx, tx, existing.ID, p.Availability); err != nil {
 				_ = tx.Rollback()
 				return nil, err
 			}
-			updated, err := s.repo.GetByIDTx(tx, existing.ID)
+			updated, err := s.repo.GetByIDTx(ctx, tx, existing.ID)
 			if err != nil {
 				_ = tx.Rollback()
 				return nil, err
@@ -235,7 +221,7 @@ func (s *ProductService) AddProductsBulk(products []models.Product) ([]models.Pr
 
 			var categoryName string
 			if updated.CategoryID.Valid {
-				categoryName, _ = s.repo.GetCategoryNameByIDTx(tx, int(updated.CategoryID.Int64))
+				categoryName, _ = s.repo.GetCategoryNameByIDTx(ctx, tx, int(updated.CategoryID.Int64))
 			}
 
 			response := utils.BuildProductResponse(updated, categoryName)
@@ -244,14 +230,14 @@ func (s *ProductService) AddProductsBulk(products []models.Product) ([]models.Pr
 			continue
 		}
 
-		if err := s.repo.CreateTx(tx, &p); err != nil {
+		if err := s.repo.CreateTx(ctx, tx, &p); err != nil {
 			_ = tx.Rollback()
 			return nil, err
 		}
 
 		var categoryName string
 		if p.CategoryID.Valid {
-			categoryName, _ = s.repo.GetCategoryNameByIDTx(tx, int(p.CategoryID.Int64))
+			categoryName, _ = s.repo.GetCategoryNameByIDTx(ctx, tx, int(p.CategoryID.Int64))
 		}
 
 		response := utils.BuildProductResponse(&p, categoryName)
@@ -266,17 +252,18 @@ func (s *ProductService) AddProductsBulk(products []models.Product) ([]models.Pr
 	return responses, nil
 }
 
-func (s *ProductService) GetAverageRating(productID int) (float64, error) {
-	rating, err := s.repo.GetAverageRating(productID)
+func (s *ProductService) GetAverageRating(ctx context.Context, productID int) (float64, error) {
+	rating, err := s.repo.GetAverageRating(ctx, productID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch average rating: %w", err)
 	}
 	return rating, nil
 }
 
-func (s *ProductService) PatchProduct(id int, updates map[string]interface{}) error {
+func (s *ProductService) PatchProduct(ctx context.Context, id int, updates map[string]interface{}, userID int) error {
 	patch := buildProductPatch(updates)
-	return s.repo.PatchProduct(id, patch)
+	patch.PriceChange = adminPriceChange(userID)
+	return s.repo.PatchProduct(ctx, id, patch)
 }
 
 func buildProductPatch(updates map[string]interface{}) models.ProductPatch {
@@ -285,12 +272,18 @@ func buildProductPatch(updates map[string]interface{}) models.ProductPatch {
 	if v, ok := updates["name"].(string); ok {
 		patch.Name = &v
 	}
+	if v, ok := updates["sku"].(string); ok {
+		patch.SKU = &v
+	}
 	if v, ok := updates["description"].(string); ok {
 		patch.Description = &v
 	}
 	if v, ok := updates["price"].(float64); ok {
 		patch.Price = &v
 	}
+	if v, ok := updates["old_price"].(float64); ok {
+		patch.OldPrice = &v
+	}
 	if v, ok := updates["availability"].(bool); ok {
 		patch.Availability = &v
 	}
@@ -305,34 +298,152 @@ func buildProductPatch(updates map[string]interface{}) models.ProductPatch {
 	return patch
 }
 
-func (s *ProductService) GetPaginated(
-	search, category string,
-	minPrice, maxPrice float64,
-	limit, offset int,
-	sort string,
-	availability *bool,
-) ([]models.ProductResponse, int, error) {
-	products, err := s.GetFiltered(search, category, minPrice, maxPrice, limit, offset, sort, availability)
+// GetPaginated возвращает страницу каталога вместе с общим количеством,
+// курсором следующей страницы и фасетами
+func (s *ProductService) GetPaginated(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error) {
+	filter.Normalize()
+
+	products, next, err := s.repo.GetFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountFiltered(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	facets, err := s.repo.GetFacets(ctx, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	page := &models.ProductPage{
+		Items:  models.ConvertProductsToCache(products),
+		Total:  total,
+		Facets: facets,
+	}
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+	return page, nil
+}
+
+func (s *ProductService) CountFiltered(ctx context.Context, filter models.ProductFilter) (int, error) {
+	filter.Normalize()
+	return s.repo.CountFiltered(ctx, filter)
+}
+
+func (s *ProductService) IsProductNameExists(ctx context.Context, name string) (bool, error) {
+	return s.repo.IsProductNameExists(ctx, name)
+}
+
+// findExternal ищет товар из учётной системы: по её идентификатору, затем по артикулу
+func (s *ProductService) findExternal(ctx context.Context, externalID, sku string) (*models.Product, error) {
+	if externalID != "" {
+		p, err := s.repo.GetByExternalID(ctx, externalID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return p, err
+		}
+	}
+	if sku != "" {
+		p, err := s.repo.GetBySKU(ctx, sku)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return p, err
+		}
 	}
+	return nil, nil
+}
 
-	total, err := s.repo.CountFiltered(search, category, minPrice, maxPrice, availability)
+// SyncExternal создаёт или обновляет товар по данным учётной системы.
+// Новый товар создаётся скрытым: цена и остаток приходят отдельно (UpdateOffer).
+func (s *ProductService) SyncExternal(ctx context.Context, item models.ExternalProduct) (*models.Product, bool, error) {
+	existing, err := s.findExternal(ctx, item.ExternalID, item.SKU)
 	if err != nil {
-		return nil, 0, err
+		return nil, false, err
+	}
+	if existing == nil && item.Name != "" {
+		existing, err = s.repo.GetByName(ctx, item.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			existing, err = nil, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if existing == nil {
+		if item.Deleted {
+			return nil, false, nil
+		}
+		product := &models.Product{
+			Name:        item.Name,
+			Description: item.Description,
+			SKU:         sql.NullString{String: item.SKU, Valid: item.SKU != ""},
+			ExternalID:  sql.NullString{String: item.ExternalID, Valid: item.ExternalID != ""},
+		}
+		if item.CategoryID != nil {
+			product.CategoryID = sql.NullInt64{Int64: int64(*item.CategoryID), Valid: true}
+		}
+		if strings.TrimSpace(product.Name) == "" {
+			return nil, false, fmt.Errorf("product name is required")
+		}
+		if err := s.repo.Create(ctx, product); err != nil {
+			return nil, false, err
+		}
+		return product, true, nil
 	}
 
-	return products, total, nil
+	var patch models.ProductPatch
+	if item.Name != "" && item.Name != existing.Name {
+		patch.Name = &item.Name
+	}
+	if item.Description != "" && item.Description != existing.Description {
+		patch.Description = &item.Description
+	}
+	if item.SKU != "" && item.SKU != existing.SKU.String {
+		patch.SKU = &item.SKU
+	}
+	if item.CategoryID != nil && (!existing.CategoryID.Valid || int(existing.CategoryID.Int64) != *item.CategoryID) {
+		patch.CategoryID = item.CategoryID
+	}
+	if item.Deleted && existing.Availability {
+		unavailable := false
+		patch.Availability = &unavailable
+	}
+	if err := s.repo.PatchProduct(ctx, existing.ID, patch); err != nil {
+		return nil, false, err
+	}
+	if item.ExternalID != "" && existing.ExternalID.String != item.ExternalID {
+		if err := s.repo.SetExternalID(ctx, existing.ID, item.ExternalID); err != nil {
+			return nil, false, err
+		}
+	}
+	return existing, false, nil
 }
 
-func (s *ProductService) CountFiltered(
-	search, category string,
-	minPrice, maxPrice float64,
-	availability *bool,
-) (int, error) {
-	return s.repo.CountFiltered(search, category, minPrice, maxPrice, availability)
+// UpdateOffer обновляет цену и наличие товара из учётной системы.
+// Возвращает false, если товар не найден.
+func (s *ProductService) UpdateOffer(ctx context.Context, externalID, sku string, price *float64, quantity *float64) (bool, error) {
+	product, err := s.findExternal(ctx, externalID, sku)
+	if err != nil || product == nil {
+		return false, err
+	}
+
+	var patch models.ProductPatch
+	if price != nil && *price > 0 && *price != product.Price {
+		patch.Price = price
+		patch.PriceChange = models.PriceChange{Source: models.PriceSource1C}
+	}
+	if quantity != nil {
+		available := *quantity > 0
+		if available != product.Availability {
+			patch.Availability = &available
+		}
+	}
+	return true, s.repo.PatchProduct(ctx, product.ID, patch)
 }
 
-func (s *ProductService) IsProductNameExists(name string) (bool, error) {
-	return s.repo.IsProductNameExists(name)
+func (s *ProductService) GetExternalIDs(ctx context.Context, ids []int) (map[int]string, error) {
+	return s.repo.GetExternalIDs(ctx, ids)
 }
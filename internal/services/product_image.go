@@ -0,0 +1,244 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/imaging"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+)
+
+var (
+	ErrImageNotFound      = errors.New("image not found")
+	ErrInvalidImagesOrder = errors.New("image list must contain every product image exactly once")
+)
+
+type ProductImageServiceInterface interface {
+	Upload(ctx context.Context, r io.Reader) (*models.UploadedImage, error)
+	Store(ctx context.Context, r io.Reader) (*models.ProductImage, error)
+	AddToProduct(ctx context.Context, productID int, r io.Reader) (*models.ProductImage, error)
+	GetByProductID(ctx context.Context, productID int) ([]models.ProductImage, error)
+	SetPrimary(ctx context.Context, productID, imageID int) error
+	Reorder(ctx context.Context, productID int, imageIDs []int) ([]models.ProductImage, error)
+	Delete(ctx context.Context, productID, imageID int) error
+	PublicURL(name string) string
+	MaxUploadSize() int64
+}
+
+type ProductImageService struct {
+	repo    repositories.ProductImageRepository
+	files   FileServiceInterface
+	maxSize int64
+	logger  *zap.Logger
+}
+
+func NewProductImageService(repo repositories.ProductImageRepository, files FileServiceInterface, cfg *config.Config, logger *zap.Logger) *ProductImageService {
+	return &ProductImageService{
+		repo:    repo,
+		files:   files,
+		maxSize: int64(cfg.UploadMaxSizeMB) << 20,
+		logger:  logger,
+	}
+}
+
+// PublicURL собирает ссылку на загруженный файл.
+// Полные ссылки (например, перенесённые из products.url) возвращаются как есть.
+func (s *ProductImageService) PublicURL(name string) string {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return name
+	}
+	return s.files.URL(name)
+}
+
+func (s *ProductImageService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+// Upload обрабатывает изображение и сохраняет все его варианты, не привязывая к товару
+func (s *ProductImageService) Upload(ctx context.Context, r io.Reader) (*models.UploadedImage, error) {
+	img, err := s.processAndStore(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UploadedImage{
+		URL:       img.URL,
+		MediumURL: img.MediumURL,
+		ThumbURL:  img.ThumbURL,
+		Width:     img.Width,
+		Height:    img.Height,
+	}, nil
+}
+
+// Store обрабатывает и сохраняет изображение, возвращая имена файлов всех вариантов.
+// Используется там, где изображение привязывается не к товару (например, фото отзывов).
+func (s *ProductImageService) Store(ctx context.Context, r io.Reader) (*models.ProductImage, error) {
+	return s.processAndStore(ctx, r)
+}
+
+func (s *ProductImageService) AddToProduct(ctx context.Context, productID int, r io.Reader) (*models.ProductImage, error) {
+	img, err := s.processAndStore(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	img.ProductID = productID
+
+	existing, err := s.repo.GetByProductID(ctx, productID)
+	if err != nil {
+		s.removeFiles(ctx, img)
+		return nil, fmt.Errorf("failed to fetch product images: %w", err)
+	}
+
+	img.SortOrder, err = s.repo.NextSortOrder(ctx, productID)
+	if err != nil {
+		s.removeFiles(ctx, img)
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, img); err != nil {
+		s.removeFiles(ctx, img)
+		return nil, err
+	}
+
+	// Первое изображение товара автоматически становится основным
+	if len(existing) == 0 {
+		if err := s.repo.SetPrimary(ctx, productID, img.ID, img.URL); err != nil {
+			return nil, err
+		}
+		img.IsPrimary = true
+	}
+
+	return img, nil
+}
+
+func (s *ProductImageService) GetByProductID(ctx context.Context, productID int) ([]models.ProductImage, error) {
+	images, err := s.repo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if images == nil {
+		return []models.ProductImage{}, nil
+	}
+	for i := range images {
+		s.fillURLs(&images[i])
+	}
+	return images, nil
+}
+
+func (s *ProductImageService) SetPrimary(ctx context.Context, productID, imageID int) error {
+	img, err := s.getProductImage(ctx, productID, imageID)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetPrimary(ctx, productID, img.ID, img.URL)
+}
+
+func (s *ProductImageService) Reorder(ctx context.Context, productID int, imageIDs []int) ([]models.ProductImage, error) {
+	current, err := s.repo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) != len(imageIDs) {
+		return nil, ErrInvalidImagesOrder
+	}
+	known := make(map[int]bool, len(current))
+	for _, img := range current {
+		known[img.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !known[id] {
+			return nil, ErrInvalidImagesOrder
+		}
+		delete(known, id)
+	}
+
+	if err := s.repo.UpdateSortOrder(ctx, productID, imageIDs); err != nil {
+		return nil, err
+	}
+	return s.GetByProductID(ctx, productID)
+}
+
+func (s *ProductImageService) Delete(ctx context.Context, productID, imageID int) error {
+	img, err := s.getProductImage(ctx, productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, img.ID); err != nil {
+		return err
+	}
+	s.removeFiles(ctx, img)
+
+	if !img.IsPrimary {
+		return nil
+	}
+
+	// Удалили основное изображение — назначаем следующее по порядку
+	rest, err := s.GetByProductID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return s.repo.ClearProductURL(ctx, productID)
+	}
+	return s.repo.SetPrimary(ctx, productID, rest[0].ID, rest[0].URL)
+}
+
+func (s *ProductImageService) getProductImage(ctx context.Context, productID, imageID int) (*models.ProductImage, error) {
+	img, err := s.repo.GetByID(ctx, imageID)
+	if err != nil || img.ProductID != productID {
+		return nil, ErrImageNotFound
+	}
+	s.fillURLs(img)
+	return img, nil
+}
+
+func (s *ProductImageService) processAndStore(ctx context.Context, r io.Reader) (*models.ProductImage, error) {
+	processed, err := imaging.Process(r, s.maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &models.ProductImage{
+		Width:  processed.Original.Width,
+		Height: processed.Original.Height,
+	}
+
+	variants := []struct {
+		variant imaging.Variant
+		key     *string
+	}{
+		{processed.Original, &img.FileName},
+		{processed.Medium, &img.MediumFileName},
+		{processed.Thumb, &img.ThumbFileName},
+	}
+	for _, v := range variants {
+		key, err := s.files.Store(ctx, v.variant.Data, v.variant.Ext, v.variant.ContentType)
+		if err != nil {
+			s.removeFiles(ctx, img)
+			return nil, fmt.Errorf("failed to save image: %w", err)
+		}
+		*v.key = key
+	}
+
+	s.fillURLs(img)
+	return img, nil
+}
+
+// removeFiles удаляет файлы изображения, если они больше не используются
+func (s *ProductImageService) removeFiles(ctx context.Context, img *models.ProductImage) {
+	for _, key := range []string{img.FileName, img.MediumFileName, img.ThumbFileName} {
+		s.files.DeleteIfUnreferenced(ctx, key)
+	}
+}
+
+func (s *ProductImageService) fillURLs(img *models.ProductImage) {
+	img.URL = s.PublicURL(img.FileName)
+	img.MediumURL = s.PublicURL(img.MediumFileName)
+	img.ThumbURL = s.PublicURL(img.ThumbFileName)
+}
@@ -3,8 +3,11 @@ package services
 import (
 	"bytes"
 	"chechnya-product/config"
+	"chechnya-product/internal/metrics"
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SherClockHolmes/webpush-go"
@@ -18,11 +21,12 @@ var base64URLRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
 var base64urlPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
 
 type PushServiceInterface interface {
-	SendPush(sub webpush.Subscription, message string) error
-	Broadcast(message string) error
-	DeleteByEndpoint(endpoint string) error
-	SendPushToAdmins(message string) error
-	SaveSubscription(sub webpush.Subscription, isAdmin bool) error
+	SendPush(ctx context.Context, sub webpush.Subscription, message string) error
+	Broadcast(ctx context.Context, message string) error
+	DeleteByEndpoint(ctx context.Context, endpoint string) error
+	SendPushToAdmins(ctx context.Context, message string) error
+	SendPushToUser(ctx context.Context, userID int, message string) error
+	SaveSubscription(ctx context.Context, sub webpush.Subscription, isAdmin bool, userID int) error
 }
 
 type PushService struct {
@@ -35,7 +39,8 @@ func NewPushService(repo repositories.PushRepositoryInterface, logger *zap.Logge
 	return &PushService{repo: repo, logger: logger, cfg: cfg}
 }
 
-func (s *PushService) SaveSubscription(sub webpush.Subscription, isAdmin bool) error {
+// SaveSubscription сохраняет подписку; userID — авторизованный пользователь или 0 для гостя
+func (s *PushService) SaveSubscription(ctx context.Context, sub webpush.Subscription, isAdmin bool, userID int) error {
 	// Проверка: ключи не пустые
 	if sub.Keys.P256dh == "" || sub.Keys.Auth == "" {
 		return errors.New("ключи подписки отсутствуют")
@@ -43,7 +48,7 @@ func (s *PushService) SaveSubscription(sub webpush.Subscription, isAdmin bool) e
 
 	// Проверка: формат base64url
 	if !base64urlPattern.MatchString(sub.Keys.P256dh) || !base64urlPattern.MatchString(sub.Keys.Auth) {
-		s.logger.Warn("❌ Ключи не в формате base64url",
+		tracing.Logger(ctx, s.logger).Warn("❌ Ключи не в формате base64url",
 			zap.String("p256dh", sub.Keys.P256dh),
 			zap.String("auth", sub.Keys.Auth),
 		)
@@ -56,28 +61,32 @@ func (s *PushService) SaveSubscription(sub webpush.Subscription, isAdmin bool) e
 	}
 
 	// Сохраняем подписку
-	err := s.repo.SaveSubscription(models.Subscription{
+	subscription := models.Subscription{
 		Endpoint: sub.Endpoint,
 		P256dh:   sub.Keys.P256dh,
 		Auth:     sub.Keys.Auth,
 		IsAdmin:  isAdmin,
-	})
+	}
+	if userID != 0 {
+		subscription.UserID = &userID
+	}
+	err := s.repo.SaveSubscription(ctx, subscription)
 	if err != nil {
-		s.logger.Warn("❗ Не удалось сохранить подписку", zap.Error(err))
+		tracing.Logger(ctx, s.logger).Warn("❗ Не удалось сохранить подписку", zap.Error(err))
 		return err
 	}
 
 	return nil
 }
 
-func (s *PushService) SendPush(sub webpush.Subscription, message string) error {
+func (s *PushService) SendPush(ctx context.Context, sub webpush.Subscription, message string) error {
 	// Подготавливаем payload
 	payload, _ := json.Marshal(map[string]string{
 		"title": "Новое сообщение",
 		"body":  message,
 	})
 
-	s.logger.Debug("📦 Отправка пуша",
+	tracing.Logger(ctx, s.logger).Debug("📦 Отправка пуша",
 		zap.String("endpoint", sub.Endpoint),
 		zap.Int("p256dh_len", len(sub.Keys.P256dh)),
 		zap.Int("auth_len", len(sub.Keys.Auth)),
@@ -91,11 +100,14 @@ func (s *PushService) SendPush(sub webpush.Subscription, message string) error {
 	})
 
 	if err != nil {
-		s.logger.Error("❌ Webpush ошибка", zap.String("body", err.Error()))
+		tracing.Logger(ctx, s.logger).Error("❌ Webpush ошибка", zap.String("body", err.Error()))
 
 		if strings.Contains(err.Error(), "unsubscribed") || strings.Contains(err.Error(), "expired") {
-			_ = s.repo.DeleteByEndpoint(sub.Endpoint)
-			s.logger.Info("🗑️ Удалена неактивная подписка", zap.String("endpoint", sub.Endpoint))
+			_ = s.repo.DeleteByEndpoint(ctx, sub.Endpoint)
+			tracing.Logger(ctx, s.logger).Info("🗑️ Удалена неактивная подписка", zap.String("endpoint", sub.Endpoint))
+			metrics.PushNotifications.WithLabelValues("expired").Inc()
+		} else {
+			metrics.PushNotifications.WithLabelValues("failed").Inc()
 		}
 
 		return err
@@ -106,18 +118,20 @@ func (s *PushService) SendPush(sub webpush.Subscription, message string) error {
 	buf.ReadFrom(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		s.logger.Error("📛 Webpush ошибка",
+		tracing.Logger(ctx, s.logger).Error("📛 Webpush ошибка",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("body", buf.String()),
 		)
+		metrics.PushNotifications.WithLabelValues("failed").Inc()
 		return errors.New("web push failed")
 	}
 
+	metrics.PushNotifications.WithLabelValues("sent").Inc()
 	return nil
 }
 
-func (s *PushService) Broadcast(message string) error {
-	subs, err := s.repo.GetAllSubscriptions()
+func (s *PushService) Broadcast(ctx context.Context, message string) error {
+	subs, err := s.repo.GetAllSubscriptions(ctx)
 	if err != nil {
 		return err
 	}
@@ -129,16 +143,16 @@ func (s *PushService) Broadcast(message string) error {
 				Auth:   sub.Auth,
 			},
 		}
-		_ = s.SendPush(webSub, message)
+		_ = s.SendPush(ctx, webSub, message)
 	}
 	return nil
 }
-func (s *PushService) DeleteByEndpoint(endpoint string) error {
-	return s.repo.DeleteByEndpoint(endpoint)
+func (s *PushService) DeleteByEndpoint(ctx context.Context, endpoint string) error {
+	return s.repo.DeleteByEndpoint(ctx, endpoint)
 }
 
-func (s *PushService) SendPushToAdmins(message string) error {
-	subs, err := s.repo.GetAllSubscriptions()
+func (s *PushService) SendPushToAdmins(ctx context.Context, message string) error {
+	subs, err := s.repo.GetAllSubscriptions(ctx)
 	if err != nil {
 		return err
 	}
@@ -161,10 +175,10 @@ func (s *PushService) SendPushToAdmins(message string) error {
 			},
 		}
 
-		err := s.SendPush(webSub, message)
+		err := s.SendPush(ctx, webSub, message)
 		if err != nil {
 			failCount++
-			s.logger.Warn("❌ Ошибка отправки админу",
+			tracing.Logger(ctx, s.logger).Warn("❌ Ошибка отправки админу",
 				zap.String("endpoint", sub.Endpoint),
 				zap.Error(err))
 		} else {
@@ -172,10 +186,36 @@ func (s *PushService) SendPushToAdmins(message string) error {
 		}
 	}
 
-	s.logger.Info("📨 Push отправлен администраторам",
+	tracing.Logger(ctx, s.logger).Info("📨 Push отправлен администраторам",
 		zap.Int("admins", adminCount),
 		zap.Int("успешно", successCount),
 		zap.Int("ошибки", failCount),
 	)
 	return nil
 }
+
+// SendPushToUser отправляет уведомление на все устройства пользователя.
+// Возвращает ошибку, только если не удалось получить подписки; сбои отдельных устройств пишутся в журнал.
+func (s *PushService) SendPushToUser(ctx context.Context, userID int, message string) error {
+	subs, err := s.repo.GetUserSubscriptions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		webSub := webpush.Subscription{
+			Endpoint: sub.Endpoint,
+			Keys: webpush.Keys{
+				P256dh: sub.P256dh,
+				Auth:   sub.Auth,
+			},
+		}
+		if err := s.SendPush(ctx, webSub, message); err != nil {
+			tracing.Logger(ctx, s.logger).Warn("❌ Ошибка отправки пользователю",
+				zap.Int("user_id", userID),
+				zap.String("endpoint", sub.Endpoint),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
@@ -1,49 +1,671 @@
 package services
 
 import (
+	"chechnya-product/config"
 	"chechnya-product/internal/models"
+	"chechnya-product/internal/moderation"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"strings"
+	"time"
 )
 
+var (
+	ErrInvalidRating        = errors.New("rating must be between 1 and 5")
+	ErrReviewAlreadyExists  = errors.New("you have already left a review for this product")
+	ErrReviewNotFound       = errors.New("review not found")
+	ErrInvalidReviewStatus  = errors.New("status must be approved or rejected")
+	ErrRejectReasonRequired = errors.New("rejection reason is required")
+	ErrTooManyReviewPhotos  = errors.New("too many photos attached to the review")
+	ErrReviewPhotoNotFound  = errors.New("review photo not found")
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderNotDelivered    = errors.New("feedback can be left only after the order is delivered")
+	ErrOrderFeedbackExists  = errors.New("feedback for this order has already been left")
+	ErrOrderFeedbackEmpty   = errors.New("feedback must contain at least one rating")
+	ErrProductNotInOrder    = errors.New("product is not part of the order")
+	ErrInvalidPeriod        = errors.New("period must be day, week or month")
+	ErrInvalidDateRange     = errors.New("from must be before to")
+)
+
+// Максимальное количество фото в одном отзыве
+const MaxReviewPhotos = 5
+
+const (
+	// Отчёт по оценкам по умолчанию строится за последние 30 дней
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	// Товар попадает в список худших, только если у него достаточно отзывов
+	lowestRatedMinReviews = 3
+	lowestRatedLimit      = 10
+)
+
+var analyticsPeriods = map[string]bool{"day": true, "week": true, "month": true}
+
 type ReviewServiceInterface interface {
-	AddReview(ownerID string, productID, rating int, comment string) error
-	GetReviewsByProductID(productID int) ([]models.Review, error)
-	UpdateReview(ownerID string, productID, rating int, comment string) error
-	DeleteReview(ownerID string, productID int) error
+	AddReview(ctx context.Context, ownerID string, productID, rating int, comment string) (*models.Review, error)
+	GetReviewsByProductID(ctx context.Context, productID int) ([]models.Review, error)
+	UpdateReview(ctx context.Context, ownerID string, productID, rating int, comment string) (*models.Review, error)
+	DeleteReview(ctx context.Context, ownerID string, productID int) error
+	GetRatingSummary(ctx context.Context, productID int) (*models.ProductRatingSummary, error)
+	AddPhoto(ctx context.Context, ownerID string, productID int, r io.Reader) (*models.ReviewPhoto, error)
+	DeletePhoto(ctx context.Context, ownerID string, productID, photoID int) error
+	MaxPhotoSize() int64
+	GetModerationQueue(ctx context.Context, status string, limit, offset int) ([]models.AdminReview, int, error)
+	Moderate(ctx context.Context, reviewID int, status, reason string, adminID int) (*models.Review, error)
+	Reply(ctx context.Context, reviewID int, reply string) (*models.Review, error)
+	SubmitOrderFeedback(ctx context.Context, orderID int, ownerID string, userID *int, req models.OrderFeedbackRequest) (*models.OrderReview, error)
+	GetOrderFeedback(ctx context.Context, orderID int) (*models.OrderReview, error)
+	GetAllOrderFeedback(ctx context.Context) ([]models.OrderReview, error)
+	GetAnalytics(ctx context.Context, period string, from, to *time.Time) (*models.FeedbackAnalytics, error)
 }
 
 type ReviewService struct {
-	repo repositories.ReviewRepository
+	repo          repositories.ReviewRepository
+	orders        repositories.OrderRepository
+	images        ProductImageServiceInterface
+	files         FileServiceInterface
+	filters       moderation.Chain
+	premoderation bool
+	logger        *zap.Logger
+}
+
+func NewReviewService(
+	repo repositories.ReviewRepository,
+	orders repositories.OrderRepository,
+	images ProductImageServiceInterface,
+	files FileServiceInterface,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *ReviewService {
+	return &ReviewService{
+		repo:          repo,
+		orders:        orders,
+		images:        images,
+		files:         files,
+		filters:       moderation.Default(cfg.ReviewStopWords),
+		premoderation: cfg.ReviewPremoderation,
+		logger:        logger,
+	}
 }
 
-func NewReviewService(repo repositories.ReviewRepository) *ReviewService {
-	return &ReviewService{repo: repo}
+// moderate прогоняет текст через фильтры и решает, можно ли опубликовать отзыв сразу.
+// Отзывы с найденными нарушениями всегда уходят на ручную проверку.
+func (s *ReviewService) moderate(review *models.Review) {
+	review.ModerationNote = nil
+	review.Status = models.ReviewStatusApproved
+
+	if violations := s.filters.Check(review.Comment); len(violations) > 0 {
+		reasons := make([]string, 0, len(violations))
+		for _, v := range violations {
+			reasons = append(reasons, v.Reason)
+		}
+		note := strings.Join(reasons, "; ")
+		review.ModerationNote = &note
+		review.Status = models.ReviewStatusPending
+		return
+	}
+
+	if s.premoderation {
+		review.Status = models.ReviewStatusPending
+	}
 }
 
-func (s *ReviewService) AddReview(ownerID string, productID, rating int, comment string) error {
-	exists, err := s.repo.Exists(ownerID, productID)
+func (s *ReviewService) AddReview(ctx context.Context, ownerID string, productID, rating int, comment string) (*models.Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrInvalidRating
+	}
+	exists, err := s.repo.Exists(ctx, ownerID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrReviewAlreadyExists
+	}
+
+	verified, err := s.repo.IsVerifiedPurchase(ctx, ownerID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check purchase: %w", err)
+	}
+
+	review := &models.Review{
+		OwnerID:          ownerID,
+		ProductID:        productID,
+		Rating:           rating,
+		Comment:          strings.TrimSpace(comment),
+		VerifiedPurchase: verified,
+	}
+	s.moderate(review)
+
+	if err := s.repo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RefreshProductRating(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	review.Photos = []models.ReviewPhoto{}
+	return review, nil
+}
+
+// GetReviewsByProductID возвращает опубликованные отзывы с фото и ответами магазина
+func (s *ReviewService) GetReviewsByProductID(ctx context.Context, productID int) ([]models.Review, error) {
+	reviews, err := s.repo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if reviews == nil {
+		return []models.Review{}, nil
+	}
+
+	if err := s.attachPhotos(ctx, reviews); err != nil {
+		return nil, err
+	}
+	hideModeration(reviews)
+	return reviews, nil
+}
+
+// hideModeration убирает служебные поля модерации из отзывов для публичных ответов
+func hideModeration(reviews []models.Review) {
+	for i := range reviews {
+		reviews[i].ModerationNote = nil
+		reviews[i].RejectionReason = nil
+		reviews[i].ModeratedBy = nil
+	}
+}
+
+func (s *ReviewService) UpdateReview(ctx context.Context, ownerID string, productID, rating int, comment string) (*models.Review, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrInvalidRating
+	}
+	review, err := s.getOwnReview(ctx, ownerID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := s.repo.IsVerifiedPurchase(ctx, ownerID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check purchase: %w", err)
+	}
+
+	review.Rating = rating
+	review.Comment = strings.TrimSpace(comment)
+	review.VerifiedPurchase = verified
+	s.moderate(review)
+
+	if err := s.repo.Update(ctx, review); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RefreshProductRating(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.getWithPhotos(ctx, review.ID)
+}
+
+func (s *ReviewService) DeleteReview(ctx context.Context, ownerID string, productID int) error {
+	review, err := s.getOwnReview(ctx, ownerID, productID)
 	if err != nil {
 		return err
 	}
+	photos, err := s.repo.GetPhotos(ctx, []int{review.ID})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, ownerID, productID); err != nil {
+		return err
+	}
+	for i := range photos {
+		s.removePhotoFiles(ctx, &photos[i])
+	}
+	return s.repo.RefreshProductRating(ctx, productID)
+}
+
+// GetRatingSummary возвращает распределение оценок товара (все оценки от 5 до 1, включая нулевые)
+func (s *ReviewService) GetRatingSummary(ctx context.Context, productID int) (*models.ProductRatingSummary, error) {
+	buckets, err := s.repo.GetRatingDistribution(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int, len(buckets))
+	total, sum := 0, 0
+	for _, b := range buckets {
+		counts[b.Rating] = b.Count
+		total += b.Count
+		sum += b.Rating * b.Count
+	}
+
+	summary := &models.ProductRatingSummary{
+		ProductID:    productID,
+		Count:        total,
+		Distribution: make([]models.RatingBucket, 0, 5),
+	}
+	if total > 0 {
+		summary.Average = math.Round(float64(sum)/float64(total)*100) / 100
+	}
+	for rating := 5; rating >= 1; rating-- {
+		bucket := models.RatingBucket{Rating: rating, Count: counts[rating]}
+		if total > 0 {
+			bucket.Percent = math.Round(float64(bucket.Count)/float64(total)*1000) / 10
+		}
+		summary.Distribution = append(summary.Distribution, bucket)
+	}
+	return summary, nil
+}
+
+func (s *ReviewService) MaxPhotoSize() int64 {
+	return s.images.MaxUploadSize()
+}
+
+// AddPhoto прикрепляет фото к отзыву владельца. Фото проходит ту же обработку,
+// что и изображения товаров; при премодерации отзыв заново уходит на проверку.
+func (s *ReviewService) AddPhoto(ctx context.Context, ownerID string, productID int, r io.Reader) (*models.ReviewPhoto, error) {
+	review, err := s.getOwnReview(ctx, ownerID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := s.repo.GetPhotos(ctx, []int{review.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) >= MaxReviewPhotos {
+		return nil, ErrTooManyReviewPhotos
+	}
+
+	img, err := s.images.Store(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	photo := &models.ReviewPhoto{
+		ReviewID:       review.ID,
+		FileName:       img.FileName,
+		MediumFileName: img.MediumFileName,
+		ThumbFileName:  img.ThumbFileName,
+	}
+	if err := s.repo.AddPhoto(ctx, photo); err != nil {
+		s.removePhotoFiles(ctx, photo)
+		return nil, err
+	}
+
+	if s.premoderation && review.Status != models.ReviewStatusPending {
+		s.moderate(review)
+		if err := s.repo.Update(ctx, review); err != nil {
+			return nil, err
+		}
+		if err := s.repo.RefreshProductRating(ctx, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.fillPhotoURLs(photo)
+	return photo, nil
+}
+
+func (s *ReviewService) DeletePhoto(ctx context.Context, ownerID string, productID, photoID int) error {
+	review, err := s.getOwnReview(ctx, ownerID, productID)
+	if err != nil {
+		return err
+	}
+
+	photo, err := s.repo.GetPhotoByID(ctx, photoID)
+	if err != nil || photo.ReviewID != review.ID {
+		return ErrReviewPhotoNotFound
+	}
+
+	if err := s.repo.DeletePhoto(ctx, photo.ID); err != nil {
+		return err
+	}
+	s.removePhotoFiles(ctx, photo)
+	return nil
+}
+
+func (s *ReviewService) GetModerationQueue(ctx context.Context, status string, limit, offset int) ([]models.AdminReview, int, error) {
+	if status == "" {
+		status = models.ReviewStatusPending
+	}
+	if !models.AllowedReviewStatuses[status] {
+		return nil, 0, ErrInvalidReviewStatus
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	reviews, total, err := s.repo.GetByStatus(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if reviews == nil {
+		return []models.AdminReview{}, total, nil
+	}
+
+	plain := make([]models.Review, len(reviews))
+	for i := range reviews {
+		plain[i] = reviews[i].Review
+	}
+	if err := s.attachPhotos(ctx, plain); err != nil {
+		return nil, 0, err
+	}
+	for i := range reviews {
+		reviews[i].Photos = plain[i].Photos
+	}
+	return reviews, total, nil
+}
+
+// Moderate одобряет или отклоняет отзыв и пересчитывает рейтинг товара
+func (s *ReviewService) Moderate(ctx context.Context, reviewID int, status, reason string, adminID int) (*models.Review, error) {
+	if status != models.ReviewStatusApproved && status != models.ReviewStatusRejected {
+		return nil, ErrInvalidReviewStatus
+	}
+
+	var rejectionReason *string
+	if status == models.ReviewStatusRejected {
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			return nil, ErrRejectReasonRequired
+		}
+		rejectionReason = &reason
+	}
+
+	review, err := s.getByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetStatus(ctx, review.ID, status, rejectionReason, adminID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RefreshProductRating(ctx, review.ProductID); err != nil {
+		return nil, err
+	}
+
+	tracing.Logger(ctx, s.logger).Info("review moderated",
+		zap.Int("review_id", review.ID),
+		zap.Int("product_id", review.ProductID),
+		zap.String("status", status),
+		zap.Int("admin_id", adminID),
+	)
+	return s.getWithPhotos(ctx, review.ID)
+}
+
+// Reply сохраняет ответ магазина под отзывом; пустой ответ удаляет его
+func (s *ReviewService) Reply(ctx context.Context, reviewID int, reply string) (*models.Review, error) {
+	review, err := s.getByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	var text *string
+	if reply = strings.TrimSpace(reply); reply != "" {
+		text = &reply
+	}
+	if err := s.repo.SetReply(ctx, review.ID, text); err != nil {
+		return nil, err
+	}
+	return s.getWithPhotos(ctx, review.ID)
+}
+
+// SubmitOrderFeedback принимает оценку доставленного заказа (общую, доставки и курьера)
+// и оценки его товаров одним запросом. Оценки товаров сохраняются как обычные отзывы
+// с пометкой "проверенная покупка" и проходят ту же модерацию.
+func (s *ReviewService) SubmitOrderFeedback(ctx context.Context, orderID int, ownerID string, userID *int, req models.OrderFeedbackRequest) (*models.OrderReview, error) {
+	for _, rating := range []*int{req.Rating, req.DeliveryRating, req.CourierRating} {
+		if rating != nil && (*rating < 1 || *rating > 5) {
+			return nil, ErrInvalidRating
+		}
+	}
+	if req.Rating == nil && req.DeliveryRating == nil && req.CourierRating == nil && len(req.Items) == 0 {
+		return nil, ErrOrderFeedbackEmpty
+	}
+
+	order, err := s.orders.GetByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && order.OwnerID != ownerID) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusDelivered {
+		return nil, ErrOrderNotDelivered
+	}
+
+	exists, err := s.repo.OrderFeedbackExists(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
 	if exists {
-		return fmt.Errorf("you have already left a review for this product")
+		return nil, ErrOrderFeedbackExists
+	}
+
+	orderItems, err := s.orders.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order items: %w", err)
 	}
-	return s.repo.Create(&models.Review{
-		OwnerID:   ownerID,
-		ProductID: productID,
-		Rating:    rating,
-		Comment:   comment,
-	})
+	inOrder := make(map[int]bool, len(orderItems))
+	for _, item := range orderItems {
+		inOrder[item.ProductID] = true
+	}
+
+	reviews := make([]models.Review, 0, len(req.Items))
+	seen := make(map[int]bool, len(req.Items))
+	for _, item := range req.Items {
+		if item.Rating < 1 || item.Rating > 5 {
+			return nil, ErrInvalidRating
+		}
+		if !inOrder[item.ProductID] || seen[item.ProductID] {
+			return nil, fmt.Errorf("%w: %d", ErrProductNotInOrder, item.ProductID)
+		}
+		seen[item.ProductID] = true
+
+		exists, err := s.repo.Exists(ctx, ownerID, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %d", ErrReviewAlreadyExists, item.ProductID)
+		}
+
+		review := models.Review{
+			OwnerID:          ownerID,
+			ProductID:        item.ProductID,
+			OrderID:          &orderID,
+			Rating:           item.Rating,
+			Comment:          strings.TrimSpace(item.Comment),
+			VerifiedPurchase: true,
+		}
+		s.moderate(&review)
+		reviews = append(reviews, review)
+	}
+
+	feedback := &models.OrderReview{
+		OrderID:        orderID,
+		OwnerID:        ownerID,
+		UserID:         userID,
+		Rating:         req.Rating,
+		DeliveryRating: req.DeliveryRating,
+		CourierRating:  req.CourierRating,
+	}
+	if req.Comment != nil {
+		if comment := strings.TrimSpace(*req.Comment); comment != "" {
+			feedback.Comment = &comment
+		}
+	}
+
+	if err := s.repo.CreateOrderFeedback(ctx, feedback, reviews); err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		if err := s.repo.RefreshProductRating(ctx, review.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	tracing.Logger(ctx, s.logger).Info("order feedback submitted",
+		zap.Int("order_id", orderID),
+		zap.String("owner_id", ownerID),
+		zap.Int("items", len(reviews)),
+	)
+	return s.GetOrderFeedback(ctx, orderID)
 }
 
-func (s *ReviewService) GetReviewsByProductID(productID int) ([]models.Review, error) {
-	return s.repo.GetByProductID(productID)
+// GetOrderFeedback возвращает отзыв о заказе вместе с опубликованными оценками его товаров;
+// отзывы на модерации появятся после одобрения
+func (s *ReviewService) GetOrderFeedback(ctx context.Context, orderID int) (*models.OrderReview, error) {
+	feedback, err := s.repo.GetOrderFeedback(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Review{}
+	}
+	if err := s.attachPhotos(ctx, items); err != nil {
+		return nil, err
+	}
+	hideModeration(items)
+	for i := range items {
+		// Как и у самого отзыва о заказе, owner_id покупателя наружу не отдаём
+		items[i].OwnerID = ""
+	}
+	feedback.Items = items
+	return feedback, nil
+}
+
+func (s *ReviewService) GetAllOrderFeedback(ctx context.Context) ([]models.OrderReview, error) {
+	feedback, err := s.repo.GetAllOrderFeedback(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if feedback == nil {
+		return []models.OrderReview{}, nil
+	}
+	return feedback, nil
+}
+
+// GetAnalytics строит динамику средних оценок товаров, заказов, доставки и курьеров
+// и список товаров с самым низким рейтингом
+func (s *ReviewService) GetAnalytics(ctx context.Context, period string, from, to *time.Time) (*models.FeedbackAnalytics, error) {
+	if period == "" {
+		period = "day"
+	}
+	if !analyticsPeriods[period] {
+		return nil, ErrInvalidPeriod
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultAnalyticsRange)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidDateRange
+	}
+
+	timeline, err := s.repo.GetRatingTimeline(ctx, period, start, end)
+	if err != nil {
+		return nil, err
+	}
+	lowest, err := s.repo.GetLowestRatedProducts(ctx, lowestRatedMinReviews, lowestRatedLimit)
+	if err != nil {
+		return nil, err
+	}
+	if timeline == nil {
+		timeline = []models.RatingTimelinePoint{}
+	}
+	if lowest == nil {
+		lowest = []models.LowestRatedProduct{}
+	}
+
+	return &models.FeedbackAnalytics{
+		Period:      period,
+		From:        start,
+		To:          end,
+		Timeline:    timeline,
+		LowestRated: lowest,
+	}, nil
+}
+
+func (s *ReviewService) getByID(ctx context.Context, id int) (*models.Review, error) {
+	review, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (s *ReviewService) getOwnReview(ctx context.Context, ownerID string, productID int) (*models.Review, error) {
+	review, err := s.repo.GetByOwner(ctx, ownerID, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (s *ReviewService) getWithPhotos(ctx context.Context, id int) (*models.Review, error) {
+	review, err := s.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reviews := []models.Review{*review}
+	if err := s.attachPhotos(ctx, reviews); err != nil {
+		return nil, err
+	}
+	return &reviews[0], nil
 }
-func (s *ReviewService) UpdateReview(ownerID string, productID, rating int, comment string) error {
-	return s.repo.Update(ownerID, productID, rating, comment)
+
+func (s *ReviewService) attachPhotos(ctx context.Context, reviews []models.Review) error {
+	ids := make([]int, 0, len(reviews))
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+
+	photos, err := s.repo.GetPhotos(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byReview := make(map[int][]models.ReviewPhoto, len(reviews))
+	for i := range photos {
+		s.fillPhotoURLs(&photos[i])
+		byReview[photos[i].ReviewID] = append(byReview[photos[i].ReviewID], photos[i])
+	}
+	for i := range reviews {
+		reviews[i].Photos = byReview[reviews[i].ID]
+		if reviews[i].Photos == nil {
+			reviews[i].Photos = []models.ReviewPhoto{}
+		}
+	}
+	return nil
 }
 
-func (s *ReviewService) DeleteReview(ownerID string, productID int) error {
-	return s.repo.Delete(ownerID, productID)
+func (s *ReviewService) fillPhotoURLs(photo *models.ReviewPhoto) {
+	photo.URL = s.images.PublicURL(photo.FileName)
+	photo.MediumURL = s.images.PublicURL(photo.MediumFileName)
+	photo.ThumbURL = s.images.PublicURL(photo.ThumbFileName)
+}
+
+func (s *ReviewService) removePhotoFiles(ctx context.Context, photo *models.ReviewPhoto) {
+	for _, key := range []string{photo.FileName, photo.MediumFileName, photo.ThumbFileName} {
+		s.files.DeleteIfUnreferenced(ctx, key)
+	}
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/utils"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+const (
+	defaultSearchLimit     = 20
+	maxSearchLimit         = 100
+	maxSearchQueryLength   = 200
+	minSuggestQueryLength  = 2
+	defaultSuggestionLimit = 10
+)
+
+type SearchServiceInterface interface {
+	SearchProducts(ctx context.Context, query string, limit, offset int) (*models.ProductSearchResponse, error)
+	Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error)
+	GetZeroResults(ctx context.Context, limit int) ([]models.SearchZeroResult, error)
+}
+
+type SearchService struct {
+	repo   repositories.SearchRepository
+	logger *zap.Logger
+}
+
+func NewSearchService(repo repositories.SearchRepository, logger *zap.Logger) *SearchService {
+	return &SearchService{repo: repo, logger: logger}
+}
+
+// normalizeSearchQuery приводит запрос к нижнему регистру, схлопывает пробелы и обрезает длину
+func normalizeSearchQuery(query string) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		query = string([]rune(query)[:maxSearchQueryLength])
+	}
+	return query
+}
+
+func (s *SearchService) SearchProducts(ctx context.Context, query string, limit, offset int) (*models.ProductSearchResponse, error) {
+	query = normalizeSearchQuery(query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	hits, err := s.repo.SearchProducts(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.CountProducts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Пустую выдачу запоминаем только для первой страницы, чтобы не считать один поиск дважды
+	if total == 0 && offset == 0 {
+		if err := s.repo.LogZeroResult(ctx, query); err != nil {
+			tracing.Logger(ctx, s.logger).Warn("failed to log zero-result search", zap.String("query", query), zap.Error(err))
+		}
+		tracing.Logger(ctx, s.logger).Info("search returned no results", zap.String("query", query))
+	}
+
+	items := make([]models.ProductSearchResult, 0, len(hits))
+	for i := range hits {
+		hit := &hits[i]
+		items = append(items, models.ProductSearchResult{
+			ProductResponse: utils.BuildProductResponse(&hit.Product, hit.CategoryName),
+			Rank:            hit.Rank,
+			NameHighlight:   hit.NameHighlight,
+			Snippet:         hit.Snippet,
+		})
+	}
+
+	return &models.ProductSearchResponse{
+		Query:  query,
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+func (s *SearchService) Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error) {
+	query = normalizeSearchQuery(query)
+	if utf8.RuneCountInString(query) < minSuggestQueryLength {
+		return []models.SearchSuggestion{}, nil
+	}
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSuggestionLimit
+	}
+
+	suggestions, err := s.repo.Suggest(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if suggestions == nil {
+		return []models.SearchSuggestion{}, nil
+	}
+	return suggestions, nil
+}
+
+func (s *SearchService) GetZeroResults(ctx context.Context, limit int) ([]models.SearchZeroResult, error) {
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	results, err := s.repo.GetZeroResults(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return []models.SearchZeroResult{}, nil
+	}
+	return results, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"chechnya-product/config"
+	"chechnya-product/internal/buildinfo"
+	"chechnya-product/internal/cache"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/storage"
+	"chechnya-product/internal/tracing"
+	"chechnya-product/internal/ws"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// readyCheckTimeout ограничивает каждую проверку /readyz, чтобы зависшая зависимость не держала пробу
+const readyCheckTimeout = 2 * time.Second
+
+// storageProbeKey — файл, которым проверяется запись в хранилище; сразу удаляется
+const storageProbeKey = ".readyz-probe"
+
+type SystemServiceInterface interface {
+	Ready(ctx context.Context) models.ReadinessReport
+	Info(ctx context.Context) (*models.SystemInfo, error)
+}
+
+type SystemService struct {
+	repo      repositories.SystemRepository
+	adminRepo repositories.AdminRepoInterface
+	cache     *cache.RedisCache
+	storage   storage.Storage
+	hub       *ws.Hub
+	cfg       *config.Config
+	logger    *zap.Logger
+	startedAt time.Time
+}
+
+func NewSystemService(
+	repo repositories.SystemRepository,
+	adminRepo repositories.AdminRepoInterface,
+	cache *cache.RedisCache,
+	storage storage.Storage,
+	hub *ws.Hub,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *SystemService {
+	return &SystemService{
+		repo:      repo,
+		adminRepo: adminRepo,
+		cache:     cache,
+		storage:   storage,
+		hub:       hub,
+		cfg:       cfg,
+		logger:    logger,
+		startedAt: time.Now(),
+	}
+}
+
+// Ready параллельно проверяет Postgres, Redis, актуальность схемы и запись в хранилище.
+// Экземпляр готов, только если прошли все проверки.
+func (s *SystemService) Ready(ctx context.Context) models.ReadinessReport {
+	checks := map[string]func(ctx context.Context) (string, error){
+		"postgres":   s.checkPostgres,
+		"redis":      s.checkRedis,
+		"migrations": s.checkMigrations,
+		"storage":    s.checkStorage,
+	}
+
+	report := models.ReadinessReport{Ready: true, Checks: make(map[string]models.HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) (string, error)) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != models.CheckStatusOK {
+				report.Ready = false
+				tracing.Logger(ctx, s.logger).Warn("readiness check failed", zap.String("check", name), zap.String("error", result.Error))
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, check func(ctx context.Context) (string, error)) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := models.HealthCheck{
+		Status:     models.CheckStatusOK,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = models.CheckStatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (s *SystemService) checkPostgres(ctx context.Context) (string, error) {
+	return "", s.repo.Ping(ctx)
+}
+
+func (s *SystemService) checkRedis(ctx context.Context) (string, error) {
+	return "", s.cache.Ping(ctx)
+}
+
+// checkMigrations сравнивает версию схемы с последним файлом миграции. Неприменённые миграции
+// делают экземпляр неготовым; схема новее файлов — нормальная ситуация при выкладке, когда новая
+// версия уже мигрировала базу, а старые экземпляры ещё обслуживают запросы.
+func (s *SystemService) checkMigrations(ctx context.Context) (string, error) {
+	if err := s.repo.Ping(ctx); err != nil {
+		return "", err
+	}
+	current, err := s.adminRepo.SchemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	latest, err := s.latestMigration()
+	if err != nil {
+		return "", err
+	}
+
+	detail := fmt.Sprintf("schema %d, files %d", current, latest)
+	if current < latest {
+		return detail, errors.New("database schema is behind migration files")
+	}
+	return detail, nil
+}
+
+func (s *SystemService) latestMigration() (int64, error) {
+	migrations, err := goose.CollectMigrations(s.cfg.MigrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// checkStorage записывает и удаляет маленький файл — так проверяются и права, и место на диске, и доступ к S3
+func (s *SystemService) checkStorage(ctx context.Context) (string, error) {
+	probe := []byte("ok")
+	errCh := make(chan error, 1)
+	go func() {
+		if err := s.storage.Put(ctx, storageProbeKey, bytes.NewReader(probe), int64(len(probe)), "text/plain"); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- s.storage.Delete(ctx, storageProbeKey)
+	}()
+
+	// Операции с локальным диском не прерываются по ctx, поэтому таймаут проверки отслеживается здесь
+	select {
+	case err := <-errCh:
+		return s.storage.Driver(), err
+	case <-ctx.Done():
+		return s.storage.Driver(), ctx.Err()
+	}
+}
+
+// Info собирает сведения об экземпляре для админки. Недоступная база не мешает ответу — версия схемы просто будет нулевой.
+func (s *SystemService) Info(ctx context.Context) (*models.SystemInfo, error) {
+	info := &models.SystemInfo{
+		Build:            buildinfo.Read(),
+		Env:              s.cfg.Env,
+		StartedAt:        s.startedAt,
+		UptimeSeconds:    int64(time.Since(s.startedAt).Seconds()),
+		Goroutines:       runtime.NumGoroutine(),
+		WebSocketClients: s.hub.ClientCount(),
+		StorageDriver:    s.storage.Driver(),
+	}
+
+	if version, err := s.adminRepo.SchemaVersion(ctx); err == nil {
+		info.SchemaVersion = version
+	} else {
+		tracing.Logger(ctx, s.logger).Warn("failed to fetch schema version", zap.Error(err))
+	}
+	latest, err := s.latestMigration()
+	if err != nil {
+		return nil, err
+	}
+	info.LatestMigration = latest
+
+	db := s.repo.Stats()
+	info.Database = models.DBPoolStats{
+		MaxOpen:        db.MaxOpenConnections,
+		Open:           db.OpenConnections,
+		InUse:          db.InUse,
+		Idle:           db.Idle,
+		WaitCount:      db.WaitCount,
+		WaitDurationMs: db.WaitDuration.Milliseconds(),
+	}
+
+	if redisStats := s.cache.PoolStats(); redisStats != nil {
+		info.Redis = models.RedisPoolStats{
+			TotalConns: redisStats.TotalConns,
+			IdleConns:  redisStats.IdleConns,
+			StaleConns: redisStats.StaleConns,
+			Hits:       redisStats.Hits,
+			Misses:     redisStats.Misses,
+			Timeouts:   redisStats.Timeouts,
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+var (
+	ErrUnknownTrashType  = errors.New("unknown trash type: expected product, category or order")
+	ErrTrashItemNotFound = errors.New("item not found in trash")
+	ErrRestoreConflict   = errors.New("cannot restore: an active record with the same name, sku, slug or external id exists")
+)
+
+type TrashServiceInterface interface {
+	List(ctx context.Context, kind string) ([]models.TrashItem, error)
+	Restore(ctx context.Context, kind string, id int) error
+	Purge(ctx context.Context) (*models.TrashPurgeResult, error)
+	RunPurge(ctx context.Context, interval time.Duration)
+}
+
+// TrashService — корзина удалённых товаров, категорий и заказов
+type TrashService struct {
+	products   repositories.ProductRepository
+	categories repositories.CategoryRepository
+	orders     repositories.OrderRepository
+	retention  time.Duration
+	logger     *zap.Logger
+}
+
+func NewTrashService(products repositories.ProductRepository, categories repositories.CategoryRepository, orders repositories.OrderRepository, retention time.Duration, logger *zap.Logger) *TrashService {
+	return &TrashService{
+		products:   products,
+		categories: categories,
+		orders:     orders,
+		retention:  retention,
+		logger:     logger,
+	}
+}
+
+// List возвращает содержимое корзины; пустой kind — записи всех типов, новые первыми
+func (s *TrashService) List(ctx context.Context, kind string) ([]models.TrashItem, error) {
+	if kind != "" && !models.AllowedTrashTypes[kind] {
+		return nil, ErrUnknownTrashType
+	}
+
+	sources := []struct {
+		kind  string
+		fetch func(ctx context.Context) ([]models.TrashItem, error)
+	}{
+		{models.TrashTypeProduct, s.products.GetDeleted},
+		{models.TrashTypeCategory, s.categories.GetDeleted},
+		{models.TrashTypeOrder, s.orders.GetDeleted},
+	}
+
+	items := make([]models.TrashItem, 0)
+	for _, src := range sources {
+		if kind != "" && kind != src.kind {
+			continue
+		}
+		list, err := src.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.retention)
+	}
+	return items, nil
+}
+
+func (s *TrashService) Restore(ctx context.Context, kind string, id int) error {
+	switch kind {
+	case models.TrashTypeProduct:
+		return s.restoreProduct(ctx, id)
+	case models.TrashTypeCategory:
+		return s.restoreCategory(ctx, id)
+	case models.TrashTypeOrder:
+		restored, err := s.orders.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return ErrTrashItemNotFound
+		}
+		return nil
+	default:
+		return ErrUnknownTrashType
+	}
+}
+
+// restoreProduct проверяет, что за время нахождения в корзине не появился
+// другой товар с тем же названием, артикулом или идентификатором 1С
+func (s *TrashService) restoreProduct(ctx context.Context, id int) error {
+	product, err := s.products.GetDeletedByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTrashItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch deleted product: %w", err)
+	}
+
+	exists, err := s.products.IsProductNameExists(ctx, product.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrRestoreConflict
+	}
+	if product.SKU.Valid {
+		if _, err := s.products.GetBySKU(ctx, product.SKU.String); !errors.Is(err, sql.ErrNoRows) {
+			return restoreConflict(err)
+		}
+	}
+	if product.ExternalID.Valid {
+		if _, err := s.products.GetByExternalID(ctx, product.ExternalID.String); !errors.Is(err, sql.ErrNoRows) {
+			return restoreConflict(err)
+		}
+	}
+
+	return s.products.Restore(ctx, id)
+}
+
+func (s *TrashService) restoreCategory(ctx context.Context, id int) error {
+	category, err := s.categories.GetDeletedByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTrashItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch deleted category: %w", err)
+	}
+
+	if _, err := s.categories.GetByName(ctx, category.Name); !errors.Is(err, sql.ErrNoRows) {
+		return restoreConflict(err)
+	}
+	taken, err := s.categories.SlugExists(ctx, category.Slug, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrRestoreConflict
+	}
+	if category.ExternalID != nil {
+		if _, err := s.categories.GetByExternalID(ctx, *category.ExternalID); !errors.Is(err, sql.ErrNoRows) {
+			return restoreConflict(err)
+		}
+	}
+
+	return s.categories.Restore(ctx, id)
+}
+
+// restoreConflict — итог поиска активной записи с теми же данными, когда она нашлась
+// (или поиск завершился ошибкой, которую нужно вернуть как есть)
+func restoreConflict(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrRestoreConflict
+}
+
+// Purge окончательно удаляет записи, пролежавшие в корзине дольше срока хранения.
+// Заказы удаляются первыми, затем товары и категории.
+func (s *TrashService) Purge(ctx context.Context) (*models.TrashPurgeResult, error) {
+	var result models.TrashPurgeResult
+	var err error
+
+	if result.Orders, err = s.orders.PurgeDeleted(ctx, s.retention); err != nil {
+		return nil, err
+	}
+	if result.Products, err = s.products.PurgeDeleted(ctx, s.retention); err != nil {
+		return nil, err
+	}
+	if result.Categories, err = s.categories.PurgeDeleted(ctx, s.retention); err != nil {
+		return nil, err
+	}
+
+	tracing.Logger(ctx, s.logger).Info("trash purged",
+		zap.Duration("retention", s.retention),
+		zap.Int64("orders", result.Orders),
+		zap.Int64("products", result.Products),
+		zap.Int64("categories", result.Categories),
+	)
+	return &result, nil
+}
+
+// RunPurge периодически очищает корзину до отмены ctx
+func (s *TrashService) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil {
+				tracing.Logger(ctx, s.logger).Error("trash purge failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -1,41 +1,108 @@
 package services
 
 import (
+	"chechnya-product/config"
 	"chechnya-product/internal/models"
 	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/tracing"
 	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"go.uber.org/zap"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserServiceInterface interface {
-	Register(req RegisterRequest) (*models.User, error)
-	LoginWithUser(req LoginRequest) (*models.User, string, error)
-	GetByID(userID int) (*models.User, error)
-	GetByOwnerID(ownerID string) (*models.User, error)
-	TransferCart(oldOwnerID, newOwnerID string) error
-	CreateByPhone(phone string) (*models.User, string, error)
-	GetAllUsers() ([]models.User, error)
-	GetUserByID(id int) (*models.User, error)
-	UpdateAddress(userID int, address *string) error
-	GetAddress(userID int) (*string, error)
-	ClearAddress(userID int) error
+	Register(ctx context.Context, req RegisterRequest) (*models.User, error)
+	LoginWithUser(ctx context.Context, req LoginRequest) (*models.User, string, error)
+	GetByID(ctx context.Context, userID int) (*models.User, error)
+	GetByOwnerID(ctx context.Context, ownerID string) (*models.User, error)
+	TransferCart(ctx context.Context, oldOwnerID, newOwnerID string) error
+	CreateByPhone(ctx context.Context, phone string) (*models.User, string, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdateAddress(ctx context.Context, userID int, address *string) error
+	GetAddress(ctx context.Context, userID int) (*string, error)
+	ClearAddress(ctx context.Context, userID int) error
+	CreateAdmin(ctx context.Context, req RegisterRequest) (*models.User, string, error)
+	ResetPassword(ctx context.Context, identifier, password string) (*models.User, string, error)
+	UnlockLogin(ctx context.Context, userID int) error
+	GetLoginHistory(ctx context.Context, userID int) ([]models.LoginEvent, error)
+}
+
+var (
+	// ErrInvalidCredentials — один ответ и для неизвестного аккаунта, и для неверного пароля,
+	// чтобы по нему нельзя было выяснить, зарегистрирован ли телефон или e-mail
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrPhoneNotVerified   = errors.New("phone is not verified")
+	ErrLoginLocked        = errors.New("login is temporarily locked after failed attempts")
+	ErrUserNotFound       = errors.New("user not found")
+)
+
+// LoginLockedError — вход закрыт после неудачных попыток; errors.Is(err, ErrLoginLocked) для него истинно
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%v: retry after %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+const (
+	// Первые неудачные попытки проходят без задержки, дальше пауза перед следующей попыткой
+	// удваивается от loginBaseDelay до loginMaxDelay; после LOGIN_MAX_ATTEMPTS неудач аккаунт блокируется
+	loginFreeAttempts = 3
+	loginBaseDelay    = time.Second
+	loginMaxDelay     = time.Minute
+	// loginFailureWindow — неудачи, разделённые большим перерывом, не складываются
+	loginFailureWindow = 24 * time.Hour
+
+	loginHistoryLimit  = 20
+	maxUserAgentLength = 512
+)
+
+// dummyPasswordHash — хэш, с которым сравнивается пароль для несуществующего аккаунта:
+// так ответ занимает столько же времени, сколько для существующего
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
+	return string(hash)
+})
+
+// IdentifierLoginStore считает неудачные входы по логину, для которого нет аккаунта. Неизвестный логин
+// задерживается и блокируется так же, как существующий, — иначе по 429 на N-й попытке подбора
+// было бы видно, что аккаунт есть.
+type IdentifierLoginStore interface {
+	GetIdentifierLoginLock(ctx context.Context, identifier string) (time.Duration, error)
+	RecordIdentifierLoginFailure(ctx context.Context, identifier string, window time.Duration) (int, error)
+	DelayIdentifierLogin(ctx context.Context, identifier string, delay time.Duration) error
+	LockIdentifierLogin(ctx context.Context, identifier string, duration time.Duration) error
 }
 
 type UserService struct {
 	repo        repositories.UserRepository
+	logins      IdentifierLoginStore
 	jwt         utils.JWTManagerInterface
 	cartService CartServiceInterface
+	pushService PushServiceInterface
+	tasks       *utils.TaskGroup
+	cfg         *config.Config
+	logger      *zap.Logger
 }
 
-func NewUserService(repo repositories.UserRepository, jwt utils.JWTManagerInterface, cart CartServiceInterface) *UserService {
-	return &UserService{repo: repo, jwt: jwt, cartService: cart}
+func NewUserService(repo repositories.UserRepository, logins IdentifierLoginStore, jwt utils.JWTManagerInterface, cart CartServiceInterface, push PushServiceInterface, tasks *utils.TaskGroup, cfg *config.Config, logger *zap.Logger) *UserService {
+	return &UserService{repo: repo, logins: logins, jwt: jwt, cartService: cart, pushService: push, tasks: tasks, cfg: cfg, logger: logger}
 }
 
 // Данные для регистрации пользователя
@@ -51,29 +118,49 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Identifier string // телефон, почта или имя
 	Password   string
+	// Откуда пришла попытка — для истории входов
+	IP        string
+	UserAgent string
 }
 
 // Регистрация пользователя по телефону
-func (s *UserService) Register(req RegisterRequest) (*models.User, error) {
+func (s *UserService) Register(ctx context.Context, req RegisterRequest) (*models.User, error) {
+	return s.register(ctx, req, models.UserRoleUser)
+}
+
+// CreateAdmin создаёт администратора (из консоли). Если пароль не задан, он генерируется
+// и возвращается вторым значением, чтобы его можно было передать владельцу.
+func (s *UserService) CreateAdmin(ctx context.Context, req RegisterRequest) (*models.User, string, error) {
+	if req.Password == "" {
+		req.Password = utils.GeneratePassword(12)
+	}
+	user, err := s.register(ctx, req, models.UserRoleAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, req.Password, nil
+}
+
+func (s *UserService) register(ctx context.Context, req RegisterRequest, role models.UserRole) (*models.User, error) {
 	// Валидация (например, длина пароля, пустой username и т.д.)
 	if err := validateRegisterRequest(req); err != nil {
 		return nil, err
 	}
 
 	// Проверки на существование (ускоряет ответ)
-	existingPhone, _ := s.repo.GetByPhone(req.Phone)
+	existingPhone, _ := s.repo.GetByPhone(ctx, req.Phone)
 	if existingPhone != nil {
 		return nil, errors.New("Пользователь с таким номером уже существует")
 	}
 
 	if req.Email != nil && *req.Email != "" {
-		existingEmail, _ := s.repo.GetByEmail(*req.Email)
+		existingEmail, _ := s.repo.GetByEmail(ctx, *req.Email)
 		if existingEmail != nil {
 			return nil, errors.New("Пользователь с таким email уже существует")
 		}
 	}
 
-	existingUsername, _ := s.repo.GetByUsername(req.Username)
+	existingUsername, _ := s.repo.GetByUsername(ctx, req.Username)
 	if existingUsername != nil {
 		return nil, errors.New("Пользователь с таким именем уже существует")
 	}
@@ -88,14 +175,14 @@ func (s *UserService) Register(req RegisterRequest) (*models.User, error) {
 		Phone:        req.Phone,
 		Email:        req.Email,
 		PasswordHash: string(hashedPassword),
-		Role:         models.UserRoleUser,
+		Role:         role,
 		IsVerified:   true,
 		OwnerID:      "user_" + uuid.New().String(), // всегда новый уникальный owner_id!
 		CreatedAt:    time.Now(),
 	}
 
 	// Пытаемся создать пользователя — ловим уникальные ограничения
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		fmt.Printf("Ошибка при создании пользователя: %+v\n", err)
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			switch {
@@ -114,56 +201,240 @@ func (s *UserService) Register(req RegisterRequest) (*models.User, error) {
 }
 
 // Аутентификация пользователя (вход)
-// Возвращает пользователя и JWT токен
-func (s *UserService) LoginWithUser(req LoginRequest) (*models.User, string, error) {
+// Возвращает пользователя и JWT токен. Неизвестный аккаунт и неверный пароль дают одну ошибку
+// ErrInvalidCredentials; после нескольких неудач подряд вход закрывается (LoginLockedError) —
+// по одному расписанию для существующего аккаунта и для неизвестного логина.
+func (s *UserService) LoginWithUser(ctx context.Context, req LoginRequest) (*models.User, string, error) {
 	if err := utils.ValidateIdentifier(req.Identifier); err != nil {
 		return nil, "", err
 	}
 
-	// Определяем тип идентификатора (телефон, e-mail или username)
-	var user *models.User
-	var err error
-	switch {
-	case strings.Contains(req.Identifier, "@"):
-		user, err = s.repo.GetByEmail(req.Identifier)
-	case strings.HasPrefix(req.Identifier, "+"):
-		user, err = s.repo.GetByPhone(req.Identifier)
-	default:
-		user, err = s.repo.GetByUsername(req.Identifier)
+	user, err := s.findByIdentifier(ctx, req.Identifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("Ошибка поиска пользователя: %w", err)
 	}
-	if err != nil || user == nil {
-		return nil, "", errors.New("Неверные данные для входа")
+	if user == nil {
+		return nil, "", s.loginUnknown(ctx, req)
 	}
 
-	if !user.IsVerified {
-		return nil, "", errors.New("Сначала подтвердите номер телефона")
+	// Попытка засчитывается неудачной и штраф за неё ставится до проверки пароля:
+	// параллельные попытки уже упираются в задержку или блокировку, а не перебирают пароли
+	failures, err := s.repo.RecordLoginAttempt(ctx, user.ID, loginFailureWindow, s.cfg.LoginMaxAttempts)
+	if err != nil {
+		return nil, "", err
 	}
+	if failures == 0 {
+		locked, err := s.repo.GetLoginLock(ctx, user.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		// Блокировка могла истечь между запросами — клиенту всё равно стоит повторить попытку
+		return nil, "", &LoginLockedError{RetryAfter: max(locked, time.Second)}
+	}
+	lock := s.applyLoginPenalty(ctx, user.ID, failures)
 
 	if !CheckPasswordHash(req.Password, user.PasswordHash) {
-		return nil, "", errors.New("Неверный пароль")
+		s.recordLogin(ctx, user.ID, req, false)
+		if lock {
+			tracing.Logger(ctx, s.logger).Warn("Вход в аккаунт заблокирован после неудачных попыток",
+				zap.Int("user_id", user.ID),
+				zap.Int("failures", failures),
+				zap.Duration("lockout", s.cfg.LoginLockout),
+			)
+			s.notifyLockout(ctx, user, failures)
+		}
+		return nil, "", ErrInvalidCredentials
+	}
+
+	// Пароль верный — попытка не была неудачной, счётчик и поставленный за неё штраф снимаются
+	if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+		tracing.Logger(ctx, s.logger).Warn("Не удалось сбросить счётчик неудачных входов", zap.Int("user_id", user.ID), zap.Error(err))
+	}
+
+	// Проверяется после пароля: иначе ответ выдавал бы, что аккаунт существует
+	if !user.IsVerified {
+		return nil, "", ErrPhoneNotVerified
 	}
 
 	token, err := s.jwt.Generate(user.ID, user.Role)
 	if err != nil {
 		return nil, "", fmt.Errorf("Ошибка генерации токена: %w", err)
 	}
+	s.recordLogin(ctx, user.ID, req, true)
 
 	return user, token, nil
 }
 
+// loginUnknown отвечает на вход в несуществующий аккаунт так же, как на неверный пароль:
+// с той же проверкой хэша по времени и теми же задержками и блокировкой
+func (s *UserService) loginUnknown(ctx context.Context, req LoginRequest) error {
+	if s.logins == nil {
+		CheckPasswordHash(req.Password, dummyPasswordHash())
+		return ErrInvalidCredentials
+	}
+	logger := tracing.Logger(ctx, s.logger)
+
+	locked, err := s.logins.GetIdentifierLoginLock(ctx, req.Identifier)
+	if err != nil {
+		// Как и ограничитель запросов, при недоступном Redis вход не закрываем
+		logger.Warn("Не удалось проверить блокировку входа по логину", zap.Error(err))
+	}
+	if locked > 0 {
+		return &LoginLockedError{RetryAfter: locked}
+	}
+
+	CheckPasswordHash(req.Password, dummyPasswordHash())
+
+	failures, err := s.logins.RecordIdentifierLoginFailure(ctx, req.Identifier, loginFailureWindow)
+	if err != nil {
+		logger.Warn("Не удалось учесть неудачный вход по логину", zap.Error(err))
+		return ErrInvalidCredentials
+	}
+	delay, lock := s.loginPenalty(failures)
+	switch {
+	case lock:
+		err = s.logins.LockIdentifierLogin(ctx, req.Identifier, s.cfg.LoginLockout)
+	case delay > 0:
+		err = s.logins.DelayIdentifierLogin(ctx, req.Identifier, delay)
+	}
+	if err != nil {
+		logger.Warn("Не удалось закрыть вход по логину", zap.Error(err))
+	}
+	return ErrInvalidCredentials
+}
+
+// loginPenalty — что следует за failures неудачами подряд: после loginFreeAttempts вход закрывается
+// на растущую паузу, после LOGIN_MAX_ATTEMPTS — блокируется на LOGIN_LOCKOUT_SECONDS
+func (s *UserService) loginPenalty(failures int) (delay time.Duration, lock bool) {
+	switch {
+	case failures >= s.cfg.LoginMaxAttempts:
+		return 0, true
+	case failures >= loginFreeAttempts:
+		return min(loginBaseDelay<<min(failures-loginFreeAttempts, 10), loginMaxDelay), false
+	}
+	return 0, false
+}
+
+// applyLoginPenalty закрывает вход в аккаунт по loginPenalty после failures неудач подряд
+// и сообщает, была ли это блокировка
+func (s *UserService) applyLoginPenalty(ctx context.Context, userID, failures int) bool {
+	logger := tracing.Logger(ctx, s.logger)
+
+	delay, lock := s.loginPenalty(failures)
+	switch {
+	case lock:
+		if err := s.repo.LockLogin(ctx, userID, s.cfg.LoginLockout); err != nil {
+			logger.Warn("Не удалось заблокировать вход", zap.Int("user_id", userID), zap.Error(err))
+			return false
+		}
+	case delay > 0:
+		if err := s.repo.DelayLogin(ctx, userID, delay); err != nil {
+			logger.Warn("Не удалось задержать вход", zap.Int("user_id", userID), zap.Error(err))
+		}
+	}
+	return lock
+}
+
+// notifyLockout отправляет владельцу аккаунта push о блокировке входа. Отправка идёт в фоне,
+// чтобы ответ на попытку входа не отличался по времени.
+func (s *UserService) notifyLockout(ctx context.Context, user *models.User, failures int) {
+	if s.pushService == nil {
+		return
+	}
+	taskCtx := context.WithoutCancel(ctx)
+	msg := fmt.Sprintf("🔒 Вход в аккаунт %s заблокирован на %d мин. после %d неудачных попыток. Если это были не вы, смените пароль.",
+		user.Username, int(s.cfg.LoginLockout.Round(time.Minute)/time.Minute), failures)
+	s.tasks.Go(func() {
+		if err := s.pushService.SendPushToUser(taskCtx, user.ID, msg); err != nil {
+			tracing.Logger(taskCtx, s.logger).Warn("Не удалось уведомить о блокировке входа", zap.Int("user_id", user.ID), zap.Error(err))
+		}
+	})
+}
+
+// recordLogin пишет попытку в историю входов; сбой записи не мешает входу
+func (s *UserService) recordLogin(ctx context.Context, userID int, req LoginRequest, success bool) {
+	userAgent := req.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	err := s.repo.AddLoginEvent(ctx, models.LoginEvent{
+		UserID:    userID,
+		Success:   success,
+		IP:        req.IP,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		tracing.Logger(ctx, s.logger).Warn("Не удалось записать историю входа", zap.Int("user_id", userID), zap.Error(err))
+	}
+}
+
+// UnlockLogin снимает блокировку входа и обнуляет счётчик неудач (для администратора)
+func (s *UserService) UnlockLogin(ctx context.Context, userID int) error {
+	err := s.repo.ResetLoginFailures(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// GetLoginHistory возвращает последние попытки входа в аккаунт
+func (s *UserService) GetLoginHistory(ctx context.Context, userID int) ([]models.LoginEvent, error) {
+	return s.repo.GetLoginHistory(ctx, userID, loginHistoryLimit)
+}
+
+// ResetPassword задаёт пользователю новый пароль; пустой пароль генерируется и возвращается
+func (s *UserService) ResetPassword(ctx context.Context, identifier, password string) (*models.User, string, error) {
+	user, err := s.findByIdentifier(ctx, identifier)
+	if err != nil || user == nil {
+		return nil, "", errors.New("Пользователь не найден")
+	}
+
+	if password == "" {
+		password = utils.GeneratePassword(12)
+	}
+	if len(password) < 6 {
+		return nil, "", errors.New("Пароль должен быть не менее 6 символов")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("Не удалось создать хэш пароля: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hash)); err != nil {
+		return nil, "", err
+	}
+	// После смены пароля блокировка за подбор старого больше не нужна
+	if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// findByIdentifier ищет пользователя по телефону, e-mail или имени
+func (s *UserService) findByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	switch {
+	case strings.Contains(identifier, "@"):
+		return s.repo.GetByEmail(ctx, identifier)
+	case strings.HasPrefix(identifier, "+"):
+		return s.repo.GetByPhone(ctx, identifier)
+	default:
+		return s.repo.GetByUsername(ctx, identifier)
+	}
+}
+
 // Получить пользователя по ID
-func (s *UserService) GetByID(userID int) (*models.User, error) {
-	return s.repo.GetByID(userID)
+func (s *UserService) GetByID(ctx context.Context, userID int) (*models.User, error) {
+	return s.repo.GetByID(ctx, userID)
 }
 
 // Получить пользователя по owner_id
-func (s *UserService) GetByOwnerID(ownerID string) (*models.User, error) {
-	return s.repo.GetByOwnerID(ownerID)
+func (s *UserService) GetByOwnerID(ctx context.Context, ownerID string) (*models.User, error) {
+	return s.repo.GetByOwnerID(ctx, ownerID)
 }
 
 // Перенос корзины при смене пользователя
-func (s *UserService) TransferCart(oldOwnerID, newOwnerID string) error {
-	return s.cartService.TransferCart(oldOwnerID, newOwnerID)
+func (s *UserService) TransferCart(ctx context.Context, oldOwnerID, newOwnerID string) error {
+	return s.cartService.TransferCart(ctx, oldOwnerID, newOwnerID)
 }
 
 // Валидация данных регистрации
@@ -184,12 +455,12 @@ func CheckPasswordHash(password, hash string) bool {
 }
 
 // Создание пользователя только по номеру телефона (для админов)
-func (s *UserService) CreateByPhone(phone string) (*models.User, string, error) {
+func (s *UserService) CreateByPhone(ctx context.Context, phone string) (*models.User, string, error) {
 	if err := utils.ValidatePhone(phone); err != nil {
 		return nil, "", err
 	}
 
-	existing, _ := s.repo.GetByPhone(phone)
+	existing, _ := s.repo.GetByPhone(ctx, phone)
 	if existing != nil {
 		return nil, "", errors.New("Пользователь уже существует")
 	}
@@ -210,29 +481,29 @@ func (s *UserService) CreateByPhone(phone string) (*models.User, string, error)
 		CreatedAt:    time.Now(),
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, "", err
 	}
 
 	return user, password, nil
 }
 
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAllUsers()
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.repo.GetAllUsers(ctx)
 }
 
-func (s *UserService) GetUserByID(id int) (*models.User, error) {
-	return s.repo.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	return s.repo.GetUserByID(ctx, id)
 }
 
-func (s *UserService) ClearAddress(userID int) error {
-	return s.repo.ClearAddress(userID)
+func (s *UserService) ClearAddress(ctx context.Context, userID int) error {
+	return s.repo.ClearAddress(ctx, userID)
 }
 
-func (s *UserService) GetAddress(userID int) (*string, error) {
-	return s.repo.GetAddress(userID)
+func (s *UserService) GetAddress(ctx context.Context, userID int) (*string, error) {
+	return s.repo.GetAddress(ctx, userID)
 }
 
-func (s *UserService) UpdateAddress(userID int, address *string) error {
-	return s.repo.UpdateAddress(userID, address)
+func (s *UserService) UpdateAddress(ctx context.Context, userID int, address *string) error {
+	return s.repo.UpdateAddress(ctx, userID, address)
 }
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/models"
+	"chechnya-product/internal/repositories"
+	"chechnya-product/internal/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testPassword    = "correct-password"
+	testMaxAttempts = 5
+	testLockout     = 15 * time.Minute
+)
+
+// loginClock — общее время для фейковых хранилищ блокировок, чтобы тест мог «переждать» задержку
+type loginClock struct {
+	now time.Time
+}
+
+func (c *loginClock) until(t time.Time) time.Duration {
+	return max(t.Sub(c.now), 0)
+}
+
+// maxTime — как GREATEST в UserRepo: блокировка только продлевается
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// memUserRepo — один пользователь в памяти; поля блокировки ведутся как в UserRepo
+type memUserRepo struct {
+	repositories.UserRepository
+
+	clock     *loginClock
+	user      models.User
+	lastFail  time.Time
+	lockUntil time.Time
+}
+
+func (r *memUserRepo) find(name string) (*models.User, error) {
+	if name != r.user.Username {
+		return nil, nil
+	}
+	copied := r.user
+	return &copied, nil
+}
+
+func (r *memUserRepo) GetByUsername(_ context.Context, name string) (*models.User, error) {
+	return r.find(name)
+}
+
+func (r *memUserRepo) GetLoginLock(context.Context, int) (time.Duration, error) {
+	return r.clock.until(r.lockUntil), nil
+}
+
+func (r *memUserRepo) RecordLoginAttempt(_ context.Context, _ int, window time.Duration, maxAttempts int) (int, error) {
+	if r.clock.until(r.lockUntil) > 0 {
+		return 0, nil
+	}
+	if r.clock.now.Sub(r.lastFail) > window || r.user.FailedLogins >= maxAttempts {
+		r.user.FailedLogins = 0
+	}
+	r.user.FailedLogins++
+	r.lastFail = r.clock.now
+	return r.user.FailedLogins, nil
+}
+
+func (r *memUserRepo) DelayLogin(_ context.Context, _ int, delay time.Duration) error {
+	r.lockUntil = maxTime(r.lockUntil, r.clock.now.Add(delay))
+	return nil
+}
+
+func (r *memUserRepo) LockLogin(_ context.Context, _ int, duration time.Duration) error {
+	r.lockUntil = maxTime(r.lockUntil, r.clock.now.Add(duration))
+	return nil
+}
+
+func (r *memUserRepo) ResetLoginFailures(_ context.Context, id int) error {
+	if id != r.user.ID {
+		return sql.ErrNoRows
+	}
+	r.user.FailedLogins = 0
+	r.lockUntil = time.Time{}
+	return nil
+}
+
+func (r *memUserRepo) AddLoginEvent(context.Context, models.LoginEvent) error {
+	return nil
+}
+
+// memLoginStore — счётчики неизвестных логинов, как в Redis
+type memLoginStore struct {
+	clock     *loginClock
+	failures  map[string]int
+	lastFail  map[string]time.Time
+	lockUntil map[string]time.Time
+}
+
+func (s *memLoginStore) GetIdentifierLoginLock(_ context.Context, identifier string) (time.Duration, error) {
+	return s.clock.until(s.lockUntil[identifier]), nil
+}
+
+func (s *memLoginStore) RecordIdentifierLoginFailure(_ context.Context, identifier string, window time.Duration) (int, error) {
+	if s.clock.now.Sub(s.lastFail[identifier]) > window {
+		s.failures[identifier] = 0
+	}
+	s.failures[identifier]++
+	s.lastFail[identifier] = s.clock.now
+	return s.failures[identifier], nil
+}
+
+func (s *memLoginStore) DelayIdentifierLogin(_ context.Context, identifier string, delay time.Duration) error {
+	s.lockUntil[identifier] = maxTime(s.lockUntil[identifier], s.clock.now.Add(delay))
+	return nil
+}
+
+func (s *memLoginStore) LockIdentifierLogin(_ context.Context, identifier string, duration time.Duration) error {
+	s.lockUntil[identifier] = maxTime(s.lockUntil[identifier], s.clock.now.Add(duration))
+	s.failures[identifier] = 0
+	return nil
+}
+
+// userPush запоминает уведомления пользователям
+type userPush struct {
+	PushServiceInterface
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (p *userPush) SendPushToUser(_ context.Context, _ int, message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+type loginFixture struct {
+	clock   *loginClock
+	repo    *memUserRepo
+	push    *userPush
+	tasks   *utils.TaskGroup
+	service *UserService
+}
+
+func newLoginFixture(t *testing.T) *loginFixture {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &loginClock{now: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	f := &loginFixture{
+		clock: clock,
+		repo: &memUserRepo{clock: clock, user: models.User{
+			ID: 7, Username: "alice", PasswordHash: string(hash), Role: models.UserRoleUser, IsVerified: true,
+		}},
+		push:  &userPush{},
+		tasks: utils.NewTaskGroup(),
+	}
+	logins := &memLoginStore{
+		clock:     clock,
+		failures:  map[string]int{},
+		lastFail:  map[string]time.Time{},
+		lockUntil: map[string]time.Time{},
+	}
+	cfg := &config.Config{LoginMaxAttempts: testMaxAttempts, LoginLockout: testLockout}
+	jwt := utils.NewJWTManager("test-secret", time.Hour)
+	f.service = NewUserService(f.repo, logins, jwt, nil, f.push, f.tasks, cfg, zap.NewNop())
+	return f
+}
+
+func (f *loginFixture) login(identifier, password string) error {
+	_, _, err := f.service.LoginWithUser(context.Background(), LoginRequest{Identifier: identifier, Password: password})
+	return err
+}
+
+// expectedLocks — на сколько закрывается вход после каждой из testMaxAttempts неудач подряд:
+// первые две без задержки, после третьей и четвёртой паузы 1 с и 2 с, на пятой — блокировка
+var expectedLocks = []time.Duration{0, 0, time.Second, 2 * time.Second, testLockout}
+
+func TestLoginDelaysAndLockout(t *testing.T) {
+	for _, identifier := range []string{"alice", "nobody"} {
+		t.Run(identifier, func(t *testing.T) {
+			f := newLoginFixture(t)
+			for attempt, want := range expectedLocks {
+				// Неверный пароль при открытом входе — обычная ошибка, а не блокировка
+				if err := f.login(identifier, "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", attempt+1, err)
+				}
+				if want == 0 {
+					continue
+				}
+
+				// Попытка при закрытом входе не проверяет пароль и не засчитывается
+				var locked *LoginLockedError
+				err := f.login(identifier, testPassword)
+				if !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+					t.Fatalf("after attempt %d: err = %v, want LoginLockedError", attempt+1, err)
+				}
+				if locked.RetryAfter != want {
+					t.Fatalf("after attempt %d: retry after %v, want %v", attempt+1, locked.RetryAfter, want)
+				}
+				f.clock.now = f.clock.now.Add(want)
+			}
+
+			// После блокировки счёт начинается заново
+			if err := f.login(identifier, "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("after lockout: err = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+func TestLoginLockoutNotifiesOwner(t *testing.T) {
+	f := newLoginFixture(t)
+
+	for attempt := 1; attempt <= testMaxAttempts; attempt++ {
+		if err := f.login("alice", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v", attempt, err)
+		}
+		f.clock.now = f.clock.now.Add(time.Minute)
+	}
+	f.tasks.Wait(context.Background())
+
+	if len(f.push.messages) != 1 {
+		t.Fatalf("lockout notifications = %d, want 1", len(f.push.messages))
+	}
+	// Блокировка держится, пока не истечёт, даже с верным паролем
+	var locked *LoginLockedError
+	if err := f.login("alice", testPassword); !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want LoginLockedError", err)
+	}
+}
+
+func TestUnknownLoginIsIndistinguishable(t *testing.T) {
+	known, unknown := newLoginFixture(t), newLoginFixture(t)
+
+	for attempt := 1; attempt <= testMaxAttempts+1; attempt++ {
+		knownErr := known.login("alice", "wrong-password")
+		unknownErr := unknown.login("nobody", "wrong-password")
+
+		var knownLock, unknownLock *LoginLockedError
+		errors.As(knownErr, &knownLock)
+		errors.As(unknownErr, &unknownLock)
+		if errors.Is(knownErr, ErrInvalidCredentials) != errors.Is(unknownErr, ErrInvalidCredentials) ||
+			(knownLock == nil) != (unknownLock == nil) ||
+			(knownLock != nil && knownLock.RetryAfter != unknownLock.RetryAfter) {
+			t.Fatalf("attempt %d: existing account gets %v, unknown login gets %v", attempt, knownErr, unknownErr)
+		}
+	}
+}
+
+func TestUnlockLogin(t *testing.T) {
+	f := newLoginFixture(t)
+	ctx := context.Background()
+
+	for attempt := 1; attempt <= testMaxAttempts; attempt++ {
+		f.login("alice", "wrong-password")
+		f.clock.now = f.clock.now.Add(time.Minute)
+	}
+	if err := f.login("alice", testPassword); !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("before unlock: err = %v, want ErrLoginLocked", err)
+	}
+
+	if err := f.service.UnlockLogin(ctx, f.repo.user.ID); err != nil {
+		t.Fatalf("UnlockLogin: %v", err)
+	}
+	if err := f.login("alice", testPassword); err != nil {
+		t.Fatalf("after unlock: %v", err)
+	}
+	if f.repo.user.FailedLogins != 0 {
+		t.Fatalf("failed logins = %d, want 0", f.repo.user.FailedLogins)
+	}
+
+	if err := f.service.UnlockLogin(ctx, 999); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("unlock unknown user: err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestLoginPenaltyNeverShortensLock(t *testing.T) {
+	f := newLoginFixture(t)
+	ctx := context.Background()
+
+	// Блокировка уже стоит, а запоздавшая неудача просит короткую задержку
+	if err := f.repo.LockLogin(ctx, f.repo.user.ID, testLockout); err != nil {
+		t.Fatal(err)
+	}
+	f.service.applyLoginPenalty(ctx, f.repo.user.ID, loginFreeAttempts)
+
+	var locked *LoginLockedError
+	if err := f.login("alice", testPassword); !errors.As(err, &locked) || locked.RetryAfter != testLockout {
+		t.Fatalf("err = %v, want lock for %v", err, testLockout)
+	}
+}
+
+func TestCorrectPasswordClearsPenaltyOfTheAttempt(t *testing.T) {
+	f := newLoginFixture(t)
+
+	for attempt := 1; attempt < loginFreeAttempts; attempt++ {
+		if err := f.login("alice", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v", attempt, err)
+		}
+	}
+	// Эта попытка ставит задержку ещё до проверки пароля; верный пароль её снимает
+	if err := f.login("alice", testPassword); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if err := f.login("alice", testPassword); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if f.repo.user.FailedLogins != 0 {
+		t.Fatalf("failed logins = %d, want 0", f.repo.user.FailedLogins)
+	}
+}
@@ -0,0 +1,186 @@
+// Package spreadsheet читает и пишет табличные файлы (CSV и XLSX) как набор строк
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, use csv or xlsx")
+
+// utf8BOM — Excel без него открывает CSV в кодировке Windows-1251
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ContentType возвращает MIME-тип для формата
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FormatByName определяет формат по расширению файла
+func FormatByName(name string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(name), ".xlsx"):
+		return FormatXLSX
+	case strings.HasSuffix(strings.ToLower(name), ".csv"):
+		return FormatCSV
+	}
+	return ""
+}
+
+// Read читает все строки первого листа (для CSV — всего файла)
+func Read(format string, r io.Reader) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatXLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for j, v := range row {
+			row[j] = unescapeFormula(v)
+		}
+	}
+	return rows, nil
+}
+
+// formulaPrefixes — с этих символов Excel и LibreOffice начинают формулу
+const formulaPrefixes = "=+-@\t\r"
+
+// EscapeFormula защищает от внедрения формул (CSV/XLSX injection): значение, начинающееся
+// с символа формулы, предваряется апострофом и показывается редактором как текст
+func EscapeFormula(v string) string {
+	if v != "" && strings.ContainsRune(formulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// unescapeFormula снимает экранирование EscapeFormula, чтобы выгрузку можно было загрузить обратно
+func unescapeFormula(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+// Write записывает строки в выбранном формате. Ячейки, которые табличный редактор принял бы
+// за формулу, экранируются (см. EscapeFormula); Read снимает это экранирование.
+func Write(format string, w io.Writer, rows [][]string) error {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, v := range row {
+			escaped[i][j] = EscapeFormula(v)
+		}
+	}
+	rows = escaped
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+// readCSV понимает и запятую, и точку с запятой (так сохраняет русский Excel)
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(firstLine, []byte{';'}) > bytes.Count(firstLine, []byte{','}) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv: %w", err)
+	}
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("xlsx file has no sheets")
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read xlsx sheet: %w", err)
+	}
+	return rows, nil
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+func writeXLSX(w io.Writer, rows [][]string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(row))
+		for j, v := range row {
+			values[j] = v
+		}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return fmt.Errorf("failed to write xlsx row %d: %w", i+1, err)
+		}
+	}
+	if err := f.Write(w); err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteEscapesFormulas(t *testing.T) {
+	rows := [][]string{
+		{"name", "description"},
+		{"=HYPERLINK(\"http://evil\")", "+7 999"},
+		{"@SUM(A1)", "-1"},
+		{"Хлеб", "обычный текст"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(format, &buf, rows); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if format == FormatCSV && strings.Contains(buf.String(), "\n=HYPERLINK") {
+				t.Fatalf("formula written unescaped:\n%s", buf.String())
+			}
+
+			// Экранирование снимается при чтении: выгрузку можно загрузить обратно без изменений
+			got, err := Read(format, &buf)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Fatalf("round trip = %q, want %q", got, rows)
+			}
+		})
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"=1+1":   "'=1+1",
+		"+79990": "'+79990",
+		"-5":     "'-5",
+		"@cmd":   "'@cmd",
+		"\tx":    "'\tx",
+		"1299.5": "1299.5",
+		"":       "",
+	}
+	for in, want := range tests {
+		if got := EscapeFormula(in); got != want {
+			t.Errorf("EscapeFormula(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
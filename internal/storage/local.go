@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const tempPrefix = ".tmp-"
+
+// LocalStorage хранит файлы в каталоге на диске
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create upload dir: %w", err)
+	}
+	return &LocalStorage{dir: dir, baseURL: baseURL}, nil
+}
+
+func (s *LocalStorage) Driver() string {
+	return "local"
+}
+
+// Put пишет во временный файл и переименовывает его, чтобы не отдавать недописанные файлы.
+// Диск не прерывается по ctx, но файл отменённой загрузки не публикуется.
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid file key: %q", key)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, *Object, error) {
+	if !ValidKey(key) {
+		return nil, nil, ErrNotFound
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, key))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+
+	return f, &Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: contentTypeByKey(key),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+func (s *LocalStorage) Exists(_ context.Context, key string) (bool, error) {
+	if !ValidKey(key) {
+		return false, nil
+	}
+	_, err := os.Stat(filepath.Join(s.dir, key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrNotFound
+	}
+	err := os.Remove(filepath.Join(s.dir, key))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *LocalStorage) Touch(_ context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrNotFound
+	}
+	now := time.Now()
+	err := os.Chtimes(filepath.Join(s.dir, key), now, now)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *LocalStorage) List(_ context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload dir: %w", err)
+	}
+
+	objects := make([]Object, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		objects = append(objects, Object{
+			Key:         entry.Name(),
+			Size:        info.Size(),
+			ContentType: contentTypeByKey(entry.Name()),
+			ModTime:     info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return publicURL(s.baseURL, key)
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3CacheControl — имена файлов вычисляются по содержимому, поэтому файл по ключу никогда не меняется
+const s3CacheControl = "public, max-age=31536000, immutable"
+
+type S3Options struct {
+	Endpoint  string // host:port, например "storage.yandexcloud.net" или "localhost:9000" для MinIO
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PublicURL string // прямая публичная ссылка на бакет; если пусто — файлы отдаются через /uploads/
+	BaseURL   string
+}
+
+// S3Storage хранит файлы в S3-совместимом бакете (AWS S3, MinIO, Yandex Object Storage и т.п.)
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+	baseURL   string
+}
+
+func NewS3Storage(ctx context.Context, opts S3Options) (*S3Storage, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check s3 bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, opts.Bucket, minio.MakeBucketOptions{Region: opts.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create s3 bucket: %w", err)
+		}
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    opts.Bucket,
+		publicURL: strings.TrimRight(opts.PublicURL, "/"),
+		baseURL:   opts.BaseURL,
+	}, nil
+}
+
+func (s *S3Storage) Driver() string {
+	return "s3"
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("invalid file key: %q", key)
+	}
+	if contentType == "" {
+		contentType = contentTypeByKey(key)
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: s3CacheControl,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	if !ValidKey(key) {
+		return nil, nil, ErrNotFound
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, mapS3Error(err)
+	}
+
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, mapS3Error(err)
+	}
+
+	return obj, &Object{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	if !ValidKey(key) {
+		return false, nil
+	}
+	_, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if errors.Is(mapS3Error(err), ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	exists, err := s.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// Touch копирует объект сам в себя: у S3 нет отдельной смены времени, а копия получает новое LastModified.
+// При копировании с заменой метаданных их нужно передать заново, иначе пропадут тип и кэширование.
+func (s *S3Storage) Touch(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrNotFound
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return mapS3Error(err)
+	}
+
+	_, err = s.client.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:          s.bucket,
+			Object:          key,
+			ReplaceMetadata: true,
+			UserMetadata: map[string]string{
+				"Content-Type":  info.ContentType,
+				"Cache-Control": s3CacheControl,
+			},
+		},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: key},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch s3 object: %w", mapS3Error(err))
+	}
+	return nil
+}
+
+func (s *S3Storage) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list s3 objects: %w", info.Err)
+		}
+		objects = append(objects, Object{
+			Key:         info.Key,
+			Size:        info.Size,
+			ContentType: contentTypeByKey(info.Key),
+			ModTime:     info.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + key
+	}
+	return publicURL(s.baseURL, key)
+}
+
+func mapS3Error(err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == 404 {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,383 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"chechnya-product/internal/services"
+	"chechnya-product/internal/storage"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testBucket = "uploads"
+
+type stubObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// s3Stub — минимальный S3 на httptest: бакет, PUT/GET/HEAD/DELETE и копирование объекта, ListObjectsV2
+type s3Stub struct {
+	mu      sync.Mutex
+	bucket  bool
+	objects map[string]stubObject
+	puts    int
+	copies  int
+}
+
+func newS3Stub(t *testing.T) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{objects: make(map[string]stubObject)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		s.error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		if !s.bucket {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodPut:
+		s.bucket = true
+	case key == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		s.list(w)
+	case key != "" && r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copy(w, r, key)
+	case key != "" && r.Method == http.MethodPut:
+		data, err := readPayload(r)
+		if err != nil {
+			s.error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.puts++
+		s.objects[key] = stubObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+		w.Header().Set("ETag", `"etag"`)
+	case key != "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		obj, ok := s.objects[key]
+		if !ok {
+			s.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case key != "" && r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// copy копирует объект внутри бакета; копия получает новое время изменения
+func (s *s3Stub) copy(w http.ResponseWriter, r *http.Request, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		s.error(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	obj, ok := s.objects[strings.TrimPrefix(strings.TrimPrefix(source, "/"), testBucket+"/")]
+	if !ok {
+		s.error(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		obj.contentType = r.Header.Get("Content-Type")
+	}
+	obj.modTime = time.Now().UTC()
+	s.objects[key] = obj
+	s.copies++
+
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprintf(w, `<CopyObjectResult><LastModified>%s</LastModified><ETag>"etag"</ETag></CopyObjectResult>`,
+		obj.modTime.Format(time.RFC3339))
+}
+
+func (s *s3Stub) list(w http.ResponseWriter) {
+	type content struct {
+		Key          string
+		LastModified string
+		Size         int
+		ETag         string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name        string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: testBucket, KeyCount: len(s.objects)}
+	for key, obj := range s.objects {
+		result.Contents = append(result.Contents, content{
+			Key: key, LastModified: obj.modTime.Format(time.RFC3339), Size: len(obj.data), ETag: `"etag"`,
+		})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (s *s3Stub) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// age делает объект старым, как будто его загрузили давно
+func (s *s3Stub) age(key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj := s.objects[key]
+	obj.modTime = obj.modTime.Add(-d)
+	s.objects[key] = obj
+}
+
+func (s *s3Stub) object(key string) stubObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[key]
+}
+
+func (s *s3Stub) stored(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	return obj.data, ok
+}
+
+// readPayload читает тело PUT; по http без TLS minio-go отправляет его в aws-chunked кодировке
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, err
+		}
+		if _, err := body.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestS3Storage(t *testing.T) (*s3Stub, *storage.S3Storage) {
+	t.Helper()
+	stub, server := newS3Stub(t)
+	endpoint, _ := url.Parse(server.URL)
+
+	s, err := storage.NewS3Storage(context.Background(), storage.S3Options{
+		Endpoint:  endpoint.Host,
+		Region:    "us-east-1",
+		Bucket:    testBucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		BaseURL:   "https://shop.test",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return stub, s
+}
+
+func TestS3Storage(t *testing.T) {
+	stub, s := newTestS3Storage(t)
+	ctx := context.Background()
+	if !stub.bucket {
+		t.Fatal("missing bucket was not created")
+	}
+
+	data := []byte("png-bytes")
+	if err := s.Put(ctx, "a.png", bytes.NewReader(data), int64(len(data)), ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stored, _ := stub.stored("a.png"); !bytes.Equal(stored, data) {
+		t.Fatalf("stored = %q, want %q", stored, data)
+	}
+	if err := s.Put(ctx, "../a.png", bytes.NewReader(data), int64(len(data)), ""); err == nil {
+		t.Fatal("Put with path in key must fail")
+	}
+
+	rc, obj, err := s.Get(ctx, "a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, data) || obj.Size != int64(len(data)) || obj.ContentType != "image/png" {
+		t.Fatalf("Get = %q %+v", got, obj)
+	}
+	if _, _, err := s.Get(ctx, "missing.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Get missing: err = %v, want ErrNotFound", err)
+	}
+
+	if exists, err := s.Exists(ctx, "a.png"); err != nil || !exists {
+		t.Fatalf("Exists = %v, %v; want true", exists, err)
+	}
+	if exists, err := s.Exists(ctx, "missing.png"); err != nil || exists {
+		t.Fatalf("Exists missing = %v, %v; want false", exists, err)
+	}
+
+	objects, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "a.png" || objects[0].Size != int64(len(data)) {
+		t.Fatalf("List = %+v", objects)
+	}
+
+	stub.age("a.png", 48*time.Hour)
+	if err := s.Touch(ctx, "a.png"); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	if obj := stub.object("a.png"); time.Since(obj.modTime) > time.Minute || obj.contentType != "image/png" || !bytes.Equal(obj.data, data) {
+		t.Fatalf("after Touch: %+v", obj)
+	}
+	if err := s.Touch(ctx, "missing.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Touch missing: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Delete(ctx, "a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := stub.stored("a.png"); ok {
+		t.Fatal("object is still stored after Delete")
+	}
+	if err := s.Delete(ctx, "a.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Delete missing: err = %v, want ErrNotFound", err)
+	}
+
+	if got := s.URL("a.png"); got != "https://shop.test/uploads/a.png" {
+		t.Fatalf("URL = %q", got)
+	}
+}
+
+func TestS3StorageRespectsContext(t *testing.T) {
+	_, s := newTestS3Storage(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := []byte("x")
+	if err := s.Put(ctx, "a.png", bytes.NewReader(data), 1, ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Put with canceled ctx: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestFileServiceDeduplicatesByContent(t *testing.T) {
+	stub, s := newTestS3Storage(t)
+	files := services.NewFileService(s, nil, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	first, err := files.Store(ctx, []byte("same image"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	second, err := files.Store(ctx, []byte("same image"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatalf("Store again: %v", err)
+	}
+	other, err := files.Store(ctx, []byte("other image"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatalf("Store other: %v", err)
+	}
+
+	if first != second || first == other {
+		t.Fatalf("keys = %q, %q, %q: same content must share a key", first, second, other)
+	}
+	if first != storage.ContentKey([]byte("same image"), ".jpg") {
+		t.Fatalf("key = %q, want content key", first)
+	}
+	if stub.puts != 2 {
+		t.Fatalf("uploads = %d, want 2: duplicate content must not be uploaded again", stub.puts)
+	}
+}
+
+// memFileRefs — ссылок на файлы нет, пока тест их не добавит
+type memFileRefs struct {
+	referenced map[string]bool
+}
+
+func (r *memFileRefs) IsReferenced(_ context.Context, key string) (bool, error) {
+	return r.referenced[key], nil
+}
+
+func (r *memFileRefs) GetReferencedKeys(context.Context) (map[string]bool, error) {
+	return r.referenced, nil
+}
+
+func TestFileServiceDuplicateSurvivesOrphanCleanup(t *testing.T) {
+	stub, s := newTestS3Storage(t)
+	files := services.NewFileService(s, &memFileRefs{referenced: map[string]bool{}}, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	key, err := files.Store(ctx, []byte("image"), ".jpg", "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Файл давно загрузили и не привязали; сейчас такой же загружают для нового товара
+	stub.age(key, 48*time.Hour)
+	if _, err := files.Store(ctx, []byte("image"), ".jpg", "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := files.CleanupOrphans(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 0 {
+		t.Fatalf("orphans = %v: re-uploaded file must stay within the grace period", orphans)
+	}
+	if _, ok := stub.stored(key); !ok {
+		t.Fatal("re-uploaded file was deleted")
+	}
+	if obj := stub.object(key); obj.contentType != "image/jpeg" {
+		t.Fatalf("content type after refresh = %q", obj.contentType)
+	}
+
+	// Без повторной загрузки старый непривязанный файл удаляется
+	stub.age(key, 48*time.Hour)
+	if orphans, err := files.CleanupOrphans(ctx, false); err != nil || len(orphans) != 1 {
+		t.Fatalf("orphans = %v, %v; want the stale file", orphans, err)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"chechnya-product/config"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Object описывает файл в хранилище
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage — хранилище загруженных файлов (локальный диск или S3-совместимое).
+// Для S3 ctx ограничивает запрос; у Get он действует и на чтение возвращённого файла.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// Touch обновляет время изменения файла, не перезаписывая его: для очистки сирот
+	// повторно загруженный файл снова считается новым
+	Touch(ctx context.Context, key string) error
+	List(ctx context.Context) ([]Object, error)
+	URL(key string) string
+	// Driver возвращает название реализации: "local" или "s3"
+	Driver() string
+}
+
+// New создаёт хранилище по STORAGE_DRIVER из конфига
+func New(ctx context.Context, cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocalStorage(cfg.UploadDir, cfg.PublicBaseURL)
+	case "s3":
+		return NewS3Storage(ctx, S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.S3PublicURL,
+			BaseURL:   cfg.PublicBaseURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
+
+// ContentKey возвращает имя файла по его содержимому (sha256 + расширение),
+// поэтому одинаковые файлы сохраняются один раз
+func ContentKey(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ext
+}
+
+// ValidKey проверяет, что ключ — простое имя файла без каталогов
+func ValidKey(key string) bool {
+	return key != "" && key != "." && key != ".." &&
+		!strings.ContainsAny(key, `/\`) && path.Base(key) == key
+}
+
+func contentTypeByKey(key string) string {
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+func publicURL(baseURL, key string) string {
+	return fmt.Sprintf("%s/uploads/%s", strings.TrimRight(baseURL, "/"), key)
+}
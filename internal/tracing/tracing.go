@@ -0,0 +1,141 @@
+// Package tracing — трассировка OpenTelemetry: настройка экспортёра и помощники для span'ов и логов,
+// которые связывают записи одного запроса через request id и trace id.
+package tracing
+
+import (
+	"chechnya-product/config"
+	"chechnya-product/internal/buildinfo"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const tracerName = "chechnya-product"
+
+// Init настраивает глобальный TracerProvider по OTEL_TRACES_EXPORTER:
+//   - otlp — отправка по OTLP/HTTP, адрес берётся из OTEL_EXPORTER_OTLP_ENDPOINT;
+//   - stdout — span'ы печатаются в stdout, для локальной отладки;
+//   - none (по умолчанию) — span'ы никуда не отправляются, но trace id всё равно
+//     появляются в логах и заголовке X-Trace-ID, чтобы связывать записи одного запроса.
+//
+// Возвращённую функцию нужно вызвать при остановке, чтобы отправить накопленные span'ы.
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TraceSampleRatio))),
+	}
+
+	switch strings.ToLower(cfg.TraceExporter) {
+	case "", "none":
+	case "otlp":
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q (otlp, stdout or none)", cfg.TraceExporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.TraceServiceName),
+		semconv.ServiceVersion(buildinfo.Version),
+		semconv.DeploymentEnvironment(cfg.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	opts = append(opts, sdktrace.WithResource(res))
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start начинает span; его нужно завершить через End
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// StartChild начинает span, только если в ctx уже есть родительский. Так SQL и Redis из фоновых
+// задач и кода без контекста не порождают отдельных трасс из одного запроса.
+func StartChild(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span, bool) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil, false
+	}
+	ctx, span := Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx, span, true
+}
+
+// End завершает span, отмечая ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID возвращает trace id из ctx или пустую строку
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+type requestIDKey struct{}
+
+// WithRequestID сохраняет в ctx id HTTP-запроса, чтобы Logger добавлял его к записям
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает id запроса из ctx или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LogFields — request_id, trace_id и span_id для записей zap, относящихся к запросу
+func LogFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return fields
+}
+
+// Logger возвращает логгер, который добавляет request_id, trace_id и span_id из ctx к каждой записи
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := LogFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
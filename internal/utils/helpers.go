@@ -16,8 +16,12 @@ type UpdateItemRequest struct {
 }
 
 type CategoryRequest struct {
-	Name      string `json:"name"`
-	SortOrder int    `json:"sortOrder"`
+	Name        string  `json:"name"`
+	SortOrder   int     `json:"sortOrder"`
+	ParentID    *int    `json:"parent_id"`
+	Slug        string  `json:"slug"` // если не задан, строится из названия
+	Description *string `json:"description"`
+	ImageURL    *string `json:"image_url"`
 }
 
 type SuccessResponse struct {
@@ -76,15 +80,20 @@ func BuildProductResponse(p *models.Product, categoryName string) models.Product
 		categoryID = 0
 	}
 
+	oldPrice, salePrice := p.Discount()
 	return models.ProductResponse{
 		ID:           p.ID,
 		Name:         p.Name,
+		SKU:          p.SKU.String,
 		Description:  p.Description,
 		Price:        p.Price,
+		OldPrice:     oldPrice,
+		SalePrice:    salePrice,
 		Availability: p.Availability,
 		CategoryID:   categoryID,
 		CategoryName: categoryName,
-		Rating:       0, // если нет, можно оставить 0
+		Rating:       p.RatingAvg,
+		ReviewCount:  p.ReviewCount,
 		Url:          p.Url.String,
 	}
 }
@@ -0,0 +1,27 @@
+package utils
+
+import "context"
+
+type shutdownKey struct{}
+
+// WithShutdown кладёт в ctx сигнал остановки сервера: shutdown отменяется, когда сервер перестаёт
+// принимать запросы. Используется в http.Server.BaseContext, поэтому сигнал есть в контексте каждого запроса.
+func WithShutdown(ctx, shutdown context.Context) context.Context {
+	return context.WithValue(ctx, shutdownKey{}, shutdown)
+}
+
+// UntilShutdown возвращает контекст, который отменяется вместе с ctx или в начале остановки сервера.
+// Нужен долгим ответам (потокам): Shutdown ждёт завершения всех запросов, и незакрытый поток
+// держал бы остановку до таймаута.
+func UntilShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	shutdown, ok := ctx.Value(shutdownKey{}).(context.Context)
+	if !ok {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(shutdown, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// TaskGroup отслеживает фоновую работу, запущенную из обработчиков запросов (push-уведомления и т.п.),
+// чтобы при остановке процесса дождаться её, а не обрывать на середине
+type TaskGroup struct {
+	wg sync.WaitGroup
+}
+
+func NewTaskGroup() *TaskGroup {
+	return &TaskGroup{}
+}
+
+// Go запускает fn в отдельной горутине. На nil-группе задача просто запускается без учёта —
+// так сервисы можно использовать из CLI, где ждать нечего.
+func (g *TaskGroup) Go(fn func()) {
+	if g == nil {
+		go fn()
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+}
+
+// Wait ждёт завершения всех задач или отмены ctx; во втором случае возвращает ctx.Err()
+func (g *TaskGroup) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
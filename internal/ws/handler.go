@@ -4,6 +4,7 @@ import (
 	"chechnya-product/internal/middleware"
 	"github.com/gorilla/websocket"
 	"net/http"
+	"time"
 )
 
 var upgrader = websocket.Upgrader{
@@ -40,7 +41,9 @@ func (h *Hub) HandleConnections(w http.ResponseWriter, r *http.Request) {
 		Hub:  h,
 	}
 
-	h.register <- client
+	if !h.registerClient(client) {
+		return
+	}
 
 	go client.readPump()
 	go client.writePump()
@@ -68,15 +71,32 @@ func (h *Hub) HandleAnnouncementConnections(w http.ResponseWriter, r *http.Reque
 		Send: make(chan []byte, 256),
 		Hub:  h,
 	}
-	h.register <- client
+	if !h.registerClient(client) {
+		return
+	}
 	go client.readPump()
 	go client.writePump()
 }
 
+// registerClient передаёт клиента хабу; если хаб уже остановлен, соединение закрывается
+func (h *Hub) registerClient(client *Client) bool {
+	select {
+	case h.register <- client:
+		return true
+	case <-h.done:
+		client.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server shutting down"))
+		client.Conn.Close()
+		return false
+	}
+}
+
 // readPump — читает сообщения от клиента (игнорируем)
 func (c *Client) readPump() {
 	defer func() {
-		c.Hub.unregister <- c
+		select {
+		case c.Hub.unregister <- c:
+		case <-c.Hub.done:
+		}
 	}()
 	for {
 		if _, _, err := c.Conn.NextReader(); err != nil {
@@ -85,11 +105,22 @@ func (c *Client) readPump() {
 	}
 }
 
-// writePump — отправляет сообщения клиенту
+// writePump — отправляет сообщения клиенту. Когда хаб закрывает канал Send при остановке сервера,
+// клиент получает close-кадр, чтобы переподключиться к новому экземпляру.
 func (c *Client) writePump() {
+	defer c.Hub.pumps.Done()
+
 	for msg := range c.Send {
 		if err := c.Conn.WriteMessage(websocket.TextMessage, msg); err != nil {
 			break
 		}
 	}
+
+	select {
+	case <-c.Hub.done:
+		c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		c.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server shutting down"))
+	default:
+	}
+	c.Conn.Close()
 }
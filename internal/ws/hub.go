@@ -1,13 +1,19 @@
 package ws
 
 import (
+	"chechnya-product/internal/metrics"
 	"chechnya-product/internal/models"
+	"context"
 	"encoding/json"
 	"go.uber.org/zap"
 	"io"
 	"sync"
+	"time"
 )
 
+// writeWait — сколько ждать отправки close-кадра клиенту при остановке сервера
+const writeWait = 5 * time.Second
+
 // Client — подключённый клиент WebSocket
 type Client struct {
 	ID   int           // userID или 0 для гостей
@@ -20,6 +26,7 @@ type Client struct {
 // WebSocketConn — интерфейс для WebSocket-соединения
 type WebSocketConn interface {
 	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
 	Close() error
 	NextReader() (messageType int, r io.Reader, err error)
 }
@@ -44,27 +51,39 @@ type Hub struct {
 	broadcastAnnouncements chan AnnouncementMessage
 	mu                     sync.Mutex
 	logger                 *zap.Logger
+
+	done  chan struct{}  // закрывается, когда хаб останавливается
+	pumps sync.WaitGroup // writePump подключённых клиентов
 }
 
 // NewHub создаёт новый экземпляр Hub
 func NewHub(logger *zap.Logger) *Hub {
 	return &Hub{
-		clients:     make(map[*Client]bool),
-		register:    make(chan *Client),
-		unregister:  make(chan *Client),
-		broadcastCh: make(chan OrderMessage),
-		logger:      logger,
+		clients:                make(map[*Client]bool),
+		register:               make(chan *Client),
+		unregister:             make(chan *Client),
+		broadcastCh:            make(chan OrderMessage),
+		broadcastAnnouncements: make(chan AnnouncementMessage),
+		logger:                 logger,
+		done:                   make(chan struct{}),
 	}
 }
 
-// Run запускает основной цикл хаба
-func (h *Hub) Run() {
+// Run запускает основной цикл хаба. После отмены ctx хаб перестаёт принимать подключения,
+// отправляет клиентам close-кадр и возвращается, когда все соединения закрыты.
+func (h *Hub) Run(ctx context.Context) {
 	for {
 		select {
+		case <-ctx.Done():
+			h.shutdown()
+			return
+
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			h.pumps.Add(1)
 			h.mu.Unlock()
+			metrics.WebSocketClients.WithLabelValues(client.Role).Inc()
 			h.logger.Info("WebSocket client connected",
 				zap.Int("user_id", client.ID),
 				zap.String("role", client.Role),
@@ -73,8 +92,7 @@ func (h *Hub) Run() {
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
+				h.removeClient(client)
 				client.Conn.Close()
 				h.logger.Info("WebSocket client disconnected",
 					zap.Int("user_id", client.ID),
@@ -90,8 +108,7 @@ func (h *Hub) Run() {
 				select {
 				case client.Send <- data:
 				default:
-					close(client.Send)
-					delete(h.clients, client)
+					h.removeClient(client)
 					client.Conn.Close()
 				}
 			}
@@ -105,8 +122,7 @@ func (h *Hub) Run() {
 				select {
 				case client.Send <- data:
 				default:
-					close(client.Send)
-					delete(h.clients, client)
+					h.removeClient(client)
 					client.Conn.Close()
 				}
 			}
@@ -116,24 +132,64 @@ func (h *Hub) Run() {
 	}
 }
 
+// shutdown закрывает каналы отправки всех клиентов; их writePump отправляют close-кадр и закрывают соединение
+func (h *Hub) shutdown() {
+	close(h.done)
+
+	h.mu.Lock()
+	count := len(h.clients)
+	for client := range h.clients {
+		h.removeClient(client)
+	}
+	h.mu.Unlock()
+
+	h.pumps.Wait()
+	h.logger.Info("WebSocket hub stopped", zap.Int("closed_clients", count))
+}
+
+// removeClient убирает клиента из хаба и закрывает его канал отправки; вызывается под h.mu
+func (h *Hub) removeClient(client *Client) {
+	delete(h.clients, client)
+	close(client.Send)
+	metrics.WebSocketClients.WithLabelValues(client.Role).Dec()
+}
+
+// ClientCount — число подключённых WebSocket-клиентов
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
 // BroadcastNewOrder — рассылает заказ пользователю и всем админам
 func (h *Hub) BroadcastNewOrder(order models.Order) {
-	h.broadcastCh <- OrderMessage{
+	h.broadcastOrder(OrderMessage{
 		Type:  "new_order",
 		Order: order,
-	}
+	})
 }
 
 func (h *Hub) BroadcastAnnouncement(announcement models.Announcement) {
-	h.broadcastAnnouncements <- AnnouncementMessage{
+	select {
+	case h.broadcastAnnouncements <- AnnouncementMessage{
 		Type:         "announcement",
 		Announcement: announcement,
+	}:
+	case <-h.done:
 	}
 }
 
 func (h *Hub) BroadcastStatusUpdate(order models.Order) {
-	h.broadcastCh <- OrderMessage{
+	h.broadcastOrder(OrderMessage{
 		Type:  "status_update",
 		Order: order,
+	})
+}
+
+// broadcastOrder передаёт сообщение циклу хаба; после остановки хаба сообщение отбрасывается
+func (h *Hub) broadcastOrder(msg OrderMessage) {
+	select {
+	case h.broadcastCh <- msg:
+	case <-h.done:
 	}
 }
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE product_images (
+                                id SERIAL PRIMARY KEY,
+                                product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                file_name TEXT NOT NULL,                 -- оригинал (перекодированный, без метаданных)
+                                medium_file_name TEXT NOT NULL,
+                                thumb_file_name TEXT NOT NULL,
+                                width INT NOT NULL DEFAULT 0,
+                                height INT NOT NULL DEFAULT 0,
+                                sort_order INT NOT NULL DEFAULT 0,
+                                is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+                                created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images(product_id, sort_order);
+
+-- У товара может быть только одно основное изображение
+CREATE UNIQUE INDEX idx_product_images_primary ON product_images(product_id) WHERE is_primary;
+
+-- Переносим существующие ссылки как основные изображения
+INSERT INTO product_images (product_id, file_name, medium_file_name, thumb_file_name, is_primary)
+SELECT id, url, url, url, TRUE
+FROM products
+WHERE url IS NOT NULL AND url <> '';
+
+-- +goose Down
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Полнотекстовый поиск с русской морфологией: "яблоки" находит "Яблоко"
+CREATE INDEX idx_products_search_fts ON products
+    USING GIN (to_tsvector('russian', name || ' ' || COALESCE(description, '')));
+
+-- Нечёткий поиск по триграммам (опечатки, частичные слова)
+CREATE INDEX idx_products_name_trgm ON products USING GIN (LOWER(name) gin_trgm_ops);
+CREATE INDEX idx_categories_name_trgm ON categories USING GIN (LOWER(name) gin_trgm_ops);
+
+-- Запросы, по которым ничего не нашлось: подсказка для наполнения каталога
+CREATE TABLE search_zero_results (
+                                     query TEXT PRIMARY KEY,            -- нормализованный запрос (нижний регистр, без лишних пробелов)
+                                     hits INT NOT NULL DEFAULT 1,
+                                     first_searched_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                                     last_searched_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_search_zero_results_hits ON search_zero_results(hits DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS search_zero_results;
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search_fts;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Агрегаты отзывов хранятся прямо в товаре и пересчитываются при каждом изменении отзыва,
+-- чтобы каталог мог сортировать и фильтровать по рейтингу без подзапросов
+ALTER TABLE products
+    ADD COLUMN rating_avg NUMERIC(3,2) NOT NULL DEFAULT 0,
+    ADD COLUMN review_count INT NOT NULL DEFAULT 0;
+
+UPDATE products p
+SET rating_avg = agg.rating_avg,
+    review_count = agg.review_count
+FROM (
+         SELECT product_id, ROUND(AVG(rating), 2) AS rating_avg, COUNT(*) AS review_count
+         FROM reviews
+         WHERE rating IS NOT NULL
+         GROUP BY product_id
+     ) agg
+WHERE agg.product_id = p.id;
+
+CREATE INDEX idx_products_rating ON products(rating_avg DESC, id DESC);
+CREATE INDEX idx_reviews_product_id ON reviews(product_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reviews_product_id;
+DROP INDEX IF EXISTS idx_products_rating;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS review_count,
+    DROP COLUMN IF EXISTS rating_avg;
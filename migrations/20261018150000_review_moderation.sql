@@ -0,0 +1,52 @@
+-- +goose Up
+ALTER TABLE reviews
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'approved',
+    ADD COLUMN rejection_reason TEXT,
+    ADD COLUMN moderation_note TEXT,                 -- что нашли автоматические фильтры (ссылки, стоп-слова)
+    ADD COLUMN verified_purchase BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN moderated_at TIMESTAMP,
+    ADD COLUMN moderated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN admin_reply TEXT,
+    ADD COLUMN admin_reply_at TIMESTAMP,
+    ADD CONSTRAINT reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected'));
+
+-- Уже опубликованные отзывы считаем одобренными, новые попадают в очередь модерации
+ALTER TABLE reviews ALTER COLUMN status SET DEFAULT 'pending';
+
+UPDATE reviews r
+SET verified_purchase = TRUE
+WHERE EXISTS (
+    SELECT 1
+    FROM orders o
+    JOIN order_items oi ON oi.order_id = o.id
+    WHERE o.owner_id = r.owner_id
+      AND oi.product_id = r.product_id
+      AND o.status = 'доставлен'
+);
+
+CREATE INDEX idx_reviews_status ON reviews(status, created_at);
+
+CREATE TABLE review_photos (
+                               id SERIAL PRIMARY KEY,
+                               review_id INT NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+                               file_name TEXT NOT NULL,
+                               medium_file_name TEXT NOT NULL,
+                               thumb_file_name TEXT NOT NULL,
+                               created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_photos_review_id ON review_photos(review_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS review_photos;
+DROP INDEX IF EXISTS idx_reviews_status;
+ALTER TABLE reviews
+    DROP CONSTRAINT IF EXISTS reviews_status_check,
+    DROP COLUMN IF EXISTS admin_reply_at,
+    DROP COLUMN IF EXISTS admin_reply,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS verified_purchase,
+    DROP COLUMN IF EXISTS moderation_note,
+    DROP COLUMN IF EXISTS rejection_reason,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Отзывы о заказе ведём по owner_id, как и отзывы о товарах
+ALTER TABLE order_reviews
+    ADD COLUMN owner_id TEXT,
+    ADD COLUMN delivery_rating INT CHECK (delivery_rating BETWEEN 1 AND 5),
+    ADD COLUMN courier_rating INT CHECK (courier_rating BETWEEN 1 AND 5);
+
+UPDATE order_reviews orr
+SET owner_id = o.owner_id
+FROM orders o
+WHERE o.id = orr.order_id;
+
+ALTER TABLE order_reviews ALTER COLUMN owner_id SET NOT NULL;
+
+UPDATE order_reviews SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE order_reviews ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX idx_order_reviews_order_id ON order_reviews(order_id);
+CREATE INDEX idx_order_reviews_created_at ON order_reviews(created_at);
+
+-- Отзыв о товаре может быть оставлен вместе с отзывом о заказе
+UPDATE reviews SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE reviews ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE reviews ADD COLUMN order_id INT REFERENCES orders(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_reviews_order_id ON reviews(order_id) WHERE order_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reviews_order_id;
+ALTER TABLE reviews DROP COLUMN IF EXISTS order_id;
+ALTER TABLE reviews ALTER COLUMN created_at DROP NOT NULL;
+
+DROP INDEX IF EXISTS idx_order_reviews_created_at;
+DROP INDEX IF EXISTS idx_order_reviews_order_id;
+ALTER TABLE order_reviews ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE order_reviews
+    DROP COLUMN IF EXISTS courier_rating,
+    DROP COLUMN IF EXISTS delivery_rating,
+    DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE categories
+    ADD COLUMN parent_id INT REFERENCES categories(id) ON DELETE RESTRICT,
+    ADD COLUMN slug TEXT,
+    ADD COLUMN description TEXT,
+    ADD COLUMN image_url TEXT,
+    ADD CONSTRAINT categories_parent_not_self CHECK (parent_id IS NULL OR parent_id <> id);
+
+-- Существующим категориям даём slug из названия; id в конце гарантирует уникальность.
+-- Администратор может заменить его на транслитерацию через PUT /api/admin/categories/{id}.
+UPDATE categories
+SET slug = COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^[:alnum:]]+', '-', 'g'))), ''), 'category')
+           || '-' || id;
+
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug);
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_categories_parent_id;
+DROP INDEX IF EXISTS idx_categories_slug;
+ALTER TABLE categories
+    DROP CONSTRAINT IF EXISTS categories_parent_not_self,
+    DROP COLUMN IF EXISTS image_url,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS slug,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Артикул товара: ключ для импорта прайсов из таблиц и обмена с учётными системами
+ALTER TABLE products ADD COLUMN sku TEXT;
+CREATE UNIQUE INDEX idx_products_sku ON products(sku) WHERE sku IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_products_sku;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
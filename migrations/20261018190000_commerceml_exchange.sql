@@ -0,0 +1,21 @@
+-- +goose Up
+-- Идентификаторы товаров и групп из 1С (элемент <Ид> в CommerceML)
+ALTER TABLE products ADD COLUMN external_id TEXT;
+CREATE UNIQUE INDEX idx_products_external_id ON products(external_id) WHERE external_id IS NOT NULL;
+
+ALTER TABLE categories ADD COLUMN external_id TEXT;
+CREATE UNIQUE INDEX idx_categories_external_id ON categories(external_id) WHERE external_id IS NOT NULL;
+
+-- Когда заказ был выгружен в 1С; NULL — ещё не выгружен
+ALTER TABLE orders ADD COLUMN exported_at TIMESTAMP;
+CREATE INDEX idx_orders_not_exported ON orders(id) WHERE exported_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_not_exported;
+ALTER TABLE orders DROP COLUMN IF EXISTS exported_at;
+
+DROP INDEX IF EXISTS idx_categories_external_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS external_id;
+
+DROP INDEX IF EXISTS idx_products_external_id;
+ALTER TABLE products DROP COLUMN IF EXISTS external_id;
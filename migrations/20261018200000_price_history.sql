@@ -0,0 +1,35 @@
+-- +goose Up
+-- Цена до скидки: показывается зачёркнутой, если больше текущей
+ALTER TABLE products ADD COLUMN old_price NUMERIC(10,2);
+
+CREATE TABLE price_history (
+                               id SERIAL PRIMARY KEY,
+                               product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                               old_price NUMERIC(10,2),
+                               new_price NUMERIC(10,2) NOT NULL,
+                               changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+                               source TEXT NOT NULL,      -- admin, import, 1c, schedule
+                               created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_price_history_product ON price_history(product_id, created_at DESC);
+
+-- Отложенные изменения цены: применяются фоновой задачей, когда наступает starts_at
+CREATE TABLE scheduled_prices (
+                                  id SERIAL PRIMARY KEY,
+                                  product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                  price NUMERIC(10,2) NOT NULL CHECK (price > 0),
+                                  old_price NUMERIC(10,2),
+                                  starts_at TIMESTAMP NOT NULL,
+                                  created_by INT REFERENCES users(id) ON DELETE SET NULL,
+                                  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                                  applied_at TIMESTAMP
+);
+
+CREATE INDEX idx_scheduled_prices_due ON scheduled_prices(starts_at) WHERE applied_at IS NULL;
+CREATE INDEX idx_scheduled_prices_product ON scheduled_prices(product_id, starts_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS scheduled_prices;
+DROP TABLE IF EXISTS price_history;
+ALTER TABLE products DROP COLUMN IF EXISTS old_price;
@@ -0,0 +1,61 @@
+-- +goose Up
+-- Мягкое удаление: запись остаётся в корзине до восстановления или очистки по сроку хранения
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE orders ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_products_deleted_at ON products(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_orders_deleted_at ON orders(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Уникальность — только среди неудалённых: удалённый товар не мешает завести новый с тем же артикулом.
+-- При восстановлении конфликт проверяется в приложении.
+DROP INDEX idx_products_sku;
+CREATE UNIQUE INDEX idx_products_sku ON products(sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
+DROP INDEX idx_products_external_id;
+CREATE UNIQUE INDEX idx_products_external_id ON products(external_id) WHERE external_id IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE categories DROP CONSTRAINT categories_name_key;
+CREATE UNIQUE INDEX idx_categories_name ON categories(name) WHERE deleted_at IS NULL;
+DROP INDEX idx_categories_slug;
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug) WHERE deleted_at IS NULL;
+DROP INDEX idx_categories_external_id;
+CREATE UNIQUE INDEX idx_categories_external_id ON categories(external_id) WHERE external_id IS NOT NULL AND deleted_at IS NULL;
+
+-- Окончательное удаление товара не должно стирать позиции из истории заказов:
+-- название и цена в order_items сохранены, ссылка на товар просто обнуляется
+ALTER TABLE order_items DROP CONSTRAINT order_items_product_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE order_items DROP CONSTRAINT order_items_product_id_fkey;
+ALTER TABLE order_items
+    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+-- Удалённые записи из корзины нужно убрать до возврата полных уникальных индексов
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+DELETE FROM orders WHERE deleted_at IS NOT NULL;
+UPDATE categories c SET parent_id = NULL
+WHERE parent_id IN (SELECT id FROM categories WHERE deleted_at IS NOT NULL);
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_categories_external_id;
+CREATE UNIQUE INDEX idx_categories_external_id ON categories(external_id) WHERE external_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_categories_slug;
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug);
+DROP INDEX IF EXISTS idx_categories_name;
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_products_external_id;
+CREATE UNIQUE INDEX idx_products_external_id ON products(external_id) WHERE external_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_products_sku;
+CREATE UNIQUE INDEX idx_products_sku ON products(sku) WHERE sku IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
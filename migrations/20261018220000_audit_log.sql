@@ -0,0 +1,24 @@
+-- +goose Up
+-- Журнал действий администраторов. Внешних ключей нет намеренно: TRUNCATE ... CASCADE
+-- по users или products не должен стирать журнал, а имя администратора сохраняется на момент действия.
+CREATE TABLE audit_log (
+    id          BIGSERIAL PRIMARY KEY,
+    actor_id    INTEGER,
+    actor_name  VARCHAR(100),
+    action      VARCHAR(255) NOT NULL,          -- метод и шаблон маршрута, например "PATCH /products/{id}"
+    entity_type VARCHAR(50)  NOT NULL,
+    entity_id   VARCHAR(255),
+    changes     JSONB,                          -- {"поле": {"before": ..., "after": ...}}
+    request     JSONB,                          -- тело запроса без паролей и токенов
+    status      INTEGER      NOT NULL,
+    ip          VARCHAR(64)  NOT NULL DEFAULT '',
+    request_id  VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Защита входа: счётчик неудачных попыток и время, до которого вход закрыт (задержка или блокировка)
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP;
+
+-- История входов в аккаунт, удачных и нет; пользователь видит её в профиле
+CREATE TABLE login_history (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    success    BOOLEAN      NOT NULL,
+    ip         VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_history_user ON login_history(user_id, created_at DESC);
+
+-- Подписка, оформленная авторизованным пользователем, привязывается к нему:
+-- так ему можно отправить уведомление о блокировке входа
+ALTER TABLE push_subscriptions ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_push_subscriptions_user ON push_subscriptions(user_id) WHERE user_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_push_subscriptions_user;
+ALTER TABLE push_subscriptions DROP COLUMN IF EXISTS user_id;
+DROP TABLE IF EXISTS login_history;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_logins;
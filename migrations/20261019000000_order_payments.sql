@@ -0,0 +1,20 @@
+-- +goose Up
+-- Онлайн-оплата заказа: платёж у провайдера, его состояние и ссылка на страницу оплаты.
+-- payment_status пустой у заказов с оплатой при получении
+ALTER TABLE orders ADD COLUMN payment_provider VARCHAR(32);
+ALTER TABLE orders ADD COLUMN payment_id VARCHAR(128);
+ALTER TABLE orders ADD COLUMN payment_status VARCHAR(20);
+ALTER TABLE orders ADD COLUMN payment_url TEXT;
+ALTER TABLE orders ADD COLUMN paid_at TIMESTAMP;
+ALTER TABLE orders ADD COLUMN refunded_at TIMESTAMP;
+
+CREATE INDEX idx_orders_payment_status ON orders(payment_status) WHERE payment_status IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_payment_status;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS paid_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_url;
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_status;
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_provider;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- payment_reference — наш id текущей попытки оплаты. Провайдер возвращает его в уведомлениях,
+-- поэтому запоздавший отказ по прежней попытке не затрёт новую ожидающую оплату.
+ALTER TABLE orders ADD COLUMN payment_reference VARCHAR(64);
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_reference;
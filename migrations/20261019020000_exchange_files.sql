@@ -0,0 +1,14 @@
+-- +goose Up
+-- Файлы обмена с 1С хранятся в базе, а не на диске реплики: части одного файла и его загрузка
+-- могут прийти на разные реплики. Каждая часть — отдельная строка, файл собирается по порядку id.
+CREATE TABLE exchange_files (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    data       BYTEA     NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_exchange_files_name ON exchange_files (name, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS exchange_files;